@@ -0,0 +1,785 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/config"
+	"test_nanimai/backend/internal/repository/postgres"
+	"test_nanimai/backend/internal/service/admin"
+	"text/tabwriter"
+	"time"
+)
+
+const adminUsage = `admin commands:
+  service create [-perms LIST] NAME     register a service and print its API key;
+                                        without -perms it gets every permission except admin
+  service list                          list registered services with permissions and scope
+  service permissions NAME [LIST]       replace the permissions; no LIST removes all
+  service scope [-accounts IDS] [-tags TAGS] NAME
+                                        limit the service to these accounts; no flags lift the limit
+  service signing [-rotate] NAME off|optional|required
+                                        set request signing; prints the secret when a new one is issued
+  service cert NAME [NAMES]             replace the client certificate names (CN or SAN) the
+                                        service authenticates with; no NAMES removes all
+  service rate-limit [-rate R] [-burst N] [-account-rate R] [-account-burst N] NAME
+                                        limit requests per second of the service and of
+                                        the service per account; no flags lift the limits
+  service keys NAME                     list API keys of the service
+  service add-key [-ttl D] NAME         issue one more API key
+  service rotate-key [-overlap D] [-ttl D] NAME
+                                        issue a new API key; the others stop working after the overlap
+  service revoke-key NAME KEY_ID        reject the key at once
+  account create [-user-id N] [-max-amount N]
+                                        create an account
+  account tag ID [TAGS]                 replace the account tags; no TAGS removes all
+  account freeze ID                     reject money movements on the account
+  account unfreeze ID                   lift the freeze
+  account show [-journal N] ID          show the account, its reservations and last N ledger entries
+  reservation cancel -reason TEXT ID    cancel an active reservation of any service
+  reservation expire -reason TEXT ID    expire an active reservation before its deadline
+  velocity list [-account ID]           list velocity rules; with -account only those applying to it
+  velocity add (-account ID | -tag TAG) -kind KIND -limit N [-window D] [-action decline|review]
+                                        add a rule: debit_amount, debit_count or reservation_count
+                                        within the rolling window, or single_debit without one;
+                                        review holds violating operations for approval,
+                                        single_debit always holds them
+  velocity delete ID                    delete a rule
+  pending list [-status STATUS]         list operations held by the risk check; all statuses by default
+  pending show ID                       show a held operation
+  pending approve [-note TEXT] ID       execute a held operation
+  pending reject [-note TEXT] ID        reject a held operation
+  limit list [-status STATUS]           list proposed limit changes; all statuses by default
+  limit show ID                         show a proposed limit change
+  limit propose -as SERVICE [-kind max|credit] [-comment TEXT] ACCOUNT_ID DELTA
+                                        propose a limit or credit line increase as an
+                                        admin service; another one has to approve it
+  limit approve -as SERVICE [-note TEXT] ID
+                                        apply a limit change proposed by another service
+  limit reject -as SERVICE [-note TEXT] ID
+                                        reject or withdraw a proposed limit change
+  reconcile                             check every account against its reservations and ledger`
+
+// runAdmin выполняет команду оператора через сервисный слой, чтобы
+// изменения попадали в журнал, метрики и лог так же, как запросы API.
+func runAdmin(ctx context.Context, cfg *config.Config, group string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(adminUsage)
+	}
+	cmd, args := args[0], args[1:]
+
+	repo, err := postgres.NewBalanceStorage(cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	svc := admin.NewAdminService(repo, cfg.LimitApproval)
+
+	switch group + " " + cmd {
+	case "service create":
+		fs := flag.NewFlagSet("service create", flag.ContinueOnError)
+		perms := fs.String("perms", "", "comma-separated permissions")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		name, err := oneArg(fs.Args(), "NAME")
+		if err != nil {
+			return err
+		}
+		var permissions []string
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "perms" {
+				permissions = splitList(*perms)
+			}
+		})
+		service, apiKey, err := svc.RegisterService(ctx, name, permissions)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("service %d %q registered with permissions %s\napi key: %s\n",
+			service.ID, service.Name, joinList(service.Permissions), apiKey)
+	case "service list":
+		services, err := svc.ListServices(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPERMISSIONS\tSCOPE ACCOUNTS\tSCOPE TAGS\tSIGNING\tCERT NAMES\tRATE LIMIT")
+		for _, s := range services {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.ID, s.Name, joinList(s.Permissions),
+				joinList(s.Scope.AccountIDs), joinList(s.Scope.Tags), s.SigningMode, joinList(s.CertIdentities),
+				formatRateLimit(s.RateLimit))
+		}
+		return w.Flush()
+	case "service permissions":
+		if len(args) < 1 || len(args) > 2 {
+			return errors.New("expected NAME and optional LIST arguments")
+		}
+		service, err := svc.ServiceByName(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		var permissions []string
+		if len(args) == 2 {
+			permissions = splitList(args[1])
+		}
+		if err := svc.SetServicePermissions(ctx, service.ID, permissions); err != nil {
+			return err
+		}
+		fmt.Printf("service %d %q: permissions %s\n", service.ID, service.Name, joinList(permissions))
+	case "service scope":
+		fs := flag.NewFlagSet("service scope", flag.ContinueOnError)
+		accounts := fs.String("accounts", "", "comma-separated account IDs")
+		tags := fs.String("tags", "", "comma-separated account tags")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		name, err := oneArg(fs.Args(), "NAME")
+		if err != nil {
+			return err
+		}
+		var accountIDs []int64
+		for _, s := range splitList(*accounts) {
+			id, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid account ID %q", s)
+			}
+			accountIDs = append(accountIDs, id)
+		}
+		service, err := svc.ServiceByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := svc.SetServiceScope(ctx, service.ID, accountIDs, splitList(*tags)); err != nil {
+			return err
+		}
+		fmt.Printf("service %d %q: scope accounts %s, tags %s\n", service.ID, service.Name,
+			joinList(accountIDs), joinList(splitList(*tags)))
+	case "service signing":
+		fs := flag.NewFlagSet("service signing", flag.ContinueOnError)
+		rotate := fs.Bool("rotate", false, "issue a new signing secret")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 2 {
+			return errors.New("expected NAME and MODE arguments")
+		}
+		service, err := svc.ServiceByName(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		secret, err := svc.SetSigning(ctx, service.ID, fs.Arg(1), *rotate)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("service %d %q: signing %s\n", service.ID, service.Name, fs.Arg(1))
+		if secret != "" {
+			fmt.Printf("signing secret: %s\n", secret)
+		}
+	case "service rate-limit":
+		fs := flag.NewFlagSet("service rate-limit", flag.ContinueOnError)
+		var limit domain.RateLimit
+		fs.Float64Var(&limit.Rate, "rate", 0, "requests per second of the service, 0 is unlimited")
+		fs.IntVar(&limit.Burst, "burst", 0, "requests in a burst of the service")
+		fs.Float64Var(&limit.AccountRate, "account-rate", 0, "requests per second of the service to one account, 0 is unlimited")
+		fs.IntVar(&limit.AccountBurst, "account-burst", 0, "requests in a burst of the service to one account")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		name, err := oneArg(fs.Args(), "NAME")
+		if err != nil {
+			return err
+		}
+		service, err := svc.ServiceByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := svc.SetRateLimit(ctx, service.ID, limit); err != nil {
+			return err
+		}
+		fmt.Printf("service %d %q: %s\n", service.ID, service.Name, formatRateLimit(limit))
+	case "service cert":
+		if len(args) < 1 || len(args) > 2 {
+			return errors.New("expected NAME and optional NAMES arguments")
+		}
+		service, err := svc.ServiceByName(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		var names []string
+		if len(args) == 2 {
+			names = splitList(args[1])
+		}
+		names, err = svc.SetCertIdentities(ctx, service.ID, names)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("service %d %q: certificate names %s\n", service.ID, service.Name, joinList(names))
+	case "service keys":
+		name, err := oneArg(args, "NAME")
+		if err != nil {
+			return err
+		}
+		service, err := svc.ServiceByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		keys, err := svc.ListAPIKeys(ctx, service.ID)
+		if err != nil {
+			return err
+		}
+		return printAPIKeys(keys)
+	case "service add-key", "service rotate-key":
+		fs := flag.NewFlagSet("service "+cmd, flag.ContinueOnError)
+		ttl := fs.Duration("ttl", 0, "key lifetime, 0 means no expiry")
+		overlap := fs.Duration("overlap", 24*time.Hour, "how long the previous keys keep working (rotate-key only)")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		name, err := oneArg(fs.Args(), "NAME")
+		if err != nil {
+			return err
+		}
+		service, err := svc.ServiceByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		var apiKey string
+		var key *domain.APIKey
+		if cmd == "rotate-key" {
+			apiKey, key, err = svc.RotateAPIKey(ctx, service.ID, *overlap, *ttl)
+		} else {
+			apiKey, key, err = svc.CreateAPIKey(ctx, service.ID, *ttl)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("service %d %q, key %d\napi key: %s\n", service.ID, name, key.ID, apiKey)
+	case "service revoke-key":
+		if len(args) != 2 {
+			return errors.New("expected NAME and KEY_ID arguments")
+		}
+		service, err := svc.ServiceByName(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		keyID, err := idArg(args[1:])
+		if err != nil {
+			return err
+		}
+		if err := svc.RevokeAPIKey(ctx, service.ID, keyID); err != nil {
+			return err
+		}
+		fmt.Printf("service %d %q: key %d revoked\n", service.ID, service.Name, keyID)
+	case "account create":
+		fs := flag.NewFlagSet("account create", flag.ContinueOnError)
+		userID := fs.Int64("user-id", 0, "owner user ID")
+		maxAmount := fs.Int64("max-amount", 0, "initial max amount")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		acc, err := svc.CreateAccount(ctx, *userID, *maxAmount)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("account %d created\n", acc.ID)
+	case "account tag":
+		if len(args) < 1 || len(args) > 2 {
+			return errors.New("expected ID and optional TAGS arguments")
+		}
+		id, err := idArg(args[:1])
+		if err != nil {
+			return err
+		}
+		var tags []string
+		if len(args) == 2 {
+			tags = splitList(args[1])
+		}
+		if err := svc.SetAccountTags(ctx, id, tags); err != nil {
+			return err
+		}
+		fmt.Printf("account %d tags: %s\n", id, joinList(tags))
+	case "account freeze", "account unfreeze":
+		id, err := idArg(args)
+		if err != nil {
+			return err
+		}
+		frozen := cmd == "freeze"
+		if err := svc.SetAccountFrozen(ctx, id, frozen); err != nil {
+			return err
+		}
+		fmt.Printf("account %d frozen: %t\n", id, frozen)
+	case "account show":
+		fs := flag.NewFlagSet("account show", flag.ContinueOnError)
+		journal := fs.Int("journal", 20, "number of recent ledger entries")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		id, err := idArg(fs.Args())
+		if err != nil {
+			return err
+		}
+		report, err := svc.InspectAccount(ctx, id, *journal)
+		if err != nil {
+			return err
+		}
+		return printAccountReport(report)
+	case "reservation cancel", "reservation expire":
+		fs := flag.NewFlagSet("reservation "+cmd, flag.ContinueOnError)
+		reason := fs.String("reason", "", "why the reservation is closed (required)")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		id, err := idArg(fs.Args())
+		if err != nil {
+			return err
+		}
+		closeReservation := svc.CancelReservation
+		if cmd == "expire" {
+			closeReservation = svc.ExpireReservation
+		}
+		entry, err := closeReservation(ctx, id, *reason)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reservation %d: %s, %d returned to account %d (ledger entry %d)\n",
+			id, entry.Operation, -entry.DeltaReserved, entry.AccountID, entry.ID)
+	case "velocity list":
+		fs := flag.NewFlagSet("velocity list", flag.ContinueOnError)
+		accountID := fs.Int64("account", 0, "account ID")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 0 {
+			return errors.New("unexpected arguments")
+		}
+		rules, err := svc.ListVelocityRules(ctx, *accountID)
+		if err != nil {
+			return err
+		}
+		return printVelocityRules(rules)
+	case "velocity add":
+		fs := flag.NewFlagSet("velocity add", flag.ContinueOnError)
+		var rule domain.VelocityRule
+		fs.Int64Var(&rule.AccountID, "account", 0, "account ID the rule applies to")
+		fs.StringVar(&rule.Tag, "tag", "", "account tag the rule applies to")
+		kind := fs.String("kind", "", "debit_amount, debit_count, reservation_count or single_debit")
+		fs.Int64Var(&rule.Limit, "limit", 0, "largest allowed amount or number of operations")
+		fs.DurationVar(&rule.Window, "window", 0, "rolling window, e.g. 24h")
+		action := fs.String("action", "", "decline or review; decline by default, single_debit is always review")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 0 {
+			return errors.New("unexpected arguments")
+		}
+		rule.Kind = domain.VelocityKind(*kind)
+		rule.Action = domain.RiskDecision(*action)
+		created, err := svc.CreateVelocityRule(ctx, rule)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("velocity rule %d created: %s\n", created.ID, formatVelocityRule(created))
+	case "velocity delete":
+		id, err := idArg(args)
+		if err != nil {
+			return err
+		}
+		if err := svc.DeleteVelocityRule(ctx, id); err != nil {
+			return err
+		}
+		fmt.Printf("velocity rule %d deleted\n", id)
+	case "pending list":
+		fs := flag.NewFlagSet("pending list", flag.ContinueOnError)
+		status := fs.String("status", "", "PENDING, APPROVED, REJECTED or FAILED")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 0 {
+			return errors.New("unexpected arguments")
+		}
+		ops, err := svc.ListPendingOperations(ctx, strings.ToUpper(*status))
+		if err != nil {
+			return err
+		}
+		return printPendingOperations(ops)
+	case "pending show":
+		id, err := idArg(args)
+		if err != nil {
+			return err
+		}
+		op, err := svc.GetPendingOperation(ctx, id)
+		if err != nil {
+			return err
+		}
+		return printPendingOperation(op)
+	case "pending approve", "pending reject":
+		fs := flag.NewFlagSet("pending "+cmd, flag.ContinueOnError)
+		note := fs.String("note", "", "comment on the decision")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		id, err := idArg(fs.Args())
+		if err != nil {
+			return err
+		}
+		decide := svc.ApprovePendingOperation
+		if cmd == "reject" {
+			decide = svc.RejectPendingOperation
+		}
+		op, err := decide(ctx, id, 0, *note)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("pending operation %d: %s\n", op.ID, formatPendingResult(op))
+	case "limit list":
+		fs := flag.NewFlagSet("limit list", flag.ContinueOnError)
+		status := fs.String("status", "", "PENDING, APPROVED, REJECTED or EXPIRED")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 0 {
+			return errors.New("unexpected arguments")
+		}
+		changes, err := svc.ListLimitChanges(ctx, strings.ToUpper(*status))
+		if err != nil {
+			return err
+		}
+		return printLimitChanges(changes)
+	case "limit show":
+		id, err := idArg(args)
+		if err != nil {
+			return err
+		}
+		change, err := svc.GetLimitChange(ctx, id)
+		if err != nil {
+			return err
+		}
+		return printLimitChange(change)
+	case "limit propose":
+		fs := flag.NewFlagSet("limit propose", flag.ContinueOnError)
+		as := fs.String("as", "", "admin service the operator acts as (required)")
+		comment := fs.String("comment", "", "why the limit is raised")
+		kind := fs.String("kind", "max", "max (account limit) or credit (credit line)")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		operator, err := svc.Operator(ctx, *as)
+		if err != nil {
+			return err
+		}
+		if fs.NArg() != 2 {
+			return errors.New("expected ACCOUNT_ID and DELTA arguments")
+		}
+		accountID, err := idArg(fs.Args()[:1])
+		if err != nil {
+			return err
+		}
+		delta, err := strconv.ParseInt(fs.Arg(1), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid DELTA %q", fs.Arg(1))
+		}
+		change, err := svc.ProposeLimitChange(ctx, strings.ToUpper(*kind), accountID, delta, operator.ID, *comment)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("limit change %d proposed, expires %s\n", change.ID, optionalTime(change.ExpiresAt))
+	case "limit approve", "limit reject":
+		fs := flag.NewFlagSet("limit "+cmd, flag.ContinueOnError)
+		as := fs.String("as", "", "admin service the operator acts as (required)")
+		note := fs.String("note", "", "comment on the decision")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		id, err := idArg(fs.Args())
+		if err != nil {
+			return err
+		}
+		operator, err := svc.Operator(ctx, *as)
+		if err != nil {
+			return err
+		}
+		decide := svc.ApproveLimitChange
+		if cmd == "reject" {
+			decide = svc.RejectLimitChange
+		}
+		change, err := decide(ctx, id, operator.ID, *note)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("limit change %d: %s\n", change.ID, formatLimitChangeResult(change))
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", group+" "+cmd, adminUsage)
+	}
+	return nil
+}
+
+// runReconcile печатает расхождения и возвращает ошибку, если они найдены,
+// чтобы сверку можно было запускать по расписанию и проверять код выхода.
+func runReconcile(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: backend reconcile")
+	}
+	repo, err := postgres.NewBalanceStorage(cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	report, err := admin.NewAdminService(repo, cfg.LimitApproval).Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+	for _, d := range report.Discrepancies {
+		fmt.Printf("account %d: %s\n", d.AccountID, d.Problem)
+	}
+	fmt.Printf("checked %d accounts, %d discrepancies\n", report.Accounts, len(report.Discrepancies))
+	if len(report.Discrepancies) > 0 {
+		return errors.New("reconciliation found discrepancies")
+	}
+	return nil
+}
+
+func printAccountReport(r *admin.AccountReport) error {
+	acc := r.Account
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "account\t%d\n", acc.ID)
+	fmt.Fprintf(w, "user\t%d\n", acc.UserID)
+	fmt.Fprintf(w, "tags\t%s\n", joinList(r.Tags))
+	fmt.Fprintf(w, "frozen\t%t\n", acc.Frozen)
+	fmt.Fprintf(w, "current\t%d\n", acc.CurrentAmount)
+	fmt.Fprintf(w, "reserved\t%d\n", acc.ReservedAmount)
+	fmt.Fprintf(w, "available\t%d\n", acc.Available())
+	fmt.Fprintf(w, "max\t%d\n", acc.MaxAmount)
+	fmt.Fprintf(w, "credit limit\t%d\n", acc.CreditLimit)
+
+	fmt.Fprintf(w, "\nRESERVATION\tSERVICE\tAMOUNT\tSTATUS\tEXPIRES\tKEY\n")
+	for _, res := range r.Reservations {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%s\t%s\n", res.ID, res.OwnerServiceID, res.Amount, res.Status,
+			res.ExpiresAt.Format(time.RFC3339), res.IdempotencyKey)
+	}
+
+	fmt.Fprintf(w, "\nENTRY\tTIME\tOPERATION\tCURRENT\tRESERVED\tMAX\tCREDIT\tSERVICE\tRESERVATION\tDESCRIPTION\n")
+	for _, e := range r.Journal {
+		desc := e.Description
+		if e.ReasonCode != "" {
+			desc = e.ReasonCode + ": " + desc
+		}
+		if e.ReversedBy != 0 {
+			desc += fmt.Sprintf(" (reversed by %d)", e.ReversedBy)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%+d\t%+d\t%+d\t%+d\t%s\t%s\t%s\n", e.ID, e.CreatedAt.Format(time.RFC3339), e.Operation,
+			e.DeltaCurrent, e.DeltaReserved, e.DeltaMax, e.DeltaCredit, optionalID(e.ActorServiceID), optionalID(e.ReservationID), desc)
+	}
+	return w.Flush()
+}
+
+func printAPIKeys(keys []domain.APIKey) error {
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tPREFIX\tACTIVE\tCREATED\tEXPIRES\tREVOKED\tLAST USED")
+	for _, k := range keys {
+		fmt.Fprintf(w, "%d\t%s\t%t\t%s\t%s\t%s\t%s\n", k.ID, k.Prefix, k.Active(now), optionalTime(k.CreatedAt),
+			optionalTime(k.ExpiresAt), optionalTime(k.RevokedAt), optionalTime(k.LastUsedAt))
+	}
+	return w.Flush()
+}
+
+func printVelocityRules(rules []domain.VelocityRule) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE\tACCOUNT\tTAG\tKIND\tLIMIT\tWINDOW\tACTION\tCREATED")
+	for _, r := range rules {
+		tag := r.Tag
+		if tag == "" {
+			tag = "-"
+		}
+		window := "-"
+		if r.Window > 0 {
+			window = r.Window.String()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n", r.ID, optionalID(r.AccountID), tag, r.Kind, r.Limit,
+			window, r.Action, optionalTime(r.CreatedAt))
+	}
+	return w.Flush()
+}
+
+// formatVelocityRule описывает правило: "account 7: debit_amount 50000 per 24h0m0s, decline"
+// или "tag vip: single_debit 10000, review".
+func formatVelocityRule(r *domain.VelocityRule) string {
+	target := fmt.Sprintf("account %d", r.AccountID)
+	if r.Tag != "" {
+		target = "tag " + r.Tag
+	}
+	if r.Window == 0 {
+		return fmt.Sprintf("%s: %s %d, %s", target, r.Kind, r.Limit, r.Action)
+	}
+	return fmt.Sprintf("%s: %s %d per %s, %s", target, r.Kind, r.Limit, r.Window, r.Action)
+}
+
+func printPendingOperations(ops []domain.PendingOperation) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "OPERATION\tKIND\tSERVICE\tACCOUNT\tAMOUNT\tSTATUS\tCREATED\tREASON")
+	for _, op := range ops {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\t%s\t%s\t%s\n", op.ID, op.Kind, op.ServiceID, op.AccountID, op.Amount,
+			op.Status, optionalTime(op.CreatedAt), op.Reason)
+	}
+	return w.Flush()
+}
+
+func printPendingOperation(op *domain.PendingOperation) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "operation\t%d\n", op.ID)
+	fmt.Fprintf(w, "kind\t%s\n", op.Kind)
+	fmt.Fprintf(w, "service\t%d\n", op.ServiceID)
+	fmt.Fprintf(w, "account\t%d\n", op.AccountID)
+	fmt.Fprintf(w, "amount\t%d\n", op.Amount)
+	switch op.Kind {
+	case domain.OperationReservation:
+		fmt.Fprintf(w, "key\t%s\n", op.IdempotencyKey)
+		fmt.Fprintf(w, "timeout\t%s\n", op.Timeout)
+	case domain.OperationTransfer:
+		fmt.Fprintf(w, "description\t%s\n", op.Description)
+		for _, p := range op.Postings {
+			fmt.Fprintf(w, "posting\t%s %s %d\n", p.Side, p.LedgerAccount, p.Amount)
+		}
+	case domain.OperationReversal:
+		fmt.Fprintf(w, "reversal of\t%d\n", op.ReversalOf)
+		fmt.Fprintf(w, "reason code\t%s\n", op.ReasonCode)
+		fmt.Fprintf(w, "description\t%s\n", op.Description)
+	}
+	fmt.Fprintf(w, "reason\t%s\n", op.Reason)
+	fmt.Fprintf(w, "status\t%s\n", op.Status)
+	fmt.Fprintf(w, "created\t%s\n", optionalTime(op.CreatedAt))
+	if op.Status != domain.PendingWaiting {
+		fmt.Fprintf(w, "decided\t%s\n", optionalTime(op.DecidedAt))
+		fmt.Fprintf(w, "decided by\t%s\n", optionalID(op.DecidedBy))
+		fmt.Fprintf(w, "note\t%s\n", op.Note)
+		fmt.Fprintf(w, "result\t%s\n", formatPendingResult(op))
+	}
+	return w.Flush()
+}
+
+// formatPendingResult описывает исход решения: "APPROVED, reservation 12",
+// "APPROVED, ledger entry 40", "REJECTED" или "FAILED: not enough funds".
+func formatPendingResult(op *domain.PendingOperation) string {
+	switch {
+	case op.Status == domain.PendingFailed:
+		return string(op.Status) + ": " + op.Error
+	case op.ReservationID != 0:
+		return fmt.Sprintf("%s, reservation %d", op.Status, op.ReservationID)
+	case op.EntryID != 0:
+		return fmt.Sprintf("%s, ledger entry %d", op.Status, op.EntryID)
+	}
+	return string(op.Status)
+}
+
+func printLimitChanges(changes []domain.LimitChange) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHANGE\tACCOUNT\tKIND\tDELTA\tPROPOSED BY\tSTATUS\tCREATED\tEXPIRES\tCOMMENT")
+	for _, c := range changes {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", c.ID, c.AccountID, c.Kind, c.Delta, optionalID(c.ProposedBy),
+			c.Status, optionalTime(c.CreatedAt), optionalTime(c.ExpiresAt), c.Comment)
+	}
+	return w.Flush()
+}
+
+func printLimitChange(c *domain.LimitChange) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "change\t%d\n", c.ID)
+	fmt.Fprintf(w, "account\t%d\n", c.AccountID)
+	fmt.Fprintf(w, "kind\t%s\n", c.Kind)
+	fmt.Fprintf(w, "delta\t%d\n", c.Delta)
+	fmt.Fprintf(w, "proposed by\t%s\n", optionalID(c.ProposedBy))
+	fmt.Fprintf(w, "comment\t%s\n", c.Comment)
+	fmt.Fprintf(w, "status\t%s\n", c.Status)
+	fmt.Fprintf(w, "created\t%s\n", optionalTime(c.CreatedAt))
+	fmt.Fprintf(w, "expires\t%s\n", optionalTime(c.ExpiresAt))
+	if c.Status == domain.LimitChangeApproved || c.Status == domain.LimitChangeRejected {
+		fmt.Fprintf(w, "decided\t%s\n", optionalTime(c.DecidedAt))
+		fmt.Fprintf(w, "decided by\t%s\n", optionalID(c.DecidedBy))
+		fmt.Fprintf(w, "note\t%s\n", c.Note)
+		fmt.Fprintf(w, "result\t%s\n", formatLimitChangeResult(c))
+	}
+	return w.Flush()
+}
+
+// formatLimitChangeResult описывает исход решения: "APPROVED, ledger entry 40" или "REJECTED".
+func formatLimitChangeResult(c *domain.LimitChange) string {
+	if c.EntryID != 0 {
+		return fmt.Sprintf("%s, ledger entry %d", c.Status, c.EntryID)
+	}
+	return string(c.Status)
+}
+
+func optionalTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func optionalID(id int64) string {
+	if id == 0 {
+		return "-"
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// splitList разбирает список через запятую; пустая строка — пустой список.
+func splitList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// formatRateLimit описывает ограничение частоты: "10/s burst 20, account 1/s burst 5"
+// или "-" без ограничений.
+func formatRateLimit(l domain.RateLimit) string {
+	var parts []string
+	if l.Rate > 0 {
+		parts = append(parts, fmt.Sprintf("%g/s burst %d", l.Rate, l.Burst))
+	}
+	if l.AccountRate > 0 {
+		parts = append(parts, fmt.Sprintf("account %g/s burst %d", l.AccountRate, l.AccountBurst))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func joinList[T any](items []T) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = fmt.Sprint(item)
+	}
+	return strings.Join(parts, ",")
+}
+
+func oneArg(args []string, name string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("expected %s argument", name)
+	}
+	return args[0], nil
+}
+
+func idArg(args []string) (int64, error) {
+	s, err := oneArg(args, "ID")
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid ID %q", s)
+	}
+	return id, nil
+}
@@ -0,0 +1,72 @@
+// Команда stress прогоняет конкурентную нагрузку по резервам и балансам
+// против хранилища и проверяет инварианты. Для PostgreSQL миграции должны
+// быть применены заранее (их применяет основной сервис при старте).
+// Подключение и пул соединений настраиваются как у сервиса (см.
+// internal/config): файлом, окружением или флагами сервиса после флагов
+// команды:
+//
+//	DATABASE_URL=postgresql://... go run ./backend/cmd/stress -store postgres -workers 32
+//	go run ./backend/cmd/stress -store postgres -- -config prod.env -db-max-open-conns 64
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"test_nanimai/backend/internal/config"
+	"test_nanimai/backend/internal/repository/memory"
+	"test_nanimai/backend/internal/repository/postgres"
+	"test_nanimai/backend/internal/repository/repotest"
+	"test_nanimai/backend/internal/stress"
+)
+
+func main() {
+	cfg := stress.DefaultConfig()
+	storeKind := flag.String("store", "memory", "storage: memory or postgres (service settings after --, e.g. -- -database-url ...)")
+	flag.IntVar(&cfg.Accounts, "accounts", cfg.Accounts, "number of accounts")
+	flag.IntVar(&cfg.Workers, "workers", cfg.Workers, "number of concurrent workers")
+	flag.IntVar(&cfg.Ops, "ops", cfg.Ops, "operations per worker")
+	flag.Int64Var(&cfg.MaxAmount, "max-amount", cfg.MaxAmount, "max amount of each account")
+	flag.Float64Var(&cfg.ExpireRatio, "expire-ratio", cfg.ExpireRatio, "share of reservations with a short timeout")
+	flag.DurationVar(&cfg.ShortTimeout, "short-timeout", cfg.ShortTimeout, "timeout of short reservations")
+	flag.Int64Var(&cfg.Seed, "seed", cfg.Seed, "random seed")
+	flag.Parse()
+
+	var store repotest.Store
+	switch *storeKind {
+	case "memory":
+		store = memory.NewBalanceStorage()
+	case "postgres":
+		settings, err := config.Load(flag.Args(), os.Getenv)
+		if err != nil {
+			log.Fatalf("invalid configuration:\n%v", err)
+		}
+		pg, err := postgres.NewBalanceStorage(settings.DatabaseURL)
+		if err != nil {
+			log.Fatalf("failed to connect to database: %v", err)
+		}
+		db := pg.GetDb()
+		db.SetMaxOpenConns(settings.DB.MaxOpenConns)
+		db.SetMaxIdleConns(settings.DB.MaxIdleConns)
+		db.SetConnMaxLifetime(settings.DB.ConnMaxLifetime)
+		db.SetConnMaxIdleTime(settings.DB.ConnMaxIdleTime)
+		store = pg
+	default:
+		log.Fatalf("unknown store %q", *storeKind)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := stress.Run(ctx, store, cfg)
+	if err != nil {
+		log.Fatalf("stress run failed: %v", err)
+	}
+	fmt.Printf("seed: %d\n%s", cfg.Seed, report)
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
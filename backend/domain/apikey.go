@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// APIKeyPrefixLen — длина открытого префикса ключа, по которому ключ ищется
+// в хранилище. Для ключей, выданных до хранения хешей (UUID), это первая группа.
+const APIKeyPrefixLen = 8
+
+// APIKey — API-ключ сервиса. Сам ключ не хранится: только SHA-256 от него и
+// открытый префикс. Ключи случайные и длинные, поэтому медленный хеш не нужен.
+type APIKey struct {
+	ID         int64
+	ServiceID  int64
+	Prefix     string
+	Hash       string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time // нулевое значение — бессрочный
+	RevokedAt  time.Time // нулевое значение — не отозван
+	LastUsedAt time.Time // с точностью до минуты
+}
+
+// Active сообщает, принимается ли ключ в момент now.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt.IsZero() && (k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt))
+}
+
+// Matches сравнивает хеш ключа plain с сохранённым за постоянное время.
+func (k *APIKey) Matches(plain string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKey(plain)), []byte(k.Hash)) == 1
+}
+
+// NewAPIKey выпускает ключ для сервиса serviceID: возвращает открытое
+// значение, которое показывается один раз, и запись для хранилища.
+// При ttl > 0 ключ истекает через ttl после now.
+func NewAPIKey(serviceID int64, ttl time.Duration, now time.Time) (string, *APIKey) {
+	var b [4 + 32]byte
+	rand.Read(b[:])
+	plain := hex.EncodeToString(b[:4]) + "." + base64.RawURLEncoding.EncodeToString(b[4:])
+	key := &APIKey{
+		ServiceID: serviceID,
+		Prefix:    APIKeyPrefix(plain),
+		Hash:      HashAPIKey(plain),
+	}
+	if ttl > 0 {
+		key.ExpiresAt = now.Add(ttl)
+	}
+	return plain, key
+}
+
+// APIKeyPrefix возвращает открытый префикс ключа.
+func APIKeyPrefix(plain string) string {
+	if len(plain) < APIKeyPrefixLen {
+		return plain
+	}
+	return plain[:APIKeyPrefixLen]
+}
+
+// HashAPIKey возвращает SHA-256 ключа в hex.
+func HashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// SigningMode — режим подписи запросов сервиса (см. internal/signing).
+type SigningMode string
+
+const (
+	SigningOff      SigningMode = "off"      // только API-ключ; подписанные запросы отклоняются
+	SigningOptional SigningMode = "optional" // подпись проверяется, если она есть
+	SigningRequired SigningMode = "required" // запросы без подписи отклоняются
+)
+
+// ParseSigningMode проверяет название режима; неизвестный — ErrInvalidSigningMode.
+func ParseSigningMode(s string) (SigningMode, error) {
+	switch m := SigningMode(s); m {
+	case SigningOff, SigningOptional, SigningRequired:
+		return m, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidSigningMode, s)
+}
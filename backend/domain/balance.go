@@ -8,6 +8,25 @@ type Account struct {
 	CurrentAmount  int64
 	MaxAmount      int64
 	ReservedAmount int64
+	CreditLimit    int64
+	// Frozen — счёт заморожен оператором: пополнения, списания, новые
+	// резервы, их подтверждение, возвраты и сторно отклоняются с
+	// ErrAccountFrozen. Отмена и истечение резервов и изменение лимитов
+	// разрешены.
+	Frozen bool
+}
+
+// Available — средства, доступные для списания и резервирования.
+func (a *Account) Available() int64 {
+	return a.CurrentAmount + a.CreditLimit - a.ReservedAmount
+}
+
+// UsedCredit — использованная часть кредитной линии.
+func (a *Account) UsedCredit() int64 {
+	if a.CurrentAmount < 0 {
+		return -a.CurrentAmount
+	}
+	return 0
 }
 
 type Reservation struct {
@@ -20,3 +39,29 @@ type Reservation struct {
 	ExpiresAt      time.Time
 	CreatedAt      time.Time
 }
+
+// Refund — возврат средств по подтверждённому резерву.
+type Refund struct {
+	ID             int64
+	ReservationID  int64
+	OwnerServiceID int64
+	Amount         int64
+	IdempotencyKey string
+	LedgerEntryID  int64
+	CreatedAt      time.Time
+}
+
+// Service — внешний сервис-клиент API.
+type Service struct {
+	ID          int64
+	Name        string
+	Permissions []Permission
+	Scope       AccountScope
+	// SigningMode и SigningSecret — подпись запросов HMAC; секрет пуст при SigningOff.
+	SigningMode   SigningMode
+	SigningSecret string
+	// CertIdentities — имена из клиентского сертификата (CN или SAN), по
+	// которым сервис аутентифицируется без API-ключа (см. internal/mtls).
+	CertIdentities []string
+	RateLimit      RateLimit
+}
@@ -0,0 +1,50 @@
+package domain
+
+import "errors"
+
+var (
+	ErrNotEnoughFunds  = errors.New("not enough funds")
+	ErrNotFound        = errors.New("not found")
+	ErrForbidden       = errors.New("forbidden")
+	ErrExpired         = errors.New("reservation expired")
+	ErrNotActive       = errors.New("reservation not active")
+	ErrAlreadyReversed = errors.New("entry already reversed")
+	ErrNotReversible   = errors.New("entry cannot be reversed")
+	ErrInvalidReason   = errors.New("invalid reason code")
+	ErrInvalidAmount   = errors.New("invalid amount")
+	ErrNotConfirmed    = errors.New("reservation not confirmed")
+	ErrRefundExceeded  = errors.New("refund exceeds captured amount")
+	ErrLimitExceeded   = errors.New("max amount exceeded")
+	ErrCreditInUse     = errors.New("credit limit below used credit")
+	ErrAccountFrozen   = errors.New("account frozen")
+	ErrServiceExists   = errors.New("service already exists")
+	ErrKeyRevoked      = errors.New("api key already revoked")
+	ErrInvalidName     = errors.New("invalid service name")
+	ErrInvalidDuration = errors.New("invalid duration")
+	ErrInvalidTag      = errors.New("invalid tag")
+	ErrInvalidScope    = errors.New("invalid scope")
+
+	ErrInvalidPermission  = errors.New("invalid permission")
+	ErrInvalidSigningMode = errors.New("invalid signing mode")
+	ErrInvalidIdentity    = errors.New("invalid certificate identity")
+	ErrIdentityInUse      = errors.New("certificate identity already assigned")
+	ErrAmbiguousIdentity  = errors.New("certificate identities match several services")
+	ErrInvalidRateLimit   = errors.New("invalid rate limit")
+	ErrRateLimited        = errors.New("rate limit exceeded")
+
+	ErrInvalidVelocityRule = errors.New("invalid velocity rule")
+	ErrVelocityExceeded    = errors.New("velocity limit exceeded")
+
+	ErrRiskDeclined         = errors.New("declined by risk check")
+	ErrRiskUnavailable      = errors.New("risk check unavailable")
+	ErrPendingReview        = errors.New("operation pending review")
+	ErrNotPending           = errors.New("operation already decided")
+	ErrInvalidPendingStatus = errors.New("invalid pending operation status")
+
+	ErrApprovalRequired         = errors.New("limit increase requires approval")
+	ErrSelfApproval             = errors.New("must be approved by another operator")
+	ErrOperatorRequired         = errors.New("limit change requires an operator identity")
+	ErrChangeExpired            = errors.New("limit change expired")
+	ErrInvalidLimitChangeStatus = errors.New("invalid limit change status")
+	ErrInvalidLimitKind         = errors.New("invalid limit kind")
+)
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// NormalizeCertIdentities обрезает пробелы, убирает повторы и сортирует
+// имена сертификатов; пустое имя или имя с запятой или пробелом — ErrInvalidIdentity.
+func NormalizeCertIdentities(identities []string) ([]string, error) {
+	out := make([]string, 0, len(identities))
+	for _, id := range identities {
+		id = strings.TrimSpace(id)
+		if id == "" || strings.ContainsAny(id, ", \t") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidIdentity, id)
+		}
+		out = append(out, id)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
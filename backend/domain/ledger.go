@@ -0,0 +1,334 @@
+package domain
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Операции журнала (значения enum ledger_op).
+const (
+	OpLimitIncrease   = "LIMIT_INCREASE"
+	OpLimitDecrease   = "LIMIT_DECREASE"
+	OpBalanceIncrease = "BALANCE_INCREASE"
+	OpBalanceDecrease = "BALANCE_DECREASE"
+	OpReserveOpen     = "RESERVE_OPEN"
+	OpReserveConfirm  = "RESERVE_CONFIRM"
+	OpReserveCancel   = "RESERVE_CANCEL"
+	OpReserveExpire   = "RESERVE_EXPIRE"
+	OpReserveRefund   = "RESERVE_REFUND"
+	OpAdjustment      = "ADJUSTMENT"
+	OpReversal        = "REVERSAL"
+	OpCreditIncrease  = "CREDIT_LIMIT_INCREASE"
+	OpCreditDecrease  = "CREDIT_LIMIT_DECREASE"
+)
+
+// Коды причин сторнирования.
+const (
+	ReasonOperatorError  = "OPERATOR_ERROR"
+	ReasonDuplicate      = "DUPLICATE"
+	ReasonCustomerRefund = "CUSTOMER_REFUND"
+	ReasonFraud          = "FRAUD"
+	ReasonOther          = "OTHER"
+)
+
+// Системные счета главной книги.
+const (
+	LedgerExternalFunding = "external_funding" // внешние пополнения и выводы
+	LedgerFees            = "fees"             // комиссии
+	LedgerHolds           = "holds"            // средства в активных резервах
+	LedgerSettlement      = "settlement"       // средства, списанные по подтверждённым резервам
+)
+
+const accountLedgerPrefix = "account:"
+
+var (
+	ErrUnbalancedJournal = errors.New("journal entry is not balanced")
+	ErrInvalidPosting    = errors.New("invalid posting")
+)
+
+type PostingSide string
+
+const (
+	Debit  PostingSide = "DEBIT"
+	Credit PostingSide = "CREDIT"
+)
+
+type Posting struct {
+	LedgerAccount string
+	Side          PostingSide
+	Amount        int64
+}
+
+// JournalEntry — проводка по одному счёту: заголовок с изменениями
+// current/reserved/max и набор сбалансированных строк дебета и кредита.
+type JournalEntry struct {
+	ID             int64
+	AccountID      int64
+	ReservationID  int64
+	ActorServiceID int64
+	Operation      string
+	Description    string
+	DeltaCurrent   int64
+	DeltaReserved  int64
+	DeltaMax       int64
+	DeltaCredit    int64
+	ReversalOf     int64 // ID сторнируемой проводки
+	ReversedBy     int64 // ID сторнирующей проводки
+	ReasonCode     string
+	Postings       []Posting
+	CreatedAt      time.Time
+}
+
+// AccountLedger возвращает код счёта главной книги для пользовательского счёта.
+// Его остаток (кредит минус дебет) равен доступным средствам: current - reserved.
+func AccountLedger(accountID int64) string {
+	return accountLedgerPrefix + strconv.FormatInt(accountID, 10)
+}
+
+// ParseAccountLedger извлекает ID пользовательского счёта из кода счёта главной книги.
+func ParseAccountLedger(code string) (int64, bool) {
+	rest, ok := strings.CutPrefix(code, accountLedgerPrefix)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+func IsSystemLedger(code string) bool {
+	switch code {
+	case LedgerExternalFunding, LedgerFees, LedgerHolds, LedgerSettlement:
+		return true
+	}
+	return false
+}
+
+// Validate проверяет, что сумма дебета равна сумме кредита и что строки по
+// счёту проводки согласованы с изменением доступных средств.
+func (e *JournalEntry) Validate() error {
+	own := AccountLedger(e.AccountID)
+	var debit, credit, net int64
+	for _, p := range e.Postings {
+		if p.Amount <= 0 {
+			return ErrInvalidPosting
+		}
+		if p.LedgerAccount != own && !IsSystemLedger(p.LedgerAccount) {
+			return ErrInvalidPosting
+		}
+		var signed int64
+		switch p.Side {
+		case Debit:
+			debit += p.Amount
+			signed = -p.Amount
+		case Credit:
+			credit += p.Amount
+			signed = p.Amount
+		default:
+			return ErrInvalidPosting
+		}
+		if p.LedgerAccount == own {
+			net += signed
+		}
+	}
+	if debit != credit {
+		return ErrUnbalancedJournal
+	}
+	if net != e.DeltaCurrent-e.DeltaReserved {
+		return ErrUnbalancedJournal
+	}
+	return nil
+}
+
+// transfer — пара строк: дебет from, кредит to.
+func transfer(from, to string, amount int64) []Posting {
+	if amount == 0 {
+		return nil
+	}
+	if amount < 0 {
+		from, to, amount = to, from, -amount
+	}
+	return []Posting{
+		{LedgerAccount: from, Side: Debit, Amount: amount},
+		{LedgerAccount: to, Side: Credit, Amount: amount},
+	}
+}
+
+// BalanceJournal — пополнение или списание через внешний источник.
+func BalanceJournal(accountID, delta int64) *JournalEntry {
+	op := OpBalanceIncrease
+	if delta < 0 {
+		op = OpBalanceDecrease
+	}
+	return &JournalEntry{
+		AccountID:    accountID,
+		Operation:    op,
+		DeltaCurrent: delta,
+		Postings:     transfer(LedgerExternalFunding, AccountLedger(accountID), delta),
+	}
+}
+
+// LimitJournal — изменение лимита; денежных строк не содержит.
+func LimitJournal(accountID, delta int64) *JournalEntry {
+	op := OpLimitIncrease
+	if delta < 0 {
+		op = OpLimitDecrease
+	}
+	return &JournalEntry{
+		AccountID: accountID,
+		Operation: op,
+		DeltaMax:  delta,
+	}
+}
+
+// CreditLimitJournal — изменение кредитной линии; денежных строк не содержит.
+func CreditLimitJournal(accountID, delta int64) *JournalEntry {
+	op := OpCreditIncrease
+	if delta < 0 {
+		op = OpCreditDecrease
+	}
+	return &JournalEntry{
+		AccountID:   accountID,
+		Operation:   op,
+		DeltaCredit: delta,
+	}
+}
+
+// ReservationJournal строит проводку для перехода резерва в новое состояние.
+// Открытие переносит средства со счёта в holds, подтверждение — из holds
+// в settlement, отмена и истечение возвращают их на счёт.
+func ReservationJournal(op string, res *Reservation) *JournalEntry {
+	e := &JournalEntry{
+		AccountID:      res.AccountID,
+		ReservationID:  res.ID,
+		ActorServiceID: res.OwnerServiceID,
+		Operation:      op,
+	}
+	acc := AccountLedger(res.AccountID)
+	switch op {
+	case OpReserveOpen:
+		e.DeltaReserved = res.Amount
+		e.Postings = transfer(acc, LedgerHolds, res.Amount)
+	case OpReserveConfirm:
+		e.DeltaCurrent = -res.Amount
+		e.DeltaReserved = -res.Amount
+		e.Postings = transfer(LedgerHolds, LedgerSettlement, res.Amount)
+	case OpReserveCancel, OpReserveExpire:
+		e.DeltaReserved = -res.Amount
+		e.Postings = transfer(LedgerHolds, acc, res.Amount)
+	}
+	return e
+}
+
+// RefundJournal — возврат части или всей суммы подтверждённого резерва из settlement на счёт.
+func RefundJournal(res *Reservation, amount int64) *JournalEntry {
+	return &JournalEntry{
+		AccountID:      res.AccountID,
+		ReservationID:  res.ID,
+		ActorServiceID: res.OwnerServiceID,
+		Operation:      OpReserveRefund,
+		DeltaCurrent:   amount,
+		Postings:       transfer(LedgerSettlement, AccountLedger(res.AccountID), amount),
+	}
+}
+
+// AdjustmentJournal — произвольная сбалансированная проводка по счёту
+// (например, списание комиссии). Изменение баланса вычисляется по строкам.
+// Счёт holds ведут только резервы, корректировка его не касается: такие
+// строки отклоняет ValidateAdjustment.
+func AdjustmentJournal(accountID, actorServiceID int64, description string, postings []Posting) *JournalEntry {
+	own := AccountLedger(accountID)
+	var delta int64
+	for _, p := range postings {
+		if p.LedgerAccount != own {
+			continue
+		}
+		switch p.Side {
+		case Debit:
+			delta -= p.Amount
+		case Credit:
+			delta += p.Amount
+		}
+	}
+	return &JournalEntry{
+		AccountID:      accountID,
+		ActorServiceID: actorServiceID,
+		Operation:      OpAdjustment,
+		Description:    description,
+		DeltaCurrent:   delta,
+		Postings:       postings,
+	}
+}
+
+// ValidateAdjustment проверяет корректировку: как Validate, но без строк по holds.
+func (e *JournalEntry) ValidateAdjustment() error {
+	for _, p := range e.Postings {
+		if p.LedgerAccount == LedgerHolds {
+			return ErrInvalidPosting
+		}
+	}
+	return e.Validate()
+}
+
+func IsValidReason(code string) bool {
+	switch code {
+	case ReasonOperatorError, ReasonDuplicate, ReasonCustomerRefund, ReasonFraud, ReasonOther:
+		return true
+	}
+	return false
+}
+
+// ReversePostings возвращает строки сторно проводки со строками postings:
+// дебет и кредит меняются местами.
+func ReversePostings(postings []Posting) []Posting {
+	out := make([]Posting, 0, len(postings))
+	for _, p := range postings {
+		side := Debit
+		if p.Side == Debit {
+			side = Credit
+		}
+		out = append(out, Posting{LedgerAccount: p.LedgerAccount, Side: side, Amount: p.Amount})
+	}
+	return out
+}
+
+// ReversalJournal строит сторнирующую проводку для orig: изменения
+// баланса меняют знак, строки дебета и кредита меняются местами.
+// Сторно подтверждения резерва возвращает средства из settlement на счёт,
+// а не обратно в holds. Открытие, отмену и истечение резерва, изменения
+// лимита и кредитной линии, а также сами сторнирующие проводки сторнировать
+// нельзя. Сторно уменьшения лимита было бы его увеличением в обход
+// подтверждения по LimitPolicy, поэтому лимит и кредитную линию
+// исправляют новым изменением.
+func ReversalJournal(orig *JournalEntry, actorServiceID int64, reasonCode, description string) (*JournalEntry, error) {
+	if !IsValidReason(reasonCode) {
+		return nil, ErrInvalidReason
+	}
+	e := &JournalEntry{
+		AccountID:      orig.AccountID,
+		ReservationID:  orig.ReservationID,
+		ActorServiceID: actorServiceID,
+		Operation:      OpReversal,
+		Description:    description,
+		ReversalOf:     orig.ID,
+		ReasonCode:     reasonCode,
+	}
+	switch orig.Operation {
+	case OpBalanceIncrease, OpBalanceDecrease, OpAdjustment:
+		e.DeltaCurrent = -orig.DeltaCurrent
+		e.DeltaReserved = -orig.DeltaReserved
+		e.DeltaMax = -orig.DeltaMax
+		e.DeltaCredit = -orig.DeltaCredit
+		e.Postings = ReversePostings(orig.Postings)
+	case OpReserveConfirm:
+		e.DeltaCurrent = -orig.DeltaCurrent
+		e.Postings = transfer(LedgerSettlement, AccountLedger(orig.AccountID), -orig.DeltaCurrent)
+	default:
+		return nil, ErrNotReversible
+	}
+	return e, nil
+}
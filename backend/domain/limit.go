@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// LimitPolicy — когда увеличение лимита или кредитной линии требует
+// второго подтверждения: если вместе с увеличениями того же лимита счёта
+// без подтверждения за Window и ожидающими предложениями по нему оно больше
+// Threshold, увеличение становится предложением (LimitChange), которое
+// действует TTL. Так порог не обойти, разбив увеличение на части. Нулевой
+// Threshold — подтверждение не требуется.
+type LimitPolicy struct {
+	Threshold int64
+	Window    time.Duration
+	TTL       time.Duration
+}
+
+// RequiresApproval сообщает, нужно ли подтверждение увеличения лимита на
+// delta, если за окно лимит счёта уже увеличен без подтверждения и
+// предложен к увеличению в сумме на recent.
+func (p LimitPolicy) RequiresApproval(recent, delta int64) bool {
+	return p.Threshold > 0 && delta > 0 && recent+delta > p.Threshold
+}
+
+// LimitKind — какой лимит счёта меняет предложение.
+type LimitKind string
+
+const (
+	LimitKindMax    LimitKind = "MAX"    // лимит счёта (MaxAmount)
+	LimitKindCredit LimitKind = "CREDIT" // кредитная линия (CreditLimit)
+)
+
+// ParseLimitKind проверяет вид лимита; пустая строка — LimitKindMax.
+func ParseLimitKind(s string) (LimitKind, error) {
+	switch k := LimitKind(s); k {
+	case "":
+		return LimitKindMax, nil
+	case LimitKindMax, LimitKindCredit:
+		return k, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidLimitKind, s)
+}
+
+// LimitChangeStatus — состояние предложенного изменения лимита.
+type LimitChangeStatus string
+
+const (
+	LimitChangePending  LimitChangeStatus = "PENDING"  // ждёт подтверждения
+	LimitChangeApproved LimitChangeStatus = "APPROVED" // подтверждено, лимит изменён
+	LimitChangeRejected LimitChangeStatus = "REJECTED" // отклонено
+	LimitChangeExpired  LimitChangeStatus = "EXPIRED"  // не подтверждено до ExpiresAt
+)
+
+// ParseLimitChangeStatus проверяет статус; пустая строка допустима и
+// означает любой статус.
+func ParseLimitChangeStatus(s string) (LimitChangeStatus, error) {
+	switch st := LimitChangeStatus(s); st {
+	case "", LimitChangePending, LimitChangeApproved, LimitChangeRejected, LimitChangeExpired:
+		return st, nil
+	}
+	return "", fmt.Errorf("%w: unknown status %q", ErrInvalidLimitChangeStatus, s)
+}
+
+// LimitChange — предложение увеличить лимит вида Kind счёта на Delta. Лимит
+// меняется и проводка пишется только при подтверждении, причём подтвердить
+// может только не тот, кто предложил.
+type LimitChange struct {
+	ID         int64
+	AccountID  int64
+	Kind       LimitKind
+	Delta      int64
+	ProposedBy int64 // сервис, предложивший изменение, или оператор из CLI от имени сервиса
+	Comment    string
+	Status     LimitChangeStatus
+
+	DecidedBy int64 // сервис, принявший решение, или оператор из CLI от имени сервиса
+	Note      string
+	EntryID   int64 // проводка изменения лимита после подтверждения
+
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	DecidedAt time.Time
+}
+
+// LimitChangeProposed — изменение лимита не применено, а сохранено как
+// предложение ChangeID и ждёт подтверждения. Оборачивает ErrApprovalRequired.
+type LimitChangeProposed struct {
+	ChangeID  int64
+	ExpiresAt time.Time
+}
+
+func (e *LimitChangeProposed) Error() string {
+	return fmt.Sprintf("%s: limit change %d, expires at %s", ErrApprovalRequired, e.ChangeID, e.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+func (e *LimitChangeProposed) Unwrap() error { return ErrApprovalRequired }
+
+// Journal — проводка подтверждённого изменения: лимит меняется от имени
+// подтвердившего, в описании — номер предложения.
+func (c *LimitChange) Journal() *JournalEntry {
+	e := LimitJournal(c.AccountID, c.Delta)
+	if c.Kind == LimitKindCredit {
+		e = CreditLimitJournal(c.AccountID, c.Delta)
+	}
+	e.ActorServiceID = c.DecidedBy
+	e.Description = fmt.Sprintf("limit change %d", c.ID)
+	return e
+}
+
+// Propose — предложение увеличить лимит вида kind счёта accountID на delta
+// от имени proposedBy, действующее TTL от now.
+func (p LimitPolicy) Propose(kind LimitKind, accountID, delta, proposedBy int64, comment string, now time.Time) *LimitChange {
+	return &LimitChange{
+		AccountID:  accountID,
+		Kind:       kind,
+		Delta:      delta,
+		ProposedBy: proposedBy,
+		Comment:    comment,
+		ExpiresAt:  now.Add(p.TTL),
+	}
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Permission — право сервиса на группу операций API. Чтение счёта и журнала
+// отдельного права не требует, но ограничено областью AccountScope.
+type Permission string
+
+const (
+	PermBalanceCredit   Permission = "balance:credit"   // пополнение баланса, возврат по резерву
+	PermBalanceDebit    Permission = "balance:debit"    // списание с баланса
+	PermLimitWrite      Permission = "limit:write"      // изменение лимита и кредитной линии
+	PermReservationOpen Permission = "reservation:open" // открытие, подтверждение и отмена своих резервов
+	PermJournalWrite    Permission = "journal:write"    // произвольные проводки и сторно
+	PermAdmin           Permission = "admin"            // управление сервисами, ключами и счетами (/admin)
+)
+
+// AllPermissions — все известные права в порядке объявления.
+var AllPermissions = []Permission{
+	PermBalanceCredit, PermBalanceDebit, PermLimitWrite, PermReservationOpen, PermJournalWrite, PermAdmin,
+}
+
+// DefaultPermissions — права сервиса, зарегистрированного без явного
+// списка: все операции API, кроме администрирования.
+var DefaultPermissions = []Permission{
+	PermBalanceCredit, PermBalanceDebit, PermLimitWrite, PermReservationOpen, PermJournalWrite,
+}
+
+// BalancePermission — право, нужное для изменения баланса на delta.
+func BalancePermission(delta int64) Permission {
+	if delta < 0 {
+		return PermBalanceDebit
+	}
+	return PermBalanceCredit
+}
+
+// JournalPermissions — права, нужные для проводки со строками postings:
+// journal:write, а если проводка списывает средства с системного счёта
+// главной книги, то есть зачисляет деньги извне системы, — ещё и admin.
+func JournalPermissions(postings []Posting) []Permission {
+	for _, p := range postings {
+		if p.Side == Debit && IsSystemLedger(p.LedgerAccount) {
+			return []Permission{PermJournalWrite, PermAdmin}
+		}
+	}
+	return []Permission{PermJournalWrite}
+}
+
+// ParsePermissions разбирает список прав, убирая повторы; неизвестное
+// право — ErrInvalidPermission.
+func ParsePermissions(names []string) ([]Permission, error) {
+	out := make([]Permission, 0, len(names))
+	for _, name := range names {
+		p := Permission(strings.TrimSpace(name))
+		if !slices.Contains(AllPermissions, p) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPermission, name)
+		}
+		if !slices.Contains(out, p) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// AccountScope — счета, с которыми может работать сервис: перечисленные
+// по ID и помеченные любым из тегов. Пустая область — все счета.
+type AccountScope struct {
+	AccountIDs []int64
+	Tags       []string
+}
+
+func (s AccountScope) Empty() bool {
+	return len(s.AccountIDs) == 0 && len(s.Tags) == 0
+}
+
+// Allows сообщает, входит ли счёт accountID с тегами tags в область.
+func (s AccountScope) Allows(accountID int64, tags []string) bool {
+	if s.Empty() || slices.Contains(s.AccountIDs, accountID) {
+		return true
+	}
+	for _, tag := range tags {
+		if slices.Contains(s.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Can сообщает, есть ли у сервиса право p.
+func (s *Service) Can(p Permission) bool {
+	return slices.Contains(s.Permissions, p)
+}
+
+// Require возвращает ErrForbidden с названием права, если его нет у сервиса.
+func (s *Service) Require(p Permission) error {
+	if !s.Can(p) {
+		return fmt.Errorf("%w: service %q lacks permission %s", ErrForbidden, s.Name, p)
+	}
+	return nil
+}
+
+// RequireAccount возвращает ErrForbidden, если счёт вне области сервиса.
+func (s *Service) RequireAccount(accountID int64, tags []string) error {
+	if !s.Scope.Allows(accountID, tags) {
+		return fmt.Errorf("%w: account %d is outside the scope of service %q", ErrForbidden, accountID, s.Name)
+	}
+	return nil
+}
+
+// RequireOwner возвращает ErrForbidden, если резерв открыт другим сервисом.
+func (s *Service) RequireOwner(reservationID, ownerServiceID int64) error {
+	if ownerServiceID != s.ID {
+		return fmt.Errorf("%w: reservation %d is not owned by service %q", ErrForbidden, reservationID, s.Name)
+	}
+	return nil
+}
+
+// NormalizeTags обрезает пробелы, убирает повторы и сортирует теги;
+// пустой тег или тег с запятой — ErrInvalidTag.
+func NormalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || strings.Contains(tag, ",") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+		}
+		out = append(out, tag)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "fmt"
+
+// RateLimit — ограничение частоты запросов сервиса по алгоритму token
+// bucket: в среднем Rate запросов в секунду и не больше Burst подряд.
+// AccountRate и AccountBurst так же ограничивают запросы сервиса к каждому
+// отдельному счёту. Нулевой Rate (AccountRate) — без ограничения.
+type RateLimit struct {
+	Rate         float64
+	Burst        int
+	AccountRate  float64
+	AccountBurst int
+}
+
+// Validate проверяет ограничение: частоты не отрицательны, а при заданной
+// частоте Burst не меньше 1; иначе ErrInvalidRateLimit.
+func (l RateLimit) Validate() error {
+	switch {
+	case l.Rate < 0 || l.AccountRate < 0:
+		return fmt.Errorf("%w: negative rate", ErrInvalidRateLimit)
+	case l.Burst < 0 || l.AccountBurst < 0:
+		return fmt.Errorf("%w: negative burst", ErrInvalidRateLimit)
+	case l.Rate > 0 && l.Burst < 1:
+		return fmt.Errorf("%w: burst must be at least 1", ErrInvalidRateLimit)
+	case l.AccountRate > 0 && l.AccountBurst < 1:
+		return fmt.Errorf("%w: account burst must be at least 1", ErrInvalidRateLimit)
+	}
+	return nil
+}
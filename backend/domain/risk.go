@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// RiskDecision — решение проверки риска по операции.
+type RiskDecision string
+
+const (
+	RiskApprove RiskDecision = "approve" // операция выполняется
+	RiskDecline RiskDecision = "decline" // операция отклоняется
+	RiskReview  RiskDecision = "review"  // операция ждёт решения оператора
+)
+
+// OperationKind — вид списания, которое оценивает проверка риска.
+type OperationKind string
+
+const (
+	OperationDebit       OperationKind = "debit"       // UpdateBalance с отрицательной дельтой
+	OperationReservation OperationKind = "reservation" // открытие резерва
+	OperationTransfer    OperationKind = "transfer"    // проводка, уменьшающая current счёта
+	OperationReversal    OperationKind = "reversal"    // сторно, уменьшающее current счёта
+)
+
+// RiskOperation — списание Amount со счёта AccountID по запросу сервиса ServiceID.
+type RiskOperation struct {
+	Kind      OperationKind
+	ServiceID int64
+	AccountID int64
+	Amount    int64
+}
+
+// RiskAssessment — решение по операции и его причина; у RiskApprove причина
+// может быть пустой.
+type RiskAssessment struct {
+	Decision RiskDecision
+	Reason   string
+}
+
+// Valid сообщает, известно ли решение.
+func (d RiskDecision) Valid() bool {
+	switch d {
+	case RiskApprove, RiskDecline, RiskReview:
+		return true
+	}
+	return false
+}
+
+// RiskDeclined — отказ проверки риска с причиной Reason. Оборачивает ErrRiskDeclined.
+type RiskDeclined struct {
+	Reason string
+}
+
+func (e *RiskDeclined) Error() string {
+	return ErrRiskDeclined.Error() + ": " + e.Reason
+}
+
+func (e *RiskDeclined) Unwrap() error { return ErrRiskDeclined }
+
+// PendingReview — операция отложена до решения оператора как
+// PendingOperation с ID OperationID. Оборачивает ErrPendingReview.
+type PendingReview struct {
+	OperationID int64
+	Reason      string
+}
+
+func (e *PendingReview) Error() string {
+	return fmt.Sprintf("%s: operation %d: %s", ErrPendingReview, e.OperationID, e.Reason)
+}
+
+func (e *PendingReview) Unwrap() error { return ErrPendingReview }
+
+// PendingStatus — состояние отложенной операции.
+type PendingStatus string
+
+const (
+	PendingWaiting  PendingStatus = "PENDING"  // ждёт решения
+	PendingApproved PendingStatus = "APPROVED" // одобрена и выполнена
+	PendingRejected PendingStatus = "REJECTED" // отклонена оператором
+	PendingFailed   PendingStatus = "FAILED"   // одобрена, но выполнить не удалось
+)
+
+// ParsePendingStatus проверяет статус; пустая строка допустима и означает
+// любой статус.
+func ParsePendingStatus(s string) (PendingStatus, error) {
+	switch st := PendingStatus(s); st {
+	case "", PendingWaiting, PendingApproved, PendingRejected, PendingFailed:
+		return st, nil
+	}
+	return "", fmt.Errorf("%w: unknown status %q", ErrInvalidPendingStatus, s)
+}
+
+// PendingOperation — операция, которую проверка риска отправила на
+// решение оператора. Хранит всё нужное, чтобы выполнить её после одобрения:
+// для резерва — ключ идемпотентности и срок, для проводки — описание и
+// строки, для сторно — сторнируемую проводку, код причины и описание.
+type PendingOperation struct {
+	ID        int64
+	Kind      OperationKind
+	ServiceID int64 // сервис, запросивший операцию
+	AccountID int64
+	Amount    int64 // сколько списывается со счёта
+
+	IdempotencyKey string
+	Timeout        time.Duration
+	Description    string
+	Postings       []Posting
+	ReversalOf     int64 // сторнируемая проводка
+	ReasonCode     string
+
+	Reason string // почему операция отложена
+	Status PendingStatus
+
+	DecidedBy     int64 // сервис, принявший решение; 0 — оператор из CLI
+	Note          string
+	ReservationID int64  // открытый после одобрения резерв
+	EntryID       int64  // проводка, записанная после одобрения
+	Error         string // почему не удалось выполнить (PendingFailed)
+
+	CreatedAt time.Time
+	DecidedAt time.Time
+}
+
+// Resolution возвращает ошибку для повтора уже решённой операции: отказ
+// для отклонённой или невыполненной, PendingReview для ещё ждущей.
+// Для одобренной — nil.
+func (op *PendingOperation) Resolution() error {
+	switch op.Status {
+	case PendingRejected:
+		reason := "rejected on review"
+		if op.Note != "" {
+			reason += ": " + op.Note
+		}
+		return &RiskDeclined{Reason: reason}
+	case PendingFailed:
+		return &RiskDeclined{Reason: "approved operation " + strconv.FormatInt(op.ID, 10) + " failed: " + op.Error}
+	case PendingApproved:
+		return nil
+	}
+	return &PendingReview{OperationID: op.ID, Reason: op.Reason}
+}
+
+// Journal — проводка одобренного списания или перевода так, как её записал
+// бы сервис баланса. Для резерва и сторно — nil: их хранилище выполняет по
+// IdempotencyKey и ReversalOf.
+func (op *PendingOperation) Journal() *JournalEntry {
+	switch op.Kind {
+	case OperationDebit:
+		return BalanceJournal(op.AccountID, -op.Amount)
+	case OperationTransfer:
+		return AdjustmentJournal(op.AccountID, op.ServiceID, op.Description, op.Postings)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// VelocityKind — что ограничивает правило VelocityRule.
+type VelocityKind string
+
+const (
+	// VelocityDebitAmount — сумма списаний за окно: уменьшений баланса,
+	// открытых резервов и переводов со счёта, включая проверяемую операцию,
+	// не больше Limit.
+	VelocityDebitAmount VelocityKind = "debit_amount"
+	// VelocityDebitCount — число списаний, открытых резервов и переводов за окно не больше Limit.
+	VelocityDebitCount VelocityKind = "debit_count"
+	// VelocityReservationCount — число открытых резервов за окно не больше Limit.
+	VelocityReservationCount VelocityKind = "reservation_count"
+	// VelocitySingleDebit — прямое списание или перевод больше Limit требуют
+	// подтверждения: операция откладывается до решения оператора, поэтому
+	// Action правила всегда RiskReview. Окна не имеет.
+	VelocitySingleDebit VelocityKind = "single_debit"
+)
+
+// MaxVelocityWindow — самое длинное окно правила: длиннее окно — дольше
+// запрос к ledger на каждом списании.
+const MaxVelocityWindow = 31 * 24 * time.Hour
+
+// VelocityRule — правило частоты или объёма списаний со счёта AccountID
+// либо со всех счетов с тегом Tag (группы счетов); задано ровно одно из двух.
+// Окно скользящее: учитываются операции за последние Window. Action —
+// что делать с нарушившей правило операцией: RiskDecline отклоняет её,
+// RiskReview отправляет на решение оператора.
+type VelocityRule struct {
+	ID        int64
+	AccountID int64
+	Tag       string
+	Kind      VelocityKind
+	Limit     int64
+	Window    time.Duration
+	Action    RiskDecision
+	CreatedAt time.Time
+}
+
+// Normalize обрезает пробелы в Tag, подставляет вместо пустого Action
+// RiskReview для VelocitySingleDebit и RiskDecline для остальных видов и
+// проверяет правило; ошибка — ErrInvalidVelocityRule.
+func (r *VelocityRule) Normalize() error {
+	r.Tag = strings.TrimSpace(r.Tag)
+	if r.Action == "" {
+		r.Action = RiskDecline
+		if r.Kind == VelocitySingleDebit {
+			r.Action = RiskReview
+		}
+	}
+	switch {
+	case r.Action != RiskDecline && r.Action != RiskReview:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidVelocityRule, r.Action)
+	case (r.AccountID == 0) == (r.Tag == ""):
+		return fmt.Errorf("%w: exactly one of account id and tag is required", ErrInvalidVelocityRule)
+	case r.AccountID < 0:
+		return fmt.Errorf("%w: account id %d", ErrInvalidVelocityRule, r.AccountID)
+	case strings.Contains(r.Tag, ","):
+		return fmt.Errorf("%w: tag %q", ErrInvalidVelocityRule, r.Tag)
+	case r.Limit <= 0:
+		return fmt.Errorf("%w: limit must be positive", ErrInvalidVelocityRule)
+	}
+	switch r.Kind {
+	case VelocityDebitAmount, VelocityDebitCount, VelocityReservationCount:
+		if r.Window <= 0 || r.Window > MaxVelocityWindow {
+			return fmt.Errorf("%w: window must be between 1s and %s", ErrInvalidVelocityRule, MaxVelocityWindow)
+		}
+		if r.Window%time.Second != 0 {
+			return fmt.Errorf("%w: window must be whole seconds", ErrInvalidVelocityRule)
+		}
+	case VelocitySingleDebit:
+		if r.Window != 0 {
+			return fmt.Errorf("%w: %s has no window", ErrInvalidVelocityRule, r.Kind)
+		}
+		if r.Action != RiskReview {
+			return fmt.Errorf("%w: %s requires action %s", ErrInvalidVelocityRule, r.Kind, RiskReview)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidVelocityRule, r.Kind)
+	}
+	return nil
+}
+
+// VelocityUsage — списания со счёта в ledger за окно правила. Переводы —
+// проводки ADJUSTMENT, уменьшившие current счёта.
+type VelocityUsage struct {
+	DebitAmount  int64 // сумма BALANCE_DECREASE, RESERVE_OPEN и переводов
+	Debits       int64 // число BALANCE_DECREASE, RESERVE_OPEN и переводов
+	Reservations int64 // число RESERVE_OPEN
+}
+
+// Applies сообщает, ограничивает ли правило операцию op.
+func (r VelocityRule) Applies(op RiskOperation) bool {
+	switch r.Kind {
+	case VelocityReservationCount:
+		return op.Kind == OperationReservation
+	case VelocitySingleDebit:
+		return op.Kind != OperationReservation
+	}
+	return true
+}
+
+// Check проверяет op с учётом уже сделанных в окне списаний usage;
+// нарушение — *VelocityViolation.
+func (r VelocityRule) Check(op RiskOperation, usage VelocityUsage) error {
+	var used, next int64
+	switch r.Kind {
+	case VelocityDebitAmount:
+		used, next = usage.DebitAmount, usage.DebitAmount+op.Amount
+	case VelocityDebitCount:
+		used, next = usage.Debits, usage.Debits+1
+	case VelocityReservationCount:
+		used, next = usage.Reservations, usage.Reservations+1
+	case VelocitySingleDebit:
+		next = op.Amount
+	}
+	if next <= r.Limit {
+		return nil
+	}
+	return &VelocityViolation{Rule: r, Used: used, Amount: op.Amount}
+}
+
+// CheckVelocity проверяет op по правилам rules по возрастанию ID и
+// возвращает нарушение, определяющее решение: первое нарушенное правило с
+// действием RiskDecline, а если таких нет — первое нарушенное с RiskReview;
+// nil — правила не нарушены. usage возвращает списания со счёта за окно и
+// вызывается один раз на каждое окно.
+func CheckVelocity(rules []VelocityRule, op RiskOperation, usage func(window time.Duration) (VelocityUsage, error)) (*VelocityViolation, error) {
+	used := make(map[time.Duration]VelocityUsage)
+	var review *VelocityViolation
+	for _, rule := range rules {
+		if !rule.Applies(op) {
+			continue
+		}
+		u, ok := used[rule.Window]
+		if !ok && rule.Window > 0 {
+			var err error
+			if u, err = usage(rule.Window); err != nil {
+				return nil, err
+			}
+			used[rule.Window] = u
+		}
+		var v *VelocityViolation
+		if !errors.As(rule.Check(op, u), &v) {
+			continue
+		}
+		if rule.Action != RiskReview {
+			return v, nil
+		}
+		if review == nil {
+			review = v
+		}
+	}
+	return review, nil
+}
+
+// VelocityViolation — отказ по правилу Rule: в окне уже Used (сумма или
+// число операций), операция на Amount превысила бы Limit. Оборачивает
+// ErrVelocityExceeded.
+type VelocityViolation struct {
+	Rule   VelocityRule
+	Used   int64
+	Amount int64
+}
+
+func (v *VelocityViolation) Error() string {
+	r := v.Rule
+	var reason string
+	switch r.Kind {
+	case VelocityDebitAmount:
+		reason = fmt.Sprintf("%d debited in %s, %d more exceeds %d", v.Used, r.Window, v.Amount, r.Limit)
+	case VelocityDebitCount:
+		reason = fmt.Sprintf("%d debits in %s, limit %d", v.Used, r.Window, r.Limit)
+	case VelocityReservationCount:
+		reason = fmt.Sprintf("%d reservations in %s, limit %d", v.Used, r.Window, r.Limit)
+	case VelocitySingleDebit:
+		reason = fmt.Sprintf("debit of %d exceeds %d and requires confirmation", v.Amount, r.Limit)
+	}
+	return fmt.Sprintf("%s: rule %d (%s): %s", ErrVelocityExceeded, r.ID, r.Kind, reason)
+}
+
+func (v *VelocityViolation) Unwrap() error { return ErrVelocityExceeded }
+
+// Assessment — решение по операции, нарушившей правило: его действие и
+// описание нарушения как причина.
+func (v *VelocityViolation) Assessment() RiskAssessment {
+	return RiskAssessment{Decision: v.Rule.Action, Reason: v.Error()}
+}
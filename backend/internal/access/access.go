@@ -0,0 +1,88 @@
+// Package access проверяет права сервиса на операцию и область счетов, с
+// которыми он может работать. Используется REST-middleware и
+// gRPC-перехватчиком, чтобы оба транспорта применяли одни и те же правила.
+package access
+
+import (
+	"context"
+	"slices"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/repository"
+)
+
+// Request — что нужно проверить для одного вызова API: требуемые права и
+// объект, по которому определяется счёт. Из AccountID, ReservationID и
+// EntryID задаётся не больше одного; все нулевые — вызов не касается счёта.
+// OwnReservation — резерв ReservationID должен быть открыт самим сервисом.
+// ReverseEntry — проводка EntryID сторнируется: кроме Permissions нужны
+// права на сторнирующую проводку, как если бы её строки пришли в
+// PostJournal (domain.JournalPermissions).
+type Request struct {
+	Permissions    []domain.Permission
+	AccountID      int64
+	ReservationID  int64
+	EntryID        int64
+	OwnReservation bool
+	ReverseEntry   bool
+}
+
+type Checker struct {
+	store repository.Access
+}
+
+func NewChecker(store repository.Access) *Checker {
+	return &Checker{store: store}
+}
+
+// Check возвращает ошибку, обёртывающую domain.ErrForbidden, если у сервиса
+// нет нужного права (в том числе на строки сторно), резерв открыт другим
+// сервисом или счёт вне его области.
+// Счёт ищется в хранилище только для сервисов с ограниченной областью; если
+// объекта нет — domain.ErrNotFound.
+func (c *Checker) Check(ctx context.Context, svc *domain.Service, r Request) error {
+	permissions := r.Permissions
+	if r.ReverseEntry {
+		postings, err := c.store.EntryPostings(ctx, r.EntryID)
+		if err != nil {
+			return err
+		}
+		permissions = append(slices.Clip(permissions), domain.JournalPermissions(domain.ReversePostings(postings))...)
+	}
+	for _, p := range permissions {
+		if err := svc.Require(p); err != nil {
+			return err
+		}
+	}
+	if r.OwnReservation {
+		ownerID, err := c.store.ReservationOwnerID(ctx, r.ReservationID)
+		if err != nil {
+			return err
+		}
+		if err := svc.RequireOwner(r.ReservationID, ownerID); err != nil {
+			return err
+		}
+	}
+	if svc.Scope.Empty() {
+		return nil
+	}
+
+	accountID := r.AccountID
+	var err error
+	switch {
+	case r.ReservationID != 0:
+		accountID, err = c.store.ReservationAccountID(ctx, r.ReservationID)
+	case r.EntryID != 0:
+		accountID, err = c.store.EntryAccountID(ctx, r.EntryID)
+	}
+	if err != nil {
+		return err
+	}
+	if accountID == 0 {
+		return nil
+	}
+	tags, err := c.store.AccountTags(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	return svc.RequireAccount(accountID, tags)
+}
@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/access"
+	pb "test_nanimai/backend/internal/api/grpc/pb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AccessInterceptor — аналог REST AccessMiddleware: проверяет права сервиса
+// из контекста, владельца резерва и область счетов по типу запроса; запросы
+// без правила требуют права admin. Отказ — PermissionDenied с названием
+// недостающего права, чужого резерва или счёта вне области. Ставится после
+// APIKeyInterceptor; вызовы без аутентифицированного сервиса пропускает.
+func AccessInterceptor(checker *access.Checker) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		svc := ServiceFromContext(ctx)
+		if svc == nil {
+			return handler(ctx, req)
+		}
+		r, ok := accessRequest(req)
+		if !ok {
+			r = access.Request{Permissions: []domain.Permission{domain.PermAdmin}}
+		}
+		if err := checker.Check(ctx, svc, r); err != nil {
+			if errors.Is(err, domain.ErrForbidden) || errors.Is(err, domain.ErrNotFound) {
+				return nil, toStatus(err)
+			}
+			return nil, status.Error(codes.Internal, "internal error")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// accessRequest описывает проверку для запроса BalanceService; false —
+// правила для запроса нет.
+func accessRequest(req any) (access.Request, bool) {
+	perm := func(p domain.Permission) []domain.Permission { return []domain.Permission{p} }
+	switch r := req.(type) {
+	case *pb.GetAccountRequest:
+		return access.Request{AccountID: r.AccountId}, true
+	case *pb.ListJournalRequest:
+		return access.Request{AccountID: r.AccountId}, true
+	case *pb.UpdateBalanceRequest:
+		return access.Request{Permissions: perm(domain.BalancePermission(r.Delta)), AccountID: r.AccountId}, true
+	case *pb.UpdateLimitRequest:
+		return access.Request{Permissions: perm(domain.PermLimitWrite), AccountID: r.AccountId}, true
+	case *pb.UpdateCreditLimitRequest:
+		return access.Request{Permissions: perm(domain.PermLimitWrite), AccountID: r.AccountId}, true
+	case *pb.OpenReservationRequest:
+		return access.Request{Permissions: perm(domain.PermReservationOpen), AccountID: r.AccountId}, true
+	case *pb.ReservationRequest: // ConfirmReservation, CancelReservation
+		return access.Request{Permissions: perm(domain.PermReservationOpen), ReservationID: r.ReservationId, OwnReservation: true}, true
+	case *pb.RefundReservationRequest:
+		return access.Request{Permissions: perm(domain.PermBalanceCredit), ReservationID: r.ReservationId, OwnReservation: true}, true
+	case *pb.PostJournalRequest:
+		postings := make([]domain.Posting, 0, len(r.Postings))
+		for _, p := range r.Postings {
+			postings = append(postings, domain.Posting{LedgerAccount: p.LedgerAccount, Side: domain.PostingSide(p.Side), Amount: p.Amount})
+		}
+		return access.Request{Permissions: domain.JournalPermissions(postings), AccountID: r.AccountId}, true
+	case *pb.ReverseEntryRequest:
+		return access.Request{Permissions: perm(domain.PermJournalWrite), EntryID: r.EntryId, ReverseEntry: true}, true
+	case *pb.ReverseReservationRequest:
+		return access.Request{Permissions: perm(domain.PermJournalWrite), ReservationID: r.ReservationId, OwnReservation: true}, true
+	}
+	return access.Request{}, false
+}
@@ -0,0 +1,102 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/logging"
+	"test_nanimai/backend/internal/mtls"
+	"test_nanimai/backend/internal/repository"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type serviceKey struct{}
+
+// APIKeyInterceptor — аналог REST ApiKeyAuthMiddleware: ищет ключ в
+// метаданных "x-api-key" или "api_key", а без ключа — проверенный
+// клиентский сертификат соединения, и кладёт сервис в контекст.
+// Без ключа и сертификата, с неизвестным, истёкшим или отозванным ключом
+// или с сертификатом, не назначенным сервису или назначенным нескольким,
+// отвечает Unauthenticated.
+// grpc.health.v1 доступен без ключа.
+func APIKeyInterceptor(services repository.Services) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return handler(ctx, req)
+		}
+
+		var apiKey string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			for _, name := range []string{"x-api-key", "api_key"} {
+				if v := md.Get(name); len(v) > 0 && v[0] != "" {
+					apiKey = v[0]
+					break
+				}
+			}
+		}
+
+		var svc *domain.Service
+		var err error
+		switch identities := peerIdentities(ctx); {
+		case apiKey != "":
+			svc, err = services.AuthenticateAPIKey(ctx, apiKey)
+		case identities != nil:
+			svc, err = services.AuthenticateCertificate(ctx, identities)
+		default:
+			return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+		}
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrAmbiguousIdentity) {
+				return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+			}
+			return nil, status.Error(codes.Internal, "internal error")
+		}
+		logging.AddAttrs(ctx, logging.ServiceID(svc.ID))
+		return handler(context.WithValue(ctx, serviceKey{}, svc), req)
+	}
+}
+
+// peerIdentities возвращает имена проверенного клиентского сертификата
+// соединения или nil.
+func peerIdentities(ctx context.Context) []string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+	return mtls.PeerIdentities(&info.State)
+}
+
+// ServiceFromContext возвращает сервис, аутентифицированный APIKeyInterceptor, или nil.
+func ServiceFromContext(ctx context.Context) *domain.Service {
+	svc, _ := ctx.Value(serviceKey{}).(*domain.Service)
+	return svc
+}
+
+// ServiceIDFromContext возвращает ID сервиса, аутентифицированного APIKeyInterceptor, или 0.
+func ServiceIDFromContext(ctx context.Context) int64 {
+	if svc := ServiceFromContext(ctx); svc != nil {
+		return svc.ID
+	}
+	return 0
+}
+
+// actorServiceID — ID сервиса-инициатора: аутентифицированный сервис, а без
+// перехватчика — значение из запроса.
+func actorServiceID(ctx context.Context, fromRequest int64) int64 {
+	if id := ServiceIDFromContext(ctx); id != 0 {
+		return id
+	}
+	return fromRequest
+}
@@ -17,27 +17,60 @@ func NewBalanceGRPCServer(svc service.Balance) *BalanceGRPCServer {
 	return &BalanceGRPCServer{svc: svc}
 }
 
+func (s *BalanceGRPCServer) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.Account, error) {
+	var asOf time.Time
+	if req.AsOf != 0 {
+		asOf = time.Unix(req.AsOf, 0)
+	}
+	acc, err := s.svc.GetAccount(ctx, req.AccountId, asOf)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.Account{
+		AccountId:       acc.ID,
+		UserId:          acc.UserID,
+		CurrentAmount:   acc.CurrentAmount,
+		ReservedAmount:  acc.ReservedAmount,
+		MaxAmount:       acc.MaxAmount,
+		CreditLimit:     acc.CreditLimit,
+		UsedCredit:      acc.UsedCredit(),
+		AvailableAmount: acc.Available(),
+		Frozen:          acc.Frozen,
+	}, nil
+}
+
 func (s *BalanceGRPCServer) UpdateLimit(ctx context.Context, req *pb.UpdateLimitRequest) (*pb.Empty, error) {
-	err := s.svc.UpdateLimit(ctx, req.AccountId, req.Delta)
-	return &pb.Empty{}, err
+	if err := s.svc.UpdateLimit(ctx, ServiceIDFromContext(ctx), req.AccountId, req.Delta); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.Empty{}, nil
 }
 
 func (s *BalanceGRPCServer) UpdateBalance(ctx context.Context, req *pb.UpdateBalanceRequest) (*pb.Empty, error) {
-	err := s.svc.UpdateBalance(ctx, req.AccountId, req.Delta)
-	return &pb.Empty{}, err
+	if err := s.svc.UpdateBalance(ctx, ServiceIDFromContext(ctx), req.AccountId, req.Delta); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.Empty{}, nil
+}
+
+func (s *BalanceGRPCServer) UpdateCreditLimit(ctx context.Context, req *pb.UpdateCreditLimitRequest) (*pb.Empty, error) {
+	if err := s.svc.UpdateCreditLimit(ctx, ServiceIDFromContext(ctx), req.AccountId, req.Delta); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.Empty{}, nil
 }
 
 func (s *BalanceGRPCServer) OpenReservation(ctx context.Context, req *pb.OpenReservationRequest) (*pb.ReservationResponse, error) {
 	res, err := s.svc.OpenReservation(
 		ctx,
-		req.OwnerServiceId,
+		ServiceIDFromContext(ctx),
 		req.AccountId,
 		req.Amount,
 		req.IdempotencyKey,
 		time.Duration(req.TimeoutSeconds)*time.Second,
 	)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 	return &pb.ReservationResponse{
 		ReservationId:  res.ID,
@@ -50,11 +83,31 @@ func (s *BalanceGRPCServer) OpenReservation(ctx context.Context, req *pb.OpenRes
 }
 
 func (s *BalanceGRPCServer) ConfirmReservation(ctx context.Context, req *pb.ReservationRequest) (*pb.Empty, error) {
-	err := s.svc.ConfirmReservation(ctx, req.ReservationId, req.OwnerServiceId)
-	return &pb.Empty{}, err
+	if err := s.svc.ConfirmReservation(ctx, req.ReservationId, ServiceIDFromContext(ctx)); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.Empty{}, nil
 }
 
 func (s *BalanceGRPCServer) CancelReservation(ctx context.Context, req *pb.ReservationRequest) (*pb.Empty, error) {
-	err := s.svc.CancelReservation(ctx, req.ReservationId, req.OwnerServiceId)
-	return &pb.Empty{}, err
+	if err := s.svc.CancelReservation(ctx, req.ReservationId, ServiceIDFromContext(ctx)); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.Empty{}, nil
+}
+
+func (s *BalanceGRPCServer) RefundReservation(ctx context.Context, req *pb.RefundReservationRequest) (*pb.RefundResponse, error) {
+	refund, err := s.svc.RefundReservation(ctx, req.ReservationId, ServiceIDFromContext(ctx), req.Amount, req.IdempotencyKey)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.RefundResponse{
+		RefundId:       refund.ID,
+		ReservationId:  refund.ReservationID,
+		OwnerServiceId: refund.OwnerServiceID,
+		Amount:         refund.Amount,
+		IdempotencyKey: refund.IdempotencyKey,
+		LedgerEntryId:  refund.LedgerEntryID,
+		CreatedAt:      refund.CreatedAt.Unix(),
+	}, nil
 }
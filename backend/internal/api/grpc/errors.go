@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"errors"
+	"strconv"
+
+	"test_nanimai/backend/domain"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Причины в errdetails.ErrorInfo статуса операции, которая не выполнена,
+// но принята: отложенной до решения оператора (ID в метаданных
+// pending_operation_id) и изменения лимита, ждущего подтверждения (ID в
+// метаданных limit_change_id).
+const (
+	pendingReviewReason    = "PENDING_REVIEW"
+	approvalRequiredReason = "APPROVAL_REQUIRED"
+)
+
+// toStatus переводит доменную ошибку в gRPC-статус с соответствующим кодом.
+func toStatus(err error) error {
+	var pending *domain.PendingReview
+	if errors.As(err, &pending) {
+		return acceptedStatus(err, pendingReviewReason, "pending_operation_id", pending.OperationID)
+	}
+	var proposed *domain.LimitChangeProposed
+	if errors.As(err, &proposed) {
+		return acceptedStatus(err, approvalRequiredReason, "limit_change_id", proposed.ChangeID)
+	}
+	switch {
+	case errors.Is(err, domain.ErrUnbalancedJournal),
+		errors.Is(err, domain.ErrInvalidPosting),
+		errors.Is(err, domain.ErrInvalidReason),
+		errors.Is(err, domain.ErrInvalidAmount):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrAlreadyReversed),
+		errors.Is(err, domain.ErrNotReversible),
+		errors.Is(err, domain.ErrNotEnoughFunds),
+		errors.Is(err, domain.ErrNotConfirmed),
+		errors.Is(err, domain.ErrRefundExceeded),
+		errors.Is(err, domain.ErrLimitExceeded),
+		errors.Is(err, domain.ErrCreditInUse),
+		errors.Is(err, domain.ErrAccountFrozen),
+		errors.Is(err, domain.ErrVelocityExceeded),
+		errors.Is(err, domain.ErrRiskDeclined),
+		errors.Is(err, domain.ErrChangeExpired),
+		errors.Is(err, domain.ErrExpired),
+		errors.Is(err, domain.ErrNotActive):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrForbidden),
+		errors.Is(err, domain.ErrSelfApproval),
+		errors.Is(err, domain.ErrOperatorRequired):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrRateLimited):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, domain.ErrRiskUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return err
+}
+
+// acceptedStatus — FailedPrecondition с errdetails.ErrorInfo: причиной
+// reason и ID принятой операции в метаданных key.
+func acceptedStatus(err error, reason, key string, id int64) error {
+	st := status.New(codes.FailedPrecondition, err.Error())
+	if detailed, derr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Metadata: map[string]string{key: strconv.FormatInt(id, 10)},
+	}); derr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"context"
+
+	"test_nanimai/backend/domain"
+	pb "test_nanimai/backend/internal/api/grpc/pb"
+)
+
+const (
+	defaultJournalLimit = 50
+	maxJournalLimit     = 500
+)
+
+func (s *BalanceGRPCServer) PostJournal(ctx context.Context, req *pb.PostJournalRequest) (*pb.JournalEntry, error) {
+	postings := make([]domain.Posting, 0, len(req.Postings))
+	for _, p := range req.Postings {
+		postings = append(postings, domain.Posting{
+			LedgerAccount: p.LedgerAccount,
+			Side:          domain.PostingSide(p.Side),
+			Amount:        p.Amount,
+		})
+	}
+	entry, err := s.svc.PostJournal(ctx, ServiceIDFromContext(ctx), req.AccountId, req.Description, postings)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBJournalEntry(entry), nil
+}
+
+func (s *BalanceGRPCServer) ListJournal(ctx context.Context, req *pb.ListJournalRequest) (*pb.ListJournalResponse, error) {
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultJournalLimit
+	}
+	if limit > maxJournalLimit {
+		limit = maxJournalLimit
+	}
+	entries, err := s.svc.ListJournal(ctx, req.AccountId, limit)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &pb.ListJournalResponse{Entries: make([]*pb.JournalEntry, 0, len(entries))}
+	for i := range entries {
+		resp.Entries = append(resp.Entries, toPBJournalEntry(&entries[i]))
+	}
+	return resp, nil
+}
+
+func toPBJournalEntry(e *domain.JournalEntry) *pb.JournalEntry {
+	out := &pb.JournalEntry{
+		Id:             e.ID,
+		AccountId:      e.AccountID,
+		ReservationId:  e.ReservationID,
+		ActorServiceId: e.ActorServiceID,
+		Operation:      e.Operation,
+		Description:    e.Description,
+		DeltaCurrent:   e.DeltaCurrent,
+		DeltaReserved:  e.DeltaReserved,
+		DeltaMax:       e.DeltaMax,
+		DeltaCredit:    e.DeltaCredit,
+		ReversalOf:     e.ReversalOf,
+		ReversedBy:     e.ReversedBy,
+		ReasonCode:     e.ReasonCode,
+		CreatedAt:      e.CreatedAt.Unix(),
+	}
+	for _, p := range e.Postings {
+		out.Postings = append(out.Postings, &pb.Posting{
+			LedgerAccount: p.LedgerAccount,
+			Side:          string(p.Side),
+			Amount:        p.Amount,
+		})
+	}
+	return out
+}
+
+func (s *BalanceGRPCServer) ReverseEntry(ctx context.Context, req *pb.ReverseEntryRequest) (*pb.JournalEntry, error) {
+	entry, err := s.svc.ReverseEntry(ctx, req.EntryId, actorServiceID(ctx, req.ActorServiceId), req.ReasonCode, req.Description)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBJournalEntry(entry), nil
+}
+
+func (s *BalanceGRPCServer) ReverseReservation(ctx context.Context, req *pb.ReverseReservationRequest) (*pb.JournalEntry, error) {
+	entry, err := s.svc.ReverseReservation(ctx, req.ReservationId, ServiceIDFromContext(ctx), req.ReasonCode, req.Description)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBJournalEntry(entry), nil
+}
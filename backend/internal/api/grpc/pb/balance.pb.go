@@ -57,6 +57,166 @@ func (*Empty) Descriptor() ([]byte, []int) {
 	return file_balance_proto_rawDescGZIP(), []int{0}
 }
 
+type GetAccountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     int64                  `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	AsOf          int64                  `protobuf:"varint,2,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"` // unix-время; 0 — текущее состояние
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountRequest) Reset() {
+	*x = GetAccountRequest{}
+	mi := &file_balance_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountRequest) ProtoMessage() {}
+
+func (x *GetAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountRequest.ProtoReflect.Descriptor instead.
+func (*GetAccountRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetAccountRequest) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *GetAccountRequest) GetAsOf() int64 {
+	if x != nil {
+		return x.AsOf
+	}
+	return 0
+}
+
+type Account struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	AccountId       int64                  `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	UserId          int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	CurrentAmount   int64                  `protobuf:"varint,3,opt,name=current_amount,json=currentAmount,proto3" json:"current_amount,omitempty"`
+	ReservedAmount  int64                  `protobuf:"varint,4,opt,name=reserved_amount,json=reservedAmount,proto3" json:"reserved_amount,omitempty"`
+	MaxAmount       int64                  `protobuf:"varint,5,opt,name=max_amount,json=maxAmount,proto3" json:"max_amount,omitempty"`
+	CreditLimit     int64                  `protobuf:"varint,6,opt,name=credit_limit,json=creditLimit,proto3" json:"credit_limit,omitempty"`
+	UsedCredit      int64                  `protobuf:"varint,7,opt,name=used_credit,json=usedCredit,proto3" json:"used_credit,omitempty"`
+	AvailableAmount int64                  `protobuf:"varint,8,opt,name=available_amount,json=availableAmount,proto3" json:"available_amount,omitempty"`
+	Frozen          bool                   `protobuf:"varint,9,opt,name=frozen,proto3" json:"frozen,omitempty"` // счёт заморожен оператором
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	mi := &file_balance_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Account) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *Account) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Account) GetCurrentAmount() int64 {
+	if x != nil {
+		return x.CurrentAmount
+	}
+	return 0
+}
+
+func (x *Account) GetReservedAmount() int64 {
+	if x != nil {
+		return x.ReservedAmount
+	}
+	return 0
+}
+
+func (x *Account) GetMaxAmount() int64 {
+	if x != nil {
+		return x.MaxAmount
+	}
+	return 0
+}
+
+func (x *Account) GetCreditLimit() int64 {
+	if x != nil {
+		return x.CreditLimit
+	}
+	return 0
+}
+
+func (x *Account) GetUsedCredit() int64 {
+	if x != nil {
+		return x.UsedCredit
+	}
+	return 0
+}
+
+func (x *Account) GetAvailableAmount() int64 {
+	if x != nil {
+		return x.AvailableAmount
+	}
+	return 0
+}
+
+func (x *Account) GetFrozen() bool {
+	if x != nil {
+		return x.Frozen
+	}
+	return false
+}
+
 type UpdateLimitRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	AccountId     int64                  `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
@@ -67,7 +227,7 @@ type UpdateLimitRequest struct {
 
 func (x *UpdateLimitRequest) Reset() {
 	*x = UpdateLimitRequest{}
-	mi := &file_balance_proto_msgTypes[1]
+	mi := &file_balance_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -79,7 +239,7 @@ func (x *UpdateLimitRequest) String() string {
 func (*UpdateLimitRequest) ProtoMessage() {}
 
 func (x *UpdateLimitRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_balance_proto_msgTypes[1]
+	mi := &file_balance_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -92,7 +252,7 @@ func (x *UpdateLimitRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateLimitRequest.ProtoReflect.Descriptor instead.
 func (*UpdateLimitRequest) Descriptor() ([]byte, []int) {
-	return file_balance_proto_rawDescGZIP(), []int{1}
+	return file_balance_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *UpdateLimitRequest) GetAccountId() int64 {
@@ -119,7 +279,7 @@ type UpdateBalanceRequest struct {
 
 func (x *UpdateBalanceRequest) Reset() {
 	*x = UpdateBalanceRequest{}
-	mi := &file_balance_proto_msgTypes[2]
+	mi := &file_balance_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -131,7 +291,7 @@ func (x *UpdateBalanceRequest) String() string {
 func (*UpdateBalanceRequest) ProtoMessage() {}
 
 func (x *UpdateBalanceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_balance_proto_msgTypes[2]
+	mi := &file_balance_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -144,7 +304,7 @@ func (x *UpdateBalanceRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateBalanceRequest.ProtoReflect.Descriptor instead.
 func (*UpdateBalanceRequest) Descriptor() ([]byte, []int) {
-	return file_balance_proto_rawDescGZIP(), []int{2}
+	return file_balance_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *UpdateBalanceRequest) GetAccountId() int64 {
@@ -161,10 +321,61 @@ func (x *UpdateBalanceRequest) GetDelta() int64 {
 	return 0
 }
 
+type UpdateCreditLimitRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     int64                  `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Delta         int64                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCreditLimitRequest) Reset() {
+	*x = UpdateCreditLimitRequest{}
+	mi := &file_balance_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCreditLimitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCreditLimitRequest) ProtoMessage() {}
+
+func (x *UpdateCreditLimitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCreditLimitRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCreditLimitRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateCreditLimitRequest) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *UpdateCreditLimitRequest) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
 type OpenReservationRequest struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	AccountId      int64                  `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
-	OwnerServiceId int64                  `protobuf:"varint,2,opt,name=owner_service_id,json=ownerServiceId,proto3" json:"owner_service_id,omitempty"`
 	Amount         int64                  `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
 	IdempotencyKey string                 `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
 	TimeoutSeconds int64                  `protobuf:"varint,5,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"`
@@ -174,7 +385,7 @@ type OpenReservationRequest struct {
 
 func (x *OpenReservationRequest) Reset() {
 	*x = OpenReservationRequest{}
-	mi := &file_balance_proto_msgTypes[3]
+	mi := &file_balance_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -186,7 +397,7 @@ func (x *OpenReservationRequest) String() string {
 func (*OpenReservationRequest) ProtoMessage() {}
 
 func (x *OpenReservationRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_balance_proto_msgTypes[3]
+	mi := &file_balance_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -199,7 +410,7 @@ func (x *OpenReservationRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OpenReservationRequest.ProtoReflect.Descriptor instead.
 func (*OpenReservationRequest) Descriptor() ([]byte, []int) {
-	return file_balance_proto_rawDescGZIP(), []int{3}
+	return file_balance_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *OpenReservationRequest) GetAccountId() int64 {
@@ -209,13 +420,6 @@ func (x *OpenReservationRequest) GetAccountId() int64 {
 	return 0
 }
 
-func (x *OpenReservationRequest) GetOwnerServiceId() int64 {
-	if x != nil {
-		return x.OwnerServiceId
-	}
-	return 0
-}
-
 func (x *OpenReservationRequest) GetAmount() int64 {
 	if x != nil {
 		return x.Amount
@@ -251,7 +455,7 @@ type ReservationResponse struct {
 
 func (x *ReservationResponse) Reset() {
 	*x = ReservationResponse{}
-	mi := &file_balance_proto_msgTypes[4]
+	mi := &file_balance_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -263,7 +467,7 @@ func (x *ReservationResponse) String() string {
 func (*ReservationResponse) ProtoMessage() {}
 
 func (x *ReservationResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_balance_proto_msgTypes[4]
+	mi := &file_balance_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -276,7 +480,7 @@ func (x *ReservationResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReservationResponse.ProtoReflect.Descriptor instead.
 func (*ReservationResponse) Descriptor() ([]byte, []int) {
-	return file_balance_proto_rawDescGZIP(), []int{4}
+	return file_balance_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ReservationResponse) GetReservationId() int64 {
@@ -322,16 +526,15 @@ func (x *ReservationResponse) GetExpiresAt() int64 {
 }
 
 type ReservationRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	ReservationId  int64                  `protobuf:"varint,1,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
-	OwnerServiceId int64                  `protobuf:"varint,2,opt,name=owner_service_id,json=ownerServiceId,proto3" json:"owner_service_id,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReservationId int64                  `protobuf:"varint,1,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ReservationRequest) Reset() {
 	*x = ReservationRequest{}
-	mi := &file_balance_proto_msgTypes[5]
+	mi := &file_balance_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -343,7 +546,7 @@ func (x *ReservationRequest) String() string {
 func (*ReservationRequest) ProtoMessage() {}
 
 func (x *ReservationRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_balance_proto_msgTypes[5]
+	mi := &file_balance_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -356,7 +559,7 @@ func (x *ReservationRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReservationRequest.ProtoReflect.Descriptor instead.
 func (*ReservationRequest) Descriptor() ([]byte, []int) {
-	return file_balance_proto_rawDescGZIP(), []int{5}
+	return file_balance_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ReservationRequest) GetReservationId() int64 {
@@ -366,52 +569,785 @@ func (x *ReservationRequest) GetReservationId() int64 {
 	return 0
 }
 
-func (x *ReservationRequest) GetOwnerServiceId() int64 {
+type RefundReservationRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ReservationId  int64                  `protobuf:"varint,1,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	Amount         int64                  `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RefundReservationRequest) Reset() {
+	*x = RefundReservationRequest{}
+	mi := &file_balance_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundReservationRequest) ProtoMessage() {}
+
+func (x *RefundReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundReservationRequest.ProtoReflect.Descriptor instead.
+func (*RefundReservationRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RefundReservationRequest) GetReservationId() int64 {
+	if x != nil {
+		return x.ReservationId
+	}
+	return 0
+}
+
+func (x *RefundReservationRequest) GetAmount() int64 {
 	if x != nil {
-		return x.OwnerServiceId
+		return x.Amount
 	}
 	return 0
 }
 
-var File_balance_proto protoreflect.FileDescriptor
+func (x *RefundReservationRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
 
-const file_balance_proto_rawDesc = "" +
-	"\n" +
-	"\rbalance.proto\x12\abalance\"\a\n" +
-	"\x05Empty\"I\n" +
-	"\x12UpdateLimitRequest\x12\x1d\n" +
-	"\n" +
-	"account_id\x18\x01 \x01(\x03R\taccountId\x12\x14\n" +
-	"\x05delta\x18\x02 \x01(\x03R\x05delta\"K\n" +
-	"\x14UpdateBalanceRequest\x12\x1d\n" +
-	"\n" +
-	"account_id\x18\x01 \x01(\x03R\taccountId\x12\x14\n" +
-	"\x05delta\x18\x02 \x01(\x03R\x05delta\"\xcb\x01\n" +
-	"\x16OpenReservationRequest\x12\x1d\n" +
-	"\n" +
-	"account_id\x18\x01 \x01(\x03R\taccountId\x12(\n" +
-	"\x10owner_service_id\x18\x02 \x01(\x03R\x0eownerServiceId\x12\x16\n" +
-	"\x06amount\x18\x03 \x01(\x03R\x06amount\x12'\n" +
-	"\x0fidempotency_key\x18\x04 \x01(\tR\x0eidempotencyKey\x12'\n" +
-	"\x0ftimeout_seconds\x18\x05 \x01(\x03R\x0etimeoutSeconds\"\xd4\x01\n" +
-	"\x13ReservationResponse\x12%\n" +
-	"\x0ereservation_id\x18\x01 \x01(\x03R\rreservationId\x12\x1d\n" +
-	"\n" +
-	"account_id\x18\x02 \x01(\x03R\taccountId\x12(\n" +
-	"\x10owner_service_id\x18\x03 \x01(\x03R\x0eownerServiceId\x12\x16\n" +
-	"\x06amount\x18\x04 \x01(\x03R\x06amount\x12\x16\n" +
-	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1d\n" +
-	"\n" +
-	"expires_at\x18\x06 \x01(\x03R\texpiresAt\"e\n" +
-	"\x12ReservationRequest\x12%\n" +
-	"\x0ereservation_id\x18\x01 \x01(\x03R\rreservationId\x12(\n" +
-	"\x10owner_service_id\x18\x02 \x01(\x03R\x0eownerServiceId2\xe3\x02\n" +
-	"\x0eBalanceService\x12:\n" +
+type RefundResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RefundId       int64                  `protobuf:"varint,1,opt,name=refund_id,json=refundId,proto3" json:"refund_id,omitempty"`
+	ReservationId  int64                  `protobuf:"varint,2,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	OwnerServiceId int64                  `protobuf:"varint,3,opt,name=owner_service_id,json=ownerServiceId,proto3" json:"owner_service_id,omitempty"`
+	Amount         int64                  `protobuf:"varint,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,5,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	LedgerEntryId  int64                  `protobuf:"varint,6,opt,name=ledger_entry_id,json=ledgerEntryId,proto3" json:"ledger_entry_id,omitempty"`
+	CreatedAt      int64                  `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RefundResponse) Reset() {
+	*x = RefundResponse{}
+	mi := &file_balance_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundResponse) ProtoMessage() {}
+
+func (x *RefundResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundResponse.ProtoReflect.Descriptor instead.
+func (*RefundResponse) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RefundResponse) GetRefundId() int64 {
+	if x != nil {
+		return x.RefundId
+	}
+	return 0
+}
+
+func (x *RefundResponse) GetReservationId() int64 {
+	if x != nil {
+		return x.ReservationId
+	}
+	return 0
+}
+
+func (x *RefundResponse) GetOwnerServiceId() int64 {
+	if x != nil {
+		return x.OwnerServiceId
+	}
+	return 0
+}
+
+func (x *RefundResponse) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *RefundResponse) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *RefundResponse) GetLedgerEntryId() int64 {
+	if x != nil {
+		return x.LedgerEntryId
+	}
+	return 0
+}
+
+func (x *RefundResponse) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type Posting struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LedgerAccount string                 `protobuf:"bytes,1,opt,name=ledger_account,json=ledgerAccount,proto3" json:"ledger_account,omitempty"`
+	Side          string                 `protobuf:"bytes,2,opt,name=side,proto3" json:"side,omitempty"`
+	Amount        int64                  `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Posting) Reset() {
+	*x = Posting{}
+	mi := &file_balance_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Posting) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Posting) ProtoMessage() {}
+
+func (x *Posting) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Posting.ProtoReflect.Descriptor instead.
+func (*Posting) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Posting) GetLedgerAccount() string {
+	if x != nil {
+		return x.LedgerAccount
+	}
+	return ""
+}
+
+func (x *Posting) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *Posting) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+type PostJournalRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     int64                  `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Postings      []*Posting             `protobuf:"bytes,3,rep,name=postings,proto3" json:"postings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PostJournalRequest) Reset() {
+	*x = PostJournalRequest{}
+	mi := &file_balance_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostJournalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostJournalRequest) ProtoMessage() {}
+
+func (x *PostJournalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostJournalRequest.ProtoReflect.Descriptor instead.
+func (*PostJournalRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PostJournalRequest) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *PostJournalRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *PostJournalRequest) GetPostings() []*Posting {
+	if x != nil {
+		return x.Postings
+	}
+	return nil
+}
+
+type JournalEntry struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	AccountId      int64                  `protobuf:"varint,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	ReservationId  int64                  `protobuf:"varint,3,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	ActorServiceId int64                  `protobuf:"varint,4,opt,name=actor_service_id,json=actorServiceId,proto3" json:"actor_service_id,omitempty"`
+	Operation      string                 `protobuf:"bytes,5,opt,name=operation,proto3" json:"operation,omitempty"`
+	Description    string                 `protobuf:"bytes,6,opt,name=description,proto3" json:"description,omitempty"`
+	DeltaCurrent   int64                  `protobuf:"varint,7,opt,name=delta_current,json=deltaCurrent,proto3" json:"delta_current,omitempty"`
+	DeltaReserved  int64                  `protobuf:"varint,8,opt,name=delta_reserved,json=deltaReserved,proto3" json:"delta_reserved,omitempty"`
+	DeltaMax       int64                  `protobuf:"varint,9,opt,name=delta_max,json=deltaMax,proto3" json:"delta_max,omitempty"`
+	Postings       []*Posting             `protobuf:"bytes,10,rep,name=postings,proto3" json:"postings,omitempty"`
+	CreatedAt      int64                  `protobuf:"varint,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ReversalOf     int64                  `protobuf:"varint,12,opt,name=reversal_of,json=reversalOf,proto3" json:"reversal_of,omitempty"`
+	ReversedBy     int64                  `protobuf:"varint,13,opt,name=reversed_by,json=reversedBy,proto3" json:"reversed_by,omitempty"`
+	ReasonCode     string                 `protobuf:"bytes,14,opt,name=reason_code,json=reasonCode,proto3" json:"reason_code,omitempty"`
+	DeltaCredit    int64                  `protobuf:"varint,15,opt,name=delta_credit,json=deltaCredit,proto3" json:"delta_credit,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *JournalEntry) Reset() {
+	*x = JournalEntry{}
+	mi := &file_balance_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JournalEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JournalEntry) ProtoMessage() {}
+
+func (x *JournalEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JournalEntry.ProtoReflect.Descriptor instead.
+func (*JournalEntry) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *JournalEntry) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *JournalEntry) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *JournalEntry) GetReservationId() int64 {
+	if x != nil {
+		return x.ReservationId
+	}
+	return 0
+}
+
+func (x *JournalEntry) GetActorServiceId() int64 {
+	if x != nil {
+		return x.ActorServiceId
+	}
+	return 0
+}
+
+func (x *JournalEntry) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *JournalEntry) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *JournalEntry) GetDeltaCurrent() int64 {
+	if x != nil {
+		return x.DeltaCurrent
+	}
+	return 0
+}
+
+func (x *JournalEntry) GetDeltaReserved() int64 {
+	if x != nil {
+		return x.DeltaReserved
+	}
+	return 0
+}
+
+func (x *JournalEntry) GetDeltaMax() int64 {
+	if x != nil {
+		return x.DeltaMax
+	}
+	return 0
+}
+
+func (x *JournalEntry) GetPostings() []*Posting {
+	if x != nil {
+		return x.Postings
+	}
+	return nil
+}
+
+func (x *JournalEntry) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *JournalEntry) GetReversalOf() int64 {
+	if x != nil {
+		return x.ReversalOf
+	}
+	return 0
+}
+
+func (x *JournalEntry) GetReversedBy() int64 {
+	if x != nil {
+		return x.ReversedBy
+	}
+	return 0
+}
+
+func (x *JournalEntry) GetReasonCode() string {
+	if x != nil {
+		return x.ReasonCode
+	}
+	return ""
+}
+
+func (x *JournalEntry) GetDeltaCredit() int64 {
+	if x != nil {
+		return x.DeltaCredit
+	}
+	return 0
+}
+
+type ListJournalRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     int64                  `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJournalRequest) Reset() {
+	*x = ListJournalRequest{}
+	mi := &file_balance_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJournalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJournalRequest) ProtoMessage() {}
+
+func (x *ListJournalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJournalRequest.ProtoReflect.Descriptor instead.
+func (*ListJournalRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListJournalRequest) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *ListJournalRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListJournalResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*JournalEntry        `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJournalResponse) Reset() {
+	*x = ListJournalResponse{}
+	mi := &file_balance_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJournalResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJournalResponse) ProtoMessage() {}
+
+func (x *ListJournalResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJournalResponse.ProtoReflect.Descriptor instead.
+func (*ListJournalResponse) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListJournalResponse) GetEntries() []*JournalEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type ReverseEntryRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	EntryId        int64                  `protobuf:"varint,1,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
+	ActorServiceId int64                  `protobuf:"varint,2,opt,name=actor_service_id,json=actorServiceId,proto3" json:"actor_service_id,omitempty"`
+	ReasonCode     string                 `protobuf:"bytes,3,opt,name=reason_code,json=reasonCode,proto3" json:"reason_code,omitempty"`
+	Description    string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReverseEntryRequest) Reset() {
+	*x = ReverseEntryRequest{}
+	mi := &file_balance_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReverseEntryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReverseEntryRequest) ProtoMessage() {}
+
+func (x *ReverseEntryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReverseEntryRequest.ProtoReflect.Descriptor instead.
+func (*ReverseEntryRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ReverseEntryRequest) GetEntryId() int64 {
+	if x != nil {
+		return x.EntryId
+	}
+	return 0
+}
+
+func (x *ReverseEntryRequest) GetActorServiceId() int64 {
+	if x != nil {
+		return x.ActorServiceId
+	}
+	return 0
+}
+
+func (x *ReverseEntryRequest) GetReasonCode() string {
+	if x != nil {
+		return x.ReasonCode
+	}
+	return ""
+}
+
+func (x *ReverseEntryRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type ReverseReservationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReservationId int64                  `protobuf:"varint,1,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	ReasonCode    string                 `protobuf:"bytes,3,opt,name=reason_code,json=reasonCode,proto3" json:"reason_code,omitempty"`
+	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReverseReservationRequest) Reset() {
+	*x = ReverseReservationRequest{}
+	mi := &file_balance_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReverseReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReverseReservationRequest) ProtoMessage() {}
+
+func (x *ReverseReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReverseReservationRequest.ProtoReflect.Descriptor instead.
+func (*ReverseReservationRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ReverseReservationRequest) GetReservationId() int64 {
+	if x != nil {
+		return x.ReservationId
+	}
+	return 0
+}
+
+func (x *ReverseReservationRequest) GetReasonCode() string {
+	if x != nil {
+		return x.ReasonCode
+	}
+	return ""
+}
+
+func (x *ReverseReservationRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+var File_balance_proto protoreflect.FileDescriptor
+
+const file_balance_proto_rawDesc = "" +
+	"\n" +
+	"\rbalance.proto\x12\abalance\"\a\n" +
+	"\x05Empty\"G\n" +
+	"\x11GetAccountRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\x12\x13\n" +
+	"\x05as_of\x18\x02 \x01(\x03R\x04asOf\"\xb7\x02\n" +
+	"\aAccount\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12%\n" +
+	"\x0ecurrent_amount\x18\x03 \x01(\x03R\rcurrentAmount\x12'\n" +
+	"\x0freserved_amount\x18\x04 \x01(\x03R\x0ereservedAmount\x12\x1d\n" +
+	"\n" +
+	"max_amount\x18\x05 \x01(\x03R\tmaxAmount\x12!\n" +
+	"\fcredit_limit\x18\x06 \x01(\x03R\vcreditLimit\x12\x1f\n" +
+	"\vused_credit\x18\a \x01(\x03R\n" +
+	"usedCredit\x12)\n" +
+	"\x10available_amount\x18\b \x01(\x03R\x0favailableAmount\x12\x16\n" +
+	"\x06frozen\x18\t \x01(\bR\x06frozen\"I\n" +
+	"\x12UpdateLimitRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\"K\n" +
+	"\x14UpdateBalanceRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\"O\n" +
+	"\x18UpdateCreditLimitRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\"\xb9\x01\n" +
+	"\x16OpenReservationRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x03R\x06amount\x12'\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tR\x0eidempotencyKey\x12'\n" +
+	"\x0ftimeout_seconds\x18\x05 \x01(\x03R\x0etimeoutSecondsJ\x04\b\x02\x10\x03R\x10owner_service_id\"\xd4\x01\n" +
+	"\x13ReservationResponse\x12%\n" +
+	"\x0ereservation_id\x18\x01 \x01(\x03R\rreservationId\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x02 \x01(\x03R\taccountId\x12(\n" +
+	"\x10owner_service_id\x18\x03 \x01(\x03R\x0eownerServiceId\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x03R\x06amount\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\x03R\texpiresAt\"S\n" +
+	"\x12ReservationRequest\x12%\n" +
+	"\x0ereservation_id\x18\x01 \x01(\x03R\rreservationIdJ\x04\b\x02\x10\x03R\x10owner_service_id\"\x9a\x01\n" +
+	"\x18RefundReservationRequest\x12%\n" +
+	"\x0ereservation_id\x18\x01 \x01(\x03R\rreservationId\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x03R\x06amount\x12'\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tR\x0eidempotencyKeyJ\x04\b\x02\x10\x03R\x10owner_service_id\"\x86\x02\n" +
+	"\x0eRefundResponse\x12\x1b\n" +
+	"\trefund_id\x18\x01 \x01(\x03R\brefundId\x12%\n" +
+	"\x0ereservation_id\x18\x02 \x01(\x03R\rreservationId\x12(\n" +
+	"\x10owner_service_id\x18\x03 \x01(\x03R\x0eownerServiceId\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x03R\x06amount\x12'\n" +
+	"\x0fidempotency_key\x18\x05 \x01(\tR\x0eidempotencyKey\x12&\n" +
+	"\x0fledger_entry_id\x18\x06 \x01(\x03R\rledgerEntryId\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAt\"\\\n" +
+	"\aPosting\x12%\n" +
+	"\x0eledger_account\x18\x01 \x01(\tR\rledgerAccount\x12\x12\n" +
+	"\x04side\x18\x02 \x01(\tR\x04side\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x03R\x06amount\"\x83\x01\n" +
+	"\x12PostJournalRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12,\n" +
+	"\bpostings\x18\x03 \x03(\v2\x10.balance.PostingR\bpostings\"\x8a\x04\n" +
+	"\fJournalEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x02 \x01(\x03R\taccountId\x12%\n" +
+	"\x0ereservation_id\x18\x03 \x01(\x03R\rreservationId\x12(\n" +
+	"\x10actor_service_id\x18\x04 \x01(\x03R\x0eactorServiceId\x12\x1c\n" +
+	"\toperation\x18\x05 \x01(\tR\toperation\x12 \n" +
+	"\vdescription\x18\x06 \x01(\tR\vdescription\x12#\n" +
+	"\rdelta_current\x18\a \x01(\x03R\fdeltaCurrent\x12%\n" +
+	"\x0edelta_reserved\x18\b \x01(\x03R\rdeltaReserved\x12\x1b\n" +
+	"\tdelta_max\x18\t \x01(\x03R\bdeltaMax\x12,\n" +
+	"\bpostings\x18\n" +
+	" \x03(\v2\x10.balance.PostingR\bpostings\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\v \x01(\x03R\tcreatedAt\x12\x1f\n" +
+	"\vreversal_of\x18\f \x01(\x03R\n" +
+	"reversalOf\x12\x1f\n" +
+	"\vreversed_by\x18\r \x01(\x03R\n" +
+	"reversedBy\x12\x1f\n" +
+	"\vreason_code\x18\x0e \x01(\tR\n" +
+	"reasonCode\x12!\n" +
+	"\fdelta_credit\x18\x0f \x01(\x03R\vdeltaCredit\"I\n" +
+	"\x12ListJournalRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"F\n" +
+	"\x13ListJournalResponse\x12/\n" +
+	"\aentries\x18\x01 \x03(\v2\x15.balance.JournalEntryR\aentries\"\x9d\x01\n" +
+	"\x13ReverseEntryRequest\x12\x19\n" +
+	"\bentry_id\x18\x01 \x01(\x03R\aentryId\x12(\n" +
+	"\x10actor_service_id\x18\x02 \x01(\x03R\x0eactorServiceId\x12\x1f\n" +
+	"\vreason_code\x18\x03 \x01(\tR\n" +
+	"reasonCode\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\"\x9d\x01\n" +
+	"\x19ReverseReservationRequest\x12%\n" +
+	"\x0ereservation_id\x18\x01 \x01(\x03R\rreservationId\x12\x1f\n" +
+	"\vreason_code\x18\x03 \x01(\tR\n" +
+	"reasonCode\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescriptionJ\x04\b\x02\x10\x03R\x10owner_service_id2\xdb\x06\n" +
+	"\x0eBalanceService\x12:\n" +
+	"\n" +
+	"GetAccount\x12\x1a.balance.GetAccountRequest\x1a\x10.balance.Account\x12:\n" +
 	"\vUpdateLimit\x12\x1b.balance.UpdateLimitRequest\x1a\x0e.balance.Empty\x12>\n" +
-	"\rUpdateBalance\x12\x1d.balance.UpdateBalanceRequest\x1a\x0e.balance.Empty\x12P\n" +
+	"\rUpdateBalance\x12\x1d.balance.UpdateBalanceRequest\x1a\x0e.balance.Empty\x12F\n" +
+	"\x11UpdateCreditLimit\x12!.balance.UpdateCreditLimitRequest\x1a\x0e.balance.Empty\x12P\n" +
 	"\x0fOpenReservation\x12\x1f.balance.OpenReservationRequest\x1a\x1c.balance.ReservationResponse\x12A\n" +
 	"\x12ConfirmReservation\x12\x1b.balance.ReservationRequest\x1a\x0e.balance.Empty\x12@\n" +
-	"\x11CancelReservation\x12\x1b.balance.ReservationRequest\x1a\x0e.balance.EmptyB.Z,test_nanimai/backend/internal/api/grpc/pb;pbb\x06proto3"
+	"\x11CancelReservation\x12\x1b.balance.ReservationRequest\x1a\x0e.balance.Empty\x12O\n" +
+	"\x11RefundReservation\x12!.balance.RefundReservationRequest\x1a\x17.balance.RefundResponse\x12A\n" +
+	"\vPostJournal\x12\x1b.balance.PostJournalRequest\x1a\x15.balance.JournalEntry\x12H\n" +
+	"\vListJournal\x12\x1b.balance.ListJournalRequest\x1a\x1c.balance.ListJournalResponse\x12C\n" +
+	"\fReverseEntry\x12\x1c.balance.ReverseEntryRequest\x1a\x15.balance.JournalEntry\x12O\n" +
+	"\x12ReverseReservation\x12\".balance.ReverseReservationRequest\x1a\x15.balance.JournalEntryB.Z,test_nanimai/backend/internal/api/grpc/pb;pbb\x06proto3"
 
 var (
 	file_balance_proto_rawDescOnce sync.Once
@@ -425,31 +1361,60 @@ func file_balance_proto_rawDescGZIP() []byte {
 	return file_balance_proto_rawDescData
 }
 
-var file_balance_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_balance_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
 var file_balance_proto_goTypes = []any{
-	(*Empty)(nil),                  // 0: balance.Empty
-	(*UpdateLimitRequest)(nil),     // 1: balance.UpdateLimitRequest
-	(*UpdateBalanceRequest)(nil),   // 2: balance.UpdateBalanceRequest
-	(*OpenReservationRequest)(nil), // 3: balance.OpenReservationRequest
-	(*ReservationResponse)(nil),    // 4: balance.ReservationResponse
-	(*ReservationRequest)(nil),     // 5: balance.ReservationRequest
+	(*Empty)(nil),                     // 0: balance.Empty
+	(*GetAccountRequest)(nil),         // 1: balance.GetAccountRequest
+	(*Account)(nil),                   // 2: balance.Account
+	(*UpdateLimitRequest)(nil),        // 3: balance.UpdateLimitRequest
+	(*UpdateBalanceRequest)(nil),      // 4: balance.UpdateBalanceRequest
+	(*UpdateCreditLimitRequest)(nil),  // 5: balance.UpdateCreditLimitRequest
+	(*OpenReservationRequest)(nil),    // 6: balance.OpenReservationRequest
+	(*ReservationResponse)(nil),       // 7: balance.ReservationResponse
+	(*ReservationRequest)(nil),        // 8: balance.ReservationRequest
+	(*RefundReservationRequest)(nil),  // 9: balance.RefundReservationRequest
+	(*RefundResponse)(nil),            // 10: balance.RefundResponse
+	(*Posting)(nil),                   // 11: balance.Posting
+	(*PostJournalRequest)(nil),        // 12: balance.PostJournalRequest
+	(*JournalEntry)(nil),              // 13: balance.JournalEntry
+	(*ListJournalRequest)(nil),        // 14: balance.ListJournalRequest
+	(*ListJournalResponse)(nil),       // 15: balance.ListJournalResponse
+	(*ReverseEntryRequest)(nil),       // 16: balance.ReverseEntryRequest
+	(*ReverseReservationRequest)(nil), // 17: balance.ReverseReservationRequest
 }
 var file_balance_proto_depIdxs = []int32{
-	1, // 0: balance.BalanceService.UpdateLimit:input_type -> balance.UpdateLimitRequest
-	2, // 1: balance.BalanceService.UpdateBalance:input_type -> balance.UpdateBalanceRequest
-	3, // 2: balance.BalanceService.OpenReservation:input_type -> balance.OpenReservationRequest
-	5, // 3: balance.BalanceService.ConfirmReservation:input_type -> balance.ReservationRequest
-	5, // 4: balance.BalanceService.CancelReservation:input_type -> balance.ReservationRequest
-	0, // 5: balance.BalanceService.UpdateLimit:output_type -> balance.Empty
-	0, // 6: balance.BalanceService.UpdateBalance:output_type -> balance.Empty
-	4, // 7: balance.BalanceService.OpenReservation:output_type -> balance.ReservationResponse
-	0, // 8: balance.BalanceService.ConfirmReservation:output_type -> balance.Empty
-	0, // 9: balance.BalanceService.CancelReservation:output_type -> balance.Empty
-	5, // [5:10] is the sub-list for method output_type
-	0, // [0:5] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	11, // 0: balance.PostJournalRequest.postings:type_name -> balance.Posting
+	11, // 1: balance.JournalEntry.postings:type_name -> balance.Posting
+	13, // 2: balance.ListJournalResponse.entries:type_name -> balance.JournalEntry
+	1,  // 3: balance.BalanceService.GetAccount:input_type -> balance.GetAccountRequest
+	3,  // 4: balance.BalanceService.UpdateLimit:input_type -> balance.UpdateLimitRequest
+	4,  // 5: balance.BalanceService.UpdateBalance:input_type -> balance.UpdateBalanceRequest
+	5,  // 6: balance.BalanceService.UpdateCreditLimit:input_type -> balance.UpdateCreditLimitRequest
+	6,  // 7: balance.BalanceService.OpenReservation:input_type -> balance.OpenReservationRequest
+	8,  // 8: balance.BalanceService.ConfirmReservation:input_type -> balance.ReservationRequest
+	8,  // 9: balance.BalanceService.CancelReservation:input_type -> balance.ReservationRequest
+	9,  // 10: balance.BalanceService.RefundReservation:input_type -> balance.RefundReservationRequest
+	12, // 11: balance.BalanceService.PostJournal:input_type -> balance.PostJournalRequest
+	14, // 12: balance.BalanceService.ListJournal:input_type -> balance.ListJournalRequest
+	16, // 13: balance.BalanceService.ReverseEntry:input_type -> balance.ReverseEntryRequest
+	17, // 14: balance.BalanceService.ReverseReservation:input_type -> balance.ReverseReservationRequest
+	2,  // 15: balance.BalanceService.GetAccount:output_type -> balance.Account
+	0,  // 16: balance.BalanceService.UpdateLimit:output_type -> balance.Empty
+	0,  // 17: balance.BalanceService.UpdateBalance:output_type -> balance.Empty
+	0,  // 18: balance.BalanceService.UpdateCreditLimit:output_type -> balance.Empty
+	7,  // 19: balance.BalanceService.OpenReservation:output_type -> balance.ReservationResponse
+	0,  // 20: balance.BalanceService.ConfirmReservation:output_type -> balance.Empty
+	0,  // 21: balance.BalanceService.CancelReservation:output_type -> balance.Empty
+	10, // 22: balance.BalanceService.RefundReservation:output_type -> balance.RefundResponse
+	13, // 23: balance.BalanceService.PostJournal:output_type -> balance.JournalEntry
+	15, // 24: balance.BalanceService.ListJournal:output_type -> balance.ListJournalResponse
+	13, // 25: balance.BalanceService.ReverseEntry:output_type -> balance.JournalEntry
+	13, // 26: balance.BalanceService.ReverseReservation:output_type -> balance.JournalEntry
+	15, // [15:27] is the sub-list for method output_type
+	3,  // [3:15] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_balance_proto_init() }
@@ -463,7 +1428,7 @@ func file_balance_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_balance_proto_rawDesc), len(file_balance_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   18,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
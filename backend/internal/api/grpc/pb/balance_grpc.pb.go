@@ -19,22 +19,36 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
+	BalanceService_GetAccount_FullMethodName         = "/balance.BalanceService/GetAccount"
 	BalanceService_UpdateLimit_FullMethodName        = "/balance.BalanceService/UpdateLimit"
 	BalanceService_UpdateBalance_FullMethodName      = "/balance.BalanceService/UpdateBalance"
+	BalanceService_UpdateCreditLimit_FullMethodName  = "/balance.BalanceService/UpdateCreditLimit"
 	BalanceService_OpenReservation_FullMethodName    = "/balance.BalanceService/OpenReservation"
 	BalanceService_ConfirmReservation_FullMethodName = "/balance.BalanceService/ConfirmReservation"
 	BalanceService_CancelReservation_FullMethodName  = "/balance.BalanceService/CancelReservation"
+	BalanceService_RefundReservation_FullMethodName  = "/balance.BalanceService/RefundReservation"
+	BalanceService_PostJournal_FullMethodName        = "/balance.BalanceService/PostJournal"
+	BalanceService_ListJournal_FullMethodName        = "/balance.BalanceService/ListJournal"
+	BalanceService_ReverseEntry_FullMethodName       = "/balance.BalanceService/ReverseEntry"
+	BalanceService_ReverseReservation_FullMethodName = "/balance.BalanceService/ReverseReservation"
 )
 
 // BalanceServiceClient is the client API for BalanceService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type BalanceServiceClient interface {
+	GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*Account, error)
 	UpdateLimit(ctx context.Context, in *UpdateLimitRequest, opts ...grpc.CallOption) (*Empty, error)
 	UpdateBalance(ctx context.Context, in *UpdateBalanceRequest, opts ...grpc.CallOption) (*Empty, error)
+	UpdateCreditLimit(ctx context.Context, in *UpdateCreditLimitRequest, opts ...grpc.CallOption) (*Empty, error)
 	OpenReservation(ctx context.Context, in *OpenReservationRequest, opts ...grpc.CallOption) (*ReservationResponse, error)
 	ConfirmReservation(ctx context.Context, in *ReservationRequest, opts ...grpc.CallOption) (*Empty, error)
 	CancelReservation(ctx context.Context, in *ReservationRequest, opts ...grpc.CallOption) (*Empty, error)
+	RefundReservation(ctx context.Context, in *RefundReservationRequest, opts ...grpc.CallOption) (*RefundResponse, error)
+	PostJournal(ctx context.Context, in *PostJournalRequest, opts ...grpc.CallOption) (*JournalEntry, error)
+	ListJournal(ctx context.Context, in *ListJournalRequest, opts ...grpc.CallOption) (*ListJournalResponse, error)
+	ReverseEntry(ctx context.Context, in *ReverseEntryRequest, opts ...grpc.CallOption) (*JournalEntry, error)
+	ReverseReservation(ctx context.Context, in *ReverseReservationRequest, opts ...grpc.CallOption) (*JournalEntry, error)
 }
 
 type balanceServiceClient struct {
@@ -45,6 +59,16 @@ func NewBalanceServiceClient(cc grpc.ClientConnInterface) BalanceServiceClient {
 	return &balanceServiceClient{cc}
 }
 
+func (c *balanceServiceClient) GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, BalanceService_GetAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *balanceServiceClient) UpdateLimit(ctx context.Context, in *UpdateLimitRequest, opts ...grpc.CallOption) (*Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Empty)
@@ -65,6 +89,16 @@ func (c *balanceServiceClient) UpdateBalance(ctx context.Context, in *UpdateBala
 	return out, nil
 }
 
+func (c *balanceServiceClient) UpdateCreditLimit(ctx context.Context, in *UpdateCreditLimitRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, BalanceService_UpdateCreditLimit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *balanceServiceClient) OpenReservation(ctx context.Context, in *OpenReservationRequest, opts ...grpc.CallOption) (*ReservationResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ReservationResponse)
@@ -95,15 +129,72 @@ func (c *balanceServiceClient) CancelReservation(ctx context.Context, in *Reserv
 	return out, nil
 }
 
+func (c *balanceServiceClient) RefundReservation(ctx context.Context, in *RefundReservationRequest, opts ...grpc.CallOption) (*RefundResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RefundResponse)
+	err := c.cc.Invoke(ctx, BalanceService_RefundReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balanceServiceClient) PostJournal(ctx context.Context, in *PostJournalRequest, opts ...grpc.CallOption) (*JournalEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JournalEntry)
+	err := c.cc.Invoke(ctx, BalanceService_PostJournal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balanceServiceClient) ListJournal(ctx context.Context, in *ListJournalRequest, opts ...grpc.CallOption) (*ListJournalResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJournalResponse)
+	err := c.cc.Invoke(ctx, BalanceService_ListJournal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balanceServiceClient) ReverseEntry(ctx context.Context, in *ReverseEntryRequest, opts ...grpc.CallOption) (*JournalEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JournalEntry)
+	err := c.cc.Invoke(ctx, BalanceService_ReverseEntry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balanceServiceClient) ReverseReservation(ctx context.Context, in *ReverseReservationRequest, opts ...grpc.CallOption) (*JournalEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JournalEntry)
+	err := c.cc.Invoke(ctx, BalanceService_ReverseReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BalanceServiceServer is the server API for BalanceService service.
 // All implementations must embed UnimplementedBalanceServiceServer
 // for forward compatibility.
 type BalanceServiceServer interface {
+	GetAccount(context.Context, *GetAccountRequest) (*Account, error)
 	UpdateLimit(context.Context, *UpdateLimitRequest) (*Empty, error)
 	UpdateBalance(context.Context, *UpdateBalanceRequest) (*Empty, error)
+	UpdateCreditLimit(context.Context, *UpdateCreditLimitRequest) (*Empty, error)
 	OpenReservation(context.Context, *OpenReservationRequest) (*ReservationResponse, error)
 	ConfirmReservation(context.Context, *ReservationRequest) (*Empty, error)
 	CancelReservation(context.Context, *ReservationRequest) (*Empty, error)
+	RefundReservation(context.Context, *RefundReservationRequest) (*RefundResponse, error)
+	PostJournal(context.Context, *PostJournalRequest) (*JournalEntry, error)
+	ListJournal(context.Context, *ListJournalRequest) (*ListJournalResponse, error)
+	ReverseEntry(context.Context, *ReverseEntryRequest) (*JournalEntry, error)
+	ReverseReservation(context.Context, *ReverseReservationRequest) (*JournalEntry, error)
 	mustEmbedUnimplementedBalanceServiceServer()
 }
 
@@ -114,12 +205,18 @@ type BalanceServiceServer interface {
 // pointer dereference when methods are called.
 type UnimplementedBalanceServiceServer struct{}
 
+func (UnimplementedBalanceServiceServer) GetAccount(context.Context, *GetAccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccount not implemented")
+}
 func (UnimplementedBalanceServiceServer) UpdateLimit(context.Context, *UpdateLimitRequest) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateLimit not implemented")
 }
 func (UnimplementedBalanceServiceServer) UpdateBalance(context.Context, *UpdateBalanceRequest) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateBalance not implemented")
 }
+func (UnimplementedBalanceServiceServer) UpdateCreditLimit(context.Context, *UpdateCreditLimitRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCreditLimit not implemented")
+}
 func (UnimplementedBalanceServiceServer) OpenReservation(context.Context, *OpenReservationRequest) (*ReservationResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method OpenReservation not implemented")
 }
@@ -129,6 +226,21 @@ func (UnimplementedBalanceServiceServer) ConfirmReservation(context.Context, *Re
 func (UnimplementedBalanceServiceServer) CancelReservation(context.Context, *ReservationRequest) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CancelReservation not implemented")
 }
+func (UnimplementedBalanceServiceServer) RefundReservation(context.Context, *RefundReservationRequest) (*RefundResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefundReservation not implemented")
+}
+func (UnimplementedBalanceServiceServer) PostJournal(context.Context, *PostJournalRequest) (*JournalEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PostJournal not implemented")
+}
+func (UnimplementedBalanceServiceServer) ListJournal(context.Context, *ListJournalRequest) (*ListJournalResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJournal not implemented")
+}
+func (UnimplementedBalanceServiceServer) ReverseEntry(context.Context, *ReverseEntryRequest) (*JournalEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReverseEntry not implemented")
+}
+func (UnimplementedBalanceServiceServer) ReverseReservation(context.Context, *ReverseReservationRequest) (*JournalEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReverseReservation not implemented")
+}
 func (UnimplementedBalanceServiceServer) mustEmbedUnimplementedBalanceServiceServer() {}
 func (UnimplementedBalanceServiceServer) testEmbeddedByValue()                        {}
 
@@ -150,6 +262,24 @@ func RegisterBalanceServiceServer(s grpc.ServiceRegistrar, srv BalanceServiceSer
 	s.RegisterService(&BalanceService_ServiceDesc, srv)
 }
 
+func _BalanceService_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalanceServiceServer).GetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BalanceService_GetAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalanceServiceServer).GetAccount(ctx, req.(*GetAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _BalanceService_UpdateLimit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UpdateLimitRequest)
 	if err := dec(in); err != nil {
@@ -186,6 +316,24 @@ func _BalanceService_UpdateBalance_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BalanceService_UpdateCreditLimit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCreditLimitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalanceServiceServer).UpdateCreditLimit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BalanceService_UpdateCreditLimit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalanceServiceServer).UpdateCreditLimit(ctx, req.(*UpdateCreditLimitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _BalanceService_OpenReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(OpenReservationRequest)
 	if err := dec(in); err != nil {
@@ -240,6 +388,96 @@ func _BalanceService_CancelReservation_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BalanceService_RefundReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefundReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalanceServiceServer).RefundReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BalanceService_RefundReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalanceServiceServer).RefundReservation(ctx, req.(*RefundReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BalanceService_PostJournal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostJournalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalanceServiceServer).PostJournal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BalanceService_PostJournal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalanceServiceServer).PostJournal(ctx, req.(*PostJournalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BalanceService_ListJournal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJournalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalanceServiceServer).ListJournal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BalanceService_ListJournal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalanceServiceServer).ListJournal(ctx, req.(*ListJournalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BalanceService_ReverseEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReverseEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalanceServiceServer).ReverseEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BalanceService_ReverseEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalanceServiceServer).ReverseEntry(ctx, req.(*ReverseEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BalanceService_ReverseReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReverseReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalanceServiceServer).ReverseReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BalanceService_ReverseReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalanceServiceServer).ReverseReservation(ctx, req.(*ReverseReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // BalanceService_ServiceDesc is the grpc.ServiceDesc for BalanceService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -247,6 +485,10 @@ var BalanceService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "balance.BalanceService",
 	HandlerType: (*BalanceServiceServer)(nil),
 	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetAccount",
+			Handler:    _BalanceService_GetAccount_Handler,
+		},
 		{
 			MethodName: "UpdateLimit",
 			Handler:    _BalanceService_UpdateLimit_Handler,
@@ -255,6 +497,10 @@ var BalanceService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateBalance",
 			Handler:    _BalanceService_UpdateBalance_Handler,
 		},
+		{
+			MethodName: "UpdateCreditLimit",
+			Handler:    _BalanceService_UpdateCreditLimit_Handler,
+		},
 		{
 			MethodName: "OpenReservation",
 			Handler:    _BalanceService_OpenReservation_Handler,
@@ -267,6 +513,26 @@ var BalanceService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CancelReservation",
 			Handler:    _BalanceService_CancelReservation_Handler,
 		},
+		{
+			MethodName: "RefundReservation",
+			Handler:    _BalanceService_RefundReservation_Handler,
+		},
+		{
+			MethodName: "PostJournal",
+			Handler:    _BalanceService_PostJournal_Handler,
+		},
+		{
+			MethodName: "ListJournal",
+			Handler:    _BalanceService_ListJournal_Handler,
+		},
+		{
+			MethodName: "ReverseEntry",
+			Handler:    _BalanceService_ReverseEntry_Handler,
+		},
+		{
+			MethodName: "ReverseReservation",
+			Handler:    _BalanceService_ReverseReservation_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "balance.proto",
@@ -0,0 +1,40 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"test_nanimai/backend/internal/ratelimit"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RateLimitInterceptor — аналог REST RateLimitMiddleware: ограничивает
+// частоту вызовов сервиса из контекста и его вызовов к счёту из запроса.
+// Отказ — ResourceExhausted с errdetails.RetryInfo и заголовком
+// "retry-after" в секундах. Ставится после APIKeyInterceptor.
+func RateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		svc := ServiceFromContext(ctx)
+		if svc == nil {
+			return handler(ctx, req)
+		}
+		r, _ := accessRequest(req)
+		var exceeded *ratelimit.Exceeded
+		if err := limiter.Allow(svc, r.AccountID); errors.As(err, &exceeded) {
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(exceeded.RetryAfterSeconds())))
+			st := status.New(codes.ResourceExhausted, err.Error())
+			if detailed, derr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(exceeded.RetryAfter)}); derr == nil {
+				st = detailed
+			}
+			return nil, st.Err()
+		}
+		return handler(ctx, req)
+	}
+}
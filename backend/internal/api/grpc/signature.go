@@ -0,0 +1,46 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"test_nanimai/backend/internal/signing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// SignatureInterceptor — аналог REST SignatureMiddleware: подпись берётся из
+// метаданных x-signature, x-signature-timestamp и x-signature-nonce и
+// покрывает полное имя метода и детерминированно сериализованный запрос.
+// Отказ — Unauthenticated с причиной. Ставится после APIKeyInterceptor.
+func SignatureInterceptor(verifier *signing.Verifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		svc := ServiceFromContext(ctx)
+		if svc == nil {
+			return handler(ctx, req)
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		get := func(name string) string {
+			if v := md.Get(strings.ToLower(name)); len(v) > 0 {
+				return v[0]
+			}
+			return ""
+		}
+		sig := signing.Signature{
+			Value:     get(signing.HeaderSignature),
+			Timestamp: get(signing.HeaderTimestamp),
+			Nonce:     get(signing.HeaderNonce),
+		}
+		body, err := signing.MarshalGRPC(req)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "internal error")
+		}
+		if err := verifier.Verify(svc, signing.GRPCMethod, info.FullMethod, body, sig); err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(ctx, req)
+	}
+}
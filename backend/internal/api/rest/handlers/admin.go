@@ -0,0 +1,671 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	svc service.Admin
+}
+
+func NewAdminHandler(svc service.Admin) *AdminHandler {
+	return &AdminHandler{svc: svc}
+}
+
+// ListServices godoc
+// @Summary Возвращает зарегистрированные сервисы
+// @Description Права и область каждого сервиса. Доступно только сервисам с правом admin
+// @Tags admin
+// @Produce json
+// @Success 200 {array} service.ServiceDTO
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/services [get]
+func (h *AdminHandler) ListServices(c *gin.Context) {
+	services, err := h.svc.ListServices(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	out := make([]service.ServiceDTO, 0, len(services))
+	for i := range services {
+		out = append(out, service.NewServiceDTO(&services[i]))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// CreateService godoc
+// @Summary Регистрирует сервис
+// @Description Создаёт сервис и выдаёт ему бессрочный API-ключ. Без Permissions сервис получает все права, кроме admin. Значение ключа возвращается только в этом ответе
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param input body service.CreateServiceInput true "Сервис"
+// @Success 201 {object} service.CreatedServiceDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/services [post]
+func (h *AdminHandler) CreateService(c *gin.Context) {
+	var input service.CreateServiceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	svc, apiKey, err := h.svc.RegisterService(c.Request.Context(), input.Name, input.Permissions)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, service.CreatedServiceDTO{Service: service.NewServiceDTO(svc), APIKey: apiKey})
+}
+
+// SetServicePermissions godoc
+// @Summary Задаёт права сервиса
+// @Description Заменяет список прав: balance:credit, balance:debit, limit:write, reservation:open, journal:write, admin. Действует с первого следующего запроса сервиса
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param service_id path int true "ID сервиса"
+// @Param input body service.SetPermissionsInput true "Права"
+// @Success 200 {string} string "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/services/{service_id}/permissions [put]
+func (h *AdminHandler) SetServicePermissions(c *gin.Context) {
+	serviceID, _ := strconv.ParseInt(c.Param("service_id"), 10, 64)
+	var input service.SetPermissionsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.SetServicePermissions(c.Request.Context(), serviceID, input.Permissions); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// SetServiceScope godoc
+// @Summary Задаёт область счетов сервиса
+// @Description Сервис работает только со счетами из AccountIDs и счетами, помеченными любым из Tags. Пустые оба списка снимают ограничение
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param service_id path int true "ID сервиса"
+// @Param input body service.SetScopeInput true "Область"
+// @Success 200 {string} string "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/services/{service_id}/scope [put]
+func (h *AdminHandler) SetServiceScope(c *gin.Context) {
+	serviceID, _ := strconv.ParseInt(c.Param("service_id"), 10, 64)
+	var input service.SetScopeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.SetServiceScope(c.Request.Context(), serviceID, input.AccountIDs, input.Tags); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// SetServiceSigning godoc
+// @Summary Задаёт режим подписи запросов сервиса
+// @Description off — только API-ключ; optional — подпись проверяется, если есть; required — запросы без подписи отклоняются. При включении сервису выдаётся секрет, если его нет или RotateSecret; секрет возвращается только в этом ответе. off удаляет секрет
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param service_id path int true "ID сервиса"
+// @Param input body service.SetSigningInput true "Режим подписи"
+// @Success 200 {object} service.SigningDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/services/{service_id}/signing [put]
+func (h *AdminHandler) SetServiceSigning(c *gin.Context) {
+	serviceID, _ := strconv.ParseInt(c.Param("service_id"), 10, 64)
+	var input service.SetSigningInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	secret, err := h.svc.SetSigning(c.Request.Context(), serviceID, input.Mode, input.RotateSecret)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, service.SigningDTO{Mode: input.Mode, Secret: secret})
+}
+
+// SetCertIdentities godoc
+// @Summary Задаёт имена клиентских сертификатов сервиса
+// @Description Запрос без API-ключа с проверенным клиентским сертификатом выполняется от имени сервиса, которому назначено любое из имён сертификата: URI, DNS или email из SAN либо CN субъекта. Имя принадлежит не более чем одному сервису. Пустой список отключает вход по сертификату
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param service_id path int true "ID сервиса"
+// @Param input body service.SetCertIdentitiesInput true "Имена сертификатов"
+// @Success 200 {object} service.CertIdentitiesDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/services/{service_id}/cert-identities [put]
+func (h *AdminHandler) SetCertIdentities(c *gin.Context) {
+	serviceID, _ := strconv.ParseInt(c.Param("service_id"), 10, 64)
+	var input service.SetCertIdentitiesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	identities, err := h.svc.SetCertIdentities(c.Request.Context(), serviceID, input.Identities)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, service.CertIdentitiesDTO{Identities: identities})
+}
+
+// SetRateLimit godoc
+// @Summary Задаёт ограничение частоты запросов сервиса
+// @Description Token bucket: в среднем Rate запросов в секунду и до Burst подряд для сервиса в целом, AccountRate и AccountBurst — для запросов сервиса к каждому счёту. Нулевая частота снимает ограничение. Превышение — 429 с заголовком Retry-After. Действует с первого следующего запроса сервиса
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param service_id path int true "ID сервиса"
+// @Param input body service.RateLimitDTO true "Ограничение частоты"
+// @Success 200 {string} string "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/services/{service_id}/rate-limit [put]
+func (h *AdminHandler) SetRateLimit(c *gin.Context) {
+	serviceID, _ := strconv.ParseInt(c.Param("service_id"), 10, 64)
+	var input service.RateLimitDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.SetRateLimit(c.Request.Context(), serviceID, domain.RateLimit(input)); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// SetAccountTags godoc
+// @Summary Задаёт теги счёта
+// @Description Заменяет теги счёта; по ним счёт попадает в область сервисов
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param account_id path int true "ID счёта"
+// @Param input body service.SetTagsInput true "Теги"
+// @Success 200 {string} string "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/accounts/{account_id}/tags [put]
+func (h *AdminHandler) SetAccountTags(c *gin.Context) {
+	accountID, _ := strconv.ParseInt(c.Param("account_id"), 10, 64)
+	var input service.SetTagsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.SetAccountTags(c.Request.Context(), accountID, input.Tags); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// ListVelocityRules godoc
+// @Summary Возвращает правила списаний
+// @Description Все правила или, если задан account_id, правила, действующие для счёта: его собственные и заданные для его тегов
+// @Tags admin
+// @Produce json
+// @Param account_id query int false "ID счёта"
+// @Success 200 {array} service.VelocityRuleDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/velocity-rules [get]
+func (h *AdminHandler) ListVelocityRules(c *gin.Context) {
+	var accountID int64
+	if v := c.Query("account_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account_id must be a positive integer"})
+			return
+		}
+		accountID = id
+	}
+	rules, err := h.svc.ListVelocityRules(c.Request.Context(), accountID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	out := make([]service.VelocityRuleDTO, 0, len(rules))
+	for i := range rules {
+		out = append(out, service.NewVelocityRuleDTO(&rules[i]))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// CreateVelocityRule godoc
+// @Summary Добавляет правило списаний
+// @Description Правило для счёта AccountID или для всех счетов с тегом Tag. debit_amount ограничивает сумму прямых списаний, открытых резервов и переводов со счёта за скользящее окно WindowSeconds, debit_count — их число, reservation_count — число открытых резервов; single_debit требует подтверждения прямого списания или перевода больше Limit. Action decline (по умолчанию) отклоняет нарушившую правило операцию — 409 с ID правила и причиной; review откладывает её до решения оператора — 202 с pending_operation_id, оператор одобряет или отклоняет её в /admin/pending-operations. У single_debit действие всегда review (по умолчанию для него), decline — 400. Действует с первого следующего списания
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param input body service.CreateVelocityRuleInput true "Правило"
+// @Success 201 {object} service.VelocityRuleDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/velocity-rules [post]
+func (h *AdminHandler) CreateVelocityRule(c *gin.Context) {
+	var input service.CreateVelocityRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule, err := h.svc.CreateVelocityRule(c.Request.Context(), domain.VelocityRule{
+		AccountID: input.AccountID,
+		Tag:       input.Tag,
+		Kind:      domain.VelocityKind(input.Kind),
+		Limit:     input.Limit,
+		Window:    time.Duration(input.WindowSeconds) * time.Second,
+		Action:    domain.RiskDecision(input.Action),
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, service.NewVelocityRuleDTO(rule))
+}
+
+// DeleteVelocityRule godoc
+// @Summary Удаляет правило списаний
+// @Tags admin
+// @Produce json
+// @Param rule_id path int true "ID правила"
+// @Success 200 {string} string "OK"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/velocity-rules/{rule_id} [delete]
+func (h *AdminHandler) DeleteVelocityRule(c *gin.Context) {
+	ruleID, _ := strconv.ParseInt(c.Param("rule_id"), 10, 64)
+	if err := h.svc.DeleteVelocityRule(c.Request.Context(), ruleID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// ListPendingOperations godoc
+// @Summary Возвращает отложенные операции
+// @Description Списания, резервы и переводы, которые проверка риска отправила на решение оператора, по возрастанию ID. Без status — во всех статусах
+// @Tags admin
+// @Produce json
+// @Param status query string false "PENDING, APPROVED, REJECTED или FAILED"
+// @Success 200 {array} service.PendingOperationDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/pending-operations [get]
+func (h *AdminHandler) ListPendingOperations(c *gin.Context) {
+	ops, err := h.svc.ListPendingOperations(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	out := make([]service.PendingOperationDTO, 0, len(ops))
+	for i := range ops {
+		out = append(out, service.NewPendingOperationDTO(&ops[i]))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// GetPendingOperation godoc
+// @Summary Возвращает отложенную операцию
+// @Tags admin
+// @Produce json
+// @Param operation_id path int true "ID отложенной операции"
+// @Success 200 {object} service.PendingOperationDTO
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/pending-operations/{operation_id} [get]
+func (h *AdminHandler) GetPendingOperation(c *gin.Context) {
+	operationID, _ := strconv.ParseInt(c.Param("operation_id"), 10, 64)
+	op, err := h.svc.GetPendingOperation(c.Request.Context(), operationID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, service.NewPendingOperationDTO(op))
+}
+
+// ApprovePendingOperation godoc
+// @Summary Одобряет отложенную операцию
+// @Description Выполняет операцию без повторной проверки риска; срок резерва отсчитывается от одобрения. Одобрение и выполнение идут в одной транзакции. Если выполнить не удалось (например, средств уже не хватает), операция переходит в FAILED, ответ — ошибка выполнения. Одобрить операцию, запрошенную самим вызывающим сервисом, нельзя — 403; решение по уже решённой операции — 409. Тело можно не передавать
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param operation_id path int true "ID отложенной операции"
+// @Param input body service.DecidePendingInput false "Комментарий"
+// @Success 200 {object} service.PendingOperationDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/pending-operations/{operation_id}/approve [post]
+func (h *AdminHandler) ApprovePendingOperation(c *gin.Context) {
+	operationID, _ := strconv.ParseInt(c.Param("operation_id"), 10, 64)
+	var input service.DecidePendingInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	op, err := h.svc.ApprovePendingOperation(c.Request.Context(), operationID, c.GetInt64("service_id"), input.Note)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, service.NewPendingOperationDTO(op))
+}
+
+// RejectPendingOperation godoc
+// @Summary Отклоняет отложенную операцию
+// @Description Операция не выполняется; повтор резерва с тем же ключом идемпотентности получит 409 с комментарием. Решение по уже решённой операции — 409. Тело можно не передавать
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param operation_id path int true "ID отложенной операции"
+// @Param input body service.DecidePendingInput false "Комментарий"
+// @Success 200 {object} service.PendingOperationDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/pending-operations/{operation_id}/reject [post]
+func (h *AdminHandler) RejectPendingOperation(c *gin.Context) {
+	operationID, _ := strconv.ParseInt(c.Param("operation_id"), 10, 64)
+	var input service.DecidePendingInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	op, err := h.svc.RejectPendingOperation(c.Request.Context(), operationID, c.GetInt64("service_id"), input.Note)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, service.NewPendingOperationDTO(op))
+}
+
+// ListLimitChanges godoc
+// @Summary Возвращает предложения изменения лимита
+// @Description Увеличения лимита, ждущие или получившие подтверждение, по возрастанию ID. Без status — во всех статусах
+// @Tags admin
+// @Produce json
+// @Param status query string false "PENDING, APPROVED, REJECTED или EXPIRED"
+// @Success 200 {array} service.LimitChangeDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/limit-changes [get]
+func (h *AdminHandler) ListLimitChanges(c *gin.Context) {
+	changes, err := h.svc.ListLimitChanges(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	out := make([]service.LimitChangeDTO, 0, len(changes))
+	for i := range changes {
+		out = append(out, service.NewLimitChangeDTO(&changes[i]))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// ProposeLimitChange godoc
+// @Summary Предлагает увеличить лимит счёта
+// @Description Сохраняет предложение от имени вызывающего сервиса увеличить лимит (Kind MAX или пустой) или кредитную линию (Kind CREDIT); лимит не меняется, пока предложение не подтвердит другой оператор. Предложение действует LIMIT_CHANGE_TTL. Предложить можно любое увеличение, не только выше LIMIT_APPROVAL_THRESHOLD
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param input body service.ProposeLimitChangeInput true "Предложение"
+// @Success 201 {object} service.LimitChangeDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/limit-changes [post]
+func (h *AdminHandler) ProposeLimitChange(c *gin.Context) {
+	var input service.ProposeLimitChangeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	change, err := h.svc.ProposeLimitChange(c.Request.Context(), input.Kind, input.AccountID, input.Delta, c.GetInt64("service_id"), input.Comment)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, service.NewLimitChangeDTO(change))
+}
+
+// GetLimitChange godoc
+// @Summary Возвращает предложение изменения лимита
+// @Tags admin
+// @Produce json
+// @Param change_id path int true "ID предложения"
+// @Success 200 {object} service.LimitChangeDTO
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/limit-changes/{change_id} [get]
+func (h *AdminHandler) GetLimitChange(c *gin.Context) {
+	changeID, _ := strconv.ParseInt(c.Param("change_id"), 10, 64)
+	change, err := h.svc.GetLimitChange(c.Request.Context(), changeID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, service.NewLimitChangeDTO(change))
+}
+
+// ApproveLimitChange godoc
+// @Summary Подтверждает изменение лимита
+// @Description Меняет лимит и пишет проводку от имени вызывающего сервиса. Подтвердить своё предложение или предложение без автора (созданное из CLI без -as или удалённым сервисом) нельзя — 403; истёкшее или уже решённое — 409. Тело можно не передавать
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param change_id path int true "ID предложения"
+// @Param input body service.DecidePendingInput false "Комментарий"
+// @Success 200 {object} service.LimitChangeDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/limit-changes/{change_id}/approve [post]
+func (h *AdminHandler) ApproveLimitChange(c *gin.Context) {
+	changeID, _ := strconv.ParseInt(c.Param("change_id"), 10, 64)
+	var input service.DecidePendingInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	change, err := h.svc.ApproveLimitChange(c.Request.Context(), changeID, c.GetInt64("service_id"), input.Note)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, service.NewLimitChangeDTO(change))
+}
+
+// RejectLimitChange godoc
+// @Summary Отклоняет изменение лимита
+// @Description Лимит не меняется. Отклонить можно и своё предложение — так оно отзывается. Решение по уже решённому предложению — 409. Тело можно не передавать
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param change_id path int true "ID предложения"
+// @Param input body service.DecidePendingInput false "Комментарий"
+// @Success 200 {object} service.LimitChangeDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/limit-changes/{change_id}/reject [post]
+func (h *AdminHandler) RejectLimitChange(c *gin.Context) {
+	changeID, _ := strconv.ParseInt(c.Param("change_id"), 10, 64)
+	var input service.DecidePendingInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	change, err := h.svc.RejectLimitChange(c.Request.Context(), changeID, c.GetInt64("service_id"), input.Note)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, service.NewLimitChangeDTO(change))
+}
+
+// ListAPIKeys godoc
+// @Summary Возвращает API-ключи сервиса
+// @Description Все ключи сервиса, включая истёкшие и отозванные: префикс, сроки и время последнего использования (с точностью до минуты). Значения и хеши ключей не отдаются
+// @Tags admin
+// @Produce json
+// @Param service_id path int true "ID сервиса"
+// @Success 200 {array} service.APIKeyDTO
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/services/{service_id}/keys [get]
+func (h *AdminHandler) ListAPIKeys(c *gin.Context) {
+	serviceID, _ := strconv.ParseInt(c.Param("service_id"), 10, 64)
+	keys, err := h.svc.ListAPIKeys(c.Request.Context(), serviceID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	now := time.Now()
+	out := make([]service.APIKeyDTO, 0, len(keys))
+	for i := range keys {
+		out = append(out, service.NewAPIKeyDTO(&keys[i], now))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// CreateAPIKey godoc
+// @Summary Выдаёт сервису ещё один API-ключ
+// @Description Действующие ключи сервиса не меняются. Значение ключа возвращается только в этом ответе
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param service_id path int true "ID сервиса"
+// @Param input body service.CreateAPIKeyInput true "Срок жизни ключа"
+// @Success 201 {object} service.IssuedAPIKeyDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/services/{service_id}/keys [post]
+func (h *AdminHandler) CreateAPIKey(c *gin.Context) {
+	serviceID, _ := strconv.ParseInt(c.Param("service_id"), 10, 64)
+	var input service.CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	apiKey, key, err := h.svc.CreateAPIKey(c.Request.Context(), serviceID, time.Duration(input.TTLSeconds)*time.Second)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, service.IssuedAPIKeyDTO{APIKey: apiKey, Key: service.NewAPIKeyDTO(key, time.Now())})
+}
+
+// RotateAPIKey godoc
+// @Summary Ротирует API-ключ сервиса
+// @Description Выдаёт новый ключ; прежние действующие ключи продолжают работать ещё OverlapSeconds, чтобы клиенты успели перейти. Значение ключа возвращается только в этом ответе
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param service_id path int true "ID сервиса"
+// @Param input body service.RotateAPIKeyInput true "Перекрытие и срок жизни нового ключа"
+// @Success 201 {object} service.IssuedAPIKeyDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/services/{service_id}/keys/rotate [post]
+func (h *AdminHandler) RotateAPIKey(c *gin.Context) {
+	serviceID, _ := strconv.ParseInt(c.Param("service_id"), 10, 64)
+	var input service.RotateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	apiKey, key, err := h.svc.RotateAPIKey(c.Request.Context(), serviceID,
+		time.Duration(input.OverlapSeconds)*time.Second, time.Duration(input.TTLSeconds)*time.Second)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, service.IssuedAPIKeyDTO{APIKey: apiKey, Key: service.NewAPIKeyDTO(key, time.Now())})
+}
+
+// RevokeAPIKey godoc
+// @Summary Отзывает API-ключ сервиса
+// @Description Ключ перестаёт приниматься сразу
+// @Tags admin
+// @Produce json
+// @Param service_id path int true "ID сервиса"
+// @Param key_id path int true "ID ключа"
+// @Success 200 {string} string "OK"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/services/{service_id}/keys/{key_id}/revoke [post]
+func (h *AdminHandler) RevokeAPIKey(c *gin.Context) {
+	serviceID, _ := strconv.ParseInt(c.Param("service_id"), 10, 64)
+	keyID, _ := strconv.ParseInt(c.Param("key_id"), 10, 64)
+	if err := h.svc.RevokeAPIKey(c.Request.Context(), serviceID, keyID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 	"test_nanimai/backend/internal/service"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,16 +17,50 @@ func NewBalanceHandler(svc service.Balance) *BalanceHandler {
 	return &BalanceHandler{svc: svc}
 }
 
+// GetAccount godoc
+// @Summary Возвращает состояние счёта
+// @Description Возвращает текущий, зарезервированный и максимальный баланс счёта, кредитную линию, использованный кредит и доступные средства (current + credit - reserved). С параметром as_of — состояние на указанный момент, восстановленное по журналу
+// @Tags accounts
+// @Produce json
+// @Param account_id path int true "ID счёта"
+// @Param as_of query string false "Момент времени в формате RFC3339"
+// @Success 200 {object} service.AccountDTO
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /accounts/{account_id} [get]
+func (h *BalanceHandler) GetAccount(c *gin.Context) {
+	accountID, _ := strconv.ParseInt(c.Param("account_id"), 10, 64)
+	var asOf time.Time
+	if v := c.Query("as_of"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must be RFC3339 timestamp"})
+			return
+		}
+		asOf = t
+	}
+	acc, err := h.svc.GetAccount(c.Request.Context(), accountID, asOf)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, service.NewAccountDTO(acc))
+}
+
 // UpdateLimit godoc
 // @Summary Обновляет лимит счёта
-// @Description Увеличивает/уменьшает максимальный лимит по счёту
+// @Description Увеличивает/уменьшает максимальный лимит по счёту. Увеличение, которое вместе с увеличениями лимита счёта без подтверждения за LIMIT_APPROVAL_WINDOW и ожидающими предложениями больше порога LIMIT_APPROVAL_THRESHOLD, не применяется, а сохраняется как предложение, которое должен подтвердить другой оператор, — 202 с limit_change_id
 // @Tags accounts
 // @Accept json
 // @Produce json
 // @Param account_id path int true "ID счёта"
 // @Param input body service.UpdateLimitInput true "Изменение лимита"
 // @Success 200 {string} string "OK"
+// @Success 202 {object} map[string]any "Accepted: ждёт подтверждения"
 // @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /accounts/{account_id}/limit [put]
 func (h *BalanceHandler) UpdateLimit(c *gin.Context) {
@@ -36,8 +71,38 @@ func (h *BalanceHandler) UpdateLimit(c *gin.Context) {
 		return
 	}
 	input.AccountID = accountID
-	if err := h.svc.UpdateLimit(c.Request.Context(), input.AccountID, input.Delta); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.UpdateLimit(c.Request.Context(), c.GetInt64("service_id"), input.AccountID, input.Delta); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// UpdateCreditLimit godoc
+// @Summary Изменяет кредитную линию счёта
+// @Description Увеличивает/уменьшает кредитную линию: баланс может уходить в минус не глубже неё. Уменьшить линию ниже использованного кредита нельзя. Увеличение, которое вместе с увеличениями кредитной линии счёта без подтверждения за LIMIT_APPROVAL_WINDOW и ожидающими предложениями больше порога LIMIT_APPROVAL_THRESHOLD, не применяется, а сохраняется как предложение, которое должен подтвердить другой оператор, — 202 с limit_change_id
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param account_id path int true "ID счёта"
+// @Param input body service.UpdateCreditLimitInput true "Изменение кредитной линии"
+// @Success 200 {string} string "OK"
+// @Success 202 {object} map[string]any "Accepted: ждёт подтверждения"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /accounts/{account_id}/credit-limit [put]
+func (h *BalanceHandler) UpdateCreditLimit(c *gin.Context) {
+	accountID, _ := strconv.ParseInt(c.Param("account_id"), 10, 64)
+	var input service.UpdateCreditLimitInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.AccountID = accountID
+	if err := h.svc.UpdateCreditLimit(c.Request.Context(), c.GetInt64("service_id"), input.AccountID, input.Delta); err != nil {
+		writeError(c, err)
 		return
 	}
 	c.Status(http.StatusOK)
@@ -45,15 +110,18 @@ func (h *BalanceHandler) UpdateLimit(c *gin.Context) {
 
 // UpdateBalance godoc
 // @Summary Изменяет баланс счёта
-// @Description Изменяет текущий баланс счёта на указанную величину
+// @Description Изменяет текущий баланс счёта на указанную величину. Списание сначала оценивает проверка риска (правила списаний счёта): отказ — 409 с причиной, проверка недоступна — 503, операция отложена до решения оператора — 202 с pending_operation_id
 // @Tags accounts
 // @Accept json
 // @Produce json
 // @Param account_id path int true "ID счёта"
 // @Param input body service.UpdateBalanceInput true "Изменение баланса"
 // @Success 200 {string} string "OK"
+// @Success 202 {object} map[string]any "Accepted: ждёт решения оператора"
 // @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 409 {object} map[string]string "Conflict"
 // @Failure 500 {object} map[string]string "Internal Server Error"
+// @Failure 503 {object} map[string]string "Service Unavailable"
 // @Router /accounts/{account_id}/balance [put]
 func (h *BalanceHandler) UpdateBalance(c *gin.Context) {
 	accountID, _ := strconv.ParseInt(c.Param("account_id"), 10, 64)
@@ -63,8 +131,8 @@ func (h *BalanceHandler) UpdateBalance(c *gin.Context) {
 		return
 	}
 	input.AccountID = accountID
-	if err := h.svc.UpdateBalance(c.Request.Context(), input.AccountID, input.Delta); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.UpdateBalance(c.Request.Context(), c.GetInt64("service_id"), input.AccountID, input.Delta); err != nil {
+		writeError(c, err)
 		return
 	}
 	c.Status(http.StatusOK)
@@ -72,15 +140,19 @@ func (h *BalanceHandler) UpdateBalance(c *gin.Context) {
 
 // OpenReservation godoc
 // @Summary Открывает резерв средств
-// @Description Создаёт резерв на сумму на указанном счёте
+// @Description Создаёт резерв на сумму на указанном счёте. Открытие сначала оценивает проверка риска (правила списаний счёта): отказ — 409 с причиной, проверка недоступна — 503, резерв отложен до решения оператора — 202 с pending_operation_id. Повтор с ключом одобренного резерва возвращает открытый резерв. Владелец резерва — аутентифицированный сервис
 // @Tags reservations
 // @Accept json
 // @Produce json
 // @Param account_id path int true "ID счёта"
 // @Param input body service.OpenReservationInput true "Параметры резерва"
 // @Success 200 {object} service.ReservationDTO
+// @Success 202 {object} map[string]any "Accepted: ждёт решения оператора"
 // @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
 // @Failure 500 {object} map[string]string "Internal Server Error"
+// @Failure 503 {object} map[string]string "Service Unavailable"
 // @Router /accounts/{account_id}/reservation [post]
 func (h *BalanceHandler) OpenReservation(c *gin.Context) {
 	accountID, _ := strconv.ParseInt(c.Param("account_id"), 10, 64)
@@ -90,9 +162,9 @@ func (h *BalanceHandler) OpenReservation(c *gin.Context) {
 		return
 	}
 	input.AccountID = accountID
-	res, err := h.svc.OpenReservation(c.Request.Context(), input.OwnerServiceID, input.AccountID, input.Amount, input.IdempotencyKey, input.Timeout)
+	res, err := h.svc.OpenReservation(c.Request.Context(), c.GetInt64("service_id"), input.AccountID, input.Amount, input.IdempotencyKey, input.Timeout)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -100,20 +172,21 @@ func (h *BalanceHandler) OpenReservation(c *gin.Context) {
 
 // ConfirmReservation godoc
 // @Summary Подтверждает резерв
-// @Description Подтверждает ранее открытый резерв
+// @Description Подтверждает ранее открытый резерв. Подтвердить можно только резерв, открытый аутентифицированным сервисом
 // @Tags reservations
 // @Accept json
 // @Produce json
 // @Param reservation_id path int true "ID резерва"
-// @Param X-Owner-Service-ID header int true "ID сервиса-владельца"
 // @Success 200 {string} string "OK"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /reservations/{reservation_id}/confirm [post]
 func (h *BalanceHandler) ConfirmReservation(c *gin.Context) {
 	reservationID, _ := strconv.ParseInt(c.Param("reservation_id"), 10, 64)
-	ownerID, _ := strconv.ParseInt(c.GetHeader("X-Owner-Service-ID"), 10, 64)
-	if err := h.svc.ConfirmReservation(c.Request.Context(), reservationID, ownerID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.ConfirmReservation(c.Request.Context(), reservationID, c.GetInt64("service_id")); err != nil {
+		writeError(c, err)
 		return
 	}
 	c.Status(http.StatusOK)
@@ -121,21 +194,52 @@ func (h *BalanceHandler) ConfirmReservation(c *gin.Context) {
 
 // CancelReservation godoc
 // @Summary Отменяет резерв
-// @Description Отменяет ранее открытый резерв
+// @Description Отменяет ранее открытый резерв. Отменить можно только резерв, открытый аутентифицированным сервисом
 // @Tags reservations
 // @Accept json
 // @Produce json
 // @Param reservation_id path int true "ID резерва"
-// @Param X-Owner-Service-ID header int true "ID сервиса-владельца"
 // @Success 200 {string} string "OK"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /reservations/{reservation_id}/cancel [post]
 func (h *BalanceHandler) CancelReservation(c *gin.Context) {
 	reservationID, _ := strconv.ParseInt(c.Param("reservation_id"), 10, 64)
-	ownerID, _ := strconv.ParseInt(c.GetHeader("X-Owner-Service-ID"), 10, 64)
-	if err := h.svc.CancelReservation(c.Request.Context(), reservationID, ownerID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.CancelReservation(c.Request.Context(), reservationID, c.GetInt64("service_id")); err != nil {
+		writeError(c, err)
 		return
 	}
 	c.Status(http.StatusOK)
 }
+
+// RefundReservation godoc
+// @Summary Возврат по подтверждённому резерву
+// @Description Возвращает на счёт часть или всю списанную по резерву сумму. Сумма всех возвратов не превышает суммы резерва, баланс счёта не превышает max_amount. Повтор с тем же IdempotencyKey возвращает ранее созданный возврат. Вернуть можно только по резерву, открытому аутентифицированным сервисом
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Param reservation_id path int true "ID резерва"
+// @Param input body service.RefundReservationInput true "Сумма и ключ идемпотентности"
+// @Success 200 {object} domain.Refund
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reservations/{reservation_id}/refunds [post]
+func (h *BalanceHandler) RefundReservation(c *gin.Context) {
+	reservationID, _ := strconv.ParseInt(c.Param("reservation_id"), 10, 64)
+	var input service.RefundReservationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	refund, err := h.svc.RefundReservation(c.Request.Context(), reservationID, c.GetInt64("service_id"), input.Amount, input.IdempotencyKey)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, refund)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"test_nanimai/backend/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeError отвечает кодом, соответствующим доменной ошибке, или 500.
+// Операция, отложенная до решения оператора, и изменение лимита, ждущее
+// подтверждения, не выполнены, но приняты: 202 с ID отложенной операции в
+// pending_operation_id или предложения в limit_change_id.
+func writeError(c *gin.Context, err error) {
+	c.Error(err)
+	var pending *domain.PendingReview
+	if errors.As(err, &pending) {
+		c.JSON(http.StatusAccepted, gin.H{"error": err.Error(), "pending_operation_id": pending.OperationID})
+		return
+	}
+	var proposed *domain.LimitChangeProposed
+	if errors.As(err, &proposed) {
+		c.JSON(http.StatusAccepted, gin.H{"error": err.Error(), "limit_change_id": proposed.ChangeID})
+		return
+	}
+	c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrUnbalancedJournal),
+		errors.Is(err, domain.ErrInvalidPosting),
+		errors.Is(err, domain.ErrInvalidReason),
+		errors.Is(err, domain.ErrInvalidAmount),
+		errors.Is(err, domain.ErrInvalidName),
+		errors.Is(err, domain.ErrInvalidDuration),
+		errors.Is(err, domain.ErrInvalidPermission),
+		errors.Is(err, domain.ErrInvalidSigningMode),
+		errors.Is(err, domain.ErrInvalidScope),
+		errors.Is(err, domain.ErrInvalidIdentity),
+		errors.Is(err, domain.ErrInvalidRateLimit),
+		errors.Is(err, domain.ErrInvalidVelocityRule),
+		errors.Is(err, domain.ErrInvalidPendingStatus),
+		errors.Is(err, domain.ErrInvalidLimitChangeStatus),
+		errors.Is(err, domain.ErrInvalidLimitKind),
+		errors.Is(err, domain.ErrInvalidTag):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrAlreadyReversed),
+		errors.Is(err, domain.ErrNotReversible),
+		errors.Is(err, domain.ErrNotEnoughFunds),
+		errors.Is(err, domain.ErrNotConfirmed),
+		errors.Is(err, domain.ErrRefundExceeded),
+		errors.Is(err, domain.ErrLimitExceeded),
+		errors.Is(err, domain.ErrCreditInUse),
+		errors.Is(err, domain.ErrAccountFrozen),
+		errors.Is(err, domain.ErrVelocityExceeded),
+		errors.Is(err, domain.ErrRiskDeclined),
+		errors.Is(err, domain.ErrNotPending),
+		errors.Is(err, domain.ErrChangeExpired),
+		errors.Is(err, domain.ErrServiceExists),
+		errors.Is(err, domain.ErrKeyRevoked),
+		errors.Is(err, domain.ErrIdentityInUse),
+		errors.Is(err, domain.ErrExpired),
+		errors.Is(err, domain.ErrNotActive):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrForbidden),
+		errors.Is(err, domain.ErrSelfApproval),
+		errors.Is(err, domain.ErrOperatorRequired):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, domain.ErrRiskUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"test_nanimai/backend/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// Healthz godoc
+// @Summary Проверка живости
+// @Description Отвечает 200, пока процесс обслуживает запросы. API-ключ не нужен
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string "OK"
+// @Router /healthz [get]
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz godoc
+// @Summary Проверка готовности
+// @Description Отвечает 200, если доступна БД, схема на ожидаемой версии миграций и сервер не останавливается; иначе 503. В ответе только ok или fail по каждой проверке (draining — сервер останавливается), причины отказа пишутся в лог сервера. API-ключ не нужен
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Status
+// @Failure 503 {object} health.Status
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	st := h.checker.Check(c.Request.Context())
+	code := http.StatusOK
+	if !st.Ready {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, st)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"test_nanimai/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultJournalLimit = 50
+	maxJournalLimit     = 500
+)
+
+// PostJournal godoc
+// @Summary Проводит корректировку по счёту
+// @Description Создаёт сбалансированную проводку (сумма дебета равна сумме кредита) между счётом и системными счетами external_funding, fees, settlement; счёт holds ведут только резервы. Дебет системного счёта (зачисление извне) требует права admin, иначе 403. Перевод со счёта (проводка, уменьшающая current) сначала оценивает проверка риска: отказ — 409, проверка недоступна — 503, перевод отложен до решения оператора — 202 с pending_operation_id
+// @Tags journal
+// @Accept json
+// @Produce json
+// @Param account_id path int true "ID счёта"
+// @Param input body service.PostJournalInput true "Описание и строки проводки"
+// @Success 200 {object} domain.JournalEntry
+// @Success 202 {object} map[string]any "Accepted: ждёт решения оператора"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Failure 503 {object} map[string]string "Service Unavailable"
+// @Router /accounts/{account_id}/journal [post]
+func (h *BalanceHandler) PostJournal(c *gin.Context) {
+	accountID, _ := strconv.ParseInt(c.Param("account_id"), 10, 64)
+	var input service.PostJournalInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.AccountID = accountID
+	entry, err := h.svc.PostJournal(c.Request.Context(), c.GetInt64("service_id"), input.AccountID, input.Description, input.Postings)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// ListJournal godoc
+// @Summary Журнал проводок по счёту
+// @Description Возвращает последние проводки по счёту со строками дебета и кредита, новые первыми
+// @Tags journal
+// @Produce json
+// @Param account_id path int true "ID счёта"
+// @Param limit query int false "Количество записей (по умолчанию 50, не более 500)"
+// @Success 200 {array} domain.JournalEntry
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /accounts/{account_id}/journal [get]
+func (h *BalanceHandler) ListJournal(c *gin.Context) {
+	accountID, _ := strconv.ParseInt(c.Param("account_id"), 10, 64)
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultJournalLimit
+	}
+	if limit > maxJournalLimit {
+		limit = maxJournalLimit
+	}
+	entries, err := h.svc.ListJournal(c.Request.Context(), accountID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// ReverseEntry godoc
+// @Summary Сторнирует проводку
+// @Description Создаёт компенсирующую проводку со ссылкой на исходную и кодом причины (OPERATOR_ERROR, DUPLICATE, CUSTOMER_REFUND, FRAUD, OTHER). Повторное сторно запрещено. Подтверждение резерва сторнируется только через /reservations/{reservation_id}/reverse (409), сторно проводки на замороженном счёте — 409. Если сторно списывает средства с системного счёта (например, сторно списания), нужно право admin (403). Сторно, уменьшающее current (например, сторно пополнения), сначала оценивает проверка риска: отказ — 409, проверка недоступна — 503, сторно отложено до решения оператора — 202 с pending_operation_id
+// @Tags journal
+// @Accept json
+// @Produce json
+// @Param entry_id path int true "ID проводки"
+// @Param input body service.ReverseInput true "Код причины и описание"
+// @Success 200 {object} domain.JournalEntry
+// @Success 202 {object} map[string]any "Accepted: ждёт решения оператора"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Failure 503 {object} map[string]string "Service Unavailable"
+// @Router /journal/{entry_id}/reverse [post]
+func (h *BalanceHandler) ReverseEntry(c *gin.Context) {
+	entryID, _ := strconv.ParseInt(c.Param("entry_id"), 10, 64)
+	var input service.ReverseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entry, err := h.svc.ReverseEntry(c.Request.Context(), entryID, c.GetInt64("service_id"), input.ReasonCode, input.Description)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// ReverseReservation godoc
+// @Summary Сторнирует подтверждённый резерв
+// @Description Возвращает на счёт средства, списанные при подтверждении резерва, компенсирующей проводкой с кодом причины. Сторнировать можно только резерв, открытый аутентифицированным сервисом
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Param reservation_id path int true "ID резерва"
+// @Param input body service.ReverseInput true "Код причины и описание"
+// @Success 200 {object} domain.JournalEntry
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reservations/{reservation_id}/reverse [post]
+func (h *BalanceHandler) ReverseReservation(c *gin.Context) {
+	reservationID, _ := strconv.ParseInt(c.Param("reservation_id"), 10, 64)
+	var input service.ReverseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entry, err := h.svc.ReverseReservation(c.Request.Context(), reservationID, c.GetInt64("service_id"), input.ReasonCode, input.Description)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
@@ -1,10 +1,20 @@
 package rest
 
 import (
+	"bytes"
 	"context"
-	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/access"
+	"test_nanimai/backend/internal/mtls"
+	"test_nanimai/backend/internal/ratelimit"
+	"test_nanimai/backend/internal/repository"
+	"test_nanimai/backend/internal/signing"
 
 	"github.com/gin-gonic/gin"
 )
@@ -39,12 +49,17 @@ func AuthMiddleware(repo AuthServiceRepo) func(http.Handler) http.Handler {
 }
 
 // ApiKeyAuthMiddleware проверяет наличие валидного API ключа в заголовках запроса.
-// Ищет ключ в заголовках: "X-API-Key" или "api_key".
-// Если ключ отсутствует или не найден в БД, возвращает 401 Unauthorized.
-func ApiKeyAuthMiddleware(db *sql.DB) gin.HandlerFunc {
+// Ищет ключ в заголовках: "X-API-Key" или "api_key". Без ключа сервис
+// определяется по проверенному клиентскому сертификату (см. internal/mtls).
+// Если ключа и сертификата нет, ключ не найден, истёк или отозван или
+// сертификат не назначен ни одному сервису или назначен нескольким,
+// возвращает 401 Unauthorized.
+// В контекст Gin кладёт "service_id" и "service" (*domain.Service).
+// Swagger, проверки /healthz, /readyz и /metrics доступны без ключа.
+func ApiKeyAuthMiddleware(services repository.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		path := c.Request.URL.Path
-		if strings.HasPrefix(path, "/swagger/") {
+		if strings.HasPrefix(path, "/swagger/") || path == "/healthz" || path == "/readyz" || path == "/metrics" {
 			c.Next()
 			return
 		}
@@ -53,15 +68,20 @@ func ApiKeyAuthMiddleware(db *sql.DB) gin.HandlerFunc {
 		if apiKey == "" {
 			apiKey = c.GetHeader("api_key")
 		}
-		if apiKey == "" {
+
+		var svc *domain.Service
+		var err error
+		switch identities := mtls.PeerIdentities(c.Request.TLS); {
+		case apiKey != "":
+			svc, err = services.AuthenticateAPIKey(c.Request.Context(), apiKey)
+		case identities != nil:
+			svc, err = services.AuthenticateCertificate(c.Request.Context(), identities)
+		default:
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
-
-		var id int64
-		err := db.QueryRowContext(c.Request.Context(), "SELECT id FROM services WHERE api_key = $1 LIMIT 1", apiKey).Scan(&id)
 		if err != nil {
-			if err == sql.ErrNoRows {
+			if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrAmbiguousIdentity) {
 				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 				return
 			}
@@ -69,7 +89,192 @@ func ApiKeyAuthMiddleware(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		c.Set("service_id", id)
+		c.Set("service_id", svc.ID)
+		c.Set("service", svc)
+		c.Next()
+	}
+}
+
+// RateLimitMiddleware ограничивает частоту запросов аутентифицированного
+// сервиса и, для маршрутов с :account_id, его запросов к счёту (см.
+// ratelimit.Limiter). Отказ — 429 Too Many Requests с заголовком
+// Retry-After в секундах. Ставится после ApiKeyAuthMiddleware.
+func RateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		svc, ok := c.Value("service").(*domain.Service)
+		if !ok {
+			c.Next()
+			return
+		}
+		accountID, _ := strconv.ParseInt(c.Param("account_id"), 10, 64)
+		var exceeded *ratelimit.Exceeded
+		if err := limiter.Allow(svc, accountID); errors.As(err, &exceeded) {
+			c.Header("Retry-After", strconv.Itoa(exceeded.RetryAfterSeconds()))
+			c.Error(err)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// SignatureMiddleware проверяет подпись запроса HMAC по режиму подписи
+// аутентифицированного сервиса (см. signing.Verifier.Verify). Неверная,
+// устаревшая, повторная или отсутствующая обязательная подпись — 401
+// Unauthorized с причиной; тело больше maxBodyBytes — 413. Ставится после
+// ApiKeyAuthMiddleware.
+func SignatureMiddleware(verifier *signing.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		svc, ok := c.Value("service").(*domain.Service)
+		if !ok {
+			c.Next()
+			return
+		}
+		body, ok := readBody(c)
+		if !ok {
+			return
+		}
+
+		sig := signing.Signature{
+			Value:     c.GetHeader(signing.HeaderSignature),
+			Timestamp: c.GetHeader(signing.HeaderTimestamp),
+			Nonce:     c.GetHeader(signing.HeaderNonce),
+		}
+		if err := verifier.Verify(svc, c.Request.Method, c.Request.URL.RequestURI(), body, sig); err != nil {
+			c.Error(err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
 		c.Next()
 	}
 }
+
+// routeAccess — что проверять для маршрута: право (пустое — только область),
+// параметр пути, по которому определяется счёт, должен ли резерв из пути
+// принадлежать сервису и сторнируется ли проводка из пути.
+type routeAccess struct {
+	permission domain.Permission
+	param      string
+	owner      bool
+	reversal   bool
+}
+
+// routeAccessRules — правила для маршрутов API; ключ — метод и шаблон пути.
+// Право на PUT /accounts/:account_id/balance зависит от знака Delta в теле,
+// на POST /accounts/:account_id/journal — от строк проводки (см.
+// domain.JournalPermissions), на POST /journal/:entry_id/reverse — ещё и от
+// строк сторно.
+var routeAccessRules = map[string]routeAccess{
+	"GET /accounts/:account_id":                  {param: "account_id"},
+	"PUT /accounts/:account_id/limit":            {permission: domain.PermLimitWrite, param: "account_id"},
+	"PUT /accounts/:account_id/balance":          {param: "account_id"},
+	"PUT /accounts/:account_id/credit-limit":     {permission: domain.PermLimitWrite, param: "account_id"},
+	"POST /accounts/:account_id/reservation":     {permission: domain.PermReservationOpen, param: "account_id"},
+	"POST /reservations/:reservation_id/confirm": {permission: domain.PermReservationOpen, param: "reservation_id", owner: true},
+	"POST /reservations/:reservation_id/cancel":  {permission: domain.PermReservationOpen, param: "reservation_id", owner: true},
+	"POST /reservations/:reservation_id/refunds": {permission: domain.PermBalanceCredit, param: "reservation_id", owner: true},
+	"POST /accounts/:account_id/journal":         {permission: domain.PermJournalWrite, param: "account_id"},
+	"GET /accounts/:account_id/journal":          {param: "account_id"},
+	"POST /journal/:entry_id/reverse":            {permission: domain.PermJournalWrite, param: "entry_id", reversal: true},
+	"POST /reservations/:reservation_id/reverse": {permission: domain.PermJournalWrite, param: "reservation_id", owner: true},
+}
+
+// AccessMiddleware проверяет права аутентифицированного сервиса, владельца
+// резерва и область счетов по routeAccessRules; маршруты /admin и все
+// маршруты без правила требуют права admin и областью не ограничены. Отказ —
+// 403 Forbidden с названием недостающего права, чужого резерва или счёта вне
+// области. Ставится после ApiKeyAuthMiddleware.
+func AccessMiddleware(checker *access.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		svc, ok := c.Value("service").(*domain.Service)
+		route := c.FullPath()
+		if !ok || route == "" {
+			// Без сервиса или без маршрута (404) проверять нечего
+			c.Next()
+			return
+		}
+
+		var req access.Request
+		key := c.Request.Method + " " + route
+		rule, ok := routeAccessRules[key]
+		if !ok {
+			rule = routeAccess{permission: domain.PermAdmin}
+		}
+		if rule.permission != "" {
+			req.Permissions = []domain.Permission{rule.permission}
+		}
+		switch key {
+		case "PUT /accounts/:account_id/balance":
+			var input struct{ Delta int64 }
+			if !peekJSON(c, &input) {
+				return
+			}
+			req.Permissions = []domain.Permission{domain.BalancePermission(input.Delta)}
+		case "POST /accounts/:account_id/journal":
+			var input struct{ Postings []domain.Posting }
+			if !peekJSON(c, &input) {
+				return
+			}
+			req.Permissions = domain.JournalPermissions(input.Postings)
+		}
+		id, _ := strconv.ParseInt(c.Param(rule.param), 10, 64)
+		switch rule.param {
+		case "account_id":
+			req.AccountID = id
+		case "reservation_id":
+			req.ReservationID = id
+			req.OwnReservation = rule.owner
+		case "entry_id":
+			req.EntryID = id
+			req.ReverseEntry = rule.reversal
+		}
+
+		if err := checker.Check(c.Request.Context(), svc, req); err != nil {
+			c.Error(err)
+			switch {
+			case errors.Is(err, domain.ErrForbidden):
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case errors.Is(err, domain.ErrNotFound):
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			}
+			return
+		}
+		c.Next()
+	}
+}
+
+// maxBodyBytes — наибольшее тело запроса, которое middleware читают целиком
+// до обработчика.
+const maxBodyBytes = 1 << 20
+
+// readBody читает тело запроса, оставляя его для обработчика. Тело больше
+// maxBodyBytes — 413 Request Entity Too Large, нечитаемое — 400; запрос
+// прерывается и возвращается false.
+func readBody(c *gin.Context) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes))
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return nil, false
+	case err != nil:
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "cannot read request body"})
+		return nil, false
+	}
+	return body, true
+}
+
+// peekJSON разбирает JSON-тело в v, оставляя тело для обработчика.
+// Неверный JSON оставляет v нулевым — обработчик отклонит его сам с 400.
+// Тело, которое нельзя прочитать (см. readBody), прерывает запрос, и
+// возвращается false.
+func peekJSON(c *gin.Context, v any) bool {
+	body, ok := readBody(c)
+	if ok {
+		_ = json.Unmarshal(body, v)
+	}
+	return ok
+}
@@ -3,15 +3,66 @@ package rest
 import (
 	"github.com/gin-gonic/gin"
 	handlers2 "test_nanimai/backend/internal/api/rest/handlers"
+	"test_nanimai/backend/internal/health"
 	"test_nanimai/backend/internal/service"
 )
 
+// RegisterHealthRoutes регистрирует /healthz и /readyz; ApiKeyAuthMiddleware их пропускает.
+func RegisterHealthRoutes(r *gin.Engine, checker *health.Checker) {
+	handler := handlers2.NewHealthHandler(checker)
+
+	r.GET("/healthz", handler.Healthz)
+	r.GET("/readyz", handler.Readyz)
+}
+
 func RegisterRoutes(r *gin.Engine, svc service.Balance) {
 	handler := handlers2.NewBalanceHandler(svc)
 
+	r.GET("/accounts/:account_id", handler.GetAccount)
 	r.PUT("/accounts/:account_id/limit", handler.UpdateLimit)
 	r.PUT("/accounts/:account_id/balance", handler.UpdateBalance)
+	r.PUT("/accounts/:account_id/credit-limit", handler.UpdateCreditLimit)
 	r.POST("/accounts/:account_id/reservation", handler.OpenReservation)
 	r.POST("/reservations/:reservation_id/confirm", handler.ConfirmReservation)
 	r.POST("/reservations/:reservation_id/cancel", handler.CancelReservation)
+	r.POST("/reservations/:reservation_id/refunds", handler.RefundReservation)
+	r.POST("/accounts/:account_id/journal", handler.PostJournal)
+	r.GET("/accounts/:account_id/journal", handler.ListJournal)
+	r.POST("/journal/:entry_id/reverse", handler.ReverseEntry)
+	r.POST("/reservations/:reservation_id/reverse", handler.ReverseReservation)
+}
+
+// RegisterAdminRoutes регистрирует /admin/*: управление сервисами, их
+// API-ключами, именами сертификатов, правами, подписью и частотой
+// запросов, тегами счетов, правилами списаний, отложенными операциями и
+// предложениями изменения лимита.
+// AccessMiddleware пускает сюда только сервисы с правом admin.
+func RegisterAdminRoutes(r *gin.Engine, svc service.Admin) {
+	handler := handlers2.NewAdminHandler(svc)
+
+	g := r.Group("/admin")
+	g.GET("/services", handler.ListServices)
+	g.POST("/services", handler.CreateService)
+	g.PUT("/services/:service_id/permissions", handler.SetServicePermissions)
+	g.PUT("/services/:service_id/scope", handler.SetServiceScope)
+	g.PUT("/services/:service_id/signing", handler.SetServiceSigning)
+	g.PUT("/services/:service_id/cert-identities", handler.SetCertIdentities)
+	g.PUT("/services/:service_id/rate-limit", handler.SetRateLimit)
+	g.GET("/services/:service_id/keys", handler.ListAPIKeys)
+	g.POST("/services/:service_id/keys", handler.CreateAPIKey)
+	g.POST("/services/:service_id/keys/rotate", handler.RotateAPIKey)
+	g.POST("/services/:service_id/keys/:key_id/revoke", handler.RevokeAPIKey)
+	g.PUT("/accounts/:account_id/tags", handler.SetAccountTags)
+	g.GET("/velocity-rules", handler.ListVelocityRules)
+	g.POST("/velocity-rules", handler.CreateVelocityRule)
+	g.DELETE("/velocity-rules/:rule_id", handler.DeleteVelocityRule)
+	g.GET("/pending-operations", handler.ListPendingOperations)
+	g.GET("/pending-operations/:operation_id", handler.GetPendingOperation)
+	g.POST("/pending-operations/:operation_id/approve", handler.ApprovePendingOperation)
+	g.POST("/pending-operations/:operation_id/reject", handler.RejectPendingOperation)
+	g.GET("/limit-changes", handler.ListLimitChanges)
+	g.POST("/limit-changes", handler.ProposeLimitChange)
+	g.GET("/limit-changes/:change_id", handler.GetLimitChange)
+	g.POST("/limit-changes/:change_id/approve", handler.ApproveLimitChange)
+	g.POST("/limit-changes/:change_id/reject", handler.RejectLimitChange)
 }
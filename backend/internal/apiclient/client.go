@@ -0,0 +1,77 @@
+// Package apiclient — клиенты REST и gRPC API сервиса баланса с общим
+// интерфейсом Client. Используется e2e-проверками и генератором нагрузки.
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/service"
+)
+
+// Client — общий для REST и gRPC клиент API. Ошибки транспорта приводятся к *Error.
+type Client interface {
+	GetAccount(ctx context.Context, accountID int64, asOf time.Time) (*service.AccountDTO, error)
+	UpdateLimit(ctx context.Context, accountID, delta int64) error
+	UpdateBalance(ctx context.Context, accountID, delta int64) error
+	UpdateCreditLimit(ctx context.Context, accountID, delta int64) error
+	OpenReservation(ctx context.Context, accountID, amount int64, idempotencyKey string, timeout time.Duration) (*domain.Reservation, error)
+	ConfirmReservation(ctx context.Context, reservationID int64) error
+	CancelReservation(ctx context.Context, reservationID int64) error
+	RefundReservation(ctx context.Context, reservationID, amount int64, idempotencyKey string) (*domain.Refund, error)
+	PostJournal(ctx context.Context, accountID int64, description string, postings []domain.Posting) (*domain.JournalEntry, error)
+	ListJournal(ctx context.Context, accountID int64, limit int) ([]domain.JournalEntry, error)
+	ReverseEntry(ctx context.Context, entryID int64, reasonCode, description string) (*domain.JournalEntry, error)
+	ReverseReservation(ctx context.Context, reservationID int64, reasonCode, description string) (*domain.JournalEntry, error)
+	// Ready сообщает, готов ли сервер: /readyz для REST, grpc.health.v1 для gRPC.
+	Ready(ctx context.Context) (bool, error)
+}
+
+// Code — класс ошибки, не зависящий от транспорта.
+type Code string
+
+const (
+	CodeInvalidArgument  Code = "INVALID_ARGUMENT"  // 400 / InvalidArgument
+	CodeUnauthenticated  Code = "UNAUTHENTICATED"   // 401 / Unauthenticated
+	CodePermissionDenied Code = "PERMISSION_DENIED" // 403 / PermissionDenied
+	CodeNotFound         Code = "NOT_FOUND"         // 404 / NotFound
+	CodeConflict         Code = "CONFLICT"          // 409 / FailedPrecondition
+	CodePendingReview    Code = "PENDING_REVIEW"    // 202 / FailedPrecondition с ErrorInfo PENDING_REVIEW
+	CodeApprovalRequired Code = "APPROVAL_REQUIRED" // 202 / FailedPrecondition с ErrorInfo APPROVAL_REQUIRED
+	CodeRateLimited      Code = "RATE_LIMITED"      // 429 / ResourceExhausted
+	CodeUnavailable      Code = "UNAVAILABLE"       // 503 / Unavailable
+	CodeInternal         Code = "INTERNAL"          // всё остальное
+)
+
+// Error — ошибка, которую вернул сервер.
+type Error struct {
+	Code    Code
+	Message string
+	// RetryAfter — через сколько можно повторить запрос, отклонённый по
+	// ограничению частоты (CodeRateLimited); иначе 0.
+	RetryAfter time.Duration
+	// PendingOperationID — ID операции, отложенной до решения оператора
+	// (CodePendingReview); иначе 0.
+	PendingOperationID int64
+	// LimitChangeID — ID предложения изменения лимита, ждущего
+	// подтверждения (CodeApprovalRequired); иначе 0.
+	LimitChangeID int64
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// CodeOf возвращает класс ошибки err; для nil — пустую строку.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return CodeInternal
+}
@@ -0,0 +1,252 @@
+package apiclient
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"test_nanimai/backend/domain"
+	pb "test_nanimai/backend/internal/api/grpc/pb"
+	"test_nanimai/backend/internal/service"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type grpcClient struct {
+	client pb.BalanceServiceClient
+	health healthpb.HealthClient
+	apiKey string
+}
+
+// NewGRPC возвращает клиент gRPC API поверх conn с ключом apiKey в
+// метаданных x-api-key; пустой ключ не передаётся.
+func NewGRPC(conn grpc.ClientConnInterface, apiKey string) Client {
+	return &grpcClient{
+		client: pb.NewBalanceServiceClient(conn),
+		health: healthpb.NewHealthClient(conn),
+		apiKey: apiKey,
+	}
+}
+
+func (c *grpcClient) ctx(ctx context.Context) context.Context {
+	if c.apiKey == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "x-api-key", c.apiKey)
+}
+
+func (c *grpcClient) GetAccount(ctx context.Context, accountID int64, asOf time.Time) (*service.AccountDTO, error) {
+	req := &pb.GetAccountRequest{AccountId: accountID}
+	if !asOf.IsZero() {
+		req.AsOf = asOf.Unix()
+	}
+	acc, err := c.client.GetAccount(c.ctx(ctx), req)
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return &service.AccountDTO{
+		ID:              acc.AccountId,
+		UserID:          acc.UserId,
+		CurrentAmount:   acc.CurrentAmount,
+		ReservedAmount:  acc.ReservedAmount,
+		MaxAmount:       acc.MaxAmount,
+		CreditLimit:     acc.CreditLimit,
+		UsedCredit:      acc.UsedCredit,
+		AvailableAmount: acc.AvailableAmount,
+		Frozen:          acc.Frozen,
+	}, nil
+}
+
+func (c *grpcClient) UpdateLimit(ctx context.Context, accountID, delta int64) error {
+	_, err := c.client.UpdateLimit(c.ctx(ctx), &pb.UpdateLimitRequest{AccountId: accountID, Delta: delta})
+	return fromStatus(err)
+}
+
+func (c *grpcClient) UpdateBalance(ctx context.Context, accountID, delta int64) error {
+	_, err := c.client.UpdateBalance(c.ctx(ctx), &pb.UpdateBalanceRequest{AccountId: accountID, Delta: delta})
+	return fromStatus(err)
+}
+
+func (c *grpcClient) UpdateCreditLimit(ctx context.Context, accountID, delta int64) error {
+	_, err := c.client.UpdateCreditLimit(c.ctx(ctx), &pb.UpdateCreditLimitRequest{AccountId: accountID, Delta: delta})
+	return fromStatus(err)
+}
+
+func (c *grpcClient) OpenReservation(ctx context.Context, accountID, amount int64, idempotencyKey string, timeout time.Duration) (*domain.Reservation, error) {
+	res, err := c.client.OpenReservation(c.ctx(ctx), &pb.OpenReservationRequest{
+		AccountId:      accountID,
+		Amount:         amount,
+		IdempotencyKey: idempotencyKey,
+		TimeoutSeconds: int64((timeout + time.Second - 1) / time.Second),
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return &domain.Reservation{
+		ID:             res.ReservationId,
+		AccountID:      res.AccountId,
+		OwnerServiceID: res.OwnerServiceId,
+		Amount:         res.Amount,
+		Status:         res.Status,
+		IdempotencyKey: idempotencyKey,
+		ExpiresAt:      time.Unix(res.ExpiresAt, 0),
+	}, nil
+}
+
+func (c *grpcClient) ConfirmReservation(ctx context.Context, reservationID int64) error {
+	_, err := c.client.ConfirmReservation(c.ctx(ctx), &pb.ReservationRequest{ReservationId: reservationID})
+	return fromStatus(err)
+}
+
+func (c *grpcClient) CancelReservation(ctx context.Context, reservationID int64) error {
+	_, err := c.client.CancelReservation(c.ctx(ctx), &pb.ReservationRequest{ReservationId: reservationID})
+	return fromStatus(err)
+}
+
+func (c *grpcClient) RefundReservation(ctx context.Context, reservationID, amount int64, idempotencyKey string) (*domain.Refund, error) {
+	refund, err := c.client.RefundReservation(c.ctx(ctx), &pb.RefundReservationRequest{
+		ReservationId:  reservationID,
+		Amount:         amount,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return &domain.Refund{
+		ID:             refund.RefundId,
+		ReservationID:  refund.ReservationId,
+		OwnerServiceID: refund.OwnerServiceId,
+		Amount:         refund.Amount,
+		IdempotencyKey: refund.IdempotencyKey,
+		LedgerEntryID:  refund.LedgerEntryId,
+		CreatedAt:      time.Unix(refund.CreatedAt, 0),
+	}, nil
+}
+
+func (c *grpcClient) PostJournal(ctx context.Context, accountID int64, description string, postings []domain.Posting) (*domain.JournalEntry, error) {
+	req := &pb.PostJournalRequest{AccountId: accountID, Description: description}
+	for _, p := range postings {
+		req.Postings = append(req.Postings, &pb.Posting{LedgerAccount: p.LedgerAccount, Side: string(p.Side), Amount: p.Amount})
+	}
+	entry, err := c.client.PostJournal(c.ctx(ctx), req)
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromPBJournalEntry(entry), nil
+}
+
+func (c *grpcClient) ListJournal(ctx context.Context, accountID int64, limit int) ([]domain.JournalEntry, error) {
+	resp, err := c.client.ListJournal(c.ctx(ctx), &pb.ListJournalRequest{AccountId: accountID, Limit: int32(limit)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	entries := make([]domain.JournalEntry, 0, len(resp.Entries))
+	for _, e := range resp.Entries {
+		entries = append(entries, *fromPBJournalEntry(e))
+	}
+	return entries, nil
+}
+
+func (c *grpcClient) ReverseEntry(ctx context.Context, entryID int64, reasonCode, description string) (*domain.JournalEntry, error) {
+	entry, err := c.client.ReverseEntry(c.ctx(ctx), &pb.ReverseEntryRequest{EntryId: entryID, ReasonCode: reasonCode, Description: description})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromPBJournalEntry(entry), nil
+}
+
+func (c *grpcClient) ReverseReservation(ctx context.Context, reservationID int64, reasonCode, description string) (*domain.JournalEntry, error) {
+	entry, err := c.client.ReverseReservation(c.ctx(ctx), &pb.ReverseReservationRequest{
+		ReservationId: reservationID,
+		ReasonCode:    reasonCode,
+		Description:   description,
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromPBJournalEntry(entry), nil
+}
+
+func (c *grpcClient) Ready(ctx context.Context) (bool, error) {
+	resp, err := c.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return false, fromStatus(err)
+	}
+	return resp.Status == healthpb.HealthCheckResponse_SERVING, nil
+}
+
+func fromPBJournalEntry(e *pb.JournalEntry) *domain.JournalEntry {
+	out := &domain.JournalEntry{
+		ID:             e.Id,
+		AccountID:      e.AccountId,
+		ReservationID:  e.ReservationId,
+		ActorServiceID: e.ActorServiceId,
+		Operation:      e.Operation,
+		Description:    e.Description,
+		DeltaCurrent:   e.DeltaCurrent,
+		DeltaReserved:  e.DeltaReserved,
+		DeltaMax:       e.DeltaMax,
+		DeltaCredit:    e.DeltaCredit,
+		ReversalOf:     e.ReversalOf,
+		ReversedBy:     e.ReversedBy,
+		ReasonCode:     e.ReasonCode,
+		CreatedAt:      time.Unix(e.CreatedAt, 0),
+	}
+	for _, p := range e.Postings {
+		out.Postings = append(out.Postings, domain.Posting{
+			LedgerAccount: p.LedgerAccount,
+			Side:          domain.PostingSide(p.Side),
+			Amount:        p.Amount,
+		})
+	}
+	return out
+}
+
+func fromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	code := CodeInternal
+	switch st.Code() {
+	case codes.InvalidArgument:
+		code = CodeInvalidArgument
+	case codes.Unauthenticated:
+		code = CodeUnauthenticated
+	case codes.PermissionDenied:
+		code = CodePermissionDenied
+	case codes.NotFound:
+		code = CodeNotFound
+	case codes.FailedPrecondition:
+		code = CodeConflict
+	case codes.ResourceExhausted:
+		code = CodeRateLimited
+	case codes.Unavailable:
+		code = CodeUnavailable
+	}
+	apiErr := &Error{Code: code, Message: st.Message()}
+	for _, d := range st.Details() {
+		switch info := d.(type) {
+		case *errdetails.RetryInfo:
+			apiErr.RetryAfter = info.GetRetryDelay().AsDuration()
+		case *errdetails.ErrorInfo:
+			switch info.GetReason() {
+			case string(CodePendingReview):
+				apiErr.Code = CodePendingReview
+				apiErr.PendingOperationID, _ = strconv.ParseInt(info.GetMetadata()["pending_operation_id"], 10, 64)
+			case string(CodeApprovalRequired):
+				apiErr.Code = CodeApprovalRequired
+				apiErr.LimitChangeID, _ = strconv.ParseInt(info.GetMetadata()["limit_change_id"], 10, 64)
+			}
+		}
+	}
+	return apiErr
+}
@@ -0,0 +1,221 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/service"
+	"test_nanimai/backend/internal/signing"
+)
+
+type restClient struct {
+	baseURL string
+	apiKey  string
+	secret  string // секрет подписи запросов; пустой — запросы не подписываются
+	http    *http.Client
+}
+
+// NewREST возвращает клиент REST API по адресу baseURL (например,
+// http://localhost:8080) с ключом apiKey; пустой ключ не передаётся.
+// При httpClient == nil используется http.DefaultClient.
+func NewREST(baseURL, apiKey string, httpClient *http.Client) Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &restClient{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey, http: httpClient}
+}
+
+// NewSignedREST — как NewREST, но каждый запрос подписывается секретом
+// secret (см. internal/signing). gRPC-клиент подписывает вызовы, если
+// соединение создано с signing.UnaryClientInterceptor.
+func NewSignedREST(baseURL, apiKey, secret string, httpClient *http.Client) Client {
+	c := NewREST(baseURL, apiKey, httpClient).(*restClient)
+	c.secret = secret
+	return c
+}
+
+func (c *restClient) GetAccount(ctx context.Context, accountID int64, asOf time.Time) (*service.AccountDTO, error) {
+	path := fmt.Sprintf("/accounts/%d", accountID)
+	if !asOf.IsZero() {
+		path += "?as_of=" + url.QueryEscape(asOf.UTC().Format(time.RFC3339))
+	}
+	var acc service.AccountDTO
+	if err := c.do(ctx, http.MethodGet, path, nil, &acc); err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+func (c *restClient) UpdateLimit(ctx context.Context, accountID, delta int64) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/accounts/%d/limit", accountID), service.UpdateLimitInput{Delta: delta}, nil)
+}
+
+func (c *restClient) UpdateBalance(ctx context.Context, accountID, delta int64) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/accounts/%d/balance", accountID), service.UpdateBalanceInput{Delta: delta}, nil)
+}
+
+func (c *restClient) UpdateCreditLimit(ctx context.Context, accountID, delta int64) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/accounts/%d/credit-limit", accountID), service.UpdateCreditLimitInput{Delta: delta}, nil)
+}
+
+func (c *restClient) OpenReservation(ctx context.Context, accountID, amount int64, idempotencyKey string, timeout time.Duration) (*domain.Reservation, error) {
+	input := service.OpenReservationInput{
+		Amount:         amount,
+		IdempotencyKey: idempotencyKey,
+		Timeout:        timeout,
+	}
+	var res domain.Reservation
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/accounts/%d/reservation", accountID), input, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *restClient) ConfirmReservation(ctx context.Context, reservationID int64) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/reservations/%d/confirm", reservationID), nil, nil)
+}
+
+func (c *restClient) CancelReservation(ctx context.Context, reservationID int64) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/reservations/%d/cancel", reservationID), nil, nil)
+}
+
+func (c *restClient) RefundReservation(ctx context.Context, reservationID, amount int64, idempotencyKey string) (*domain.Refund, error) {
+	input := service.RefundReservationInput{Amount: amount, IdempotencyKey: idempotencyKey}
+	var refund domain.Refund
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/reservations/%d/refunds", reservationID), input, &refund); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (c *restClient) PostJournal(ctx context.Context, accountID int64, description string, postings []domain.Posting) (*domain.JournalEntry, error) {
+	input := service.PostJournalInput{Description: description, Postings: postings}
+	var entry domain.JournalEntry
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/accounts/%d/journal", accountID), input, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (c *restClient) ListJournal(ctx context.Context, accountID int64, limit int) ([]domain.JournalEntry, error) {
+	var entries []domain.JournalEntry
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/accounts/%d/journal?limit=%d", accountID, limit), nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (c *restClient) ReverseEntry(ctx context.Context, entryID int64, reasonCode, description string) (*domain.JournalEntry, error) {
+	input := service.ReverseInput{ReasonCode: reasonCode, Description: description}
+	var entry domain.JournalEntry
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/journal/%d/reverse", entryID), input, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (c *restClient) ReverseReservation(ctx context.Context, reservationID int64, reasonCode, description string) (*domain.JournalEntry, error) {
+	input := service.ReverseInput{ReasonCode: reasonCode, Description: description}
+	var entry domain.JournalEntry
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/reservations/%d/reverse", reservationID), input, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (c *restClient) Ready(ctx context.Context) (bool, error) {
+	err := c.do(ctx, http.MethodGet, "/readyz", nil, nil)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.Code == CodeUnavailable {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// do выполняет запрос и декодирует ответ в out.
+func (c *restClient) do(ctx context.Context, method, path string, in, out any) error {
+	var data []byte
+	var body io.Reader
+	if in != nil {
+		var err error
+		if data, err = json.Marshal(in); err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if c.secret != "" {
+		signing.SignRequest(req, c.secret, data)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 202 — операция отложена до решения оператора или изменение лимита
+	// ждёт подтверждения; в обоих случаях не выполнено
+	if resp.StatusCode >= 400 || resp.StatusCode == http.StatusAccepted {
+		var payload struct {
+			Error              string `json:"error"`
+			PendingOperationID int64  `json:"pending_operation_id"`
+			LimitChangeID      int64  `json:"limit_change_id"`
+		}
+		json.NewDecoder(resp.Body).Decode(&payload)
+		apiErr := &Error{Code: httpCode(resp.StatusCode), Message: payload.Error,
+			PendingOperationID: payload.PendingOperationID, LimitChangeID: payload.LimitChangeID}
+		if payload.LimitChangeID != 0 {
+			apiErr.Code = CodeApprovalRequired
+		}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func httpCode(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidArgument
+	case http.StatusUnauthorized:
+		return CodeUnauthenticated
+	case http.StatusForbidden:
+		return CodePermissionDenied
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusAccepted:
+		return CodePendingReview
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	return CodeInternal
+}
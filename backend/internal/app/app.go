@@ -0,0 +1,93 @@
+// Package app собирает серверы приложения поверх сервиса баланса:
+// REST-роутер с middleware и gRPC-сервер с перехватчиками. Используется
+// в main и в e2e-обвязке, чтобы тесты поднимали тот же граф, что и прод.
+package app
+
+import (
+	"log/slog"
+
+	_ "test_nanimai/backend/docs"
+	"test_nanimai/backend/internal/access"
+	balancegrpc "test_nanimai/backend/internal/api/grpc"
+	pb "test_nanimai/backend/internal/api/grpc/pb"
+	rest "test_nanimai/backend/internal/api/rest"
+	"test_nanimai/backend/internal/health"
+	"test_nanimai/backend/internal/logging"
+	"test_nanimai/backend/internal/metrics"
+	"test_nanimai/backend/internal/ratelimit"
+	"test_nanimai/backend/internal/repository"
+	"test_nanimai/backend/internal/service"
+	"test_nanimai/backend/internal/signing"
+	"test_nanimai/backend/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// RESTOptions включает необязательные маршруты REST-роутера.
+type RESTOptions struct {
+	Metrics bool          // /metrics
+	Swagger bool          // /swagger/*any
+	Admin   service.Admin // /admin/*; nil — маршруты не регистрируются
+}
+
+// NewRESTHandler возвращает REST-роутер: трассировка, журнал запросов в
+// slog.Default(), аутентификация по API-ключу или клиентскому сертификату,
+// ограничение частоты (limiter), проверка подписи запроса (verifier), прав
+// и области счетов (store), маршруты, проверки живости и готовности, а
+// также метрики, Swagger UI и /admin, если они включены в opts.
+func NewRESTHandler(svc service.Balance, services repository.Services, store repository.Access, verifier *signing.Verifier, limiter *ratelimit.Limiter, checker *health.Checker, opts RESTOptions) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(tracing.GinMiddleware())
+	r.Use(logging.GinMiddleware(slog.Default()))
+	r.Use(metrics.GinMiddleware())
+	// API-key middleware
+	r.Use(rest.ApiKeyAuthMiddleware(services))
+	r.Use(rest.RateLimitMiddleware(limiter))
+	r.Use(rest.SignatureMiddleware(verifier))
+	r.Use(rest.AccessMiddleware(access.NewChecker(store)))
+	// REST routes
+	rest.RegisterRoutes(r, svc)
+	rest.RegisterHealthRoutes(r, checker)
+	if opts.Admin != nil {
+		rest.RegisterAdminRoutes(r, opts.Admin)
+	}
+	// Prometheus
+	if opts.Metrics {
+		r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
+	// Swagger UI (Gin)
+	if opts.Swagger {
+		r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	}
+	return r
+}
+
+// NewGRPCServer возвращает gRPC-сервер с трассировкой, журналом вызовов в
+// slog.Default(), аутентификацией по API-ключу или клиентскому сертификату,
+// ограничением частоты (limiter), проверкой подписи запроса (verifier),
+// прав и области счетов (store), BalanceService и grpc.health.v1. opts
+// дополняют настройки сервера, например grpc.Creds для TLS.
+func NewGRPCServer(svc service.Balance, services repository.Services, store repository.Access, verifier *signing.Verifier, limiter *ratelimit.Limiter, checker *health.Checker, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(append(opts, grpc.ChainUnaryInterceptor(
+		tracing.UnaryServerInterceptor(),
+		metrics.UnaryServerInterceptor(),
+		logging.UnaryServerInterceptor(slog.Default()),
+		balancegrpc.APIKeyInterceptor(services),
+		balancegrpc.RateLimitInterceptor(limiter),
+		balancegrpc.SignatureInterceptor(verifier),
+		balancegrpc.AccessInterceptor(access.NewChecker(store)),
+	))...)
+	pb.RegisterBalanceServiceServer(s, balancegrpc.NewBalanceGRPCServer(svc))
+	healthpb.RegisterHealthServer(s, checker.GRPC())
+	return s
+}
+
+// NewHealthChecker возвращает проверку готовности, публикующую статус BalanceService.
+func NewHealthChecker() *health.Checker {
+	return health.NewChecker(pb.BalanceService_ServiceDesc.ServiceName)
+}
@@ -0,0 +1,155 @@
+// Package authcache кеширует сервисы, аутентифицированные по API-ключу или
+// клиентскому сертификату, вместе с правами, областью, режимом подписи и
+// ограничениями частоты, чтобы запросы API не читали services и api_keys
+// каждый раз. Запись живёт TTL, который оператор задаёт явно и не больше
+// MaxTTL. Изменения сервисов через обёрнутое хранилище (/admin этого
+// экземпляра) сразу убирают записи сервиса, а изменения из других процессов
+// (CLI, другие экземпляры), отзыв и истечение ключей действуют не позже чем
+// через TTL.
+package authcache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/repository"
+)
+
+// MaxTTL — наибольший TTL: дольше отозванный ключ или снятое право не
+// должны действовать на других экземплярах.
+const MaxTTL = 10 * time.Second
+
+// Store — repository.Admin с кешем аутентификации. Неизвестные и
+// недействующие ключи и сертификаты не кешируются: выданный ключ работает
+// с первого запроса. Безопасен для одновременного использования.
+type Store struct {
+	repository.Admin
+	ttl time.Duration
+	now func() time.Time
+
+	mu         sync.Mutex
+	entries    map[string]entry
+	generation uint64 // растёт при каждой инвалидации
+	lastSweep  time.Time
+}
+
+type entry struct {
+	svc     *domain.Service
+	expires time.Time
+}
+
+// New оборачивает store; ttl = 0 отключает кеш, ttl больше MaxTTL
+// урезается до MaxTTL.
+func New(store repository.Admin, ttl time.Duration) *Store {
+	return &Store{Admin: store, ttl: min(ttl, MaxTTL), now: time.Now, entries: make(map[string]entry)}
+}
+
+// AuthenticateAPIKey возвращает сервис ключа apiKey из кеша или хранилища.
+// Ключ в кеше хранится только хешем.
+func (s *Store) AuthenticateAPIKey(ctx context.Context, apiKey string) (*domain.Service, error) {
+	return s.authenticate("key:"+domain.HashAPIKey(apiKey), func() (*domain.Service, error) {
+		return s.Admin.AuthenticateAPIKey(ctx, apiKey)
+	})
+}
+
+// AuthenticateCertificate возвращает сервис сертификата с именами identities
+// из кеша или хранилища.
+func (s *Store) AuthenticateCertificate(ctx context.Context, identities []string) (*domain.Service, error) {
+	return s.authenticate("cert:"+strings.Join(identities, "\n"), func() (*domain.Service, error) {
+		return s.Admin.AuthenticateCertificate(ctx, identities)
+	})
+}
+
+func (s *Store) authenticate(k string, load func() (*domain.Service, error)) (*domain.Service, error) {
+	if s.ttl == 0 {
+		return load()
+	}
+
+	s.mu.Lock()
+	now := s.now()
+	e, ok := s.entries[k]
+	generation := s.generation
+	s.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.svc, nil
+	}
+
+	svc, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Сервис, изменённый во время чтения, не кешируем: прочитанное могло устареть
+	if s.generation == generation {
+		s.sweep(now)
+		s.entries[k] = entry{svc: svc, expires: now.Add(s.ttl)}
+	}
+	return svc, nil
+}
+
+// sweep раз в TTL убирает истёкшие записи; вызывается под s.mu.
+func (s *Store) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	for k, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+	s.lastSweep = now
+}
+
+// Invalidate убирает из кеша записи сервиса serviceID.
+func (s *Store) Invalidate(serviceID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	for k, e := range s.entries {
+		if e.svc.ID == serviceID {
+			delete(s.entries, k)
+		}
+	}
+}
+
+// invalidate убирает записи serviceID, если изменение err прошло успешно.
+func (s *Store) invalidate(serviceID int64, err error) error {
+	if err == nil {
+		s.Invalidate(serviceID)
+	}
+	return err
+}
+
+func (s *Store) SetServicePermissions(ctx context.Context, serviceID int64, permissions []domain.Permission) error {
+	return s.invalidate(serviceID, s.Admin.SetServicePermissions(ctx, serviceID, permissions))
+}
+
+func (s *Store) SetServiceScope(ctx context.Context, serviceID int64, scope domain.AccountScope) error {
+	return s.invalidate(serviceID, s.Admin.SetServiceScope(ctx, serviceID, scope))
+}
+
+func (s *Store) SetServiceSigning(ctx context.Context, serviceID int64, mode domain.SigningMode, secret string) error {
+	return s.invalidate(serviceID, s.Admin.SetServiceSigning(ctx, serviceID, mode, secret))
+}
+
+func (s *Store) SetServiceRateLimit(ctx context.Context, serviceID int64, limit domain.RateLimit) error {
+	return s.invalidate(serviceID, s.Admin.SetServiceRateLimit(ctx, serviceID, limit))
+}
+
+func (s *Store) SetServiceCertIdentities(ctx context.Context, serviceID int64, identities []string) error {
+	return s.invalidate(serviceID, s.Admin.SetServiceCertIdentities(ctx, serviceID, identities))
+}
+
+func (s *Store) RotateAPIKey(ctx context.Context, key *domain.APIKey, overlap time.Duration) error {
+	return s.invalidate(key.ServiceID, s.Admin.RotateAPIKey(ctx, key, overlap))
+}
+
+func (s *Store) RevokeAPIKey(ctx context.Context, serviceID, keyID int64) error {
+	return s.invalidate(serviceID, s.Admin.RevokeAPIKey(ctx, serviceID, keyID))
+}
@@ -0,0 +1,191 @@
+package authcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/repository"
+	"test_nanimai/backend/internal/repository/memory"
+)
+
+// countingStore считает обращения к хранилищу за аутентификацией.
+type countingStore struct {
+	repository.Admin
+	calls int
+}
+
+func (s *countingStore) AuthenticateAPIKey(ctx context.Context, apiKey string) (*domain.Service, error) {
+	s.calls++
+	return s.Admin.AuthenticateAPIKey(ctx, apiKey)
+}
+
+func (s *countingStore) AuthenticateCertificate(ctx context.Context, identities []string) (*domain.Service, error) {
+	s.calls++
+	return s.Admin.AuthenticateCertificate(ctx, identities)
+}
+
+// newStore возвращает кеш с TTL ttl и часами, стоящими в now, над
+// хранилищем в памяти с одним сервисом, и ID и API-ключ этого сервиса.
+func newStore(t *testing.T, ttl time.Duration, now *time.Time) (*Store, *countingStore, int64, string) {
+	t.Helper()
+	backend := &countingStore{Admin: memory.NewBalanceStorage()}
+	id, err := backend.CreateService(context.Background(), "billing", "billing-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(backend, ttl)
+	s.now = func() time.Time { return *now }
+	return s, backend, id, "billing-key"
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	s, backend, id, apiKey := newStore(t, 5*time.Second, &now)
+
+	for range 3 {
+		svc, err := s.AuthenticateAPIKey(ctx, apiKey)
+		if err != nil || svc.ID != id {
+			t.Fatalf("AuthenticateAPIKey = %v, %v; want service %d", svc, err, id)
+		}
+	}
+	if backend.calls != 1 {
+		t.Errorf("store called %d times within TTL, want 1", backend.calls)
+	}
+
+	// Запись истекает через TTL
+	now = now.Add(5 * time.Second)
+	if _, err := s.AuthenticateAPIKey(ctx, apiKey); err != nil {
+		t.Fatal(err)
+	}
+	if backend.calls != 2 {
+		t.Errorf("store called %d times after TTL, want 2", backend.calls)
+	}
+
+	// Неизвестный ключ не кешируется: выданный позже работает сразу
+	for range 2 {
+		if _, err := s.AuthenticateAPIKey(ctx, "unknown"); !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("AuthenticateAPIKey(unknown) = %v, want %v", err, domain.ErrNotFound)
+		}
+	}
+	if backend.calls != 4 {
+		t.Errorf("store called %d times, want unknown keys not cached", backend.calls)
+	}
+	if len(s.entries) != 1 {
+		t.Errorf("%d cache entries, want 1", len(s.entries))
+	}
+
+	// Истёкшие записи убираются из памяти
+	now = now.Add(time.Minute)
+	if err := backend.SetServiceCertIdentities(ctx, id, []string{"billing.internal"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AuthenticateCertificate(ctx, []string{"billing.internal"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.entries["key:"+domain.HashAPIKey(apiKey)]; ok {
+		t.Error("expired entry was not swept")
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	s, backend, id, apiKey := newStore(t, MaxTTL, &now)
+	other, err := backend.CreateService(ctx, "other", "other-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := []struct {
+		name   string
+		change func() error
+		check  func(*domain.Service) bool
+	}{
+		{"SetServicePermissions", func() error {
+			return s.SetServicePermissions(ctx, id, []domain.Permission{domain.PermBalanceDebit})
+		}, func(svc *domain.Service) bool { return len(svc.Permissions) == 1 }},
+		{"SetServiceScope", func() error {
+			return s.SetServiceScope(ctx, id, domain.AccountScope{Tags: []string{"e2e"}})
+		}, func(svc *domain.Service) bool { return len(svc.Scope.Tags) == 1 }},
+		{"SetServiceSigning", func() error {
+			return s.SetServiceSigning(ctx, id, domain.SigningRequired, "secret")
+		}, func(svc *domain.Service) bool { return svc.SigningMode == domain.SigningRequired }},
+		{"SetServiceRateLimit", func() error {
+			return s.SetServiceRateLimit(ctx, id, domain.RateLimit{Rate: 1, Burst: 1})
+		}, func(svc *domain.Service) bool { return svc.RateLimit.Rate == 1 }},
+	}
+	for _, c := range changes {
+		if _, err := s.AuthenticateAPIKey(ctx, apiKey); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.AuthenticateAPIKey(ctx, "other-key"); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.change(); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		calls := backend.calls
+		svc, err := s.AuthenticateAPIKey(ctx, apiKey)
+		if err != nil || !c.check(svc) || backend.calls != calls+1 {
+			t.Errorf("after %s: AuthenticateAPIKey = %+v, %v; want service reloaded", c.name, svc, err)
+		}
+		// Записи других сервисов остаются
+		if _, err := s.AuthenticateAPIKey(ctx, "other-key"); err != nil || backend.calls != calls+1 {
+			t.Errorf("after %s: entry of service %d was dropped", c.name, other)
+		}
+	}
+
+	// Отозванный через кеш ключ перестаёт действовать сразу
+	keys, err := backend.ListAPIKeys(ctx, id)
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("ListAPIKeys = %v, %v", keys, err)
+	}
+	if err := s.RevokeAPIKey(ctx, id, keys[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AuthenticateAPIKey(ctx, apiKey); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("AuthenticateAPIKey(revoked) = %v, want %v", err, domain.ErrNotFound)
+	}
+
+	// Неудачное изменение записи не трогает
+	calls := backend.calls
+	if err := s.SetServicePermissions(ctx, 1000, nil); err == nil {
+		t.Error("SetServicePermissions(missing service) succeeded")
+	}
+	if _, err := s.AuthenticateAPIKey(ctx, "other-key"); err != nil || backend.calls != calls {
+		t.Error("failed change dropped cache entries")
+	}
+}
+
+func TestTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+
+	// ttl = 0 — каждый запрос идёт в хранилище
+	s, backend, _, apiKey := newStore(t, 0, &now)
+	for range 2 {
+		if _, err := s.AuthenticateAPIKey(ctx, apiKey); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if backend.calls != 2 {
+		t.Errorf("store called %d times with cache disabled, want 2", backend.calls)
+	}
+
+	// TTL больше MaxTTL урезается
+	s, backend, _, apiKey = newStore(t, time.Hour, &now)
+	if _, err := s.AuthenticateAPIKey(ctx, apiKey); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(MaxTTL)
+	if _, err := s.AuthenticateAPIKey(ctx, apiKey); err != nil {
+		t.Fatal(err)
+	}
+	if backend.calls != 2 {
+		t.Errorf("entry outlived MaxTTL: store called %d times, want 2", backend.calls)
+	}
+}
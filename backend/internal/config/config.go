@@ -0,0 +1,475 @@
+// Package config — настройки сервиса в одной структуре. Каждая настройка
+// имеет ключ (переменная окружения и строка KEY=value в файле) и флаг.
+// Источники в порядке приоритета, от низшего к высшему:
+//
+//  1. значения по умолчанию (Default);
+//  2. файл в формате .env: -config, CONFIG_FILE или ./.env, если он есть;
+//  3. переменные окружения;
+//  4. флаги командной строки, заданные явно.
+//
+// После загрузки настройки проверяются целиком, все ошибки сообщаются разом.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/authcache"
+	"test_nanimai/backend/internal/logging"
+	"test_nanimai/backend/internal/mtls"
+	"test_nanimai/backend/internal/risk"
+	"test_nanimai/backend/internal/signing"
+	"test_nanimai/backend/internal/tracing"
+
+	"github.com/joho/godotenv"
+)
+
+// DefaultFile — файл настроек, который читается, если он есть и другой не указан.
+const DefaultFile = ".env"
+
+// Config — настройки сервиса.
+type Config struct {
+	DatabaseURL string
+	DB          DBPool
+
+	RESTAddr string
+	GRPCAddr string
+	REST     RESTTimeouts
+
+	AutoMigrate        bool // применять миграции при старте сервера
+	MigrateLockTimeout time.Duration
+
+	ShutdownTimeout  time.Duration
+	SnapshotInterval time.Duration // 0 отключает снимки балансов
+	HealthInterval   time.Duration
+	SignatureSkew    time.Duration // допуск времени подписи запроса
+	AuthCacheTTL     time.Duration // 0 отключает кеш аутентифицированных сервисов
+	TLS              TLS
+	Risk             Risk
+	LimitApproval    domain.LimitPolicy // увеличения лимита выше порога за окно ждут подтверждения
+
+	LogLevel       string
+	TracesExporter string
+
+	MetricsEnabled bool
+	SwaggerEnabled bool
+
+	// File — прочитанный файл настроек или пустая строка.
+	File string
+	// PrintConfig — вывести настройки с замаскированными секретами и выйти.
+	PrintConfig bool
+	// Args — аргументы после флагов: подкоманда и её параметры.
+	Args []string
+}
+
+// DBPool — параметры пула соединений с PostgreSQL (sql.DB.SetMax*/SetConn*).
+type DBPool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration // 0 — без ограничения
+	ConnMaxIdleTime time.Duration // 0 — без ограничения
+}
+
+// RESTTimeouts — таймауты http.Server.
+type RESTTimeouts struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+}
+
+// Risk — проверка риска списаний: сколько ждать решения и что делать без
+// него (risk.FailOpen или risk.FailClosed).
+type Risk struct {
+	Timeout       time.Duration
+	FailurePolicy string
+}
+
+// Policy возвращает настройки для risk.NewGuard; политика уже проверена в Validate.
+func (r Risk) Policy() risk.Policy {
+	failOpen, _ := risk.ParseFailurePolicy(r.FailurePolicy)
+	return risk.Policy{Timeout: r.Timeout, FailOpen: failOpen}
+}
+
+// TLS — сертификаты REST и gRPC. Без CertFile оба сервера работают без TLS.
+type TLS struct {
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string // корневые сертификаты клиентов
+	ClientAuth     string // none, optional или require
+	ReloadInterval time.Duration
+}
+
+// Enabled сообщает, включён ли TLS.
+func (t TLS) Enabled() bool { return t.CertFile != "" }
+
+// Default возвращает настройки по умолчанию.
+func Default() Config {
+	return Config{
+		DB: DBPool{
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		RESTAddr: ":8080",
+		GRPCAddr: ":9090",
+		REST: RESTTimeouts{
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+		},
+		AutoMigrate:        true,
+		MigrateLockTimeout: 2 * time.Minute,
+		ShutdownTimeout:    30 * time.Second,
+		SnapshotInterval:   10 * time.Minute,
+		HealthInterval:     5 * time.Second,
+		SignatureSkew:      signing.DefaultSkew,
+		LogLevel:           "info",
+		TracesExporter:     tracing.ExporterNone,
+		MetricsEnabled:     true,
+		SwaggerEnabled:     true,
+		TLS: TLS{
+			ClientAuth:     mtls.ClientAuthNone,
+			ReloadInterval: mtls.DefaultReloadInterval,
+		},
+		Risk: Risk{
+			Timeout:       risk.DefaultTimeout,
+			FailurePolicy: risk.FailClosed,
+		},
+		LimitApproval: domain.LimitPolicy{Window: 24 * time.Hour, TTL: 24 * time.Hour},
+	}
+}
+
+// option — одна настройка: ключ, флаг и поле Config, в которое пишет value.
+type option struct {
+	key    string
+	flag   string
+	usage  string
+	secret bool
+	value  flag.Value
+}
+
+// options перечисляет настройки c в порядке вывода.
+func (c *Config) options() []option {
+	return []option{
+		{"DATABASE_URL", "database-url", "PostgreSQL connection string", true, (*stringValue)(&c.DatabaseURL)},
+		{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "max open database connections, 0 is unlimited", false, (*intValue)(&c.DB.MaxOpenConns)},
+		{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "max idle database connections", false, (*intValue)(&c.DB.MaxIdleConns)},
+		{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "max database connection lifetime, 0 is unlimited", false, (*durationValue)(&c.DB.ConnMaxLifetime)},
+		{"DB_CONN_MAX_IDLE_TIME", "db-conn-max-idle-time", "max database connection idle time, 0 is unlimited", false, (*durationValue)(&c.DB.ConnMaxIdleTime)},
+		{"REST_ADDR", "rest-addr", "REST service address", false, (*stringValue)(&c.RESTAddr)},
+		{"GRPC_ADDR", "grpc-addr", "gRPC service address", false, (*stringValue)(&c.GRPCAddr)},
+		{"REST_READ_HEADER_TIMEOUT", "rest-read-header-timeout", "time to read REST request headers", false, (*durationValue)(&c.REST.ReadHeaderTimeout)},
+		{"REST_READ_TIMEOUT", "rest-read-timeout", "time to read a whole REST request", false, (*durationValue)(&c.REST.ReadTimeout)},
+		{"REST_WRITE_TIMEOUT", "rest-write-timeout", "time to write a REST response", false, (*durationValue)(&c.REST.WriteTimeout)},
+		{"REST_IDLE_TIMEOUT", "rest-idle-timeout", "keep-alive idle timeout", false, (*durationValue)(&c.REST.IdleTimeout)},
+		{"AUTO_MIGRATE", "auto-migrate", "apply embedded migrations on server start", false, (*boolValue)(&c.AutoMigrate)},
+		{"MIGRATE_LOCK_TIMEOUT", "migrate-lock-timeout", "time to wait for another instance to finish migrating", false, (*durationValue)(&c.MigrateLockTimeout)},
+		{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "time to drain in-flight requests on shutdown", false, (*durationValue)(&c.ShutdownTimeout)},
+		{"SNAPSHOT_INTERVAL", "snapshot-interval", "balance snapshot interval, 0 disables snapshots", false, (*durationValue)(&c.SnapshotInterval)},
+		{"HEALTH_INTERVAL", "health-interval", "readiness check interval for grpc.health.v1", false, (*durationValue)(&c.HealthInterval)},
+		{"SIGNATURE_SKEW", "signature-skew", "allowed clock skew of signed requests", false, (*durationValue)(&c.SignatureSkew)},
+		{"AUTH_CACHE_TTL", "auth-cache-ttl", "how long authenticated services with their permissions and rate limits are cached, at most 10s; revocations made elsewhere apply after this delay; 0 disables the cache", false, (*durationValue)(&c.AuthCacheTTL)},
+		{"TLS_CERT_FILE", "tls-cert-file", "server certificate in PEM; empty serves REST and gRPC without TLS", false, (*stringValue)(&c.TLS.CertFile)},
+		{"TLS_KEY_FILE", "tls-key-file", "server private key in PEM", false, (*stringValue)(&c.TLS.KeyFile)},
+		{"TLS_CLIENT_CA_FILE", "tls-client-ca-file", "CA certificates in PEM that sign client certificates", false, (*stringValue)(&c.TLS.ClientCAFile)},
+		{"TLS_CLIENT_AUTH", "tls-client-auth", "client certificates: none, optional or require", false, (*stringValue)(&c.TLS.ClientAuth)},
+		{"TLS_RELOAD_INTERVAL", "tls-reload-interval", "how often certificate files are checked for changes", false, (*durationValue)(&c.TLS.ReloadInterval)},
+		{"RISK_TIMEOUT", "risk-timeout", "time to wait for a risk check decision", false, (*durationValue)(&c.Risk.Timeout)},
+		{"RISK_FAILURE_POLICY", "risk-failure-policy", "when the risk check fails: open allows the operation, closed declines it", false, (*stringValue)(&c.Risk.FailurePolicy)},
+		{"LIMIT_APPROVAL_THRESHOLD", "limit-approval-threshold", "limit increase above which a second operator must approve, 0 disables approval", false, (*int64Value)(&c.LimitApproval.Threshold)},
+		{"LIMIT_APPROVAL_WINDOW", "limit-approval-window", "window over which limit increases add up against the approval threshold", false, (*durationValue)(&c.LimitApproval.Window)},
+		{"LIMIT_CHANGE_TTL", "limit-change-ttl", "how long a proposed limit change waits for approval", false, (*durationValue)(&c.LimitApproval.TTL)},
+		{"LOG_LEVEL", "log-level", "log level: debug, info, warn or error", false, (*stringValue)(&c.LogLevel)},
+		{"OTEL_TRACES_EXPORTER", "traces-exporter", "traces exporter: none, stdout or otlp", false, (*stringValue)(&c.TracesExporter)},
+		{"METRICS_ENABLED", "metrics", "serve Prometheus metrics on /metrics", false, (*boolValue)(&c.MetricsEnabled)},
+		{"SWAGGER_ENABLED", "swagger", "serve Swagger UI on /swagger/", false, (*boolValue)(&c.SwaggerEnabled)},
+	}
+}
+
+// Load собирает настройки из файла, окружения getenv и аргументов args
+// (без имени программы) и проверяет их.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	cfg := Default()
+	opts := cfg.options()
+
+	// Флаги сначала только запоминаем: применяются они последними
+	fs := flag.NewFlagSet("backend", flag.ContinueOnError)
+	for _, o := range opts {
+		fs.Var(&flagRecorder{key: o.key, value: o.value}, o.flag, fmt.Sprintf("%s (env %s)", o.usage, o.key))
+	}
+	file := fs.String("config", "", "settings file in .env format (env CONFIG_FILE, default ./"+DefaultFile+" if present)")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "print settings with secrets masked and exit")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	cfg.Args = fs.Args()
+	// Заданный флаг действует, даже если он пуст: -tls-cert-file= отключает
+	// TLS из файла настроек
+	explicit := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		if r, ok := f.Value.(*flagRecorder); ok {
+			explicit[r.key] = r.v
+		}
+	})
+
+	path, required := *file, true
+	if path == "" {
+		path = getenv("CONFIG_FILE")
+	}
+	if path == "" {
+		path, required = DefaultFile, false
+	}
+	values, err := godotenv.Read(path)
+	switch {
+	case err == nil:
+		cfg.File = path
+	case errors.Is(err, os.ErrNotExist) && !required:
+		values = nil
+	default:
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	var errs []error
+	for _, o := range opts {
+		v, ok := explicit[o.key]
+		if !ok {
+			if v = getenv(o.key); v == "" {
+				v = values[o.key]
+			}
+			if v == "" {
+				continue
+			}
+		}
+		if err := o.value.Set(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q: %w", o.key, v, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate проверяет настройки и возвращает все найденные ошибки.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.DatabaseURL != "", "DATABASE_URL: required")
+	if c.DatabaseURL != "" {
+		if err := validateDSN(c.DatabaseURL); err != nil {
+			errs = append(errs, fmt.Errorf("DATABASE_URL: %w", err))
+		}
+	}
+	check(c.DB.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS: must not be negative")
+	check(c.DB.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS: must not be negative")
+	check(c.DB.MaxOpenConns == 0 || c.DB.MaxIdleConns <= c.DB.MaxOpenConns, "DB_MAX_IDLE_CONNS: must not exceed DB_MAX_OPEN_CONNS")
+	check(c.DB.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME: must not be negative")
+	check(c.DB.ConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME: must not be negative")
+
+	if err := validateAddr(c.RESTAddr); err != nil {
+		errs = append(errs, fmt.Errorf("REST_ADDR: %w", err))
+	}
+	if err := validateAddr(c.GRPCAddr); err != nil {
+		errs = append(errs, fmt.Errorf("GRPC_ADDR: %w", err))
+	}
+	check(c.RESTAddr != c.GRPCAddr, "GRPC_ADDR: must differ from REST_ADDR")
+	check(c.REST.ReadHeaderTimeout > 0, "REST_READ_HEADER_TIMEOUT: must be positive")
+	check(c.REST.ReadTimeout > 0, "REST_READ_TIMEOUT: must be positive")
+	check(c.REST.WriteTimeout > 0, "REST_WRITE_TIMEOUT: must be positive")
+	check(c.REST.IdleTimeout > 0, "REST_IDLE_TIMEOUT: must be positive")
+
+	check(c.MigrateLockTimeout > 0, "MIGRATE_LOCK_TIMEOUT: must be positive")
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT: must be positive")
+	check(c.SnapshotInterval >= 0, "SNAPSHOT_INTERVAL: must not be negative")
+	check(c.HealthInterval > 0, "HEALTH_INTERVAL: must be positive")
+	check(c.SignatureSkew > 0, "SIGNATURE_SKEW: must be positive")
+	check(c.AuthCacheTTL >= 0 && c.AuthCacheTTL <= authcache.MaxTTL, "AUTH_CACHE_TTL: must be between 0 and "+authcache.MaxTTL.String())
+
+	check(c.TLS.KeyFile == "" || c.TLS.Enabled(), "TLS_KEY_FILE: requires TLS_CERT_FILE")
+	check(c.TLS.KeyFile != "" || !c.TLS.Enabled(), "TLS_KEY_FILE: required with TLS_CERT_FILE")
+	check(c.TLS.ClientCAFile == "" || c.TLS.Enabled(), "TLS_CLIENT_CA_FILE: requires TLS_CERT_FILE")
+	if _, err := mtls.ParseClientAuth(c.TLS.ClientAuth); err != nil {
+		errs = append(errs, fmt.Errorf("TLS_CLIENT_AUTH: %w", err))
+	} else if c.TLS.ClientAuth != mtls.ClientAuthNone {
+		check(c.TLS.ClientCAFile != "", "TLS_CLIENT_AUTH: %s requires TLS_CLIENT_CA_FILE", c.TLS.ClientAuth)
+	}
+	check(c.TLS.ReloadInterval > 0, "TLS_RELOAD_INTERVAL: must be positive")
+
+	check(c.Risk.Timeout > 0, "RISK_TIMEOUT: must be positive")
+	if _, err := risk.ParseFailurePolicy(c.Risk.FailurePolicy); err != nil {
+		errs = append(errs, fmt.Errorf("RISK_FAILURE_POLICY: %w", err))
+	}
+	check(c.LimitApproval.Threshold >= 0, "LIMIT_APPROVAL_THRESHOLD: must not be negative")
+	check(c.LimitApproval.Window > 0, "LIMIT_APPROVAL_WINDOW: must be positive")
+	check(c.LimitApproval.TTL > 0, "LIMIT_CHANGE_TTL: must be positive")
+
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: %w", err))
+	}
+	switch c.TracesExporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		errs = append(errs, fmt.Errorf("OTEL_TRACES_EXPORTER: unknown exporter %q", c.TracesExporter))
+	}
+	return errors.Join(errs...)
+}
+
+// Print выводит настройки строками KEY=value; секреты маскируются.
+func (c *Config) Print(w io.Writer) {
+	for _, o := range c.options() {
+		fmt.Fprintf(w, "%s=%s\n", o.key, o.masked())
+	}
+}
+
+// Masked возвращает настройки для лога: ключ → значение, секреты замаскированы.
+func (c *Config) Masked() map[string]string {
+	out := make(map[string]string)
+	for _, o := range c.options() {
+		out[o.key] = o.masked()
+	}
+	return out
+}
+
+func (o option) masked() string {
+	v := o.value.String()
+	if !o.secret || v == "" {
+		return v
+	}
+	if o.key == "DATABASE_URL" {
+		return MaskDSN(v)
+	}
+	return masked
+}
+
+// masked заменяет значения секретов при выводе, как url.URL.Redacted.
+const masked = "xxxxx"
+
+var (
+	dsnPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
+	dsnKeyValue = regexp.MustCompile(`^\s*\w+=`)
+)
+
+// MaskDSN скрывает пароль в строке подключения в формате URL или key=value.
+func MaskDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if q := u.Query(); q.Has("password") {
+			q.Set("password", masked)
+			u.RawQuery = q.Encode()
+		}
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+masked)
+}
+
+func validateDSN(dsn string) error {
+	u, err := url.Parse(dsn)
+	if err == nil && u.Scheme != "" {
+		if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+			return fmt.Errorf("unsupported scheme %q", u.Scheme)
+		}
+		return nil
+	}
+	// Формат key=value: "host=db user=postgres dbname=postgres"
+	if !dsnKeyValue.MatchString(dsn) {
+		return errors.New("expected postgres:// URL or key=value connection string")
+	}
+	return nil
+}
+
+func validateAddr(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// flagRecorder запоминает значение флага, не меняя Config; заданные флаги
+// Load находит через flag.Visit.
+type flagRecorder struct {
+	key   string
+	value flag.Value // поле Config: его значение — умолчание в справке
+	v     string
+}
+
+func (f *flagRecorder) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return f.value.String()
+}
+
+func (f *flagRecorder) Set(v string) error {
+	f.v = v
+	return nil
+}
+
+func (f *flagRecorder) IsBoolFlag() bool {
+	_, ok := f.value.(*boolValue)
+	return ok
+}
+
+type stringValue string
+
+func (v *stringValue) String() string     { return string(*v) }
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	*v = intValue(n)
+	return err
+}
+
+type int64Value int64
+
+func (v *int64Value) String() string { return strconv.FormatInt(int64(*v), 10) }
+func (v *int64Value) Set(s string) error {
+	n, err := strconv.ParseInt(s, 10, 64)
+	*v = int64Value(n)
+	return err
+}
+
+type durationValue time.Duration
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	*v = durationValue(d)
+	return err
+}
+
+type boolValue bool
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	*v = boolValue(b)
+	return err
+}
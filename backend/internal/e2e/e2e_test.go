@@ -0,0 +1,12 @@
+package e2e_test
+
+import (
+	"testing"
+
+	"test_nanimai/backend/internal/e2e"
+	"test_nanimai/backend/internal/repository/memory"
+)
+
+func TestMemory(t *testing.T) {
+	e2e.Run(t, func(t *testing.T) e2e.Store { return memory.NewBalanceStorage() })
+}
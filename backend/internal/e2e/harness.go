@@ -0,0 +1,243 @@
+// Package e2e поднимает REST- и gRPC-серверы приложения в процессе на
+// свободных портах поверх подключаемого хранилища и прогоняет по ним общий
+// набор сценариев. Оба транспорта проверяются одними и теми же сценариями
+// через apiclient.Client, поэтому расхождения в поведении видны сразу.
+// Маршруты /admin есть только в REST и вызываются через AdminClient, а
+// операции, которые они разрешают или подтверждают, — через проверяемый
+// транспорт:
+//
+//	func TestMemory(t *testing.T) {
+//		e2e.Run(t, func(t *testing.T) e2e.Store { return memory.NewBalanceStorage() })
+//	}
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/apiclient"
+	"test_nanimai/backend/internal/app"
+	"test_nanimai/backend/internal/authcache"
+	"test_nanimai/backend/internal/health"
+	"test_nanimai/backend/internal/mtls"
+	"test_nanimai/backend/internal/ratelimit"
+	"test_nanimai/backend/internal/repository"
+	"test_nanimai/backend/internal/risk"
+	"test_nanimai/backend/internal/service/admin"
+	"test_nanimai/backend/internal/service/balance"
+	"test_nanimai/backend/internal/signing"
+	"test_nanimai/backend/internal/velocity"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Store — хранилище, поверх которого поднимаются серверы, включая /admin.
+type Store interface {
+	repository.Admin
+}
+
+// LimitApprovalThreshold — порог подтверждения увеличения лимита в
+// поднятых серверах: увеличение больше него ждёт второго оператора.
+const LimitApprovalThreshold = 10000
+
+// Harness — запущенные REST- и gRPC-серверы. Останавливаются в t.Cleanup.
+type Harness struct {
+	Store    Store
+	Checker  *health.Checker
+	RESTURL  string
+	GRPCAddr string
+
+	http *http.Client
+	conn *grpc.ClientConn
+}
+
+var seq atomic.Int64
+
+// Start собирает серверы так же, как main, и запускает их на 127.0.0.1 на свободных портах.
+func Start(t testing.TB, store Store) *Harness {
+	t.Helper()
+	return start(t, store, nil, tls.NoClientCert, nil)
+}
+
+// StartTLS — как Start, но серверы принимают только TLS с сертификатами
+// certs и проверкой клиентских сертификатов auth, как main с TLS_CERT_FILE.
+// Клиенты Harness подключаются с настройками client (см. WithClientTLS).
+func StartTLS(t testing.TB, store Store, certs *mtls.Reloader, auth tls.ClientAuthType, client *tls.Config) *Harness {
+	t.Helper()
+	return start(t, store, certs, auth, client)
+}
+
+func start(t testing.TB, store Store, certs *mtls.Reloader, auth tls.ClientAuthType, client *tls.Config) *Harness {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	limitPolicy := domain.LimitPolicy{Threshold: LimitApprovalThreshold, Window: time.Hour, TTL: time.Hour}
+	svc := balance.NewBalanceService(store, store, risk.NewGuard(velocity.NewEngine(store), risk.Policy{Timeout: risk.DefaultTimeout}),
+		store, limitPolicy)
+	checker := app.NewHealthChecker()
+	limiter := ratelimit.NewLimiter()
+	services := authcache.New(store, authcache.MaxTTL)
+
+	restLis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen REST: %v", err)
+	}
+	restServer := &http.Server{Handler: app.NewRESTHandler(svc, services, store, signing.NewVerifier(signing.DefaultSkew), limiter, checker, app.RESTOptions{Metrics: true, Swagger: true, Admin: admin.NewAdminService(services, limitPolicy)})}
+	serve, scheme := restServer.Serve, "http"
+	var grpcOpts []grpc.ServerOption
+	if certs != nil {
+		restServer.TLSConfig = certs.ServerConfig(auth, "h2", "http/1.1")
+		serve, scheme = func(lis net.Listener) error { return restServer.ServeTLS(lis, "", "") }, "https"
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(certs.ServerConfig(auth, "h2"))))
+	}
+	go func() {
+		if err := serve(restLis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("REST server: %v", err)
+		}
+	}()
+	t.Cleanup(func() { restServer.Close() })
+
+	grpcLis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen gRPC: %v", err)
+	}
+	grpcServer := app.NewGRPCServer(svc, services, store, signing.NewVerifier(signing.DefaultSkew), limiter, checker, grpcOpts...)
+	go grpcServer.Serve(grpcLis)
+	t.Cleanup(grpcServer.Stop)
+
+	h := &Harness{
+		Store:    store,
+		Checker:  checker,
+		RESTURL:  scheme + "://" + restLis.Addr().String(),
+		GRPCAddr: grpcLis.Addr().String(),
+	}
+	if certs == nil {
+		h.http, h.conn = http.DefaultClient, h.dial(t, insecure.NewCredentials())
+		return h
+	}
+	return h.WithClientTLS(t, client)
+}
+
+// WithClientTLS возвращает Harness тех же серверов, клиенты которого
+// подключаются по TLS с настройками client, например с другим клиентским
+// сертификатом.
+func (h *Harness) WithClientTLS(t testing.TB, client *tls.Config) *Harness {
+	t.Helper()
+	out := *h
+	out.http = &http.Client{Transport: &http.Transport{TLSClientConfig: client}}
+	t.Cleanup(out.http.CloseIdleConnections)
+	out.conn = h.dial(t, credentials.NewTLS(client))
+	return &out
+}
+
+func (h *Harness) dial(t testing.TB, creds credentials.TransportCredentials, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.NewClient(h.GRPCAddr, append(opts, grpc.WithTransportCredentials(creds))...)
+	if err != nil {
+		t.Fatalf("dial gRPC: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// NewService регистрирует сервис-клиент и возвращает его ID и API-ключ.
+func (h *Harness) NewService(t testing.TB) (int64, string) {
+	t.Helper()
+	n := seq.Add(1)
+	apiKey := fmt.Sprintf("e2e-key-%d", n)
+	id, err := h.Store.CreateService(context.Background(), fmt.Sprintf("e2e-%d", n), apiKey)
+	if err != nil {
+		t.Fatalf("CreateService: %v", err)
+	}
+	return id, apiKey
+}
+
+// NewAdminService регистрирует сервис со всеми правами, включая admin, и
+// возвращает его ID и API-ключ.
+func (h *Harness) NewAdminService(t testing.TB) (int64, string) {
+	t.Helper()
+	id, apiKey := h.NewService(t)
+	if err := h.Store.SetServicePermissions(context.Background(), id, domain.AllPermissions); err != nil {
+		t.Fatalf("SetServicePermissions: %v", err)
+	}
+	return id, apiKey
+}
+
+// REST возвращает клиент REST API с ключом apiKey; пустой ключ не передаётся.
+func (h *Harness) REST(apiKey string) apiclient.Client {
+	return apiclient.NewREST(h.RESTURL, apiKey, h.http)
+}
+
+// GRPC возвращает клиент gRPC API с ключом apiKey; пустой ключ не передаётся.
+func (h *Harness) GRPC(apiKey string) apiclient.Client {
+	return apiclient.NewGRPC(h.conn, apiKey)
+}
+
+// SignedREST возвращает клиент REST API с ключом apiKey, подписывающий
+// запросы секретом secret.
+func (h *Harness) SignedREST(t testing.TB, apiKey, secret string) apiclient.Client {
+	return apiclient.NewSignedREST(h.RESTURL, apiKey, secret, h.http)
+}
+
+// SignedGRPC возвращает клиент gRPC API с ключом apiKey по отдельному
+// соединению, подписывающему вызовы секретом secret. Harness должен быть
+// без TLS.
+func (h *Harness) SignedGRPC(t testing.TB, apiKey, secret string) apiclient.Client {
+	t.Helper()
+	conn := h.dial(t, insecure.NewCredentials(), grpc.WithUnaryInterceptor(signing.UnaryClientInterceptor(secret)))
+	return apiclient.NewGRPC(conn, apiKey)
+}
+
+// AdminClient вызывает маршруты /admin: они есть только в REST API.
+type AdminClient struct {
+	url    string
+	apiKey string
+	http   *http.Client
+}
+
+// Admin возвращает клиент /admin с ключом apiKey.
+func (h *Harness) Admin(apiKey string) *AdminClient {
+	return &AdminClient{url: h.RESTURL, apiKey: apiKey, http: h.http}
+}
+
+// Do отправляет in (nil — без тела) на path и возвращает код ответа;
+// ответ 2xx декодируется в out, если он не nil.
+func (c *AdminClient) Do(t testing.TB, method, path string, in, out any) int {
+	t.Helper()
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+	req, err := http.NewRequest(method, c.url+path, &body)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", c.apiKey)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode/100 == 2 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,963 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/apiclient"
+	"test_nanimai/backend/internal/health"
+	"test_nanimai/backend/internal/mtls"
+	"test_nanimai/backend/internal/mtls/mtlstest"
+	"test_nanimai/backend/internal/service"
+	"test_nanimai/backend/internal/signing"
+)
+
+// Env — окружение одного сценария: запущенные серверы, клиент выбранного
+// транспорта и аутентифицированный им сервис.
+type Env struct {
+	H         *Harness
+	Client    apiclient.Client
+	ServiceID int64
+	APIKey    string
+
+	// NewClient возвращает клиент того же транспорта с другим API-ключом.
+	NewClient func(apiKey string) apiclient.Client
+	// Transport — транспорт Client, чтобы подключиться к другому Harness.
+	Transport Transport
+}
+
+// Transport — способ получить apiclient.Client для Harness.
+type Transport struct {
+	Name      string
+	NewClient func(h *Harness, apiKey string) apiclient.Client
+	// NewSignedClient возвращает клиент, подписывающий запросы секретом secret.
+	NewSignedClient func(h *Harness, t testing.TB, apiKey, secret string) apiclient.Client
+}
+
+// Transports — оба транспорта приложения.
+var Transports = []Transport{
+	{Name: "REST", NewClient: (*Harness).REST, NewSignedClient: (*Harness).SignedREST},
+	{Name: "gRPC", NewClient: (*Harness).GRPC, NewSignedClient: (*Harness).SignedGRPC},
+}
+
+// Run прогоняет все сценарии через REST и через gRPC. newStore вызывается
+// для каждого сценария; серверы поднимаются заново поверх его результата.
+func Run(t *testing.T, newStore func(t *testing.T) Store) {
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, e *Env)
+	}{
+		{"Auth", testAuth},
+		{"Health", testHealth},
+		{"GetAccount", testGetAccount},
+		{"GetAccountAsOf", testGetAccountAsOf},
+		{"UpdateLimit", testUpdateLimit},
+		{"UpdateBalance", testUpdateBalance},
+		{"UpdateCreditLimit", testUpdateCreditLimit},
+		{"Reservations", testReservations},
+		{"Refunds", testRefunds},
+		{"Journal", testJournal},
+		{"ReverseEntry", testReverseEntry},
+		{"ReverseReservation", testReverseReservation},
+		{"Admin", testAdmin},
+		{"LimitChanges", testLimitChanges},
+		{"PendingOperations", testPendingOperations},
+		{"ConcurrentDebits", testConcurrentDebits},
+		{"CertificateAuth", testCertificateAuth},
+		{"Signing", testSigning},
+		{"RateLimit", testRateLimit},
+	}
+	for _, tr := range Transports {
+		t.Run(tr.Name, func(t *testing.T) {
+			for _, c := range cases {
+				t.Run(c.name, func(t *testing.T) {
+					h := Start(t, newStore(t))
+					id, apiKey := h.NewService(t)
+					c.fn(t, &Env{
+						H:         h,
+						Client:    tr.NewClient(h, apiKey),
+						ServiceID: id,
+						APIKey:    apiKey,
+						NewClient: func(apiKey string) apiclient.Client { return tr.NewClient(h, apiKey) },
+						Transport: tr,
+					})
+				})
+			}
+		})
+	}
+}
+
+func testAuth(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+
+	for _, apiKey := range []string{"", "unknown-key"} {
+		c := e.NewClient(apiKey)
+		calls := map[string]func() error{
+			"GetAccount":        func() error { _, err := c.GetAccount(ctx, acc, time.Time{}); return err },
+			"UpdateLimit":       func() error { return c.UpdateLimit(ctx, acc, 1) },
+			"UpdateBalance":     func() error { return c.UpdateBalance(ctx, acc, 1) },
+			"UpdateCreditLimit": func() error { return c.UpdateCreditLimit(ctx, acc, 1) },
+			"OpenReservation": func() error {
+				_, err := c.OpenReservation(ctx, acc, 1, "k", time.Minute)
+				return err
+			},
+			"ConfirmReservation": func() error { return c.ConfirmReservation(ctx, 1) },
+			"CancelReservation":  func() error { return c.CancelReservation(ctx, 1) },
+			"RefundReservation": func() error {
+				_, err := c.RefundReservation(ctx, 1, 1, "k")
+				return err
+			},
+			"PostJournal": func() error {
+				_, err := c.PostJournal(ctx, acc, "", fee(acc, 1))
+				return err
+			},
+			"ListJournal": func() error { _, err := c.ListJournal(ctx, acc, 10); return err },
+			"ReverseEntry": func() error {
+				_, err := c.ReverseEntry(ctx, 1, domain.ReasonOther, "")
+				return err
+			},
+			"ReverseReservation": func() error {
+				_, err := c.ReverseReservation(ctx, 1, domain.ReasonOther, "")
+				return err
+			},
+		}
+		for name, call := range calls {
+			wantCode(t, fmt.Sprintf("%s(key %q)", name, apiKey), call(), apiclient.CodeUnauthenticated)
+		}
+	}
+
+	// Ни один из отклонённых вызовов не должен был изменить счёт
+	e.wantAccount(t, acc, 0, 0, 1000)
+}
+
+func testHealth(t *testing.T, e *Env) {
+	ctx := context.Background()
+	// Проверки здоровья доступны без API-ключа
+	anon := e.NewClient("")
+	ready, err := anon.Ready(ctx)
+	must(t, "Ready", err)
+	if !ready {
+		t.Errorf("Ready = false before shutdown")
+	}
+
+	// /readyz показывает проваленную проверку как fail, без текста ошибки
+	e.H.Checker.Add("db", func(ctx context.Context) error { return errors.New("dial tcp 10.0.0.1:5432: connection refused") })
+	resp, err := http.Get(e.H.RESTURL + "/readyz")
+	must(t, "GET /readyz", err)
+	defer resp.Body.Close()
+	var st health.Status
+	must(t, "decode /readyz", json.NewDecoder(resp.Body).Decode(&st))
+	if resp.StatusCode != http.StatusServiceUnavailable || st.Checks["db"] != health.CheckFail {
+		t.Errorf("GET /readyz = %d %+v, want 503 with db: fail", resp.StatusCode, st)
+	}
+
+	e.H.Checker.SetDraining()
+	ready, err = anon.Ready(ctx)
+	must(t, "Ready(draining)", err)
+	if ready {
+		t.Errorf("Ready = true while draining")
+	}
+}
+
+func testGetAccount(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, 700))
+	_, err := e.Client.OpenReservation(ctx, acc, 200, key(), time.Minute)
+	must(t, "OpenReservation", err)
+
+	got, err := e.Client.GetAccount(ctx, acc, time.Time{})
+	must(t, "GetAccount", err)
+	if got.ID != acc || got.CurrentAmount != 700 || got.ReservedAmount != 200 || got.MaxAmount != 1000 || got.AvailableAmount != 500 {
+		t.Errorf("GetAccount = %+v", got)
+	}
+
+	_, err = e.Client.GetAccount(ctx, 1<<60, time.Time{})
+	wantCode(t, "GetAccount(missing)", err, apiclient.CodeNotFound)
+}
+
+func testGetAccountAsOf(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateBalance(+100)", e.Client.UpdateBalance(ctx, acc, 100))
+	// Оба транспорта передают момент с точностью до секунды
+	time.Sleep(1100 * time.Millisecond)
+	asOf := time.Now().Truncate(time.Second)
+	must(t, "UpdateBalance(+50)", e.Client.UpdateBalance(ctx, acc, 50))
+
+	got, err := e.Client.GetAccount(ctx, acc, asOf)
+	must(t, "GetAccount(as of)", err)
+	if got.CurrentAmount != 100 {
+		t.Errorf("GetAccount(as of).CurrentAmount = %d, want 100", got.CurrentAmount)
+	}
+	e.wantAccount(t, acc, 150, 0, 1000)
+}
+
+func testUpdateLimit(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateLimit", e.Client.UpdateLimit(ctx, acc, 500))
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, 1200))
+	e.wantAccount(t, acc, 1200, 0, 1500)
+
+	wantCode(t, "UpdateLimit(below current)", e.Client.UpdateLimit(ctx, acc, -400), apiclient.CodeConflict)
+	wantCode(t, "UpdateLimit(missing)", e.Client.UpdateLimit(ctx, 1<<60, 1), apiclient.CodeNotFound)
+}
+
+func testUpdateBalance(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, 300))
+	wantCode(t, "UpdateBalance(below zero)", e.Client.UpdateBalance(ctx, acc, -400), apiclient.CodeConflict)
+	wantCode(t, "UpdateBalance(above max)", e.Client.UpdateBalance(ctx, acc, 800), apiclient.CodeConflict)
+	e.wantAccount(t, acc, 300, 0, 1000)
+}
+
+func testUpdateCreditLimit(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateCreditLimit", e.Client.UpdateCreditLimit(ctx, acc, 300))
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, -200))
+
+	got, err := e.Client.GetAccount(ctx, acc, time.Time{})
+	must(t, "GetAccount", err)
+	if got.CreditLimit != 300 || got.UsedCredit != 200 || got.AvailableAmount != 100 {
+		t.Errorf("GetAccount = %+v, want credit 300, used 200, available 100", got)
+	}
+
+	wantCode(t, "UpdateCreditLimit(below used)", e.Client.UpdateCreditLimit(ctx, acc, -200), apiclient.CodeConflict)
+	wantCode(t, "UpdateCreditLimit(missing)", e.Client.UpdateCreditLimit(ctx, 1<<60, 1), apiclient.CodeNotFound)
+}
+
+func testReservations(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, 1000))
+	_, otherKey := e.H.NewService(t)
+	other := e.NewClient(otherKey)
+
+	k := key()
+	res, err := e.Client.OpenReservation(ctx, acc, 600, k, time.Minute)
+	must(t, "OpenReservation", err)
+	if res.Status != "ACTIVE" || res.Amount != 600 || res.AccountID != acc || res.OwnerServiceID != e.ServiceID {
+		t.Errorf("OpenReservation = %+v", res)
+	}
+	again, err := e.Client.OpenReservation(ctx, acc, 600, k, time.Minute)
+	must(t, "OpenReservation(same key)", err)
+	if again.ID != res.ID {
+		t.Errorf("OpenReservation(same key) ID = %d, want %d", again.ID, res.ID)
+	}
+	e.wantAccount(t, acc, 1000, 600, 1000)
+
+	_, err = e.Client.OpenReservation(ctx, acc, 0, key(), time.Minute)
+	wantCode(t, "OpenReservation(zero amount)", err, apiclient.CodeInvalidArgument)
+	_, err = e.Client.OpenReservation(ctx, acc, 500, key(), time.Minute)
+	wantCode(t, "OpenReservation(not enough)", err, apiclient.CodeConflict)
+	_, err = e.Client.OpenReservation(ctx, 1<<60, 1, key(), time.Minute)
+	wantCode(t, "OpenReservation(missing account)", err, apiclient.CodeNotFound)
+
+	wantCode(t, "ConfirmReservation(other service)", other.ConfirmReservation(ctx, res.ID), apiclient.CodePermissionDenied)
+	must(t, "ConfirmReservation", e.Client.ConfirmReservation(ctx, res.ID))
+	e.wantAccount(t, acc, 400, 0, 1000)
+	wantCode(t, "ConfirmReservation(again)", e.Client.ConfirmReservation(ctx, res.ID), apiclient.CodeConflict)
+	wantCode(t, "CancelReservation(confirmed)", e.Client.CancelReservation(ctx, res.ID), apiclient.CodeConflict)
+
+	cancelled, err := e.Client.OpenReservation(ctx, acc, 100, key(), time.Minute)
+	must(t, "OpenReservation", err)
+	wantCode(t, "CancelReservation(other service)", other.CancelReservation(ctx, cancelled.ID), apiclient.CodePermissionDenied)
+	must(t, "CancelReservation", e.Client.CancelReservation(ctx, cancelled.ID))
+	e.wantAccount(t, acc, 400, 0, 1000)
+	wantCode(t, "CancelReservation(missing)", e.Client.CancelReservation(ctx, 1<<60), apiclient.CodeNotFound)
+}
+
+func testRefunds(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, 1000))
+	res := e.confirmed(t, acc, 600)
+
+	refund, err := e.Client.RefundReservation(ctx, res.ID, 200, "r1")
+	must(t, "RefundReservation", err)
+	if refund.Amount != 200 || refund.ReservationID != res.ID || refund.OwnerServiceID != e.ServiceID || refund.LedgerEntryID == 0 {
+		t.Errorf("RefundReservation = %+v", refund)
+	}
+	again, err := e.Client.RefundReservation(ctx, res.ID, 200, "r1")
+	must(t, "RefundReservation(same key)", err)
+	if again.ID != refund.ID {
+		t.Errorf("RefundReservation(same key) ID = %d, want %d", again.ID, refund.ID)
+	}
+	e.wantAccount(t, acc, 600, 0, 1000)
+
+	_, err = e.Client.RefundReservation(ctx, res.ID, 500, "r2")
+	wantCode(t, "RefundReservation(exceeds)", err, apiclient.CodeConflict)
+	_, err = e.Client.RefundReservation(ctx, res.ID, 0, "r2")
+	wantCode(t, "RefundReservation(zero amount)", err, apiclient.CodeInvalidArgument)
+	_, err = e.Client.RefundReservation(ctx, 1<<60, 1, "r2")
+	wantCode(t, "RefundReservation(missing)", err, apiclient.CodeNotFound)
+
+	// Владелец резерва — аутентифицированный сервис, чужой резерв не виден
+	_, otherKey := e.H.NewService(t)
+	_, err = e.NewClient(otherKey).RefundReservation(ctx, res.ID, 100, "r3")
+	wantCode(t, "RefundReservation(other service)", err, apiclient.CodePermissionDenied)
+	e.wantAccount(t, acc, 600, 0, 1000)
+}
+
+func testJournal(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, 1000))
+
+	entry, err := e.Client.PostJournal(ctx, acc, "fee", fee(acc, 50))
+	must(t, "PostJournal", err)
+	if entry.ID == 0 || entry.Operation != domain.OpAdjustment || entry.DeltaCurrent != -50 || entry.ActorServiceID != e.ServiceID || len(entry.Postings) != 2 {
+		t.Errorf("PostJournal = %+v", entry)
+	}
+	e.wantAccount(t, acc, 950, 0, 1000)
+
+	unbalanced := []domain.Posting{
+		{LedgerAccount: domain.AccountLedger(acc), Side: domain.Debit, Amount: 50},
+		{LedgerAccount: domain.LedgerFees, Side: domain.Credit, Amount: 40},
+	}
+	_, err = e.Client.PostJournal(ctx, acc, "", unbalanced)
+	wantCode(t, "PostJournal(unbalanced)", err, apiclient.CodeInvalidArgument)
+	_, err = e.Client.PostJournal(ctx, acc, "", fee(acc, 5000))
+	wantCode(t, "PostJournal(overdraw)", err, apiclient.CodeConflict)
+	// Зачисление с системного счёта без права admin, строки по holds — никому
+	mint := []domain.Posting{
+		{LedgerAccount: domain.LedgerExternalFunding, Side: domain.Debit, Amount: 500},
+		{LedgerAccount: domain.AccountLedger(acc), Side: domain.Credit, Amount: 500},
+	}
+	_, err = e.Client.PostJournal(ctx, acc, "", mint)
+	wantCode(t, "PostJournal(mint)", err, apiclient.CodePermissionDenied)
+	holds := []domain.Posting{
+		{LedgerAccount: domain.AccountLedger(acc), Side: domain.Debit, Amount: 50},
+		{LedgerAccount: domain.LedgerHolds, Side: domain.Credit, Amount: 50},
+	}
+	_, err = e.Client.PostJournal(ctx, acc, "", holds)
+	wantCode(t, "PostJournal(holds)", err, apiclient.CodeInvalidArgument)
+	// Тело больше предела отклоняется до обработчика, только в REST
+	huge := service.PostJournalInput{Description: strings.Repeat("x", 2<<20), Postings: fee(acc, 1)}
+	if code := e.H.Admin(e.APIKey).Do(t, http.MethodPost, fmt.Sprintf("/accounts/%d/journal", acc), huge, nil); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("POST /accounts/%d/journal (huge body) = %d, want %d", acc, code, http.StatusRequestEntityTooLarge)
+	}
+
+	entries, err := e.Client.ListJournal(ctx, acc, 2)
+	must(t, "ListJournal", err)
+	if len(entries) != 2 || entries[0].ID != entry.ID || entries[1].Operation != domain.OpBalanceIncrease {
+		t.Errorf("ListJournal = %+v", entries)
+	}
+}
+
+func testReverseEntry(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, 300))
+	entries, err := e.Client.ListJournal(ctx, acc, 1)
+	must(t, "ListJournal", err)
+	orig := entries[0]
+
+	_, err = e.Client.ReverseEntry(ctx, orig.ID, "BAD_REASON", "")
+	wantCode(t, "ReverseEntry(bad reason)", err, apiclient.CodeInvalidArgument)
+
+	rev, err := e.Client.ReverseEntry(ctx, orig.ID, domain.ReasonOperatorError, "wrong account")
+	must(t, "ReverseEntry", err)
+	if rev.ReversalOf != orig.ID || rev.ReasonCode != domain.ReasonOperatorError || rev.ActorServiceID != e.ServiceID {
+		t.Errorf("ReverseEntry = %+v", rev)
+	}
+	e.wantAccount(t, acc, 0, 0, 1000)
+
+	_, err = e.Client.ReverseEntry(ctx, orig.ID, domain.ReasonOperatorError, "")
+	wantCode(t, "ReverseEntry(again)", err, apiclient.CodeConflict)
+	_, err = e.Client.ReverseEntry(ctx, 1<<60, domain.ReasonOperatorError, "")
+	wantCode(t, "ReverseEntry(missing)", err, apiclient.CodeNotFound)
+
+	// Подтверждение резерва сторнируется через /reservations/{id}/reverse,
+	// даже у admin
+	_, adminKey := e.H.NewAdminService(t)
+	admin := e.NewClient(adminKey)
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, 500))
+	e.confirmed(t, acc, 100)
+	entries, err = e.Client.ListJournal(ctx, acc, 1)
+	must(t, "ListJournal", err)
+	_, err = admin.ReverseEntry(ctx, entries[0].ID, domain.ReasonOperatorError, "")
+	wantCode(t, "ReverseEntry(reserve confirm)", err, apiclient.CodeConflict)
+
+	// Сторно списания зачисляет деньги с external_funding — только admin
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, -100))
+	entries, err = e.Client.ListJournal(ctx, acc, 1)
+	must(t, "ListJournal", err)
+	_, err = e.Client.ReverseEntry(ctx, entries[0].ID, domain.ReasonOperatorError, "")
+	wantCode(t, "ReverseEntry(debit, no admin)", err, apiclient.CodePermissionDenied)
+	_, err = admin.ReverseEntry(ctx, entries[0].ID, domain.ReasonOperatorError, "")
+	must(t, "ReverseEntry(debit, admin)", err)
+	e.wantAccount(t, acc, 400, 0, 1000)
+}
+
+func testReverseReservation(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, 1000))
+	res := e.confirmed(t, acc, 400)
+
+	// Владелец резерва — аутентифицированный сервис, чужой резерв не виден
+	_, otherKey := e.H.NewService(t)
+	_, err := e.NewClient(otherKey).ReverseReservation(ctx, res.ID, domain.ReasonCustomerRefund, "")
+	wantCode(t, "ReverseReservation(other service)", err, apiclient.CodePermissionDenied)
+
+	rev, err := e.Client.ReverseReservation(ctx, res.ID, domain.ReasonCustomerRefund, "")
+	must(t, "ReverseReservation", err)
+	if rev.ReservationID != res.ID || rev.DeltaCurrent != 400 {
+		t.Errorf("ReverseReservation = %+v", rev)
+	}
+	e.wantAccount(t, acc, 1000, 0, 1000)
+
+	_, err = e.Client.ReverseReservation(ctx, res.ID, domain.ReasonCustomerRefund, "")
+	wantCode(t, "ReverseReservation(again)", err, apiclient.CodeConflict)
+
+	active, err := e.Client.OpenReservation(ctx, acc, 100, key(), time.Minute)
+	must(t, "OpenReservation", err)
+	_, err = e.Client.ReverseReservation(ctx, active.ID, domain.ReasonCustomerRefund, "")
+	wantCode(t, "ReverseReservation(active)", err, apiclient.CodeConflict)
+	_, err = e.Client.ReverseReservation(ctx, 1<<60, domain.ReasonCustomerRefund, "")
+	wantCode(t, "ReverseReservation(missing)", err, apiclient.CodeNotFound)
+}
+
+func testAdmin(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	other := e.account(t, 1000)
+	_, adminKey := e.H.NewAdminService(t)
+	admin := e.H.Admin(adminKey)
+
+	if code := e.H.Admin(e.APIKey).Do(t, http.MethodGet, "/admin/services", nil, nil); code != http.StatusForbidden {
+		t.Errorf("GET /admin/services without admin = %d, want 403", code)
+	}
+
+	// Права нового сервиса действуют в проверяемом транспорте
+	var created service.CreatedServiceDTO
+	input := service.CreateServiceInput{Name: key(), Permissions: []string{string(domain.PermBalanceCredit)}}
+	if code := admin.Do(t, http.MethodPost, "/admin/services", input, &created); code != http.StatusCreated {
+		t.Fatalf("POST /admin/services = %d", code)
+	}
+	c := e.NewClient(created.APIKey)
+	must(t, "UpdateBalance(credit)", c.UpdateBalance(ctx, acc, 100))
+	wantCode(t, "UpdateBalance(debit)", c.UpdateBalance(ctx, acc, -50), apiclient.CodePermissionDenied)
+
+	// Область: сначала один чужой счёт, затем тег счёта
+	servicePath := fmt.Sprintf("/admin/services/%d", created.Service.ID)
+	if code := admin.Do(t, http.MethodPut, servicePath+"/scope", service.SetScopeInput{AccountIDs: []int64{other}}, nil); code != http.StatusOK {
+		t.Fatalf("PUT scope = %d", code)
+	}
+	_, err := c.GetAccount(ctx, acc, time.Time{})
+	wantCode(t, "GetAccount(outside scope)", err, apiclient.CodePermissionDenied)
+	if code := admin.Do(t, http.MethodPut, fmt.Sprintf("/admin/accounts/%d/tags", acc), service.SetTagsInput{Tags: []string{"e2e"}}, nil); code != http.StatusOK {
+		t.Fatalf("PUT tags = %d", code)
+	}
+	if code := admin.Do(t, http.MethodPut, servicePath+"/scope", service.SetScopeInput{Tags: []string{"e2e"}}, nil); code != http.StatusOK {
+		t.Fatalf("PUT scope = %d", code)
+	}
+	_, err = c.GetAccount(ctx, acc, time.Time{})
+	must(t, "GetAccount(tag scope)", err)
+
+	// Отозванный ключ больше не аутентифицирует
+	var keys []service.APIKeyDTO
+	if code := admin.Do(t, http.MethodGet, servicePath+"/keys", nil, &keys); code != http.StatusOK || len(keys) != 1 {
+		t.Fatalf("GET keys = %d, %d keys", code, len(keys))
+	}
+	if code := admin.Do(t, http.MethodPost, fmt.Sprintf("%s/keys/%d/revoke", servicePath, keys[0].ID), nil, nil); code != http.StatusOK {
+		t.Fatalf("POST revoke = %d", code)
+	}
+	_, err = c.GetAccount(ctx, acc, time.Time{})
+	wantCode(t, "GetAccount(revoked key)", err, apiclient.CodeUnauthenticated)
+	e.wantAccount(t, acc, 100, 0, 1000)
+}
+
+func testLimitChanges(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	_, adminKey := e.H.NewAdminService(t)
+	admin := e.H.Admin(adminKey)
+	_, secondKey := e.H.NewAdminService(t)
+	second := e.H.Admin(secondKey)
+
+	// Увеличение выше порога не применяется, а ждёт подтверждения
+	delta := int64(LimitApprovalThreshold + 1)
+	err := e.Client.UpdateLimit(ctx, acc, delta)
+	wantCode(t, "UpdateLimit(above threshold)", err, apiclient.CodeApprovalRequired)
+	var apiErr *apiclient.Error
+	if !errors.As(err, &apiErr) || apiErr.LimitChangeID == 0 {
+		t.Fatalf("UpdateLimit(above threshold): error = %v, want limit change ID", err)
+	}
+	e.wantAccount(t, acc, 0, 0, 1000)
+
+	changePath := fmt.Sprintf("/admin/limit-changes/%d", apiErr.LimitChangeID)
+	var change service.LimitChangeDTO
+	if code := admin.Do(t, http.MethodGet, changePath, nil, &change); code != http.StatusOK {
+		t.Fatalf("GET limit change = %d", code)
+	}
+	if change.Status != string(domain.LimitChangePending) || change.ProposedBy != e.ServiceID || change.Delta != delta {
+		t.Errorf("limit change = %+v", change)
+	}
+	if code := e.H.Admin(e.APIKey).Do(t, http.MethodPost, changePath+"/approve", nil, nil); code != http.StatusForbidden {
+		t.Errorf("approve without admin = %d, want 403", code)
+	}
+	if code := admin.Do(t, http.MethodPost, changePath+"/approve", nil, &change); code != http.StatusOK {
+		t.Fatalf("approve = %d", code)
+	}
+	if change.Status != string(domain.LimitChangeApproved) || change.EntryID == 0 {
+		t.Errorf("approved limit change = %+v", change)
+	}
+	e.wantAccount(t, acc, 0, 0, 1000+delta)
+	if code := second.Do(t, http.MethodPost, changePath+"/approve", nil, nil); code != http.StatusConflict {
+		t.Errorf("approve again = %d, want 409", code)
+	}
+	// Изменения лимита не сторнируются: сторно уменьшения обошло бы подтверждение
+	must(t, "UpdateLimit(decrease)", e.Client.UpdateLimit(ctx, acc, -delta))
+	entries, err := e.Client.ListJournal(ctx, acc, 1)
+	if err != nil || len(entries) != 1 || entries[0].Operation != domain.OpLimitDecrease {
+		t.Fatalf("ListJournal = %+v, %v", entries, err)
+	}
+	_, err = e.Client.ReverseEntry(ctx, entries[0].ID, domain.ReasonOperatorError, "")
+	wantCode(t, "ReverseEntry(limit decrease)", err, apiclient.CodeConflict)
+	e.wantAccount(t, acc, 0, 0, 1000)
+
+	// Порог не обойти, разбив увеличение на части
+	split := e.account(t, 0)
+	must(t, "UpdateLimit(first part)", e.Client.UpdateLimit(ctx, split, LimitApprovalThreshold/2+1))
+	err = e.Client.UpdateLimit(ctx, split, LimitApprovalThreshold/2+1)
+	wantCode(t, "UpdateLimit(second part)", err, apiclient.CodeApprovalRequired)
+	e.wantAccount(t, split, 0, 0, LimitApprovalThreshold/2+1)
+
+	// Своё предложение подтвердить нельзя, отклонить может другой оператор
+	input := service.ProposeLimitChangeInput{AccountID: acc, Delta: delta}
+	if code := admin.Do(t, http.MethodPost, "/admin/limit-changes", input, &change); code != http.StatusCreated {
+		t.Fatalf("POST limit change = %d", code)
+	}
+	changePath = fmt.Sprintf("/admin/limit-changes/%d", change.ID)
+	if code := admin.Do(t, http.MethodPost, changePath+"/approve", nil, nil); code != http.StatusForbidden {
+		t.Errorf("self-approve = %d, want 403", code)
+	}
+	if code := second.Do(t, http.MethodPost, changePath+"/reject", service.DecidePendingInput{Note: "no"}, &change); code != http.StatusOK {
+		t.Fatalf("reject = %d", code)
+	}
+	if change.Status != string(domain.LimitChangeRejected) {
+		t.Errorf("rejected limit change = %+v", change)
+	}
+	e.wantAccount(t, acc, 0, 0, 1000)
+
+	// Увеличение кредитной линии выше порога тоже ждёт подтверждения
+	err = e.Client.UpdateCreditLimit(ctx, acc, delta)
+	wantCode(t, "UpdateCreditLimit(above threshold)", err, apiclient.CodeApprovalRequired)
+	if !errors.As(err, &apiErr) || apiErr.LimitChangeID == 0 {
+		t.Fatalf("UpdateCreditLimit(above threshold): error = %v, want limit change ID", err)
+	}
+	got, err := e.Client.GetAccount(ctx, acc, time.Time{})
+	must(t, "GetAccount", err)
+	if got.CreditLimit != 0 {
+		t.Errorf("CreditLimit before approval = %d, want 0", got.CreditLimit)
+	}
+	changePath = fmt.Sprintf("/admin/limit-changes/%d", apiErr.LimitChangeID)
+	if code := admin.Do(t, http.MethodPost, changePath+"/approve", nil, &change); code != http.StatusOK {
+		t.Fatalf("approve credit = %d", code)
+	}
+	if change.Kind != string(domain.LimitKindCredit) || change.Status != string(domain.LimitChangeApproved) {
+		t.Errorf("approved credit change = %+v", change)
+	}
+	got, err = e.Client.GetAccount(ctx, acc, time.Time{})
+	must(t, "GetAccount", err)
+	if got.CreditLimit != delta || got.MaxAmount != 1000 {
+		t.Errorf("GetAccount = %+v, want credit %d and max 1000", got, delta)
+	}
+	// Изменение в пределах порога пишется от имени вызвавшего сервиса
+	must(t, "UpdateCreditLimit(decrease)", e.Client.UpdateCreditLimit(ctx, acc, -delta))
+	entries, err = e.Client.ListJournal(ctx, acc, 1)
+	must(t, "ListJournal", err)
+	if entries[0].Operation != domain.OpCreditDecrease || entries[0].ActorServiceID != e.ServiceID {
+		t.Errorf("credit limit entry = %+v, want actor %d", entries[0], e.ServiceID)
+	}
+}
+
+func testPendingOperations(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, 1000))
+	_, adminKey := e.H.NewAdminService(t)
+	admin := e.H.Admin(adminKey)
+
+	rule := service.CreateVelocityRuleInput{AccountID: acc, Kind: string(domain.VelocityDebitAmount), Limit: 100, WindowSeconds: 3600, Action: string(domain.RiskReview)}
+	if code := admin.Do(t, http.MethodPost, "/admin/velocity-rules", rule, nil); code != http.StatusCreated {
+		t.Fatalf("POST velocity rule = %d", code)
+	}
+
+	// Списание сверх правила откладывается до решения оператора
+	pendingID := func(op string, err error) string {
+		t.Helper()
+		wantCode(t, op, err, apiclient.CodePendingReview)
+		var apiErr *apiclient.Error
+		if !errors.As(err, &apiErr) || apiErr.PendingOperationID == 0 {
+			t.Fatalf("%s: error = %v, want pending operation ID", op, err)
+		}
+		return fmt.Sprintf("/admin/pending-operations/%d", apiErr.PendingOperationID)
+	}
+	opPath := pendingID("UpdateBalance(review)", e.Client.UpdateBalance(ctx, acc, -300))
+	e.wantAccount(t, acc, 1000, 0, 1000)
+
+	var op service.PendingOperationDTO
+	if code := admin.Do(t, http.MethodGet, opPath, nil, &op); code != http.StatusOK {
+		t.Fatalf("GET pending operation = %d", code)
+	}
+	if op.Status != string(domain.PendingWaiting) || op.ServiceID != e.ServiceID || op.Amount != 300 {
+		t.Errorf("pending operation = %+v", op)
+	}
+	if code := admin.Do(t, http.MethodPost, opPath+"/approve", nil, &op); code != http.StatusOK {
+		t.Fatalf("approve = %d", code)
+	}
+	if op.Status != string(domain.PendingApproved) || op.EntryID == 0 {
+		t.Errorf("approved operation = %+v", op)
+	}
+	e.wantAccount(t, acc, 700, 0, 1000)
+	if code := admin.Do(t, http.MethodPost, opPath+"/reject", nil, nil); code != http.StatusConflict {
+		t.Errorf("reject decided = %d, want 409", code)
+	}
+
+	opPath = pendingID("UpdateBalance(review again)", e.Client.UpdateBalance(ctx, acc, -200))
+	if code := admin.Do(t, http.MethodPost, opPath+"/reject", service.DecidePendingInput{Note: "fraud"}, &op); code != http.StatusOK {
+		t.Fatalf("reject = %d", code)
+	}
+	if op.Status != string(domain.PendingRejected) {
+		t.Errorf("rejected operation = %+v", op)
+	}
+	e.wantAccount(t, acc, 700, 0, 1000)
+
+	// Свою же операцию сервис-администратор одобрить не может
+	adminID, ownKey := e.H.NewAdminService(t)
+	opPath = pendingID("UpdateBalance(admin)", e.NewClient(ownKey).UpdateBalance(ctx, acc, -150))
+	if code := e.H.Admin(ownKey).Do(t, http.MethodPost, opPath+"/approve", nil, nil); code != http.StatusForbidden {
+		t.Errorf("approve own operation = %d, want 403", code)
+	}
+	// Невыполнимая операция переходит в FAILED и счёт не меняет
+	drain := pendingID("UpdateBalance(drain)", e.Client.UpdateBalance(ctx, acc, -600))
+	if code := admin.Do(t, http.MethodPost, drain+"/approve", nil, nil); code != http.StatusOK {
+		t.Fatalf("approve drain = %d", code)
+	}
+	if code := admin.Do(t, http.MethodPost, opPath+"/approve", nil, nil); code != http.StatusConflict {
+		t.Errorf("approve without funds = %d, want 409", code)
+	}
+	if code := admin.Do(t, http.MethodGet, opPath, nil, &op); code != http.StatusOK ||
+		op.Status != string(domain.PendingFailed) || op.ServiceID != adminID || op.EntryID != 0 {
+		t.Errorf("failed operation = %d, %+v", code, op)
+	}
+	e.wantAccount(t, acc, 100, 0, 1000)
+
+	// Крупное списание по single_debit ждёт подтверждения, а не отклоняется
+	large := e.account(t, 1000)
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, large, 1000))
+	single := service.CreateVelocityRuleInput{AccountID: large, Kind: string(domain.VelocitySingleDebit), Limit: 100}
+	var created service.VelocityRuleDTO
+	if code := admin.Do(t, http.MethodPost, "/admin/velocity-rules", single, &created); code != http.StatusCreated || created.Action != string(domain.RiskReview) {
+		t.Fatalf("POST single_debit rule = %d, %+v", code, created)
+	}
+	single.Action = string(domain.RiskDecline)
+	if code := admin.Do(t, http.MethodPost, "/admin/velocity-rules", single, nil); code != http.StatusBadRequest {
+		t.Errorf("POST single_debit rule with decline = %d, want 400", code)
+	}
+	must(t, "UpdateBalance(small)", e.Client.UpdateBalance(ctx, large, -100))
+	opPath = pendingID("UpdateBalance(single debit)", e.Client.UpdateBalance(ctx, large, -500))
+	e.wantAccount(t, large, 900, 0, 1000)
+	if code := admin.Do(t, http.MethodPost, opPath+"/approve", nil, &op); code != http.StatusOK || op.Status != string(domain.PendingApproved) {
+		t.Fatalf("approve single debit = %d, %+v", code, op)
+	}
+	e.wantAccount(t, large, 400, 0, 1000)
+
+	// Сторно пополнения — списание: его тоже оценивает проверка риска
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, large, 500))
+	entries, err := e.Client.ListJournal(ctx, large, 1)
+	if err != nil || len(entries) != 1 || entries[0].Operation != domain.OpBalanceIncrease {
+		t.Fatalf("ListJournal = %+v, %v", entries, err)
+	}
+	_, err = e.Client.ReverseEntry(ctx, entries[0].ID, domain.ReasonOther, "chargeback")
+	opPath = pendingID("ReverseEntry(single debit)", err)
+	e.wantAccount(t, large, 900, 0, 1000)
+	if code := admin.Do(t, http.MethodGet, opPath, nil, &op); code != http.StatusOK ||
+		op.Kind != string(domain.OperationReversal) || op.ReversalOf != entries[0].ID || op.Amount != 500 {
+		t.Fatalf("GET pending reversal = %d, %+v", code, op)
+	}
+	if code := admin.Do(t, http.MethodPost, opPath+"/approve", nil, &op); code != http.StatusOK || op.Status != string(domain.PendingApproved) {
+		t.Fatalf("approve reversal = %d, %+v", code, op)
+	}
+	e.wantAccount(t, large, 400, 0, 1000)
+	_, err = e.Client.ReverseEntry(ctx, entries[0].ID, domain.ReasonOther, "")
+	wantCode(t, "ReverseEntry(approved again)", err, apiclient.CodeConflict)
+}
+
+func testConcurrentDebits(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, 1000))
+	_, adminKey := e.H.NewAdminService(t)
+	rule := service.CreateVelocityRuleInput{AccountID: acc, Kind: string(domain.VelocityDebitCount), Limit: 1, WindowSeconds: 3600}
+	if code := e.H.Admin(adminKey).Do(t, http.MethodPost, "/admin/velocity-rules", rule, nil); code != http.StatusCreated {
+		t.Fatalf("POST velocity rule = %d", code)
+	}
+
+	// Все списания проходят проверку до операции прежде, чем выполнится
+	// первое, но хранилище проверяет правило под блокировкой счёта: проходит одно
+	const n = 8
+	store := newBarrierStore(e.H.Store, n)
+	client := e.Transport.NewClient(Start(t, store), e.APIKey)
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- client.UpdateBalance(ctx, acc, -10)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	var passed int
+	for err := range errs {
+		if err == nil {
+			passed++
+			continue
+		}
+		wantCode(t, "UpdateBalance(concurrent)", err, apiclient.CodeConflict)
+	}
+	if passed != 1 {
+		t.Errorf("%d concurrent debits passed, want 1", passed)
+	}
+	e.wantAccount(t, acc, 990, 0, 1000)
+}
+
+func testCertificateAuth(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	must(t, "UpdateBalance", e.Client.UpdateBalance(ctx, acc, 1000))
+	billing, _ := e.H.NewService(t)
+	other, _ := e.H.NewService(t)
+	must(t, "SetServiceCertIdentities", e.H.Store.SetServiceCertIdentities(ctx, billing, []string{"spiffe://e2e/billing", "billing.internal"}))
+	must(t, "SetServiceCertIdentities", e.H.Store.SetServiceCertIdentities(ctx, other, []string{"other.internal"}))
+
+	ca := mtlstest.NewCA(t, "e2e")
+	dir := t.TempDir()
+	certPEM, keyPEM := ca.Issue(t, "server", "localhost")
+	certs, err := mtls.NewReloader(mtlstest.WriteFile(t, dir, "server.pem", certPEM),
+		mtlstest.WriteFile(t, dir, "server-key.pem", keyPEM), mtlstest.WriteFile(t, dir, "clients.pem", ca.PEM))
+	must(t, "NewReloader", err)
+	config := func(certs ...tls.Certificate) *tls.Config {
+		return &tls.Config{RootCAs: ca.Pool(), ServerName: "localhost", Certificates: certs}
+	}
+	h := StartTLS(t, e.H.Store, certs, tls.VerifyClientCertIfGiven, config())
+	withCert := func(cert tls.Certificate) apiclient.Client {
+		return e.Transport.NewClient(h.WithClientTLS(t, config(cert)), "")
+	}
+
+	// Сертификат заменяет API-ключ: резерв открывается от имени сервиса с его именем
+	res, err := withCert(ca.Certificate(t, "billing", "spiffe://e2e/billing")).OpenReservation(ctx, acc, 100, "cert", time.Minute)
+	must(t, "OpenReservation(certificate)", err)
+	if owner, err := e.H.Store.ReservationOwnerID(ctx, res.ID); err != nil || owner != billing {
+		t.Errorf("reservation owner = %d, %v; want %d", owner, err, billing)
+	}
+
+	// Сертификат без назначенных имён и сертификат с именами разных сервисов не аутентифицируют
+	for name, cert := range map[string]tls.Certificate{
+		"unassigned": ca.Certificate(t, "unknown", "unknown.internal"),
+		"ambiguous":  ca.Certificate(t, "other.internal", "billing.internal"),
+	} {
+		_, err := withCert(cert).GetAccount(ctx, acc, time.Time{})
+		wantCode(t, "GetAccount("+name+")", err, apiclient.CodeUnauthenticated)
+	}
+
+	// Без сертификата сервис определяется по API-ключу
+	_, err = e.Transport.NewClient(h, e.APIKey).GetAccount(ctx, acc, time.Time{})
+	must(t, "GetAccount(API key over TLS)", err)
+	_, err = e.Transport.NewClient(h, "").GetAccount(ctx, acc, time.Time{})
+	wantCode(t, "GetAccount(no credentials)", err, apiclient.CodeUnauthenticated)
+}
+
+func testSigning(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	// Режим задаётся до первого запроса сервиса, иначе его закэширует authcache
+	id, apiKey := e.H.NewService(t)
+	secret := signing.NewSecret()
+	must(t, "SetServiceSigning", e.H.Store.SetServiceSigning(ctx, id, domain.SigningRequired, secret))
+
+	signed := e.Transport.NewSignedClient(e.H, t, apiKey, secret)
+	must(t, "UpdateBalance(signed)", signed.UpdateBalance(ctx, acc, 500))
+	if _, err := signed.GetAccount(ctx, acc, time.Time{}); err != nil {
+		t.Errorf("GetAccount(signed): %v", err)
+	}
+	e.wantAccount(t, acc, 500, 0, 1000)
+
+	err := e.NewClient(apiKey).UpdateBalance(ctx, acc, 100)
+	wantCode(t, "UpdateBalance(unsigned)", err, apiclient.CodeUnauthenticated)
+	err = e.Transport.NewSignedClient(e.H, t, apiKey, signing.NewSecret()).UpdateBalance(ctx, acc, 100)
+	wantCode(t, "UpdateBalance(wrong secret)", err, apiclient.CodeUnauthenticated)
+	e.wantAccount(t, acc, 500, 0, 1000)
+
+	// Повтор перехваченного запроса с той же подписью отклоняется по nonce
+	body := []byte(`{"Delta":100}`)
+	req := signedRequest(t, e.H, apiKey, secret, fmt.Sprintf("/accounts/%d/balance", acc), body)
+	if code := send(t, req, body); code != http.StatusOK {
+		t.Errorf("PUT balance = %d, want %d", code, http.StatusOK)
+	}
+	if code := send(t, req, body); code != http.StatusUnauthorized {
+		t.Errorf("PUT balance (replay) = %d, want %d", code, http.StatusUnauthorized)
+	}
+	e.wantAccount(t, acc, 600, 0, 1000)
+
+	// Тело больше 1 МиБ не читается для проверки подписи
+	body = []byte(fmt.Sprintf(`{"Delta":1,"Pad":%q}`, strings.Repeat("x", 1<<20)))
+	req = signedRequest(t, e.H, apiKey, secret, fmt.Sprintf("/accounts/%d/balance", acc), body)
+	if code := send(t, req, body); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("PUT balance (large body) = %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func testRateLimit(t *testing.T, e *Env) {
+	ctx := context.Background()
+	acc := e.account(t, 1000)
+	// Ограничение задаётся до первого запроса сервиса, иначе его закэширует authcache
+	id, apiKey := e.H.NewService(t)
+	must(t, "SetServiceRateLimit", e.H.Store.SetServiceRateLimit(ctx, id, domain.RateLimit{Rate: 0.1, Burst: 2}))
+	client := e.NewClient(apiKey)
+
+	for i := range 2 {
+		if _, err := client.GetAccount(ctx, acc, time.Time{}); err != nil {
+			t.Fatalf("GetAccount #%d: %v", i+1, err)
+		}
+	}
+	// Сверх burst — 429 / ResourceExhausted с временем до следующего токена
+	_, err := client.GetAccount(ctx, acc, time.Time{})
+	wantCode(t, "GetAccount(over limit)", err, apiclient.CodeRateLimited)
+	var apiErr *apiclient.Error
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter <= 0 || apiErr.RetryAfter > 10*time.Second {
+		t.Errorf("RetryAfter of %v, want within (0, 10s]", err)
+	}
+	// Корзины у сервисов свои
+	if _, err := e.Client.GetAccount(ctx, acc, time.Time{}); err != nil {
+		t.Errorf("GetAccount(other service): %v", err)
+	}
+}
+
+// signedRequest готовит PUT на path REST API с телом body, подписанный
+// секретом secret.
+func signedRequest(t *testing.T, h *Harness, apiKey, secret, path string, body []byte) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPut, h.RESTURL+path, nil)
+	must(t, "NewRequest", err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", apiKey)
+	signing.SignRequest(req, secret, body)
+	return req
+}
+
+// send отправляет req с телом body и возвращает код ответа; один запрос
+// можно отправить несколько раз.
+func send(t *testing.T, req *http.Request, body []byte) int {
+	t.Helper()
+	req = req.Clone(req.Context())
+	req.Body, req.ContentLength = io.NopCloser(bytes.NewReader(body)), int64(len(body))
+	resp, err := http.DefaultClient.Do(req)
+	must(t, req.Method+" "+req.URL.Path, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// barrierStore задерживает ответ VelocityUsage для проверки до операции,
+// пока счёт не прочитают n операций или не пройдёт секунда: так все они
+// видят счёт до первого списания.
+type barrierStore struct {
+	Store
+	arrived sync.WaitGroup
+	all     chan struct{}
+}
+
+func newBarrierStore(store Store, n int) *barrierStore {
+	s := &barrierStore{Store: store, all: make(chan struct{})}
+	s.arrived.Add(n)
+	go func() {
+		s.arrived.Wait()
+		close(s.all)
+	}()
+	return s
+}
+
+func (s *barrierStore) VelocityUsage(ctx context.Context, accountID int64, window time.Duration) (domain.VelocityUsage, error) {
+	usage, err := s.Store.VelocityUsage(ctx, accountID, window)
+	s.arrived.Done()
+	select {
+	case <-s.all:
+	case <-time.After(time.Second):
+	}
+	return usage, err
+}
+
+// account заводит счёт напрямую в хранилище: API создания счетов нет.
+func (e *Env) account(t *testing.T, maxAmount int64) int64 {
+	t.Helper()
+	acc, err := e.H.Store.CreateAccount(context.Background(), seq.Add(1), maxAmount)
+	if err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+	return acc.ID
+}
+
+func (e *Env) confirmed(t *testing.T, accountID, amount int64) *domain.Reservation {
+	t.Helper()
+	ctx := context.Background()
+	res, err := e.Client.OpenReservation(ctx, accountID, amount, key(), time.Minute)
+	must(t, "OpenReservation", err)
+	must(t, "ConfirmReservation", e.Client.ConfirmReservation(ctx, res.ID))
+	return res
+}
+
+func (e *Env) wantAccount(t *testing.T, accountID, current, reserved, maxAmount int64) {
+	t.Helper()
+	acc, err := e.Client.GetAccount(context.Background(), accountID, time.Time{})
+	must(t, "GetAccount", err)
+	if acc.CurrentAmount != current || acc.ReservedAmount != reserved || acc.MaxAmount != maxAmount {
+		t.Errorf("account %d: current %d reserved %d max %d, want %d %d %d",
+			accountID, acc.CurrentAmount, acc.ReservedAmount, acc.MaxAmount, current, reserved, maxAmount)
+	}
+}
+
+// fee — списание комиссии amount со счёта в fees.
+func fee(accountID, amount int64) []domain.Posting {
+	return []domain.Posting{
+		{LedgerAccount: domain.AccountLedger(accountID), Side: domain.Debit, Amount: amount},
+		{LedgerAccount: domain.LedgerFees, Side: domain.Credit, Amount: amount},
+	}
+}
+
+func must(t *testing.T, op string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", op, err)
+	}
+}
+
+func wantCode(t *testing.T, op string, err error, want apiclient.Code) {
+	t.Helper()
+	if got := apiclient.CodeOf(err); got != want {
+		t.Errorf("%s: error = %v, want %s", op, err, want)
+	}
+}
+
+func key() string {
+	return fmt.Sprintf("e2e-%d", seq.Add(1))
+}
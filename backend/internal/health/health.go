@@ -0,0 +1,130 @@
+// Package health отвечает на вопросы «жив ли процесс» и «готов ли он
+// принимать запросы». Готовность складывается из проверок зависимостей
+// (БД, миграции) и признака остановки; то же состояние публикуется через
+// стандартный сервис grpc.health.v1.
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// checkTimeout — сколько ждать одну проверку.
+const checkTimeout = 2 * time.Second
+
+// Check проверяет одну зависимость; nil — зависимость в порядке.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Результаты отдельной проверки в Status.Checks.
+const (
+	CheckOK   = "ok"
+	CheckFail = "fail"
+)
+
+// Status — результат проверки готовности. Отдаётся без аутентификации,
+// поэтому содержит только исход проверок; причины отказа пишутся в лог.
+type Status struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"` // имя проверки → CheckOK или CheckFail
+}
+
+type Checker struct {
+	mu       sync.RWMutex
+	checks   []namedCheck
+	draining atomic.Bool
+	grpc     *grpchealth.Server
+	services []string
+}
+
+// NewChecker создаёт проверку готовности; services — имена gRPC-сервисов,
+// статус которых публикуется в grpc.health.v1 наряду с общим ("").
+func NewChecker(services ...string) *Checker {
+	return &Checker{grpc: grpchealth.NewServer(), services: services}
+}
+
+// Add регистрирует проверку готовности.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// SetDraining помечает сервер останавливающимся: дальше он не готов,
+// а gRPC health сразу отвечает NOT_SERVING.
+func (c *Checker) SetDraining() {
+	c.draining.Store(true)
+	c.grpc.Shutdown()
+}
+
+// Check выполняет все проверки; ошибки проваленных пишет в slog.Default().
+func (c *Checker) Check(ctx context.Context) Status {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	st := Status{Ready: true, Checks: make(map[string]string, len(checks)+1)}
+	if c.draining.Load() {
+		st.Ready = false
+		st.Checks["draining"] = CheckFail
+	}
+	for _, nc := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := nc.check(checkCtx)
+		cancel()
+		if err != nil {
+			st.Ready = false
+			st.Checks[nc.name] = CheckFail
+			slog.Warn("readiness check failed", "check", nc.name, "error", err)
+			continue
+		}
+		st.Checks[nc.name] = CheckOK
+	}
+	return st
+}
+
+// GRPC возвращает реализацию grpc.health.v1 для регистрации на gRPC-сервере.
+func (c *Checker) GRPC() healthpb.HealthServer {
+	return c.grpc
+}
+
+// Run периодически обновляет статус gRPC health по результатам Check.
+// Работает, пока не отменён ctx.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.update(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) update(ctx context.Context) {
+	if c.draining.Load() {
+		return
+	}
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if c.Check(ctx).Ready {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	// Пустое имя — состояние сервера целиком
+	c.grpc.SetServingStatus("", status)
+	for _, name := range c.services {
+		c.grpc.SetServingStatus(name, status)
+	}
+}
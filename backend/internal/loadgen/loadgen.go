@@ -0,0 +1,313 @@
+// Package loadgen нагружает API сервиса баланса заданной смесью операций
+// через apiclient.Client и собирает пропускную способность, перцентили
+// задержек и разбивку ошибок по операциям.
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/apiclient"
+)
+
+// Операции смеси.
+const (
+	OpGet      = "get"      // GetAccount
+	OpDeposit  = "deposit"  // UpdateBalance с положительной дельтой
+	OpWithdraw = "withdraw" // UpdateBalance с отрицательной дельтой
+	OpOpen     = "open"     // OpenReservation
+	OpConfirm  = "confirm"  // ConfirmReservation резерва, открытого в этом прогоне
+	OpCancel   = "cancel"   // CancelReservation резерва, открытого в этом прогоне
+	OpJournal  = "journal"  // ListJournal
+)
+
+var ops = []string{OpGet, OpDeposit, OpWithdraw, OpOpen, OpConfirm, OpCancel, OpJournal}
+
+// DefaultMix — смесь по умолчанию: в основном жизненный цикл резервов.
+const DefaultMix = "open=40,confirm=20,cancel=15,get=15,deposit=5,withdraw=5"
+
+type Config struct {
+	Mix                map[string]int // операция → вес
+	Accounts           []int64        // существующие счета; операции распределяются равномерно
+	Concurrency        int
+	Duration           time.Duration
+	Amount             int64 // верхняя граница суммы резерва и изменения баланса
+	ReservationTimeout time.Duration
+}
+
+// ParseMix разбирает смесь вида "open=40,confirm=20,get=10".
+func ParseMix(s string) (map[string]int, error) {
+	mix := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("mix entry %q: want op=weight", part)
+		}
+		if !isOp(name) {
+			return nil, fmt.Errorf("mix entry %q: unknown op, want one of %s", part, strings.Join(ops, ", "))
+		}
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("mix entry %q: weight must be a non-negative integer", part)
+		}
+		mix[name] = w
+	}
+	return mix, nil
+}
+
+func isOp(name string) bool {
+	for _, op := range ops {
+		if op == name {
+			return true
+		}
+	}
+	return false
+}
+
+// OpStats — статистика одной операции.
+type OpStats struct {
+	Count     int
+	Errors    map[string]int // код ошибки → количество
+	latencies []time.Duration
+}
+
+// Percentile возвращает p-й перцентиль задержки (0 < p <= 100).
+func (s *OpStats) Percentile(p float64) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(s.latencies))*p/100+0.5) - 1
+	return s.latencies[min(max(i, 0), len(s.latencies)-1)]
+}
+
+type Report struct {
+	Elapsed time.Duration
+	Ops     map[string]*OpStats
+}
+
+func (r *Report) Total() (count, errs int) {
+	for _, s := range r.Ops {
+		count += s.Count
+		for _, n := range s.Errors {
+			errs += n
+		}
+	}
+	return count, errs
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+	count, errs := r.Total()
+	fmt.Fprintf(&b, "elapsed: %s, requests: %d, errors: %d, throughput: %.1f req/s\n\n",
+		r.Elapsed.Round(time.Millisecond), count, errs, float64(count)/r.Elapsed.Seconds())
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "op\tcount\trps\tp50\tp90\tp99\tmax\terrors\t")
+	for _, op := range ops {
+		s, ok := r.Ops[op]
+		if !ok {
+			continue
+		}
+		var failed int
+		for _, n := range s.Errors {
+			failed += n
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t%s\t%s\t%s\t%s\t%d\t\n", op, s.Count, float64(s.Count)/r.Elapsed.Seconds(),
+			round(s.Percentile(50)), round(s.Percentile(90)), round(s.Percentile(99)), round(s.Percentile(100)), failed)
+	}
+	w.Flush()
+
+	if errs > 0 {
+		b.WriteString("\nerrors:\n")
+		for _, op := range ops {
+			s, ok := r.Ops[op]
+			if !ok {
+				continue
+			}
+			codes := make([]string, 0, len(s.Errors))
+			for code := range s.Errors {
+				codes = append(codes, code)
+			}
+			sort.Strings(codes)
+			for _, code := range codes {
+				fmt.Fprintf(&b, "  %s %s: %d\n", op, code, s.Errors[code])
+			}
+		}
+	}
+	return b.String()
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
+
+// workerStats копит статистику одного воркера без блокировок.
+type workerStats map[string]*OpStats
+
+func (ws workerStats) record(op string, latency time.Duration, err error) {
+	s, ok := ws[op]
+	if !ok {
+		s = &OpStats{Errors: make(map[string]int)}
+		ws[op] = s
+	}
+	s.Count++
+	s.latencies = append(s.latencies, latency)
+	if err != nil {
+		code := "TRANSPORT"
+		var apiErr *apiclient.Error
+		if errors.As(err, &apiErr) {
+			code = string(apiErr.Code)
+		}
+		s.Errors[code]++
+	}
+}
+
+type opened struct {
+	mu  sync.Mutex
+	ids []int64
+}
+
+func (o *opened) push(id int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.ids = append(o.ids, id)
+}
+
+func (o *opened) pop() (int64, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.ids) == 0 {
+		return 0, false
+	}
+	id := o.ids[len(o.ids)-1]
+	o.ids = o.ids[:len(o.ids)-1]
+	return id, true
+}
+
+// Run гоняет cfg.Concurrency воркеров в течение cfg.Duration или до отмены ctx.
+// Подтверждение и отмена берут резервы, открытые в этом же прогоне; пока
+// таких нет, вместо них открывается новый резерв.
+func Run(ctx context.Context, client apiclient.Client, cfg Config) (*Report, error) {
+	if len(cfg.Accounts) == 0 {
+		return nil, errors.New("no accounts")
+	}
+	var total int
+	for _, w := range cfg.Mix {
+		total += w
+	}
+	if total == 0 {
+		return nil, errors.New("empty mix")
+	}
+	if cfg.Amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	runID := time.Now().UnixNano()
+	var pool opened
+	results := make([]workerStats, cfg.Concurrency)
+	started := time.Now()
+
+	var wg sync.WaitGroup
+	for w := range cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats := make(workerStats)
+			results[w] = stats
+			rnd := rand.New(rand.NewSource(runID + int64(w)))
+			for i := 0; ctx.Err() == nil; i++ {
+				op := pick(rnd, cfg.Mix, total)
+				key := fmt.Sprintf("loadgen-%d-%d-%d", runID, w, i)
+				op, latency, err := do(ctx, client, cfg, rnd, &pool, op, key)
+				// Запросы, прерванные окончанием прогона, не считаем
+				if ctx.Err() != nil {
+					break
+				}
+				stats.record(op, latency, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{Elapsed: time.Since(started), Ops: make(map[string]*OpStats)}
+	for _, stats := range results {
+		for op, s := range stats {
+			agg, ok := report.Ops[op]
+			if !ok {
+				agg = &OpStats{Errors: make(map[string]int)}
+				report.Ops[op] = agg
+			}
+			agg.Count += s.Count
+			agg.latencies = append(agg.latencies, s.latencies...)
+			for code, n := range s.Errors {
+				agg.Errors[code] += n
+			}
+		}
+	}
+	for _, s := range report.Ops {
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	}
+	return report, nil
+}
+
+func pick(rnd *rand.Rand, mix map[string]int, total int) string {
+	n := rnd.Intn(total)
+	for _, op := range ops {
+		if n < mix[op] {
+			return op
+		}
+		n -= mix[op]
+	}
+	return OpOpen
+}
+
+// do выполняет операцию и возвращает фактически выполненную операцию и её задержку.
+func do(ctx context.Context, client apiclient.Client, cfg Config, rnd *rand.Rand, pool *opened, op, key string) (string, time.Duration, error) {
+	accountID := cfg.Accounts[rnd.Intn(len(cfg.Accounts))]
+	amount := 1 + rnd.Int63n(cfg.Amount)
+
+	var reservationID int64
+	if op == OpConfirm || op == OpCancel {
+		id, ok := pool.pop()
+		if !ok {
+			op = OpOpen
+		}
+		reservationID = id
+	}
+
+	started := time.Now()
+	var err error
+	switch op {
+	case OpGet:
+		_, err = client.GetAccount(ctx, accountID, time.Time{})
+	case OpDeposit:
+		err = client.UpdateBalance(ctx, accountID, amount)
+	case OpWithdraw:
+		err = client.UpdateBalance(ctx, accountID, -amount)
+	case OpOpen:
+		var res *domain.Reservation
+		res, err = client.OpenReservation(ctx, accountID, amount, key, cfg.ReservationTimeout)
+		if err == nil {
+			pool.push(res.ID)
+		}
+	case OpConfirm:
+		err = client.ConfirmReservation(ctx, reservationID)
+	case OpCancel:
+		err = client.CancelReservation(ctx, reservationID)
+	case OpJournal:
+		_, err = client.ListJournal(ctx, accountID, 20)
+	}
+	return op, time.Since(started), err
+}
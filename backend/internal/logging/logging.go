@@ -0,0 +1,154 @@
+// Package logging — структурные JSON-логи на log/slog: логгер с маскированием
+// секретов, ID запроса и журнал входящих REST- и gRPC-запросов. Запись о
+// запросе собирается по ходу обработки: middleware кладёт в контекст
+// набор атрибутов, а нижние слои дополняют его через AddAttrs.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader — заголовок (и ключ gRPC-метаданных) с ID запроса.
+// Пришедший от клиента ID сохраняется, иначе генерируется новый; в ответе
+// ID возвращается в том же заголовке.
+const RequestIDHeader = "X-Request-ID"
+
+// Redacted заменяет значения секретов в логах.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys — заголовки и атрибуты, значения которых не попадают в лог.
+var sensitiveKeys = map[string]bool{
+	"x-api-key":     true,
+	"api_key":       true,
+	"authorization": true,
+	"cookie":        true,
+}
+
+// New возвращает JSON-логгер с минимальным уровнем level. Атрибуты с
+// именами секретов (api_key, x-api-key, authorization) маскируются.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if IsSensitive(a.Key) {
+				a.Value = slog.StringValue(Redacted)
+			}
+			return a
+		},
+	}))
+}
+
+// ParseLevel разбирает уровень логирования: debug, info, warn, error.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(s))
+	return level, err
+}
+
+// IsSensitive сообщает, является ли заголовок или атрибут секретом.
+func IsSensitive(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// Headers возвращает группу "headers" с заголовками запроса; секреты маскируются.
+func Headers(h http.Header) slog.Attr {
+	attrs := make([]any, 0, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		if IsSensitive(name) {
+			value = Redacted
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return slog.Group("headers", attrs...)
+}
+
+// Атрибуты идентификаторов в записях о запросах.
+func ServiceID(id int64) slog.Attr          { return slog.Int64("service_id", id) }
+func AccountID(id int64) slog.Attr          { return slog.Int64("account_id", id) }
+func ReservationID(id int64) slog.Attr      { return slog.Int64("reservation_id", id) }
+func EntryID(id int64) slog.Attr            { return slog.Int64("entry_id", id) }
+func PendingOperationID(id int64) slog.Attr { return slog.Int64("pending_operation_id", id) }
+func LimitChangeID(id int64) slog.Attr      { return slog.Int64("limit_change_id", id) }
+
+// Исходы запросов в поле outcome.
+const (
+	OutcomeOK       = "ok"
+	OutcomeRejected = "rejected" // ошибка клиента: 4xx, InvalidArgument, NotFound...
+	OutcomeError    = "error"    // ошибка сервера: 5xx, Internal, Unavailable...
+)
+
+type requestKey struct{}
+
+// request — запись о запросе, которую дополняют по ходу обработки.
+type request struct {
+	id string
+
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+func withRequest(ctx context.Context, id string) (context.Context, *request) {
+	r := &request{id: id}
+	return context.WithValue(ctx, requestKey{}, r), r
+}
+
+// AddAttrs дописывает атрибуты в запись о текущем запросе. Без middleware
+// или перехватчика этого пакета ничего не делает.
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	r, ok := ctx.Value(requestKey{}).(*request)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	r.attrs = append(r.attrs, attrs...)
+	r.mu.Unlock()
+}
+
+// RequestID возвращает ID текущего запроса или пустую строку.
+func RequestID(ctx context.Context) string {
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		return r.id
+	}
+	return ""
+}
+
+// requestID возвращает ID от клиента, если он разумной длины и из печатных
+// ASCII-символов, иначе новый случайный.
+func requestID(fromClient string) string {
+	if n := len(fromClient); n > 0 && n <= 128 && strings.IndexFunc(fromClient, func(r rune) bool { return r < 0x21 || r > 0x7e }) < 0 {
+		return fromClient
+	}
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// log пишет запись о завершённом запросе: общие поля, переданные attrs и
+// атрибуты, добавленные через AddAttrs.
+func (r *request) log(ctx context.Context, logger *slog.Logger, level slog.Level, msg string, started time.Time, attrs ...slog.Attr) {
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	out := make([]slog.Attr, 0, len(attrs)+8)
+	out = append(out, slog.String("request_id", r.id))
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		out = append(out, slog.String("trace_id", sc.TraceID().String()))
+	}
+	out = append(out, attrs...)
+	r.mu.Lock()
+	out = append(out, r.attrs...)
+	r.mu.Unlock()
+	out = append(out, slog.Float64("latency_ms", float64(time.Since(started).Microseconds())/1000))
+	logger.LogAttrs(ctx, level, msg, out...)
+}
@@ -0,0 +1,170 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// quietPaths — служебные REST-пути, запросы к которым пишутся только на уровне debug.
+var quietPaths = map[string]bool{
+	"/metrics": true,
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+// routeParams — параметры маршрутов, которые попадают в запись о запросе.
+var routeParams = []struct {
+	name string
+	attr func(int64) slog.Attr
+}{
+	{"account_id", AccountID},
+	{"reservation_id", ReservationID},
+	{"entry_id", EntryID},
+}
+
+// GinMiddleware пишет запись о каждом REST-запросе: ID запроса, сервис,
+// идентификаторы из пути, статус, исход и время обработки. 5xx пишутся с
+// уровнем error, 4xx — warn. На уровне debug добавляются заголовки без секретов.
+// Ставится после трассировки, чтобы в запись попал trace_id.
+func GinMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		ctx, req := withRequest(c.Request.Context(), requestID(c.GetHeader(RequestIDHeader)))
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(RequestIDHeader, req.id)
+
+		c.Next()
+
+		code := c.Writer.Status()
+		level, outcome := slog.LevelInfo, OutcomeOK
+		switch {
+		case code >= 500:
+			level, outcome = slog.LevelError, OutcomeError
+		case code >= 400:
+			level, outcome = slog.LevelWarn, OutcomeRejected
+		}
+		if quietPaths[c.Request.URL.Path] && outcome == OutcomeOK {
+			level = slog.LevelDebug
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		attrs := []slog.Attr{
+			slog.String("transport", "rest"),
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if id := c.GetInt64("service_id"); id != 0 {
+			attrs = append(attrs, ServiceID(id))
+		}
+		for _, p := range routeParams {
+			if id, err := strconv.ParseInt(c.Param(p.name), 10, 64); err == nil {
+				attrs = append(attrs, p.attr(id))
+			}
+		}
+		attrs = append(attrs, slog.Int("status", code), slog.String("outcome", outcome))
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.Last().Error()))
+		}
+		if logger.Enabled(ctx, slog.LevelDebug) {
+			attrs = append(attrs, Headers(c.Request.Header))
+		}
+		req.log(ctx, logger, level, "rest request", started, attrs...)
+	}
+}
+
+// Запросы gRPC с идентификаторами, которые попадают в запись о вызове.
+type (
+	accountRequest     interface{ GetAccountId() int64 }
+	reservationRequest interface{ GetReservationId() int64 }
+	entryRequest       interface{ GetEntryId() int64 }
+)
+
+// UnaryServerInterceptor — аналог GinMiddleware для gRPC. ID запроса
+// берётся из метаданных x-request-id и возвращается в заголовке ответа;
+// ID сервиса добавляет перехватчик аутентификации через AddAttrs.
+func UnaryServerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, in any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		started := time.Now()
+		md, _ := metadata.FromIncomingContext(ctx)
+		var fromClient string
+		if v := md.Get(RequestIDHeader); len(v) > 0 {
+			fromClient = v[0]
+		}
+		ctx, req := withRequest(ctx, requestID(fromClient))
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, req.id))
+
+		resp, err := handler(ctx, in)
+
+		code := status.Code(err)
+		level, outcome := slog.LevelInfo, OutcomeOK
+		switch {
+		case serverFault(code):
+			level, outcome = slog.LevelError, OutcomeError
+		case code != codes.OK:
+			level, outcome = slog.LevelWarn, OutcomeRejected
+		}
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") && outcome == OutcomeOK {
+			level = slog.LevelDebug
+		}
+
+		attrs := []slog.Attr{
+			slog.String("transport", "grpc"),
+			slog.String("method", info.FullMethod),
+		}
+		if r, ok := in.(accountRequest); ok && r.GetAccountId() != 0 {
+			attrs = append(attrs, AccountID(r.GetAccountId()))
+		}
+		if r, ok := in.(reservationRequest); ok && r.GetReservationId() != 0 {
+			attrs = append(attrs, ReservationID(r.GetReservationId()))
+		}
+		if r, ok := in.(entryRequest); ok && r.GetEntryId() != 0 {
+			attrs = append(attrs, EntryID(r.GetEntryId()))
+		}
+		attrs = append(attrs, slog.String("code", code.String()), slog.String("outcome", outcome))
+		if err != nil {
+			attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+		}
+		if logger.Enabled(ctx, slog.LevelDebug) {
+			attrs = append(attrs, metadataAttr(md))
+		}
+		req.log(ctx, logger, level, "grpc request", started, attrs...)
+		return resp, err
+	}
+}
+
+// serverFault сообщает, считается ли код ошибкой сервера, а не клиента.
+func serverFault(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented,
+		codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// metadataAttr возвращает группу "metadata" с метаданными вызова; секреты маскируются.
+func metadataAttr(md metadata.MD) slog.Attr {
+	attrs := make([]any, 0, len(md))
+	for name, values := range md {
+		value := strings.Join(values, ", ")
+		if IsSensitive(name) {
+			value = Redacted
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return slog.Group("metadata", attrs...)
+}
@@ -0,0 +1,173 @@
+// Package metrics — метрики Prometheus: запросы REST и gRPC, бизнес-события
+// резервов, отказы по ограничению частоты и правилам списаний, решения
+// проверки риска, предложения изменения лимита, состояние резервов и пула
+// соединений с БД. Метрики глобальные и регистрируются в
+// prometheus.DefaultRegisterer один раз на процесс.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "balance"
+
+// События резервов для ReservationEvent.
+const (
+	EventOpened    = "opened"
+	EventConfirmed = "confirmed"
+	EventCancelled = "cancelled"
+	EventExpired   = "expired" // попытка подтвердить истёкший резерв
+	EventRefunded  = "refunded"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "REST requests by route, method and status code.",
+	}, []string{"method", "route", "code"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "REST request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	grpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "gRPC requests by method and status code.",
+	}, []string{"method", "code"})
+	grpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC request latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	reservationEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reservation_events_total",
+		Help:      "Reservation lifecycle events: opened, confirmed, cancelled, expired, refunded.",
+	}, []string{"event"})
+	insufficientFunds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "insufficient_funds_total",
+		Help:      "Operations rejected for insufficient funds.",
+	}, []string{"operation"})
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by rate limits by service and scope: service or account.",
+	}, []string{"service", "scope"})
+	velocityRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "velocity_rejected_total",
+		Help:      "Debits and reservations rejected by velocity rules by rule kind.",
+	}, []string{"kind"})
+	riskDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "risk_decisions_total",
+		Help:      "Risk check decisions by operation kind and decision: approve, decline, review, fail_open or fail_closed.",
+	}, []string{"operation", "decision"})
+	pendingResolved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pending_operations_resolved_total",
+		Help:      "Operations held for review by resulting status: APPROVED, REJECTED or FAILED.",
+	}, []string{"operation", "status"})
+	limitChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "limit_changes_total",
+		Help:      "Limit change proposals by status: PENDING when proposed, then APPROVED, REJECTED or EXPIRED.",
+	}, []string{"status"})
+)
+
+// ReservationEvent учитывает событие жизненного цикла резерва.
+func ReservationEvent(event string) {
+	reservationEvents.WithLabelValues(event).Inc()
+}
+
+// InsufficientFunds учитывает отказ операции operation из-за нехватки средств.
+func InsufficientFunds(operation string) {
+	insufficientFunds.WithLabelValues(operation).Inc()
+}
+
+// RateLimited учитывает запрос сервиса service, отклонённый ограничением частоты scope.
+func RateLimited(service, scope string) {
+	rateLimited.WithLabelValues(service, scope).Inc()
+}
+
+// VelocityRejected учитывает операцию, отклонённую правилом вида kind.
+func VelocityRejected(kind string) {
+	velocityRejected.WithLabelValues(kind).Inc()
+}
+
+// Решения проверки риска, которая не ответила, для RiskDecision.
+const (
+	DecisionFailOpen   = "fail_open"
+	DecisionFailClosed = "fail_closed"
+)
+
+// RiskDecision учитывает решение проверки риска по операции вида operation.
+func RiskDecision(operation, decision string) {
+	riskDecisions.WithLabelValues(operation, decision).Inc()
+}
+
+// PendingResolved учитывает решение по отложенной операции вида operation.
+func PendingResolved(operation, status string) {
+	pendingResolved.WithLabelValues(operation, status).Inc()
+}
+
+// LimitChanges учитывает n предложений изменения лимита, перешедших в статус status.
+func LimitChanges(status string, n int) {
+	limitChanges.WithLabelValues(status).Add(float64(n))
+}
+
+// Handler отдаёт метрики в формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// GinMiddleware считает REST-запросы по шаблону маршрута (/accounts/:account_id),
+// чтобы ID не раздували число рядов.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(started).Seconds())
+	}
+}
+
+// UnaryServerInterceptor считает gRPC-запросы; ставится первым, чтобы учитывать и отказы аутентификации.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		started := time.Now()
+		resp, err := handler(ctx, req)
+		grpcRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+		grpcDuration.WithLabelValues(info.FullMethod).Observe(time.Since(started).Seconds())
+		return resp, err
+	}
+}
+
+// RegisterDBStats публикует статистику пула соединений sql.DB.Stats().
+func RegisterDBStats(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
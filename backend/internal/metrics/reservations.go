@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeTimeout — сколько ждать запрос состояния резервов при сборе метрик.
+const scrapeTimeout = 2 * time.Second
+
+// ReservationStatsFunc возвращает число ACTIVE-резервов и суммарный зарезервированный остаток.
+type ReservationStatsFunc func(ctx context.Context) (active, reserved int64, err error)
+
+type reservationCollector struct {
+	stats    ReservationStatsFunc
+	active   *prometheus.Desc
+	reserved *prometheus.Desc
+}
+
+// RegisterReservationGauges публикует число активных резервов и сумму
+// зарезервированных средств; stats вызывается при каждом сборе метрик.
+func RegisterReservationGauges(stats ReservationStatsFunc) {
+	prometheus.MustRegister(&reservationCollector{
+		stats: stats,
+		active: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "active_reservations"),
+			"Number of ACTIVE reservations.", nil, nil,
+		),
+		reserved: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "reserved_amount"),
+			"Total reserved amount over all accounts.", nil, nil,
+		),
+	})
+}
+
+func (c *reservationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.active
+	ch <- c.reserved
+}
+
+func (c *reservationCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	active, reserved, err := c.stats(ctx)
+	if err != nil {
+		slog.Error("reservation metrics failed", "error", err)
+		ch <- prometheus.NewInvalidMetric(c.active, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, float64(active))
+	ch <- prometheus.MustNewConstMetric(c.reserved, prometheus.GaugeValue, float64(reserved))
+}
@@ -0,0 +1,194 @@
+// Package migration применяет встроенные миграции схемы (migrations.FS)
+// через golang-migrate. Каждая операция выполняется под advisory-lock
+// PostgreSQL, поэтому реплики, стартующие одновременно, мигрируют по очереди:
+// первая применяет миграции, остальные дожидаются её и видят актуальную схему.
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	"test_nanimai/backend/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/lib/pq"
+)
+
+// lockID — ключ pg_advisory_lock для операций с миграциями; отличается от
+// ключа, который golang-migrate берёт на время отдельного Up/Down.
+const lockID int64 = 0x62616c616e6365 // "balance"
+
+// Migrator управляет схемой базы данных.
+type Migrator struct {
+	db  *sql.DB
+	src source.Driver
+	m   *migrate.Migrate
+}
+
+// Status — состояние схемы.
+type Status struct {
+	Version uint   // применённая версия, 0 — миграций не было
+	Dirty   bool   // последняя миграция не завершилась, нужен force
+	Latest  uint   // последняя встроенная версия
+	Pending []uint // встроенные, но не применённые версии
+}
+
+// New подключается к базе dsn. Закрывать через Close.
+func New(dsn string) (*Migrator, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	mg, err := newMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return mg, nil
+}
+
+func newMigrator(db *sql.DB) (*Migrator, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("open embedded migrations: %w", err)
+	}
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		return nil, err
+	}
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, src: src, m: m}, nil
+}
+
+// Close закрывает подключение к базе.
+func (mg *Migrator) Close() error {
+	srcErr, dbErr := mg.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// Up применяет все встроенные миграции. Если схема уже актуальна, ничего не делает.
+func (mg *Migrator) Up(ctx context.Context) error {
+	return mg.withLock(ctx, func() error {
+		return ignoreNoChange(mg.m.Up())
+	})
+}
+
+// Down откатывает steps последних миграций; steps <= 0 откатывает все.
+func (mg *Migrator) Down(ctx context.Context, steps int) error {
+	return mg.withLock(ctx, func() error {
+		if steps <= 0 {
+			return ignoreNoChange(mg.m.Down())
+		}
+		return ignoreNoChange(mg.m.Steps(-steps))
+	})
+}
+
+// Force записывает версию version без выполнения миграций и снимает признак
+// dirty. Нужен после ручного исправления схемы, упавшей посреди миграции.
+func (mg *Migrator) Force(ctx context.Context, version int) error {
+	return mg.withLock(ctx, func() error {
+		return mg.m.Force(version)
+	})
+}
+
+// Status возвращает применённую и встроенные версии.
+func (mg *Migrator) Status(ctx context.Context) (Status, error) {
+	var st Status
+	err := mg.withLock(ctx, func() error {
+		version, dirty, err := mg.m.Version()
+		if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+			return err
+		}
+		st.Version, st.Dirty = version, dirty
+
+		available, err := versions(mg.src)
+		if err != nil {
+			return err
+		}
+		for _, v := range available {
+			st.Latest = v
+			if v > st.Version {
+				st.Pending = append(st.Pending, v)
+			}
+		}
+		return nil
+	})
+	return st, err
+}
+
+// Latest возвращает последнюю встроенную версию схемы.
+func Latest() (uint, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	vs, err := versions(src)
+	if err != nil || len(vs) == 0 {
+		return 0, err
+	}
+	return vs[len(vs)-1], nil
+}
+
+// versions перечисляет версии источника по возрастанию.
+func versions(src source.Driver) ([]uint, error) {
+	v, err := src.First()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []uint{v}
+	for {
+		v, err = src.Next(v)
+		if errors.Is(err, fs.ErrNotExist) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+}
+
+// withLock выполняет fn под advisory-lock на отдельном соединении. Если
+// блокировку держит другая реплика, ждёт её, пока не отменён ctx.
+func (mg *Migrator) withLock(ctx context.Context, fn func() error) error {
+	conn, err := mg.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockID).Scan(&locked); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	if !locked {
+		slog.Info("waiting for migration lock held by another instance")
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	return fn()
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,223 @@
+package mtls_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"test_nanimai/backend/internal/mtls"
+	"test_nanimai/backend/internal/mtls/mtlstest"
+)
+
+func TestParseClientAuth(t *testing.T) {
+	cases := []struct {
+		mode    string
+		want    tls.ClientAuthType
+		wantErr bool
+	}{
+		{mtls.ClientAuthNone, tls.NoClientCert, false},
+		{mtls.ClientAuthOptional, tls.VerifyClientCertIfGiven, false},
+		{mtls.ClientAuthRequire, tls.RequireAndVerifyClientCert, false},
+		{"", tls.NoClientCert, true},
+		{"REQUIRE", tls.NoClientCert, true},
+	}
+	for _, c := range cases {
+		got, err := mtls.ParseClientAuth(c.mode)
+		if got != c.want || (err != nil) != c.wantErr {
+			t.Errorf("ParseClientAuth(%q) = %v, %v; want %v, error %v", c.mode, got, err, c.want, c.wantErr)
+		}
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	ca, foreign := mtlstest.NewCA(t, "clients"), mtlstest.NewCA(t, "foreign")
+	certs := newReloader(t, t.TempDir(), ca, ca.PEM)
+	names := []string{"spiffe://test/billing", "billing.internal", "billing@example.com"}
+	valid := ca.Certificate(t, "billing", names...)
+	// Имена в порядке Identities: URI, DNS, email, затем CN
+	want := append(slices.Clone(names), "billing")
+
+	cases := []struct {
+		name    string
+		auth    string
+		cert    *tls.Certificate
+		wantErr bool
+		want    []string
+	}{
+		{"none/valid", mtls.ClientAuthNone, &valid, false, nil},
+		{"optional/without", mtls.ClientAuthOptional, nil, false, nil},
+		{"optional/valid", mtls.ClientAuthOptional, &valid, false, want},
+		// Сертификат чужого CA клиент не предъявляет: сервер перечисляет свои CA
+		{"optional/foreign", mtls.ClientAuthOptional, certPtr(foreign.Certificate(t, "billing", names...)), false, nil},
+		{"require/without", mtls.ClientAuthRequire, nil, true, nil},
+		{"require/valid", mtls.ClientAuthRequire, &valid, false, want},
+		{"require/foreign", mtls.ClientAuthRequire, certPtr(foreign.Certificate(t, "billing", names...)), true, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			auth, err := mtls.ParseClientAuth(c.auth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := clientConfig(ca, c.cert)
+			state, _, err := handshake(t, certs.ServerConfig(auth), client)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("handshake error = %v, want error %v", err, c.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := mtls.PeerIdentities(&state); !slices.Equal(got, c.want) {
+				t.Errorf("PeerIdentities = %q, want %q", got, c.want)
+			}
+		})
+	}
+	if got := mtls.PeerIdentities(nil); got != nil {
+		t.Errorf("PeerIdentities(nil) = %q, want nil", got)
+	}
+}
+
+func TestReload(t *testing.T) {
+	serverCA := mtlstest.NewCA(t, "server")
+	oldCA, newCA := mtlstest.NewCA(t, "old clients"), mtlstest.NewCA(t, "new clients")
+	dir := t.TempDir()
+	certs := newReloader(t, dir, serverCA, oldCA.PEM)
+	server := certs.ServerConfig(tls.RequireAndVerifyClientCert)
+	oldClient := clientConfig(serverCA, certPtr(oldCA.Certificate(t, "billing")))
+	newClient := clientConfig(serverCA, certPtr(newCA.Certificate(t, "billing")))
+
+	if _, _, err := handshake(t, server, oldClient); err != nil {
+		t.Fatalf("handshake with old CA: %v", err)
+	}
+	if _, _, err := handshake(t, server, newClient); err == nil {
+		t.Fatal("handshake with new CA before reload succeeded")
+	}
+
+	// Run замечает изменённые файлы и подменяет сертификаты без перезапуска
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go certs.Run(ctx, 10*time.Millisecond)
+	certPEM, keyPEM := serverCA.Issue(t, "server-2", "localhost")
+	touch(t, mtlstest.WriteFile(t, dir, "server.pem", certPEM))
+	touch(t, mtlstest.WriteFile(t, dir, "server-key.pem", keyPEM))
+	touch(t, mtlstest.WriteFile(t, dir, "clients.pem", newCA.PEM))
+	var client tls.ConnectionState
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		var err error
+		if _, client, err = handshake(t, server, newClient); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("handshake with new CA after reload: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if cn := client.PeerCertificates[0].Subject.CommonName; cn != "server-2" {
+		t.Errorf("server certificate CN = %q after reload, want server-2", cn)
+	}
+	if _, _, err := handshake(t, server, oldClient); err == nil {
+		t.Error("handshake with old CA after reload succeeded")
+	}
+
+	// Неудачная загрузка оставляет прежние сертификаты
+	mtlstest.WriteFile(t, dir, "clients.pem", []byte("not a certificate"))
+	if err := certs.Reload(); err == nil {
+		t.Error("Reload with broken CA file succeeded")
+	}
+	if _, _, err := handshake(t, server, newClient); err != nil {
+		t.Errorf("handshake after failed reload: %v", err)
+	}
+
+	if _, err := mtls.NewReloader(dir+"/missing.pem", dir+"/server-key.pem", ""); err == nil {
+		t.Error("NewReloader with missing certificate succeeded")
+	}
+}
+
+// newReloader записывает в dir сертификат сервера localhost, выпущенный
+// serverCA, и корневые сертификаты клиентов clientCA и загружает их.
+func newReloader(t *testing.T, dir string, serverCA *mtlstest.CA, clientCA []byte) *mtls.Reloader {
+	t.Helper()
+	certPEM, keyPEM := serverCA.Issue(t, "server", "localhost")
+	certs, err := mtls.NewReloader(
+		mtlstest.WriteFile(t, dir, "server.pem", certPEM),
+		mtlstest.WriteFile(t, dir, "server-key.pem", keyPEM),
+		mtlstest.WriteFile(t, dir, "clients.pem", clientCA),
+	)
+	if err != nil {
+		t.Fatalf("NewReloader: %v", err)
+	}
+	return certs
+}
+
+// clientConfig — настройки клиента, доверяющего серверу serverCA, с
+// сертификатом cert (nil — без него).
+func clientConfig(serverCA *mtlstest.CA, cert *tls.Certificate) *tls.Config {
+	cfg := &tls.Config{RootCAs: serverCA.Pool(), ServerName: "localhost"}
+	if cert != nil {
+		cfg.Certificates = []tls.Certificate{*cert}
+	}
+	return cfg
+}
+
+// handshake соединяет по TCP клиента с настройками client и сервер с
+// настройками server и возвращает состояние соединения на стороне сервера
+// и клиента. Ошибка — первая из ошибок рукопожатия сервера и клиента: в
+// TLS 1.3 клиент узнаёт об отказе в своём сертификате только от сервера.
+func handshake(t *testing.T, server, client *tls.Config) (tls.ConnectionState, tls.ConnectionState, error) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer lis.Close()
+
+	type result struct {
+		state tls.ConnectionState
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		srv := tls.Server(conn, server)
+		err = srv.Handshake()
+		done <- result{srv.ConnectionState(), err}
+	}()
+
+	conn, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	cli := tls.Client(conn, client)
+	clientErr := cli.Handshake()
+	srv := <-done
+	if srv.err != nil {
+		return srv.state, cli.ConnectionState(), srv.err
+	}
+	return srv.state, cli.ConnectionState(), clientErr
+}
+
+func certPtr(cert tls.Certificate) *tls.Certificate {
+	return &cert
+}
+
+// touch сдвигает время изменения файла вперёд, чтобы Run заметил замену
+// даже при грубом разрешении времени файловой системы.
+func touch(t *testing.T, name string) {
+	t.Helper()
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(name, future, future); err != nil {
+		t.Fatal(err)
+	}
+}
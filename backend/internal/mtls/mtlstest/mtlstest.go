@@ -0,0 +1,137 @@
+// Package mtlstest выпускает сертификаты для тестов TLS: свой
+// удостоверяющий центр и подписанные им сертификаты серверов и клиентов.
+//
+//	ca := mtlstest.NewCA(t, "test CA")
+//	certPEM, keyPEM := ca.Issue(t, "billing", "spiffe://test/billing", "127.0.0.1")
+package mtlstest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var serial atomic.Int64
+
+// CA — удостоверяющий центр с ключом в памяти.
+type CA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	// PEM — сертификат CA в PEM, например для TLS_CLIENT_CA_FILE.
+	PEM []byte
+}
+
+// NewCA создаёт самоподписанный удостоверяющий центр с CN name.
+func NewCA(t testing.TB, name string) *CA {
+	t.Helper()
+	key := newKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial.Add(1)),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create CA certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse CA certificate: %v", err)
+	}
+	return &CA{cert: cert, key: key, PEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// Pool возвращает пул с сертификатом CA.
+func (ca *CA) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// Issue выпускает сертификат с CN cn для сервера и клиента. Имена names
+// попадают в SAN по виду: со схемой — URI, с @ — email, IP-адреса — IP,
+// остальные — DNS. Возвращает сертификат и ключ в PEM.
+func (ca *CA) Issue(t testing.TB, cn string, names ...string) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key := newKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial.Add(1)),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, name := range names {
+		switch {
+		case strings.Contains(name, "://"):
+			u, err := url.Parse(name)
+			if err != nil {
+				t.Fatalf("parse URI %q: %v", name, err)
+			}
+			tmpl.URIs = append(tmpl.URIs, u)
+		case strings.Contains(name, "@"):
+			tmpl.EmailAddresses = append(tmpl.EmailAddresses, name)
+		case net.ParseIP(name) != nil:
+			tmpl.IPAddresses = append(tmpl.IPAddresses, net.ParseIP(name))
+		default:
+			tmpl.DNSNames = append(tmpl.DNSNames, name)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// Certificate — как Issue, но возвращает готовую пару для tls.Config.
+func (ca *CA) Certificate(t testing.TB, cn string, names ...string) tls.Certificate {
+	t.Helper()
+	cert, err := tls.X509KeyPair(ca.Issue(t, cn, names...))
+	if err != nil {
+		t.Fatalf("load certificate: %v", err)
+	}
+	return cert
+}
+
+// WriteFile записывает data в файл name каталога dir и возвращает его путь.
+func WriteFile(t testing.TB, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	return path
+}
+
+func newKey(t testing.TB) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
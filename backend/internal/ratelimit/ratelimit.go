@@ -0,0 +1,149 @@
+// Package ratelimit ограничивает частоту запросов сервисов по алгоритму
+// token bucket. Ограничения хранятся в services и приходят вместе с
+// сервисом при аутентификации; корзины живут в памяти экземпляра, поэтому
+// при нескольких экземплярах каждый ограничивает свою долю запросов.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/metrics"
+)
+
+// Области ограничения для Exceeded.Scope и метрик.
+const (
+	ScopeService = "service"
+	ScopeAccount = "account"
+)
+
+// sweepInterval — как часто из памяти убираются заполненные корзины: они
+// ничем не отличаются от новых.
+const sweepInterval = time.Minute
+
+// Exceeded — отказ по ограничению частоты. Оборачивает domain.ErrRateLimited.
+type Exceeded struct {
+	Scope      string        // ScopeService или ScopeAccount
+	RetryAfter time.Duration // когда в корзине появится токен
+}
+
+func (e *Exceeded) Error() string {
+	return domain.ErrRateLimited.Error() + " for " + e.Scope + ", retry after " + e.RetryAfter.Round(time.Millisecond).String()
+}
+
+func (e *Exceeded) Unwrap() error { return domain.ErrRateLimited }
+
+// RetryAfterSeconds — RetryAfter в целых секундах с округлением вверх для
+// заголовка Retry-After; не меньше 1.
+func (e *Exceeded) RetryAfterSeconds() int {
+	return max(1, int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// Limiter — корзины сервисов и пар сервис–счёт. Безопасен для одновременного использования.
+type Limiter struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[key]*bucket
+	lastSweep time.Time
+}
+
+// key — корзина сервиса (accountID = 0) или запросов сервиса к счёту.
+type key struct {
+	serviceID, accountID int64
+}
+
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{now: time.Now, buckets: make(map[key]*bucket)}
+}
+
+// Allow списывает токен из корзины сервиса svc и, если у него задан
+// AccountRate и accountID не 0, из корзины запросов сервиса к счёту.
+// Токены списываются только при наличии в обеих корзинах; иначе *Exceeded.
+func (l *Limiter) Allow(svc *domain.Service, accountID int64) error {
+	limit := svc.RateLimit
+	if limit.Rate == 0 && (limit.AccountRate == 0 || accountID == 0) {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	var serviceBucket, accountBucket *bucket
+	if limit.Rate > 0 {
+		serviceBucket = l.bucket(key{serviceID: svc.ID}, limit.Rate, limit.Burst, now)
+		if wait := serviceBucket.wait(); wait > 0 {
+			metrics.RateLimited(svc.Name, ScopeService)
+			return &Exceeded{Scope: ScopeService, RetryAfter: wait}
+		}
+	}
+	if limit.AccountRate > 0 && accountID != 0 {
+		accountBucket = l.bucket(key{serviceID: svc.ID, accountID: accountID}, limit.AccountRate, limit.AccountBurst, now)
+		if wait := accountBucket.wait(); wait > 0 {
+			metrics.RateLimited(svc.Name, ScopeAccount)
+			return &Exceeded{Scope: ScopeAccount, RetryAfter: wait}
+		}
+	}
+	for _, b := range []*bucket{serviceBucket, accountBucket} {
+		if b != nil {
+			b.tokens--
+		}
+	}
+	return nil
+}
+
+// bucket возвращает корзину k, пополненную к моменту now. Новая корзина
+// полна; при изменении ограничения токены урезаются до нового burst.
+func (l *Limiter) bucket(k key, rate float64, burst int, now time.Time) *bucket {
+	b, ok := l.buckets[k]
+	if !ok {
+		b = &bucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+		l.buckets[k] = b
+	}
+	// Время до now пополняется по прежней частоте
+	b.refill(now)
+	b.rate, b.burst = rate, float64(burst)
+	b.tokens = math.Min(b.tokens, b.burst)
+	return b
+}
+
+// sweep раз в sweepInterval убирает корзины, заполнившиеся до конца; вызывается под l.mu.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	for k, b := range l.buckets {
+		if b.refill(now); b.tokens >= b.burst {
+			delete(l.buckets, k)
+		}
+	}
+	l.lastSweep = now
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+	}
+	b.tokens = math.Min(b.tokens, b.burst)
+	b.last = now
+}
+
+// wait возвращает, через сколько в корзине будет целый токен; 0 — уже есть.
+func (b *bucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) / b.rate * float64(time.Second)))
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"test_nanimai/backend/domain"
+)
+
+// newLimiter возвращает Limiter с часами, стоящими в now.
+func newLimiter(now *time.Time) *Limiter {
+	l := NewLimiter()
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+// allowN вызывает Allow n раз и возвращает число пропущенных запросов и
+// последний отказ.
+func allowN(l *Limiter, svc *domain.Service, accountID int64, n int) (int, *Exceeded) {
+	var allowed int
+	var exceeded *Exceeded
+	for range n {
+		err := l.Allow(svc, accountID)
+		if err == nil {
+			allowed++
+			continue
+		}
+		exceeded, _ = err.(*Exceeded)
+	}
+	return allowed, exceeded
+}
+
+func TestAllow(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := newLimiter(&now)
+	svc := &domain.Service{ID: 1, Name: "billing", RateLimit: domain.RateLimit{Rate: 2, Burst: 3}}
+
+	// Новая корзина полна: проходит burst запросов подряд
+	if allowed, exceeded := allowN(l, svc, 0, 5); allowed != 3 || exceeded == nil || exceeded.Scope != ScopeService {
+		t.Fatalf("allowed %d, exceeded %+v; want 3 and service scope", allowed, exceeded)
+	}
+	err := l.Allow(svc, 0)
+	if !errors.Is(err, domain.ErrRateLimited) {
+		t.Errorf("Allow = %v, want %v", err, domain.ErrRateLimited)
+	}
+	if exceeded := err.(*Exceeded); exceeded.RetryAfter != 500*time.Millisecond || exceeded.RetryAfterSeconds() != 1 {
+		t.Errorf("RetryAfter = %v (%ds), want 500ms (1s)", exceeded.RetryAfter, exceeded.RetryAfterSeconds())
+	}
+
+	// Токены пополняются с частотой Rate, но не больше Burst
+	now = now.Add(time.Second)
+	if allowed, _ := allowN(l, svc, 0, 5); allowed != 2 {
+		t.Errorf("allowed %d after 1s, want 2", allowed)
+	}
+	now = now.Add(time.Hour)
+	if allowed, _ := allowN(l, svc, 0, 5); allowed != 3 {
+		t.Errorf("allowed %d after 1h, want burst 3", allowed)
+	}
+
+	// Корзины сервисов независимы; без ограничения запросы проходят всегда
+	other := &domain.Service{ID: 2, RateLimit: domain.RateLimit{Rate: 2, Burst: 3}}
+	if allowed, _ := allowN(l, other, 0, 3); allowed != 3 {
+		t.Errorf("allowed %d for other service, want 3", allowed)
+	}
+	if allowed, _ := allowN(l, &domain.Service{ID: 3}, 0, 100); allowed != 100 {
+		t.Errorf("allowed %d without limit, want 100", allowed)
+	}
+}
+
+func TestAllowAccount(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := newLimiter(&now)
+	svc := &domain.Service{ID: 1, RateLimit: domain.RateLimit{Rate: 10, Burst: 3, AccountRate: 1, AccountBurst: 2}}
+
+	if allowed, exceeded := allowN(l, svc, 1, 3); allowed != 2 || exceeded == nil || exceeded.Scope != ScopeAccount {
+		t.Fatalf("allowed %d, exceeded %+v; want 2 and account scope", allowed, exceeded)
+	}
+	// Отказ по счёту не списывает токен сервиса: на другой счёт остался один
+	if allowed, exceeded := allowN(l, svc, 2, 2); allowed != 1 || exceeded == nil || exceeded.Scope != ScopeService {
+		t.Errorf("allowed %d, exceeded %+v for account 2; want 1 and service scope", allowed, exceeded)
+	}
+	// Запросы без счёта ограничены только корзиной сервиса
+	now = now.Add(time.Second)
+	if allowed, _ := allowN(l, svc, 0, 20); allowed != 3 {
+		t.Errorf("allowed %d without account, want 3", allowed)
+	}
+}
+
+func TestLimitChange(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := newLimiter(&now)
+	svc := &domain.Service{ID: 1, RateLimit: domain.RateLimit{Rate: 1, Burst: 10}}
+	if allowed, _ := allowN(l, svc, 0, 2); allowed != 2 {
+		t.Fatalf("allowed %d, want 2", allowed)
+	}
+
+	// Меньший burst урезает накопленные токены
+	svc.RateLimit = domain.RateLimit{Rate: 1, Burst: 2}
+	if allowed, _ := allowN(l, svc, 0, 5); allowed != 2 {
+		t.Errorf("allowed %d after burst decrease, want 2", allowed)
+	}
+	// Снятое ограничение пропускает всё
+	svc.RateLimit = domain.RateLimit{}
+	if allowed, _ := allowN(l, svc, 0, 5); allowed != 5 {
+		t.Errorf("allowed %d without limit, want 5", allowed)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := newLimiter(&now)
+	full := &domain.Service{ID: 1, RateLimit: domain.RateLimit{Rate: 1, Burst: 1000}}
+	busy := &domain.Service{ID: 2, RateLimit: domain.RateLimit{Rate: 0.001, Burst: 1}}
+	l.Allow(full, 0)
+	l.Allow(busy, 0)
+
+	now = now.Add(sweepInterval)
+	l.Allow(&domain.Service{ID: 3, RateLimit: domain.RateLimit{Rate: 1, Burst: 1}}, 0)
+	if _, ok := l.buckets[key{serviceID: 1}]; ok {
+		t.Error("refilled bucket was not swept")
+	}
+	if _, ok := l.buckets[key{serviceID: 2}]; !ok {
+		t.Error("bucket with spent tokens was swept")
+	}
+}
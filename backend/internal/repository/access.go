@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"test_nanimai/backend/domain"
+)
+
+// Access — данные для проверки области доступа сервиса: к какому счёту
+// относится объект запроса, какими тегами помечен счёт, чей это резерв и
+// какие строки у сторнируемой проводки.
+type Access interface {
+	// AccountTags возвращает теги счёта; нет счёта — domain.ErrNotFound.
+	AccountTags(ctx context.Context, accountID int64) ([]string, error)
+	ReservationAccountID(ctx context.Context, reservationID int64) (int64, error)
+	// ReservationOwnerID возвращает сервис, открывший резерв; нет резерва —
+	// domain.ErrNotFound.
+	ReservationOwnerID(ctx context.Context, reservationID int64) (int64, error)
+	EntryAccountID(ctx context.Context, entryID int64) (int64, error)
+	// EntryPostings возвращает строки проводки; нет проводки — domain.ErrNotFound.
+	EntryPostings(ctx context.Context, entryID int64) ([]domain.Posting, error)
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"test_nanimai/backend/domain"
+	"time"
+)
+
+// Admin — операции оператора, недоступные сервисам-клиентам API.
+type Admin interface {
+	Balance
+	Services
+	Access
+	Velocity
+	PendingOperations
+	LimitChanges
+
+	CreateAccount(ctx context.Context, userID, maxAmount int64) (*domain.Account, error)
+	// SetAccountFrozen замораживает или размораживает счёт.
+	SetAccountFrozen(ctx context.Context, accountID int64, frozen bool) error
+	// ListAccountIDs возвращает ID всех счетов по возрастанию.
+	ListAccountIDs(ctx context.Context) ([]int64, error)
+	// SetAccountTags заменяет теги счёта.
+	SetAccountTags(ctx context.Context, accountID int64, tags []string) error
+
+	// CreateService регистрирует сервис с бессрочным ключом apiKey, правами
+	// domain.DefaultPermissions и без ограничения области; занятое имя — domain.ErrServiceExists.
+	CreateService(ctx context.Context, name, apiKey string) (int64, error)
+	// SetServicePermissions заменяет права сервиса.
+	SetServicePermissions(ctx context.Context, serviceID int64, permissions []domain.Permission) error
+	// SetServiceScope заменяет область сервиса; пустая область — все счета.
+	SetServiceScope(ctx context.Context, serviceID int64, scope domain.AccountScope) error
+	// SetServiceSigning задаёт режим подписи запросов и секрет (пустой при domain.SigningOff).
+	SetServiceSigning(ctx context.Context, serviceID int64, mode domain.SigningMode, secret string) error
+	// SetServiceRateLimit задаёт ограничение частоты запросов сервиса.
+	SetServiceRateLimit(ctx context.Context, serviceID int64, limit domain.RateLimit) error
+	// SetServiceCertIdentities заменяет имена клиентских сертификатов сервиса;
+	// имя, уже назначенное другому сервису, — domain.ErrIdentityInUse.
+	SetServiceCertIdentities(ctx context.Context, serviceID int64, identities []string) error
+	ListServices(ctx context.Context) ([]domain.Service, error)
+
+	// CreateAPIKey добавляет сервису key.ServiceID ещё один ключ; заполняет ID и CreatedAt.
+	CreateAPIKey(ctx context.Context, key *domain.APIKey) error
+	// RotateAPIKey добавляет ключ key и ограничивает срок остальных действующих
+	// ключей сервиса моментом now + overlap: до него работают и старые, и новый.
+	RotateAPIKey(ctx context.Context, key *domain.APIKey, overlap time.Duration) error
+	// ListAPIKeys возвращает все ключи сервиса, включая истёкшие и отозванные.
+	ListAPIKeys(ctx context.Context, serviceID int64) ([]domain.APIKey, error)
+	// RevokeAPIKey отзывает ключ keyID сервиса serviceID; повторный отзыв — domain.ErrKeyRevoked.
+	RevokeAPIKey(ctx context.Context, serviceID, keyID int64) error
+
+	// CreateVelocityRule сохраняет проверенное правило; заполняет ID и CreatedAt.
+	// Несуществующий счёт rule.AccountID — domain.ErrNotFound.
+	CreateVelocityRule(ctx context.Context, rule *domain.VelocityRule) error
+	// ListVelocityRules возвращает все правила по возрастанию ID.
+	ListVelocityRules(ctx context.Context) ([]domain.VelocityRule, error)
+	// DeleteVelocityRule удаляет правило; нет такого — domain.ErrNotFound.
+	DeleteVelocityRule(ctx context.Context, ruleID int64) error
+
+	// CloseReservation переводит ACTIVE-резерв в CANCELLED (op = domain.OpReserveCancel)
+	// или EXPIRED (op = domain.OpReserveExpire) независимо от владельца и срока,
+	// возвращает средства на счёт и пишет проводку с описанием reason.
+	CloseReservation(ctx context.Context, reservationID int64, op, reason string) (*domain.JournalEntry, error)
+}
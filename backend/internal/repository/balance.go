@@ -7,9 +7,26 @@ import (
 )
 
 type Balance interface {
+	GetAccount(ctx context.Context, accountID int64) (*domain.Account, error)
+	GetAccountAsOf(ctx context.Context, accountID int64, asOf time.Time) (*domain.Account, error)
 	UpdateLimit(ctx context.Context, accountID int64, delta int64) error
 	UpdateBalance(ctx context.Context, accountID int64, delta int64) error
+	// UpdateCreditLimit изменяет кредитную линию и пишет проводку от имени actorServiceID.
+	UpdateCreditLimit(ctx context.Context, actorServiceID, accountID int64, delta int64) error
 	OpenReservation(ctx context.Context, ownerServiceID, accountID int64, amount int64, idempotencyKey string, timeout time.Duration) (*domain.Reservation, error)
 	ConfirmReservation(ctx context.Context, reservationID int64, ownerServiceID int64) error
 	CancelReservation(ctx context.Context, reservationID int64, ownerServiceID int64) error
+	RefundReservation(ctx context.Context, reservationID, ownerServiceID int64, amount int64, idempotencyKey string) (*domain.Refund, error)
+	ListReservations(ctx context.Context, accountID int64) ([]domain.Reservation, error)
+	// ReservationByKey возвращает резерв сервиса с ключом идемпотентности; нет такого — domain.ErrNotFound.
+	ReservationByKey(ctx context.Context, ownerServiceID int64, idempotencyKey string) (*domain.Reservation, error)
+	// ReservationStats возвращает число ACTIVE-резервов и сумму reserved по всем счетам.
+	ReservationStats(ctx context.Context) (active, reserved int64, err error)
+	PostJournal(ctx context.Context, entry *domain.JournalEntry) error
+	ListJournal(ctx context.Context, accountID int64, limit int) ([]domain.JournalEntry, error)
+	// GetEntry возвращает проводку со строками; нет такой — domain.ErrNotFound.
+	GetEntry(ctx context.Context, entryID int64) (*domain.JournalEntry, error)
+	ReverseEntry(ctx context.Context, entryID, actorServiceID int64, reasonCode, description string) (*domain.JournalEntry, error)
+	ReverseReservation(ctx context.Context, reservationID, ownerServiceID int64, reasonCode, description string) (*domain.JournalEntry, error)
+	SnapshotBalances(ctx context.Context, minEntries int, settle time.Duration) (int64, error)
 }
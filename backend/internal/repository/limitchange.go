@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"test_nanimai/backend/domain"
+	"time"
+)
+
+// LimitChanges — предложенные изменения лимита и кредитной линии, ждущие
+// подтверждения.
+type LimitChanges interface {
+	// CreateLimitChange сохраняет предложение в статусе domain.LimitChangePending
+	// со сроком c.ExpiresAt; заполняет ID, Status и CreatedAt. Несуществующий
+	// счёт — domain.ErrNotFound.
+	CreateLimitChange(ctx context.Context, c *domain.LimitChange) error
+	// GetLimitChange возвращает предложение; нет такого — domain.ErrNotFound.
+	GetLimitChange(ctx context.Context, changeID int64) (*domain.LimitChange, error)
+	// ListLimitChanges возвращает предложения в статусе status (пустой — во
+	// всех) по возрастанию ID.
+	ListLimitChanges(ctx context.Context, status domain.LimitChangeStatus) ([]domain.LimitChange, error)
+	// ApproveLimitChange в одной транзакции переводит предложение из
+	// domain.LimitChangePending в domain.LimitChangeApproved с c.DecidedBy и
+	// c.Note, меняет лимит вида c.Kind и пишет проводку c.Journal();
+	// заполняет Kind, Status, EntryID и DecidedAt. Уже решённое — domain.ErrNotPending; истёкшее
+	// переводится в domain.LimitChangeExpired и возвращает domain.ErrChangeExpired.
+	ApproveLimitChange(ctx context.Context, c *domain.LimitChange) error
+	// RejectLimitChange переводит предложение из domain.LimitChangePending в
+	// domain.LimitChangeRejected с c.DecidedBy и c.Note; заполняет Status и
+	// DecidedAt. Уже решённое — domain.ErrNotPending.
+	RejectLimitChange(ctx context.Context, c *domain.LimitChange) error
+	// IncreaseLimit увеличивает лимит счёта на delta и пишет проводку, если
+	// policy.RequiresApproval не требует подтверждения с учётом увеличений
+	// лимита без подтверждения за policy.Window (в том числе начального
+	// лимита счёта) и ожидающих предложений вида domain.LimitKindMax по счёту;
+	// иначе лимит не меняется и возвращается domain.ErrApprovalRequired.
+	// Проверка и изменение идут под блокировкой счёта, поэтому параллельные
+	// увеличения порог не обходят. Несуществующий счёт — domain.ErrNotFound.
+	IncreaseLimit(ctx context.Context, accountID, delta int64, policy domain.LimitPolicy) error
+	// IncreaseCreditLimit — то же для кредитной линии: с порогом сравниваются
+	// увеличения кредитной линии без подтверждения за policy.Window и
+	// ожидающие предложения вида domain.LimitKindCredit; проводка пишется от
+	// имени actorServiceID.
+	IncreaseCreditLimit(ctx context.Context, actorServiceID, accountID, delta int64, policy domain.LimitPolicy) error
+	// ExpireLimitChanges переводит в domain.LimitChangeExpired предложения,
+	// срок которых истёк к now, и возвращает их число.
+	ExpireLimitChanges(ctx context.Context, now time.Time) (int64, error)
+}
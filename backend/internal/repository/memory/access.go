@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"test_nanimai/backend/domain"
+)
+
+func (s *BalanceStorage) AccountTags(ctx context.Context, accountID int64) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[accountID]; !ok {
+		return nil, domain.ErrNotFound
+	}
+	return slices.Clone(s.accountTags[accountID]), nil
+}
+
+func (s *BalanceStorage) ReservationAccountID(ctx context.Context, reservationID int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.reservations[reservationID]
+	if !ok {
+		return 0, domain.ErrNotFound
+	}
+	return res.AccountID, nil
+}
+
+func (s *BalanceStorage) ReservationOwnerID(ctx context.Context, reservationID int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.reservations[reservationID]
+	if !ok {
+		return 0, domain.ErrNotFound
+	}
+	return res.OwnerServiceID, nil
+}
+
+func (s *BalanceStorage) EntryAccountID(ctx context.Context, entryID int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entryID < 1 || entryID > int64(len(s.entries)) {
+		return 0, domain.ErrNotFound
+	}
+	return s.entries[entryID-1].AccountID, nil
+}
+
+func (s *BalanceStorage) EntryPostings(ctx context.Context, entryID int64) ([]domain.Posting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entryID < 1 || entryID > int64(len(s.entries)) {
+		return nil, domain.ErrNotFound
+	}
+	return slices.Clone(s.entries[entryID-1].Postings), nil
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"test_nanimai/backend/domain"
+)
+
+func (s *BalanceStorage) SetAccountFrozen(ctx context.Context, accountID int64, frozen bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc, ok := s.accounts[accountID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	acc.Frozen = frozen
+	return nil
+}
+
+func (s *BalanceStorage) SetAccountTags(ctx context.Context, accountID int64, tags []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[accountID]; !ok {
+		return domain.ErrNotFound
+	}
+	s.accountTags[accountID] = slices.Clone(tags)
+	return nil
+}
+
+func (s *BalanceStorage) ListAccountIDs(ctx context.Context) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// ID счетов идут подряд с 1
+	ids := make([]int64, 0, len(s.accounts))
+	for id := int64(1); id <= int64(len(s.accounts)); id++ {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// CloseReservation — то же, что в postgres: закрывает ACTIVE-резерв любого
+// владельца проводкой без сервиса-автора.
+func (s *BalanceStorage) CloseReservation(ctx context.Context, reservationID int64, op, reason string) (*domain.JournalEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.reservations[reservationID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if res.Status != "ACTIVE" {
+		return nil, domain.ErrNotActive
+	}
+
+	entry := domain.ReservationJournal(op, res)
+	entry.ActorServiceID = 0
+	entry.Description = reason
+	if err := s.insertJournal(entry); err != nil {
+		return nil, err
+	}
+	s.accounts[res.AccountID].ReservedAmount -= res.Amount
+	res.Status = "CANCELLED"
+	if op == domain.OpReserveExpire {
+		res.Status = "EXPIRED"
+	}
+	out := s.copyEntry(entry)
+	return &out, nil
+}
@@ -0,0 +1,552 @@
+// Package memory — реализация repository.Balance в памяти для тестов и
+// локального запуска без PostgreSQL. Семантика (проверки средств,
+// идемпотентность, истечение резервов, владение) совпадает с postgres.
+package memory
+
+import (
+	"context"
+	"sync"
+	"test_nanimai/backend/domain"
+	"time"
+)
+
+type reservationKey struct {
+	ownerServiceID int64
+	idempotencyKey string
+}
+
+type refundKey struct {
+	reservationID  int64
+	idempotencyKey string
+}
+
+// BalanceStorage хранит состояние под одним мьютексом: каждая операция
+// выполняется атомарно, как транзакция в postgres. Изменения счёта и
+// резерва применяются только после того, как проводка записана в журнал:
+// отклонённая проводка, как откат транзакции, ничего не меняет.
+type BalanceStorage struct {
+	mu sync.Mutex
+
+	accounts     map[int64]*domain.Account
+	accountTags  map[int64][]string
+	reservations map[int64]*domain.Reservation
+	resByKey     map[reservationKey]int64
+	entries      []*domain.JournalEntry // entries[i].ID == i+1
+	reversedBy   map[int64]int64
+	refunds      []*domain.Refund
+	refundByKey  map[refundKey]*domain.Refund
+	services     map[int64]*domain.Service
+	apiKeys      []*domain.APIKey // apiKeys[i].ID == i+1
+	rules        map[int64]*domain.VelocityRule
+	lastRuleID   int64
+	pending      []*domain.PendingOperation // pending[i].ID == i+1
+	limitChanges []*domain.LimitChange      // limitChanges[i].ID == i+1
+
+	now func() time.Time
+}
+
+func NewBalanceStorage() *BalanceStorage {
+	return &BalanceStorage{
+		accounts:     make(map[int64]*domain.Account),
+		accountTags:  make(map[int64][]string),
+		reservations: make(map[int64]*domain.Reservation),
+		resByKey:     make(map[reservationKey]int64),
+		reversedBy:   make(map[int64]int64),
+		refundByKey:  make(map[refundKey]*domain.Refund),
+		services:     make(map[int64]*domain.Service),
+		rules:        make(map[int64]*domain.VelocityRule),
+		now:          time.Now,
+	}
+}
+
+// CreateAccount заводит счёт с нулевым балансом и начальным лимитом maxAmount.
+func (s *BalanceStorage) CreateAccount(ctx context.Context, userID, maxAmount int64) (*domain.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc := &domain.Account{ID: int64(len(s.accounts) + 1), UserID: userID, MaxAmount: maxAmount}
+	if maxAmount != 0 {
+		if err := s.insertJournal(domain.LimitJournal(acc.ID, maxAmount)); err != nil {
+			return nil, err
+		}
+	}
+	s.accounts[acc.ID] = acc
+	out := *acc
+	return &out, nil
+}
+
+func (s *BalanceStorage) GetAccount(ctx context.Context, accountID int64) (*domain.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc, ok := s.accounts[accountID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	out := *acc
+	return &out, nil
+}
+
+// GetAccountAsOf суммирует изменения всех проводок по счёту до asOf.
+// Снимки в памяти не нужны: журнал и так целиком под рукой.
+func (s *BalanceStorage) GetAccountAsOf(ctx context.Context, accountID int64, asOf time.Time) (*domain.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc, ok := s.accounts[accountID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	out := domain.Account{ID: acc.ID, UserID: acc.UserID, Frozen: acc.Frozen}
+	for _, e := range s.entries {
+		if e.AccountID != accountID || e.CreatedAt.After(asOf) {
+			continue
+		}
+		out.CurrentAmount += e.DeltaCurrent
+		out.ReservedAmount += e.DeltaReserved
+		out.MaxAmount += e.DeltaMax
+		out.CreditLimit += e.DeltaCredit
+	}
+	return &out, nil
+}
+
+func (s *BalanceStorage) UpdateLimit(ctx context.Context, accountID int64, delta int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateLimit(accountID, delta)
+}
+
+// updateLimit вызывается под s.mu.
+func (s *BalanceStorage) updateLimit(accountID, delta int64) error {
+	acc, ok := s.accounts[accountID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if acc.MaxAmount+delta < 0 || acc.MaxAmount+delta < acc.CurrentAmount {
+		return domain.ErrLimitExceeded
+	}
+	if err := s.insertJournal(domain.LimitJournal(accountID, delta)); err != nil {
+		return err
+	}
+	acc.MaxAmount += delta
+	return nil
+}
+
+func (s *BalanceStorage) UpdateBalance(ctx context.Context, accountID int64, delta int64) error {
+	return s.PostJournal(ctx, domain.BalanceJournal(accountID, delta))
+}
+
+func (s *BalanceStorage) UpdateCreditLimit(ctx context.Context, actorServiceID, accountID int64, delta int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := domain.CreditLimitJournal(accountID, delta)
+	entry.ActorServiceID = actorServiceID
+	return s.updateCreditLimit(entry)
+}
+
+// updateCreditLimit меняет кредитную линию на entry.DeltaCredit и пишет
+// проводку entry; вызывается под s.mu.
+func (s *BalanceStorage) updateCreditLimit(entry *domain.JournalEntry) error {
+	acc, ok := s.accounts[entry.AccountID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	next := *acc
+	next.CreditLimit += entry.DeltaCredit
+	if next.CreditLimit < 0 || next.Available() < 0 {
+		return domain.ErrCreditInUse
+	}
+	if err := s.insertJournal(entry); err != nil {
+		return err
+	}
+	acc.CreditLimit = next.CreditLimit
+	return nil
+}
+
+func (s *BalanceStorage) OpenReservation(ctx context.Context, ownerServiceID, accountID int64, amount int64, idempotencyKey string, timeout time.Duration) (*domain.Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.openReservation(ctx, ownerServiceID, accountID, amount, idempotencyKey, timeout)
+}
+
+// openReservation вызывается под s.mu.
+func (s *BalanceStorage) openReservation(ctx context.Context, ownerServiceID, accountID int64, amount int64, idempotencyKey string, timeout time.Duration) (*domain.Reservation, error) {
+	if id, ok := s.resByKey[reservationKey{ownerServiceID, idempotencyKey}]; ok {
+		// Уже есть такая транзакция
+		out := *s.reservations[id]
+		return &out, nil
+	}
+
+	acc, ok := s.accounts[accountID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if amount <= 0 {
+		return nil, domain.ErrInvalidAmount
+	}
+	if acc.Frozen {
+		return nil, domain.ErrAccountFrozen
+	}
+	if err := s.checkVelocity(ctx); err != nil {
+		return nil, err
+	}
+	if acc.Available() < amount {
+		return nil, domain.ErrNotEnoughFunds
+	}
+
+	now := s.now()
+	res := &domain.Reservation{
+		ID:             int64(len(s.reservations) + 1),
+		AccountID:      accountID,
+		OwnerServiceID: ownerServiceID,
+		Amount:         amount,
+		Status:         "ACTIVE",
+		IdempotencyKey: idempotencyKey,
+		ExpiresAt:      now.Add(timeout),
+		CreatedAt:      now,
+	}
+	if err := s.insertJournal(domain.ReservationJournal(domain.OpReserveOpen, res)); err != nil {
+		return nil, err
+	}
+	s.reservations[res.ID] = res
+	s.resByKey[reservationKey{ownerServiceID, idempotencyKey}] = res.ID
+	acc.ReservedAmount += amount
+	out := *res
+	return &out, nil
+}
+
+func (s *BalanceStorage) ConfirmReservation(ctx context.Context, reservationID int64, ownerServiceID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.reservations[reservationID]
+	if !ok || res.OwnerServiceID != ownerServiceID {
+		return domain.ErrNotFound
+	}
+	if res.Status != "ACTIVE" {
+		return domain.ErrNotActive
+	}
+	if s.now().After(res.ExpiresAt) {
+		return domain.ErrExpired
+	}
+
+	acc := s.accounts[res.AccountID]
+	if acc.Frozen {
+		return domain.ErrAccountFrozen
+	}
+	if err := s.insertJournal(domain.ReservationJournal(domain.OpReserveConfirm, res)); err != nil {
+		return err
+	}
+	acc.CurrentAmount -= res.Amount
+	acc.ReservedAmount -= res.Amount
+	res.Status = "CONFIRMED"
+	return nil
+}
+
+func (s *BalanceStorage) CancelReservation(ctx context.Context, reservationID int64, ownerServiceID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.reservations[reservationID]
+	if !ok || res.OwnerServiceID != ownerServiceID {
+		return domain.ErrNotFound
+	}
+	if res.Status != "ACTIVE" {
+		return domain.ErrNotActive
+	}
+
+	if err := s.insertJournal(domain.ReservationJournal(domain.OpReserveCancel, res)); err != nil {
+		return err
+	}
+	s.accounts[res.AccountID].ReservedAmount -= res.Amount
+	res.Status = "CANCELLED"
+	return nil
+}
+
+func (s *BalanceStorage) ReservationByKey(ctx context.Context, ownerServiceID int64, idempotencyKey string) (*domain.Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.resByKey[reservationKey{ownerServiceID, idempotencyKey}]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	out := *s.reservations[id]
+	return &out, nil
+}
+
+// ListReservations возвращает все резервы по счёту, новые первыми.
+func (s *BalanceStorage) ListReservations(ctx context.Context, accountID int64) ([]domain.Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []domain.Reservation
+	for id := int64(len(s.reservations)); id > 0; id-- {
+		if res := s.reservations[id]; res.AccountID == accountID {
+			out = append(out, *res)
+		}
+	}
+	return out, nil
+}
+
+func (s *BalanceStorage) ReservationStats(ctx context.Context) (active, reserved int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, res := range s.reservations {
+		if res.Status == "ACTIVE" {
+			active++
+		}
+	}
+	for _, acc := range s.accounts {
+		reserved += acc.ReservedAmount
+	}
+	return active, reserved, nil
+}
+
+func (s *BalanceStorage) RefundReservation(ctx context.Context, reservationID, ownerServiceID int64, amount int64, idempotencyKey string) (*domain.Refund, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.reservations[reservationID]
+	if !ok || res.OwnerServiceID != ownerServiceID {
+		return nil, domain.ErrNotFound
+	}
+	if existing, ok := s.refundByKey[refundKey{reservationID, idempotencyKey}]; ok {
+		// Уже есть такой возврат
+		out := *existing
+		return &out, nil
+	}
+	if res.Status != "CONFIRMED" {
+		return nil, domain.ErrNotConfirmed
+	}
+	if s.accounts[res.AccountID].Frozen {
+		return nil, domain.ErrAccountFrozen
+	}
+	if amount <= 0 {
+		return nil, domain.ErrInvalidAmount
+	}
+
+	var refunded int64
+	for _, r := range s.refunds {
+		if r.ReservationID == reservationID {
+			refunded += r.Amount
+		}
+	}
+	if s.confirmReversed(reservationID) || refunded+amount > res.Amount {
+		return nil, domain.ErrRefundExceeded
+	}
+
+	acc, ok := s.accounts[res.AccountID]
+	if !ok || acc.CurrentAmount+amount > acc.MaxAmount {
+		return nil, domain.ErrLimitExceeded
+	}
+	entry := domain.RefundJournal(res, amount)
+	if err := s.insertJournal(entry); err != nil {
+		return nil, err
+	}
+	acc.CurrentAmount += amount
+	refund := &domain.Refund{
+		ID:             int64(len(s.refunds) + 1),
+		ReservationID:  reservationID,
+		OwnerServiceID: ownerServiceID,
+		Amount:         amount,
+		IdempotencyKey: idempotencyKey,
+		LedgerEntryID:  entry.ID,
+		CreatedAt:      entry.CreatedAt,
+	}
+	s.refunds = append(s.refunds, refund)
+	s.refundByKey[refundKey{reservationID, idempotencyKey}] = refund
+	out := *refund
+	return &out, nil
+}
+
+// PostJournal применяет к счёту сбалансированную проводку с теми же
+// ограничениями, что и в postgres: не выше max_amount и без ухода
+// доступных средств в минус.
+func (s *BalanceStorage) PostJournal(ctx context.Context, entry *domain.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.postJournal(ctx, entry)
+}
+
+// postJournal вызывается под s.mu; проводку проверяет insertJournal.
+func (s *BalanceStorage) postJournal(ctx context.Context, entry *domain.JournalEntry) error {
+	acc, ok := s.accounts[entry.AccountID]
+	if !ok {
+		return domain.ErrNotEnoughFunds
+	}
+	if acc.Frozen {
+		return domain.ErrAccountFrozen
+	}
+	if err := s.checkVelocity(ctx); err != nil {
+		return err
+	}
+	current := acc.CurrentAmount + entry.DeltaCurrent
+	if current+acc.CreditLimit < acc.ReservedAmount || current > acc.MaxAmount {
+		return domain.ErrNotEnoughFunds
+	}
+	if err := s.insertJournal(entry); err != nil {
+		return err
+	}
+	acc.CurrentAmount = current
+	return nil
+}
+
+func (s *BalanceStorage) ListJournal(ctx context.Context, accountID int64, limit int) ([]domain.JournalEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []domain.JournalEntry
+	for i := len(s.entries) - 1; i >= 0 && len(out) < limit; i-- {
+		e := s.entries[i]
+		if e.AccountID != accountID {
+			continue
+		}
+		out = append(out, s.copyEntry(e))
+	}
+	return out, nil
+}
+
+func (s *BalanceStorage) GetEntry(ctx context.Context, entryID int64) (*domain.JournalEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entryID <= 0 || entryID > int64(len(s.entries)) {
+		return nil, domain.ErrNotFound
+	}
+	out := s.copyEntry(s.entries[entryID-1])
+	return &out, nil
+}
+
+func (s *BalanceStorage) ReverseEntry(ctx context.Context, entryID, actorServiceID int64, reasonCode, description string) (*domain.JournalEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.reverseEntry(ctx, entryID, actorServiceID, reasonCode, description, false)
+}
+
+func (s *BalanceStorage) ReverseReservation(ctx context.Context, reservationID, ownerServiceID int64, reasonCode, description string) (*domain.JournalEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.reservations[reservationID]
+	if !ok || res.OwnerServiceID != ownerServiceID {
+		return nil, domain.ErrNotFound
+	}
+	if res.Status != "CONFIRMED" {
+		return nil, domain.ErrNotReversible
+	}
+	confirm := s.confirmEntry(reservationID)
+	if confirm == nil {
+		return nil, domain.ErrNotFound
+	}
+	return s.reverseEntry(ctx, confirm.ID, ownerServiceID, reasonCode, description, true)
+}
+
+// SnapshotBalances ничего не делает: GetAccountAsOf в памяти снимки не использует.
+func (s *BalanceStorage) SnapshotBalances(ctx context.Context, minEntries int, settle time.Duration) (int64, error) {
+	return 0, nil
+}
+
+// reverseEntry вызывается под s.mu; подтверждение резерва сторнируется
+// только при reservation — через ReverseReservation.
+func (s *BalanceStorage) reverseEntry(ctx context.Context, entryID, actorServiceID int64, reasonCode, description string, reservation bool) (*domain.JournalEntry, error) {
+	if entryID <= 0 || entryID > int64(len(s.entries)) {
+		return nil, domain.ErrNotFound
+	}
+	orig := s.entries[entryID-1]
+	if _, ok := s.reversedBy[entryID]; ok {
+		return nil, domain.ErrAlreadyReversed
+	}
+	if orig.Operation == domain.OpReserveConfirm && !reservation {
+		return nil, domain.ErrNotReversible
+	}
+	if orig.Operation == domain.OpReserveConfirm {
+		// После возвратов сторно подтверждения вернуло бы больше, чем списано
+		for _, r := range s.refunds {
+			if r.ReservationID == orig.ReservationID {
+				return nil, domain.ErrNotReversible
+			}
+		}
+	}
+
+	rev, err := domain.ReversalJournal(orig, actorServiceID, reasonCode, description)
+	if err != nil {
+		return nil, err
+	}
+
+	acc, ok := s.accounts[rev.AccountID]
+	if !ok {
+		return nil, domain.ErrNotEnoughFunds
+	}
+	if acc.Frozen {
+		return nil, domain.ErrAccountFrozen
+	}
+	if err := s.checkVelocity(ctx); err != nil {
+		return nil, err
+	}
+	next := *acc
+	next.CurrentAmount += rev.DeltaCurrent
+	next.MaxAmount += rev.DeltaMax
+	next.CreditLimit += rev.DeltaCredit
+	if next.CreditLimit < 0 || next.Available() < 0 || next.CurrentAmount > next.MaxAmount {
+		return nil, domain.ErrNotEnoughFunds
+	}
+	if err := s.insertJournal(rev); err != nil {
+		return nil, err
+	}
+	*acc = next
+	out := s.copyEntry(rev)
+	return &out, nil
+}
+
+// insertJournal добавляет проводку в журнал; вызывается под s.mu до
+// изменения счёта и резерва, которые она описывает.
+func (s *BalanceStorage) insertJournal(e *domain.JournalEntry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	e.ID = int64(len(s.entries) + 1)
+	e.CreatedAt = s.now()
+	stored := *e
+	stored.Postings = append([]domain.Posting(nil), e.Postings...)
+	s.entries = append(s.entries, &stored)
+	if e.ReversalOf != 0 {
+		s.reversedBy[e.ReversalOf] = e.ID
+	}
+	return nil
+}
+
+func (s *BalanceStorage) copyEntry(e *domain.JournalEntry) domain.JournalEntry {
+	out := *e
+	out.Postings = append([]domain.Posting(nil), e.Postings...)
+	out.ReversedBy = s.reversedBy[e.ID]
+	return out
+}
+
+func (s *BalanceStorage) confirmEntry(reservationID int64) *domain.JournalEntry {
+	for _, e := range s.entries {
+		if e.ReservationID == reservationID && e.Operation == domain.OpReserveConfirm {
+			return e
+		}
+	}
+	return nil
+}
+
+func (s *BalanceStorage) confirmReversed(reservationID int64) bool {
+	confirm := s.confirmEntry(reservationID)
+	if confirm == nil {
+		return false
+	}
+	_, ok := s.reversedBy[confirm.ID]
+	return ok
+}
@@ -0,0 +1,11 @@
+package memory
+
+import (
+	"testing"
+
+	"test_nanimai/backend/internal/repository/repotest"
+)
+
+func TestBalanceStorage(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Store { return NewBalanceStorage() })
+}
@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"context"
+	"test_nanimai/backend/domain"
+	"time"
+)
+
+func (s *BalanceStorage) CreateLimitChange(ctx context.Context, c *domain.LimitChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[c.AccountID]; !ok {
+		return domain.ErrNotFound
+	}
+	c.ID = int64(len(s.limitChanges) + 1)
+	c.Status = domain.LimitChangePending
+	c.CreatedAt = s.now()
+	stored := *c
+	s.limitChanges = append(s.limitChanges, &stored)
+	return nil
+}
+
+func (s *BalanceStorage) GetLimitChange(ctx context.Context, changeID int64) (*domain.LimitChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.limitChangeByID(changeID)
+	if err != nil {
+		return nil, err
+	}
+	out := *c
+	return &out, nil
+}
+
+func (s *BalanceStorage) ListLimitChanges(ctx context.Context, status domain.LimitChangeStatus) ([]domain.LimitChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []domain.LimitChange
+	for _, c := range s.limitChanges {
+		if status == "" || c.Status == status {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+func (s *BalanceStorage) ApproveLimitChange(ctx context.Context, c *domain.LimitChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.limitChangeByID(c.ID)
+	if err != nil {
+		return err
+	}
+	if stored.Status != domain.LimitChangePending {
+		return domain.ErrNotPending
+	}
+	now := s.now()
+	if !now.Before(stored.ExpiresAt) {
+		stored.Status = domain.LimitChangeExpired
+		c.Status = stored.Status
+		return domain.ErrChangeExpired
+	}
+	approved := *stored
+	approved.DecidedBy, approved.Note = c.DecidedBy, c.Note
+	entry := approved.Journal()
+	if stored.Kind == domain.LimitKindCredit {
+		err = s.updateCreditLimit(entry)
+	} else {
+		err = s.approveLimit(entry)
+	}
+	if err != nil {
+		return err
+	}
+	approved.Status, approved.EntryID, approved.DecidedAt = domain.LimitChangeApproved, entry.ID, now
+	*stored, *c = approved, approved
+	return nil
+}
+
+// approveLimit меняет лимит на entry.DeltaMax и пишет проводку entry;
+// вызывается под s.mu.
+func (s *BalanceStorage) approveLimit(entry *domain.JournalEntry) error {
+	acc, ok := s.accounts[entry.AccountID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if acc.MaxAmount+entry.DeltaMax < 0 || acc.MaxAmount+entry.DeltaMax < acc.CurrentAmount {
+		return domain.ErrLimitExceeded
+	}
+	if err := s.insertJournal(entry); err != nil {
+		return err
+	}
+	acc.MaxAmount += entry.DeltaMax
+	return nil
+}
+
+func (s *BalanceStorage) RejectLimitChange(ctx context.Context, c *domain.LimitChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.limitChangeByID(c.ID)
+	if err != nil {
+		return err
+	}
+	if stored.Status != domain.LimitChangePending {
+		return domain.ErrNotPending
+	}
+	stored.Status, stored.DecidedBy, stored.Note, stored.DecidedAt = domain.LimitChangeRejected, c.DecidedBy, c.Note, s.now()
+	c.Status, c.DecidedAt = stored.Status, stored.DecidedAt
+	return nil
+}
+
+func (s *BalanceStorage) IncreaseLimit(ctx context.Context, accountID, delta int64, policy domain.LimitPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkLimitIncrease(domain.LimitKindMax, accountID, delta, policy); err != nil {
+		return err
+	}
+	return s.updateLimit(accountID, delta)
+}
+
+func (s *BalanceStorage) IncreaseCreditLimit(ctx context.Context, actorServiceID, accountID, delta int64, policy domain.LimitPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkLimitIncrease(domain.LimitKindCredit, accountID, delta, policy); err != nil {
+		return err
+	}
+	entry := domain.CreditLimitJournal(accountID, delta)
+	entry.ActorServiceID = actorServiceID
+	return s.updateCreditLimit(entry)
+}
+
+// checkLimitIncrease возвращает domain.ErrApprovalRequired, если увеличение
+// лимита вида kind на delta требует подтверждения; вызывается под s.mu.
+func (s *BalanceStorage) checkLimitIncrease(kind domain.LimitKind, accountID, delta int64, policy domain.LimitPolicy) error {
+	if _, ok := s.accounts[accountID]; !ok {
+		return domain.ErrNotFound
+	}
+	op := domain.OpLimitIncrease
+	if kind == domain.LimitKindCredit {
+		op = domain.OpCreditIncrease
+	}
+	now := s.now()
+	approved := make(map[int64]bool)
+	var recent int64
+	for _, c := range s.limitChanges {
+		switch {
+		case c.AccountID != accountID || c.Kind != kind:
+		case c.EntryID != 0:
+			approved[c.EntryID] = true
+		case c.Status == domain.LimitChangePending && now.Before(c.ExpiresAt):
+			recent += c.Delta
+		}
+	}
+	since := now.Add(-policy.Window)
+	for i := len(s.entries) - 1; i >= 0 && s.entries[i].CreatedAt.After(since); i-- {
+		e := s.entries[i]
+		if e.AccountID == accountID && e.Operation == op && !approved[e.ID] {
+			recent += e.DeltaMax + e.DeltaCredit
+		}
+	}
+	if policy.RequiresApproval(recent, delta) {
+		return domain.ErrApprovalRequired
+	}
+	return nil
+}
+
+func (s *BalanceStorage) ExpireLimitChanges(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for _, c := range s.limitChanges {
+		if c.Status == domain.LimitChangePending && !now.Before(c.ExpiresAt) {
+			c.Status = domain.LimitChangeExpired
+			n++
+		}
+	}
+	return n, nil
+}
+
+// limitChangeByID вызывается под s.mu.
+func (s *BalanceStorage) limitChangeByID(changeID int64) (*domain.LimitChange, error) {
+	if changeID <= 0 || changeID > int64(len(s.limitChanges)) {
+		return nil, domain.ErrNotFound
+	}
+	return s.limitChanges[changeID-1], nil
+}
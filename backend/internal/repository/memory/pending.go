@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"test_nanimai/backend/domain"
+)
+
+func (s *BalanceStorage) CreatePendingOperation(ctx context.Context, op *domain.PendingOperation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[op.AccountID]; !ok {
+		return domain.ErrNotFound
+	}
+	if op.Kind == domain.OperationReservation {
+		for _, p := range s.pending {
+			if p.Kind == op.Kind && p.ServiceID == op.ServiceID && p.IdempotencyKey == op.IdempotencyKey {
+				*op = clonePending(p)
+				return nil
+			}
+		}
+	}
+	op.ID = int64(len(s.pending) + 1)
+	op.Status = domain.PendingWaiting
+	op.CreatedAt = s.now()
+	stored := clonePending(op)
+	s.pending = append(s.pending, &stored)
+	return nil
+}
+
+func (s *BalanceStorage) GetPendingOperation(ctx context.Context, operationID int64) (*domain.PendingOperation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.pendingByID(operationID)
+	if err != nil {
+		return nil, err
+	}
+	out := clonePending(p)
+	return &out, nil
+}
+
+func (s *BalanceStorage) ListPendingOperations(ctx context.Context, status domain.PendingStatus) ([]domain.PendingOperation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []domain.PendingOperation
+	for _, p := range s.pending {
+		if status == "" || p.Status == status {
+			out = append(out, clonePending(p))
+		}
+	}
+	return out, nil
+}
+
+func (s *BalanceStorage) ApprovePendingOperation(ctx context.Context, op *domain.PendingOperation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.pendingByID(op.ID)
+	if err != nil {
+		return err
+	}
+	if p.Status != domain.PendingWaiting {
+		return domain.ErrNotPending
+	}
+	decidedBy, note := op.DecidedBy, op.Note
+	*op = clonePending(p)
+	op.DecidedBy, op.Note = decidedBy, note
+
+	// Выполнение либо меняет счёт целиком, либо не меняет ничего
+	execErr := s.executePending(ctx, op)
+	op.Status = domain.PendingApproved
+	if execErr != nil {
+		op.Status, op.Error = domain.PendingFailed, execErr.Error()
+	}
+	op.DecidedAt = s.now()
+	p.Status, p.DecidedBy, p.Note, p.DecidedAt = op.Status, op.DecidedBy, op.Note, op.DecidedAt
+	p.ReservationID, p.EntryID, p.Error = op.ReservationID, op.EntryID, op.Error
+	return execErr
+}
+
+// executePending вызывается под s.mu.
+func (s *BalanceStorage) executePending(ctx context.Context, op *domain.PendingOperation) error {
+	switch op.Kind {
+	case domain.OperationReservation:
+		res, err := s.openReservation(ctx, op.ServiceID, op.AccountID, op.Amount, op.IdempotencyKey, op.Timeout)
+		if err != nil {
+			return err
+		}
+		op.ReservationID = res.ID
+		return nil
+	case domain.OperationReversal:
+		rev, err := s.reverseEntry(ctx, op.ReversalOf, op.ServiceID, op.ReasonCode, op.Description, false)
+		if err != nil {
+			return err
+		}
+		op.EntryID = rev.ID
+		return nil
+	}
+	entry := op.Journal()
+	if entry == nil {
+		return fmt.Errorf("unknown operation kind %q", op.Kind)
+	}
+	if err := s.postJournal(ctx, entry); err != nil {
+		return err
+	}
+	op.EntryID = entry.ID
+	return nil
+}
+
+func (s *BalanceStorage) ResolvePendingOperation(ctx context.Context, op *domain.PendingOperation, from domain.PendingStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.pendingByID(op.ID)
+	if err != nil {
+		return err
+	}
+	if p.Status != from {
+		return domain.ErrNotPending
+	}
+	op.DecidedAt = s.now()
+	p.Status, p.DecidedBy, p.Note, p.DecidedAt = op.Status, op.DecidedBy, op.Note, op.DecidedAt
+	p.ReservationID, p.EntryID, p.Error = op.ReservationID, op.EntryID, op.Error
+	return nil
+}
+
+// pendingByID вызывается под s.mu.
+func (s *BalanceStorage) pendingByID(operationID int64) (*domain.PendingOperation, error) {
+	if operationID <= 0 || operationID > int64(len(s.pending)) {
+		return nil, domain.ErrNotFound
+	}
+	return s.pending[operationID-1], nil
+}
+
+func clonePending(p *domain.PendingOperation) domain.PendingOperation {
+	out := *p
+	out.Postings = slices.Clone(p.Postings)
+	return out
+}
@@ -0,0 +1,223 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"test_nanimai/backend/domain"
+	"time"
+)
+
+// CreateService регистрирует внешний сервис с бессрочным API-ключом apiKey.
+func (s *BalanceStorage) CreateService(ctx context.Context, name, apiKey string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, svc := range s.services {
+		if svc.Name == name {
+			return 0, domain.ErrServiceExists
+		}
+	}
+	id := int64(len(s.services) + 1)
+	s.services[id] = &domain.Service{
+		ID:          id,
+		Name:        name,
+		Permissions: slices.Clone(domain.DefaultPermissions),
+		SigningMode: domain.SigningOff,
+	}
+	s.insertAPIKey(&domain.APIKey{ServiceID: id, Prefix: domain.APIKeyPrefix(apiKey), Hash: domain.HashAPIKey(apiKey)})
+	return id, nil
+}
+
+func (s *BalanceStorage) AuthenticateAPIKey(ctx context.Context, apiKey string) (*domain.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	prefix := domain.APIKeyPrefix(apiKey)
+	for _, key := range s.apiKeys {
+		if key.Prefix == prefix && key.Active(now) && key.Matches(apiKey) {
+			key.LastUsedAt = now
+			return cloneService(s.services[key.ServiceID]), nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (s *BalanceStorage) AuthenticateCertificate(ctx context.Context, identities []string) (*domain.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found *domain.Service
+	for id := int64(1); id <= int64(len(s.services)); id++ {
+		svc := s.services[id]
+		if !slices.ContainsFunc(identities, func(name string) bool { return slices.Contains(svc.CertIdentities, name) }) {
+			continue
+		}
+		if found != nil {
+			return nil, domain.ErrAmbiguousIdentity
+		}
+		found = svc
+	}
+	if found == nil {
+		return nil, domain.ErrNotFound
+	}
+	return cloneService(found), nil
+}
+
+func (s *BalanceStorage) SetServicePermissions(ctx context.Context, serviceID int64, permissions []domain.Permission) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc, ok := s.services[serviceID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	svc.Permissions = slices.Clone(permissions)
+	return nil
+}
+
+func (s *BalanceStorage) SetServiceScope(ctx context.Context, serviceID int64, scope domain.AccountScope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc, ok := s.services[serviceID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	svc.Scope = domain.AccountScope{AccountIDs: slices.Clone(scope.AccountIDs), Tags: slices.Clone(scope.Tags)}
+	return nil
+}
+
+func (s *BalanceStorage) SetServiceSigning(ctx context.Context, serviceID int64, mode domain.SigningMode, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc, ok := s.services[serviceID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	svc.SigningMode, svc.SigningSecret = mode, secret
+	return nil
+}
+
+func (s *BalanceStorage) SetServiceRateLimit(ctx context.Context, serviceID int64, limit domain.RateLimit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc, ok := s.services[serviceID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	svc.RateLimit = limit
+	return nil
+}
+
+func (s *BalanceStorage) SetServiceCertIdentities(ctx context.Context, serviceID int64, identities []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc, ok := s.services[serviceID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	for id, other := range s.services {
+		if id == serviceID {
+			continue
+		}
+		for _, name := range identities {
+			if slices.Contains(other.CertIdentities, name) {
+				return domain.ErrIdentityInUse
+			}
+		}
+	}
+	svc.CertIdentities = slices.Clone(identities)
+	return nil
+}
+
+func (s *BalanceStorage) ListServices(ctx context.Context) ([]domain.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]domain.Service, 0, len(s.services))
+	for id := int64(1); id <= int64(len(s.services)); id++ {
+		out = append(out, *cloneService(s.services[id]))
+	}
+	return out, nil
+}
+
+func (s *BalanceStorage) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.services[key.ServiceID]; !ok {
+		return domain.ErrNotFound
+	}
+	s.insertAPIKey(key)
+	return nil
+}
+
+func (s *BalanceStorage) RotateAPIKey(ctx context.Context, key *domain.APIKey, overlap time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.services[key.ServiceID]; !ok {
+		return domain.ErrNotFound
+	}
+	now := s.now()
+	deadline := now.Add(overlap)
+	for _, k := range s.apiKeys {
+		if k.ServiceID == key.ServiceID && k.Active(now) && (k.ExpiresAt.IsZero() || k.ExpiresAt.After(deadline)) {
+			k.ExpiresAt = deadline
+		}
+	}
+	s.insertAPIKey(key)
+	return nil
+}
+
+func (s *BalanceStorage) ListAPIKeys(ctx context.Context, serviceID int64) ([]domain.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.services[serviceID]; !ok {
+		return nil, domain.ErrNotFound
+	}
+	var out []domain.APIKey
+	for _, k := range s.apiKeys {
+		if k.ServiceID == serviceID {
+			out = append(out, *k)
+		}
+	}
+	return out, nil
+}
+
+func (s *BalanceStorage) RevokeAPIKey(ctx context.Context, serviceID, keyID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if keyID <= 0 || keyID > int64(len(s.apiKeys)) || s.apiKeys[keyID-1].ServiceID != serviceID {
+		return domain.ErrNotFound
+	}
+	key := s.apiKeys[keyID-1]
+	if !key.RevokedAt.IsZero() {
+		return domain.ErrKeyRevoked
+	}
+	key.RevokedAt = s.now()
+	return nil
+}
+
+// insertAPIKey сохраняет ключ и заполняет ID и CreatedAt; вызывается под s.mu.
+func (s *BalanceStorage) insertAPIKey(key *domain.APIKey) {
+	key.ID = int64(len(s.apiKeys) + 1)
+	key.CreatedAt = s.now()
+	stored := *key
+	s.apiKeys = append(s.apiKeys, &stored)
+}
+
+// cloneService копирует сервис вместе со срезами, чтобы вызывающий не менял хранилище.
+func cloneService(svc *domain.Service) *domain.Service {
+	out := *svc
+	out.Permissions = slices.Clone(svc.Permissions)
+	out.Scope = domain.AccountScope{AccountIDs: slices.Clone(svc.Scope.AccountIDs), Tags: slices.Clone(svc.Scope.Tags)}
+	out.CertIdentities = slices.Clone(svc.CertIdentities)
+	return &out
+}
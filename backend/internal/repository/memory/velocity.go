@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/repository"
+	"time"
+)
+
+func (s *BalanceStorage) AccountVelocityRules(ctx context.Context, accountID int64) ([]domain.VelocityRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.accountVelocityRules(accountID), nil
+}
+
+// accountVelocityRules вызывается под s.mu.
+func (s *BalanceStorage) accountVelocityRules(accountID int64) []domain.VelocityRule {
+	tags := s.accountTags[accountID]
+	var out []domain.VelocityRule
+	for _, r := range s.sortedRules() {
+		if r.AccountID == accountID || (r.Tag != "" && slices.Contains(tags, r.Tag)) {
+			out = append(out, *r)
+		}
+	}
+	return out
+}
+
+func (s *BalanceStorage) VelocityUsage(ctx context.Context, accountID int64, window time.Duration) (domain.VelocityUsage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.velocityUsage(accountID, window), nil
+}
+
+// velocityUsage идёт по журналу с конца: проводки добавляются в порядке
+// времени. Вызывается под s.mu.
+func (s *BalanceStorage) velocityUsage(accountID int64, window time.Duration) domain.VelocityUsage {
+	since := s.now().Add(-window)
+	var usage domain.VelocityUsage
+	for i := len(s.entries) - 1; i >= 0 && s.entries[i].CreatedAt.After(since); i-- {
+		e := s.entries[i]
+		if e.AccountID != accountID {
+			continue
+		}
+		switch e.Operation {
+		case domain.OpBalanceDecrease:
+			usage.DebitAmount -= e.DeltaCurrent
+			usage.Debits++
+		case domain.OpReserveOpen:
+			usage.DebitAmount += e.DeltaReserved
+			usage.Debits++
+			usage.Reservations++
+		case domain.OpAdjustment, domain.OpReversal:
+			if e.DeltaCurrent < 0 {
+				usage.DebitAmount -= e.DeltaCurrent
+				usage.Debits++
+			}
+		}
+	}
+	return usage
+}
+
+// checkVelocity проверяет списание, заданное в ctx через
+// repository.WithVelocityCheck, по правилам счёта; вызывается под s.mu,
+// поэтому параллельные списания проверяются по очереди.
+func (s *BalanceStorage) checkVelocity(ctx context.Context) error {
+	op, ok := repository.VelocityCheck(ctx)
+	if !ok {
+		return nil
+	}
+	v, _ := domain.CheckVelocity(s.accountVelocityRules(op.AccountID), op, func(window time.Duration) (domain.VelocityUsage, error) {
+		return s.velocityUsage(op.AccountID, window), nil
+	})
+	if v != nil {
+		return v
+	}
+	return nil
+}
+
+func (s *BalanceStorage) CreateVelocityRule(ctx context.Context, rule *domain.VelocityRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[rule.AccountID]; rule.AccountID != 0 && !ok {
+		return domain.ErrNotFound
+	}
+	s.lastRuleID++
+	rule.ID = s.lastRuleID
+	rule.CreatedAt = s.now()
+	stored := *rule
+	s.rules[rule.ID] = &stored
+	return nil
+}
+
+func (s *BalanceStorage) ListVelocityRules(ctx context.Context) ([]domain.VelocityRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]domain.VelocityRule, 0, len(s.rules))
+	for _, r := range s.sortedRules() {
+		out = append(out, *r)
+	}
+	return out, nil
+}
+
+func (s *BalanceStorage) DeleteVelocityRule(ctx context.Context, ruleID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rules[ruleID]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(s.rules, ruleID)
+	return nil
+}
+
+// sortedRules возвращает правила по возрастанию ID; вызывается под s.mu.
+func (s *BalanceStorage) sortedRules() []*domain.VelocityRule {
+	out := make([]*domain.VelocityRule, 0, len(s.rules))
+	for _, r := range s.rules {
+		out = append(out, r)
+	}
+	slices.SortFunc(out, func(a, b *domain.VelocityRule) int { return cmp.Compare(a.ID, b.ID) })
+	return out
+}
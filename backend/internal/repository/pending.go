@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"test_nanimai/backend/domain"
+)
+
+// PendingOperations — операции, отложенные проверкой риска до решения оператора.
+type PendingOperations interface {
+	// CreatePendingOperation сохраняет операцию в статусе domain.PendingWaiting;
+	// заполняет ID, Status и CreatedAt. Если у сервиса уже есть отложенный
+	// резерв с тем же ключом идемпотентности, ничего не создаёт и заменяет
+	// op сохранённой операцией в её текущем статусе.
+	CreatePendingOperation(ctx context.Context, op *domain.PendingOperation) error
+	// GetPendingOperation возвращает операцию; нет такой — domain.ErrNotFound.
+	GetPendingOperation(ctx context.Context, operationID int64) (*domain.PendingOperation, error)
+	// ListPendingOperations возвращает операции в статусе status (пустой —
+	// во всех) по возрастанию ID.
+	ListPendingOperations(ctx context.Context, status domain.PendingStatus) ([]domain.PendingOperation, error)
+	// ApprovePendingOperation в одной транзакции перечитывает op под
+	// блокировкой, выполняет её без проверки риска так, как выполнил бы
+	// сервис баланса (резерв — со сроком от одобрения), и переводит из
+	// domain.PendingWaiting в domain.PendingApproved с op.DecidedBy и op.Note;
+	// заполняет ReservationID или EntryID, Status и DecidedAt. Если выполнить
+	// не удалось, выполнение откатывается, операция в той же транзакции
+	// переходит в domain.PendingFailed с причиной в op.Error и возвращается
+	// ошибка выполнения. Нет такой операции — domain.ErrNotFound, уже
+	// решённая — domain.ErrNotPending.
+	ApprovePendingOperation(ctx context.Context, op *domain.PendingOperation) error
+	// ResolvePendingOperation сохраняет op.Status, DecidedBy, Note,
+	// ReservationID, EntryID и Error, если операция ещё в статусе from, и
+	// заполняет DecidedAt. Нет такой операции — domain.ErrNotFound, статус
+	// уже другой — domain.ErrNotPending.
+	ResolvePendingOperation(ctx context.Context, op *domain.PendingOperation, from domain.PendingStatus) error
+}
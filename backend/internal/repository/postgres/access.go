@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+// AccountTags возвращает теги счёта.
+func (s *BalanceStorage) AccountTags(ctx context.Context, accountID int64) (_ []string, err error) {
+	ctx, span := tracing.StartDB(ctx, "AccountTags", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	var tags []string
+	err = s.db.QueryRowContext(ctx, "SELECT tags FROM accounts WHERE id = $1", accountID).Scan(pq.Array(&tags))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return tags, err
+}
+
+// ReservationAccountID возвращает счёт резерва.
+func (s *BalanceStorage) ReservationAccountID(ctx context.Context, reservationID int64) (_ int64, err error) {
+	ctx, span := tracing.StartDB(ctx, "ReservationAccountID", tracing.ReservationID(reservationID))
+	defer func() { tracing.End(span, err) }()
+
+	var accountID int64
+	err = s.db.QueryRowContext(ctx, "SELECT account_id FROM reservations WHERE id = $1", reservationID).Scan(&accountID)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return accountID, err
+}
+
+// ReservationOwnerID возвращает сервис, открывший резерв.
+func (s *BalanceStorage) ReservationOwnerID(ctx context.Context, reservationID int64) (_ int64, err error) {
+	ctx, span := tracing.StartDB(ctx, "ReservationOwnerID", tracing.ReservationID(reservationID))
+	defer func() { tracing.End(span, err) }()
+
+	var ownerID int64
+	err = s.db.QueryRowContext(ctx, "SELECT owner_service_id FROM reservations WHERE id = $1", reservationID).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return ownerID, err
+}
+
+// EntryAccountID возвращает счёт проводки.
+func (s *BalanceStorage) EntryAccountID(ctx context.Context, entryID int64) (_ int64, err error) {
+	ctx, span := tracing.StartDB(ctx, "EntryAccountID", tracing.EntryID(entryID))
+	defer func() { tracing.End(span, err) }()
+
+	var accountID int64
+	err = s.db.QueryRowContext(ctx, "SELECT account_id FROM ledger WHERE id = $1", entryID).Scan(&accountID)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return accountID, err
+}
+
+// EntryPostings возвращает строки проводки.
+func (s *BalanceStorage) EntryPostings(ctx context.Context, entryID int64) (_ []domain.Posting, err error) {
+	ctx, span := tracing.StartDB(ctx, "EntryPostings", tracing.EntryID(entryID))
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.EntryAccountID(ctx, entryID); err != nil {
+		return nil, err
+	}
+	var postings []domain.Posting
+	err = loadPostings(ctx, s.db, []int64{entryID}, func(_ int64, p domain.Posting) {
+		postings = append(postings, p)
+	})
+	return postings, err
+}
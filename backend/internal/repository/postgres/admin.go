@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+// SetAccountFrozen замораживает или размораживает счёт.
+func (s *BalanceStorage) SetAccountFrozen(ctx context.Context, accountID int64, frozen bool) (err error) {
+	ctx, span := tracing.StartDB(ctx, "SetAccountFrozen", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	cmd, err := s.db.ExecContext(ctx, "UPDATE accounts SET frozen = $1 WHERE id = $2", frozen, accountID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := cmd.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetAccountTags заменяет теги счёта.
+func (s *BalanceStorage) SetAccountTags(ctx context.Context, accountID int64, tags []string) (err error) {
+	ctx, span := tracing.StartDB(ctx, "SetAccountTags", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	cmd, err := s.db.ExecContext(ctx, "UPDATE accounts SET tags = $1 WHERE id = $2", pq.Array(nonNil(tags)), accountID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := cmd.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListAccountIDs возвращает ID всех счетов по возрастанию.
+func (s *BalanceStorage) ListAccountIDs(ctx context.Context) (_ []int64, err error) {
+	ctx, span := tracing.StartDB(ctx, "ListAccountIDs")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id FROM accounts ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CloseReservation принудительно отменяет (domain.OpReserveCancel) или
+// истекает (domain.OpReserveExpire) ACTIVE-резерв любого владельца.
+// Проводка пишется без сервиса-автора, reason попадает в её описание.
+func (s *BalanceStorage) CloseReservation(ctx context.Context, reservationID int64, op, reason string) (_ *domain.JournalEntry, err error) {
+	ctx, span := tracing.StartDB(ctx, "CloseReservation", tracing.ReservationID(reservationID))
+	defer func() { tracing.End(span, err) }()
+
+	status := "CANCELLED"
+	if op == domain.OpReserveExpire {
+		status = "EXPIRED"
+	}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res := domain.Reservation{ID: reservationID}
+	err = tx.QueryRowContext(ctx, `
+		SELECT account_id, owner_service_id, amount, status
+		FROM reservations
+		WHERE id = $1
+		FOR UPDATE
+	`, reservationID).Scan(&res.AccountID, &res.OwnerServiceID, &res.Amount, &res.Status)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	span.SetAttributes(tracing.AccountID(res.AccountID))
+
+	if res.Status != "ACTIVE" {
+		return nil, ErrNotActive
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE accounts
+		SET reserved_amount = reserved_amount - $1
+		WHERE id = $2
+	`, res.Amount, res.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE reservations
+		SET status = $1
+		WHERE id = $2
+	`, status, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := domain.ReservationJournal(op, &res)
+	entry.ActorServiceID = 0
+	entry.Description = reason
+	if err := insertJournal(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -3,26 +3,51 @@ package postgres
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"sync"
 	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/tracing"
 	"time"
 )
 
 var (
-	ErrNotEnoughFunds = errors.New("not enough funds")
-	ErrNotFound       = errors.New("not found")
-	ErrForbidden      = errors.New("forbidden")
-	ErrExpired        = errors.New("reservation expired")
+	ErrNotEnoughFunds = domain.ErrNotEnoughFunds
+	ErrNotFound       = domain.ErrNotFound
+	ErrForbidden      = domain.ErrForbidden
+	ErrExpired        = domain.ErrExpired
+	ErrNotActive      = domain.ErrNotActive
 )
 
 type BalanceStorage struct {
 	db *sql.DB
+
+	// lastUsed — ID ключа → когда экземпляр последний раз писал его
+	// api_keys.last_used_at (см. AuthenticateAPIKey).
+	lastUsed sync.Map
 }
 
 func (bs *BalanceStorage) GetDb() *sql.DB {
 	return bs.db
 }
 
+// Close закрывает пул соединений, дождавшись завершения текущих запросов.
+func (bs *BalanceStorage) Close() error {
+	return bs.db.Close()
+}
+
+// Ping проверяет доступность БД.
+func (bs *BalanceStorage) Ping(ctx context.Context) error {
+	return bs.db.PingContext(ctx)
+}
+
+// MigrationVersion возвращает текущую версию схемы из таблицы golang-migrate
+// и признак незавершённой (dirty) миграции.
+func (bs *BalanceStorage) MigrationVersion(ctx context.Context) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := bs.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	return version, dirty, err
+}
+
 func NewBalanceStorage(connStr string) (*BalanceStorage, error) {
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
@@ -40,41 +65,156 @@ func NewBalanceStorage(connStr string) (*BalanceStorage, error) {
 	return &BalanceStorage{db: db}, nil
 }
 
-func (s *BalanceStorage) UpdateLimit(ctx context.Context, accountID int64, delta int64) error {
-	_, err := s.db.ExecContext(ctx, `
-		UPDATE accounts
-		SET max_amount = max_amount + $1
-		WHERE id = $2
-	`, delta, accountID)
-	return err
+// CreateAccount заводит счёт с нулевым балансом и начальным лимитом maxAmount.
+func (s *BalanceStorage) CreateAccount(ctx context.Context, userID, maxAmount int64) (_ *domain.Account, err error) {
+	ctx, span := tracing.StartDB(ctx, "CreateAccount")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	acc := domain.Account{UserID: userID, MaxAmount: maxAmount}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO accounts (user_id, max_amount)
+		VALUES ($1, $2)
+		RETURNING id
+	`, userID, maxAmount).Scan(&acc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Начальный лимит проводим через журнал, чтобы баланс на момент времени сходился
+	if maxAmount != 0 {
+		if err := insertJournal(ctx, tx, domain.LimitJournal(acc.ID, maxAmount)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+func (s *BalanceStorage) GetAccount(ctx context.Context, accountID int64) (_ *domain.Account, err error) {
+	ctx, span := tracing.StartDB(ctx, "GetAccount", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	var acc domain.Account
+	err = s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, current_amount, max_amount, reserved_amount, credit_limit, frozen
+		FROM accounts
+		WHERE id = $1
+	`, accountID).Scan(
+		&acc.ID, &acc.UserID, &acc.CurrentAmount, &acc.MaxAmount, &acc.ReservedAmount, &acc.CreditLimit, &acc.Frozen,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+func (s *BalanceStorage) UpdateLimit(ctx context.Context, accountID int64, delta int64) (err error) {
+	ctx, span := tracing.StartDB(ctx, "UpdateLimit", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updateLimit(ctx, tx, accountID, delta); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (s *BalanceStorage) UpdateBalance(ctx context.Context, accountID int64, delta int64) error {
-	// Запрет уйти ниже 0 и выше max_amount
-	cmd, err := s.db.ExecContext(ctx, `
+// updateLimit блокирует счёт, меняет лимит и пишет проводку в tx.
+func updateLimit(ctx context.Context, tx *sql.Tx, accountID, delta int64) error {
+	var current, maxAmount int64
+	err := tx.QueryRowContext(ctx, `
+		SELECT current_amount, max_amount
+		FROM accounts
+		WHERE id = $1
+		FOR UPDATE
+	`, accountID).Scan(&current, &maxAmount)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	// Лимит не может стать отрицательным или меньше текущего баланса
+	if maxAmount+delta < 0 || maxAmount+delta < current {
+		return domain.ErrLimitExceeded
+	}
+
+	_, err = tx.ExecContext(ctx, `
 		UPDATE accounts
-		SET current_amount = current_amount + $1
+		SET max_amount = max_amount + $1
 		WHERE id = $2
-		  AND (current_amount + $1) >= 0
-		  AND (current_amount + $1) <= max_amount
 	`, delta, accountID)
 	if err != nil {
 		return err
 	}
-	rows, _ := cmd.RowsAffected()
-	if rows == 0 {
-		return ErrNotEnoughFunds
-	}
-	return nil
+
+	return insertJournal(ctx, tx, domain.LimitJournal(accountID, delta))
+}
+
+func (s *BalanceStorage) UpdateBalance(ctx context.Context, accountID int64, delta int64) (err error) {
+	ctx, span := tracing.StartDB(ctx, "UpdateBalance", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	// Запрет уйти ниже зарезервированного с учётом кредита и выше max_amount проверяет PostJournal
+	return s.PostJournal(ctx, domain.BalanceJournal(accountID, delta))
 }
 
-func (r *BalanceStorage) OpenReservation(ctx context.Context, ownerServiceID, accountID int64, amount int64, idempotencyKey string, timeout time.Duration) (*domain.Reservation, error) {
+func (r *BalanceStorage) OpenReservation(ctx context.Context, ownerServiceID, accountID int64, amount int64, idempotencyKey string, timeout time.Duration) (_ *domain.Reservation, err error) {
+	ctx, span := tracing.StartDB(ctx, "OpenReservation", tracing.AccountID(accountID), tracing.ServiceID(ownerServiceID))
+	defer func() { tracing.End(span, err) }()
+
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
+	res, err := openReservation(ctx, tx, ownerServiceID, accountID, amount, idempotencyKey, timeout)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(tracing.ReservationID(res.ID))
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// openReservation открывает резерв в tx; повтор с тем же ключом
+// возвращает уже открытый.
+func openReservation(ctx context.Context, tx *sql.Tx, ownerServiceID, accountID int64, amount int64, idempotencyKey string, timeout time.Duration) (*domain.Reservation, error) {
+	// Блокируем аккаунт
+	var acc domain.Account
+	err := tx.QueryRowContext(ctx, `
+		SELECT id, user_id, current_amount, max_amount, reserved_amount, credit_limit, frozen
+		FROM accounts
+		WHERE id = $1
+		FOR UPDATE
+	`, accountID).Scan(
+		&acc.ID, &acc.UserID, &acc.CurrentAmount, &acc.MaxAmount, &acc.ReservedAmount, &acc.CreditLimit, &acc.Frozen,
+	)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	// Ключ проверяем под блокировкой счёта: параллельные повторы одного
+	// запроса ждут первый и получают созданный им резерв
 	var existing domain.Reservation
 	err = tx.QueryRowContext(ctx, `
 		SELECT id, account_id, owner_service_id, amount, status, idempotency_key, expires_at, created_at
@@ -89,21 +229,13 @@ func (r *BalanceStorage) OpenReservation(ctx context.Context, ownerServiceID, ac
 		return &existing, nil
 	}
 
-	// Блокируем аккаунт
-	var acc domain.Account
-	err = tx.QueryRowContext(ctx, `
-		SELECT id, user_id, current_amount, max_amount, reserved_amount
-		FROM accounts
-		WHERE id = $1
-		FOR UPDATE
-	`, accountID).Scan(
-		&acc.ID, &acc.UserID, &acc.CurrentAmount, &acc.MaxAmount, &acc.ReservedAmount,
-	)
-	if err != nil {
-		return nil, ErrNotFound
+	if acc.Frozen {
+		return nil, domain.ErrAccountFrozen
 	}
-
-	if (acc.CurrentAmount - acc.ReservedAmount) < amount {
+	if err := checkVelocity(ctx, tx); err != nil {
+		return nil, err
+	}
+	if acc.Available() < amount {
 		return nil, ErrNotEnoughFunds
 	}
 
@@ -131,14 +263,17 @@ func (r *BalanceStorage) OpenReservation(ctx context.Context, ownerServiceID, ac
 		return nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := insertJournal(ctx, tx, domain.ReservationJournal(domain.OpReserveOpen, &res)); err != nil {
 		return nil, err
 	}
 	return &res, nil
 }
 
 // 4. Подтверждение транзакции
-func (r *BalanceStorage) ConfirmReservation(ctx context.Context, reservationID int64, ownerServiceID int64) error {
+func (r *BalanceStorage) ConfirmReservation(ctx context.Context, reservationID int64, ownerServiceID int64) (err error) {
+	ctx, span := tracing.StartDB(ctx, "ConfirmReservation", tracing.ReservationID(reservationID), tracing.ServiceID(ownerServiceID))
+	defer func() { tracing.End(span, err) }()
+
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
 		return err
@@ -158,13 +293,17 @@ func (r *BalanceStorage) ConfirmReservation(ctx context.Context, reservationID i
 	if err != nil {
 		return ErrNotFound
 	}
+	span.SetAttributes(tracing.AccountID(accID))
 
 	if status != "ACTIVE" {
-		return errors.New("reservation not active")
+		return ErrNotActive
 	}
 	if time.Now().After(expiresAt) {
 		return ErrExpired
 	}
+	if err := checkNotFrozen(ctx, tx, accID); err != nil {
+		return err
+	}
 
 	// Списываем средства и уменьшаем reserved_amount
 	_, err = tx.ExecContext(ctx, `
@@ -187,11 +326,19 @@ func (r *BalanceStorage) ConfirmReservation(ctx context.Context, reservationID i
 		return err
 	}
 
+	res := &domain.Reservation{ID: reservationID, AccountID: accID, OwnerServiceID: ownerServiceID, Amount: amount}
+	if err := insertJournal(ctx, tx, domain.ReservationJournal(domain.OpReserveConfirm, res)); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
 // 5. Отмена транзакции
-func (r *BalanceStorage) CancelReservation(ctx context.Context, reservationID int64, ownerServiceID int64) error {
+func (r *BalanceStorage) CancelReservation(ctx context.Context, reservationID int64, ownerServiceID int64) (err error) {
+	ctx, span := tracing.StartDB(ctx, "CancelReservation", tracing.ReservationID(reservationID), tracing.ServiceID(ownerServiceID))
+	defer func() { tracing.End(span, err) }()
+
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
 		return err
@@ -210,9 +357,10 @@ func (r *BalanceStorage) CancelReservation(ctx context.Context, reservationID in
 	if err != nil {
 		return ErrNotFound
 	}
+	span.SetAttributes(tracing.AccountID(accID))
 
 	if status != "ACTIVE" {
-		return errors.New("reservation not active")
+		return ErrNotActive
 	}
 
 	// Возвращаем средства (уменьшаем reserved_amount)
@@ -235,5 +383,72 @@ func (r *BalanceStorage) CancelReservation(ctx context.Context, reservationID in
 		return err
 	}
 
+	res := &domain.Reservation{ID: reservationID, AccountID: accID, OwnerServiceID: ownerServiceID, Amount: amount}
+	if err := insertJournal(ctx, tx, domain.ReservationJournal(domain.OpReserveCancel, res)); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
+
+// ReservationByKey возвращает резерв сервиса с ключом идемпотентности.
+func (r *BalanceStorage) ReservationByKey(ctx context.Context, ownerServiceID int64, idempotencyKey string) (_ *domain.Reservation, err error) {
+	ctx, span := tracing.StartDB(ctx, "ReservationByKey", tracing.ServiceID(ownerServiceID))
+	defer func() { tracing.End(span, err) }()
+
+	var res domain.Reservation
+	err = r.db.QueryRowContext(ctx, `
+		SELECT id, account_id, owner_service_id, amount, status, idempotency_key, expires_at, created_at
+		FROM reservations
+		WHERE owner_service_id = $1 AND idempotency_key = $2
+	`, ownerServiceID, idempotencyKey).Scan(
+		&res.ID, &res.AccountID, &res.OwnerServiceID, &res.Amount,
+		&res.Status, &res.IdempotencyKey, &res.ExpiresAt, &res.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ListReservations возвращает все резервы по счёту, новые первыми.
+func (r *BalanceStorage) ListReservations(ctx context.Context, accountID int64) (_ []domain.Reservation, err error) {
+	ctx, span := tracing.StartDB(ctx, "ListReservations", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, account_id, owner_service_id, amount, status, idempotency_key, expires_at, created_at
+		FROM reservations
+		WHERE account_id = $1
+		ORDER BY id DESC
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.Reservation
+	for rows.Next() {
+		var res domain.Reservation
+		err := rows.Scan(
+			&res.ID, &res.AccountID, &res.OwnerServiceID, &res.Amount,
+			&res.Status, &res.IdempotencyKey, &res.ExpiresAt, &res.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, res)
+	}
+	return out, rows.Err()
+}
+
+func (r *BalanceStorage) ReservationStats(ctx context.Context) (active, reserved int64, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT (SELECT count(*) FROM reservations WHERE status = 'ACTIVE'),
+		       (SELECT COALESCE(SUM(reserved_amount), 0)::bigint FROM accounts)
+	`).Scan(&active, &reserved)
+	return active, reserved, err
+}
@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"test_nanimai/backend/internal/migration"
+	"test_nanimai/backend/internal/repository/repotest"
+)
+
+// testDatabaseEnv — переменная со строкой подключения к тестовой базе. Без
+// неё тест пропускается; миграции к базе применяются до последней версии.
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+func TestBalanceStorage(t *testing.T) {
+	dsn := os.Getenv(testDatabaseEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseEnv)
+	}
+
+	mg, err := migration.New(dsn)
+	if err != nil {
+		t.Fatalf("migration.New: %v", err)
+	}
+	err = mg.Up(context.Background())
+	mg.Close()
+	if err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	s, err := NewBalanceStorage(dsn)
+	if err != nil {
+		t.Fatalf("NewBalanceStorage: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	repotest.Run(t, func(t *testing.T) repotest.Store { return s })
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/tracing"
+)
+
+// UpdateCreditLimit изменяет кредитную линию счёта на delta.
+// Уменьшить её ниже уже использованного кредита нельзя.
+func (s *BalanceStorage) UpdateCreditLimit(ctx context.Context, actorServiceID, accountID int64, delta int64) (err error) {
+	ctx, span := tracing.StartDB(ctx, "UpdateCreditLimit", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	entry := domain.CreditLimitJournal(accountID, delta)
+	entry.ActorServiceID = actorServiceID
+	if err := updateCreditLimit(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// updateCreditLimit блокирует счёт, меняет кредитную линию на
+// entry.DeltaCredit и пишет проводку entry в tx.
+func updateCreditLimit(ctx context.Context, tx *sql.Tx, entry *domain.JournalEntry) error {
+	var acc domain.Account
+	err := tx.QueryRowContext(ctx, `
+		SELECT current_amount, reserved_amount, credit_limit
+		FROM accounts
+		WHERE id = $1
+		FOR UPDATE
+	`, entry.AccountID).Scan(&acc.CurrentAmount, &acc.ReservedAmount, &acc.CreditLimit)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	acc.CreditLimit += entry.DeltaCredit
+	if acc.CreditLimit < 0 || acc.Available() < 0 {
+		return domain.ErrCreditInUse
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE accounts
+		SET credit_limit = credit_limit + $1
+		WHERE id = $2
+	`, entry.DeltaCredit, entry.AccountID)
+	if err != nil {
+		return err
+	}
+
+	return insertJournal(ctx, tx, entry)
+}
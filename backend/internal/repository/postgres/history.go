@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/tracing"
+	"time"
+)
+
+// GetAccountAsOf восстанавливает баланс счёта на момент asOf: берёт последний
+// снимок до asOf и добавляет к нему изменения из ledger, сделанные после него.
+func (s *BalanceStorage) GetAccountAsOf(ctx context.Context, accountID int64, asOf time.Time) (_ *domain.Account, err error) {
+	ctx, span := tracing.StartDB(ctx, "GetAccountAsOf", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	acc, err := s.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastEntryID int64
+	acc.CurrentAmount, acc.ReservedAmount, acc.MaxAmount, acc.CreditLimit = 0, 0, 0, 0
+	err = s.db.QueryRowContext(ctx, `
+		SELECT last_entry_id, current_amount, reserved_amount, max_amount, credit_limit
+		FROM balance_snapshots
+		WHERE account_id = $1 AND taken_at <= $2
+		ORDER BY last_entry_id DESC
+		LIMIT 1
+	`, accountID, asOf).Scan(&lastEntryID, &acc.CurrentAmount, &acc.ReservedAmount, &acc.MaxAmount, &acc.CreditLimit)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var dCurrent, dReserved, dMax, dCredit int64
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(delta_current), 0)::bigint,
+		       COALESCE(SUM(delta_reserved), 0)::bigint,
+		       COALESCE(SUM(delta_max), 0)::bigint,
+		       COALESCE(SUM(delta_credit), 0)::bigint
+		FROM ledger
+		WHERE account_id = $1 AND id > $2 AND created_at <= $3
+	`, accountID, lastEntryID, asOf).Scan(&dCurrent, &dReserved, &dMax, &dCredit)
+	if err != nil {
+		return nil, err
+	}
+
+	acc.CurrentAmount += dCurrent
+	acc.ReservedAmount += dReserved
+	acc.MaxAmount += dMax
+	acc.CreditLimit += dCredit
+	return acc, nil
+}
+
+// SnapshotBalances сохраняет новые снимки для счетов, у которых с момента
+// последнего снимка накопилось не меньше minEntries записей в ledger.
+// Записи моложе settle не учитываются, чтобы не пропустить проводки
+// ещё не закоммиченных транзакций с меньшими id.
+func (s *BalanceStorage) SnapshotBalances(ctx context.Context, minEntries int, settle time.Duration) (_ int64, err error) {
+	ctx, span := tracing.StartDB(ctx, "SnapshotBalances")
+	defer func() { tracing.End(span, err) }()
+
+	cmd, err := s.db.ExecContext(ctx, `
+		INSERT INTO balance_snapshots (account_id, last_entry_id, current_amount, reserved_amount, max_amount, credit_limit, taken_at)
+		SELECT l.account_id,
+		       MAX(l.id),
+		       COALESCE(s.current_amount, 0) + SUM(l.delta_current)::bigint,
+		       COALESCE(s.reserved_amount, 0) + SUM(l.delta_reserved)::bigint,
+		       COALESCE(s.max_amount, 0) + SUM(l.delta_max)::bigint,
+		       COALESCE(s.credit_limit, 0) + SUM(l.delta_credit)::bigint,
+		       MAX(l.created_at)
+		FROM ledger l
+		LEFT JOIN LATERAL (
+			SELECT last_entry_id, current_amount, reserved_amount, max_amount, credit_limit
+			FROM balance_snapshots
+			WHERE account_id = l.account_id
+			ORDER BY last_entry_id DESC
+			LIMIT 1
+		) s ON true
+		WHERE l.id > COALESCE(s.last_entry_id, 0)
+		  AND l.created_at < now() - $2::interval
+		GROUP BY l.account_id, s.current_amount, s.reserved_amount, s.max_amount, s.credit_limit
+		HAVING COUNT(*) >= $1
+		ON CONFLICT (account_id, last_entry_id) DO NOTHING
+	`, minEntries, settle.String())
+	if err != nil {
+		return 0, err
+	}
+	return cmd.RowsAffected()
+}
@@ -0,0 +1,252 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+// isUniqueViolation сообщает, нарушено ли ограничение уникальности.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+func nullInt64(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
+
+// insertJournal записывает проводку и её строки в рамках транзакции tx.
+func insertJournal(ctx context.Context, tx *sql.Tx, e *domain.JournalEntry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO ledger (account_id, reservation_id, actor_service_id, operation, description, delta_current, delta_reserved, delta_max, delta_credit, reversal_of, reason_code)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`, e.AccountID, nullInt64(e.ReservationID), nullInt64(e.ActorServiceID), e.Operation, e.Description,
+		e.DeltaCurrent, e.DeltaReserved, e.DeltaMax, e.DeltaCredit, nullInt64(e.ReversalOf), e.ReasonCode,
+	).Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range e.Postings {
+		if accID, ok := domain.ParseAccountLedger(p.LedgerAccount); ok {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO ledger_accounts (code, kind, account_id)
+				VALUES ($1, 'ACCOUNT', $2)
+				ON CONFLICT (code) DO NOTHING
+			`, p.LedgerAccount, accID)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO ledger_postings (entry_id, ledger_account, side, amount)
+			VALUES ($1, $2, $3, $4)
+		`, e.ID, p.LedgerAccount, string(p.Side), p.Amount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNotFrozen блокирует счёт и возвращает domain.ErrAccountFrozen, если
+// он заморожен. Отсутствие счёта не считается ошибкой: его обрабатывает вызывающий.
+func checkNotFrozen(ctx context.Context, tx *sql.Tx, accountID int64) error {
+	var frozen bool
+	err := tx.QueryRowContext(ctx, "SELECT frozen FROM accounts WHERE id = $1 FOR UPDATE", accountID).Scan(&frozen)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if frozen {
+		return domain.ErrAccountFrozen
+	}
+	return nil
+}
+
+// PostJournal применяет к счёту сбалансированную проводку.
+// Как и UpdateBalance, не допускает превышения max_amount и ухода доступных
+// средств (current + credit - reserved) в минус.
+func (s *BalanceStorage) PostJournal(ctx context.Context, entry *domain.JournalEntry) (err error) {
+	ctx, span := tracing.StartDB(ctx, "PostJournal", tracing.AccountID(entry.AccountID))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := postJournal(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// postJournal применяет проводку в tx под блокировкой счёта.
+func postJournal(ctx context.Context, tx *sql.Tx, entry *domain.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	if err := checkNotFrozen(ctx, tx, entry.AccountID); err != nil {
+		return err
+	}
+	if err := checkVelocity(ctx, tx); err != nil {
+		return err
+	}
+
+	cmd, err := tx.ExecContext(ctx, `
+		UPDATE accounts
+		SET current_amount = current_amount + $1
+		WHERE id = $2
+		  AND (current_amount + $1 + credit_limit) >= reserved_amount
+		  AND (current_amount + $1) <= max_amount
+	`, entry.DeltaCurrent, entry.AccountID)
+	if err != nil {
+		return err
+	}
+	rows, _ := cmd.RowsAffected()
+	if rows == 0 {
+		return ErrNotEnoughFunds
+	}
+
+	return insertJournal(ctx, tx, entry)
+}
+
+// ListJournal возвращает последние проводки по счёту, новые первыми.
+func (s *BalanceStorage) ListJournal(ctx context.Context, accountID int64, limit int) (_ []domain.JournalEntry, err error) {
+	ctx, span := tracing.StartDB(ctx, "ListJournal", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+journalColumns+`
+		FROM ledger l
+		WHERE l.account_id = $1
+		ORDER BY l.id DESC
+		LIMIT $2
+	`, accountID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.JournalEntry
+	index := make(map[int64]int)
+	var ids []int64
+	for rows.Next() {
+		var e domain.JournalEntry
+		if err := scanJournalEntry(rows, &e); err != nil {
+			return nil, err
+		}
+		index[e.ID] = len(entries)
+		ids = append(ids, e.ID)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return entries, nil
+	}
+
+	err = loadPostings(ctx, s.db, ids, func(entryID int64, p domain.Posting) {
+		e := &entries[index[entryID]]
+		e.Postings = append(e.Postings, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *BalanceStorage) GetEntry(ctx context.Context, entryID int64) (_ *domain.JournalEntry, err error) {
+	ctx, span := tracing.StartDB(ctx, "GetEntry", tracing.EntryID(entryID))
+	defer func() { tracing.End(span, err) }()
+
+	var e domain.JournalEntry
+	err = scanJournalEntry(s.db.QueryRowContext(ctx, `
+		SELECT `+journalColumns+`
+		FROM ledger l
+		WHERE l.id = $1
+	`, entryID), &e)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = loadPostings(ctx, s.db, []int64{entryID}, func(_ int64, p domain.Posting) {
+		e.Postings = append(e.Postings, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// journalColumns — колонки проводки для scanJournalEntry; таблица ledger должна иметь псевдоним l.
+const journalColumns = `l.id, l.account_id, COALESCE(l.reservation_id, 0), COALESCE(l.actor_service_id, 0), l.operation, l.description,
+		       l.delta_current::bigint, l.delta_reserved::bigint, l.delta_max::bigint, l.delta_credit,
+		       COALESCE(l.reversal_of, 0), COALESCE((SELECT r.id FROM ledger r WHERE r.reversal_of = l.id), 0), l.reason_code,
+		       l.created_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJournalEntry(row scanner, e *domain.JournalEntry) error {
+	return row.Scan(
+		&e.ID, &e.AccountID, &e.ReservationID, &e.ActorServiceID, &e.Operation, &e.Description,
+		&e.DeltaCurrent, &e.DeltaReserved, &e.DeltaMax, &e.DeltaCredit,
+		&e.ReversalOf, &e.ReversedBy, &e.ReasonCode,
+		&e.CreatedAt,
+	)
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// loadPostings загружает строки проводок с указанными ID и передаёт их в add.
+func loadPostings(ctx context.Context, q queryer, entryIDs []int64, add func(entryID int64, p domain.Posting)) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT entry_id, ledger_account, side, amount
+		FROM ledger_postings
+		WHERE entry_id = ANY($1)
+		ORDER BY id
+	`, pq.Array(entryIDs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entryID int64
+		var p domain.Posting
+		var side string
+		if err := rows.Scan(&entryID, &p.LedgerAccount, &side, &p.Amount); err != nil {
+			return err
+		}
+		p.Side = domain.PostingSide(side)
+		add(entryID, p)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,280 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/tracing"
+	"time"
+)
+
+const limitChangeColumns = `id, account_id, kind, delta, COALESCE(proposed_by, 0), comment, status,
+	COALESCE(decided_by, 0), note, COALESCE(entry_id, 0), created_at, expires_at, decided_at`
+
+func (s *BalanceStorage) CreateLimitChange(ctx context.Context, c *domain.LimitChange) (err error) {
+	ctx, span := tracing.StartDB(ctx, "CreateLimitChange", tracing.AccountID(c.AccountID))
+	defer func() { tracing.End(span, err) }()
+
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO limit_changes (account_id, kind, delta, proposed_by, comment, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, created_at
+	`, c.AccountID, string(c.Kind), c.Delta, nullInt64(c.ProposedBy), c.Comment, c.ExpiresAt,
+	).Scan(&c.ID, &c.Status, &c.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *BalanceStorage) GetLimitChange(ctx context.Context, changeID int64) (_ *domain.LimitChange, err error) {
+	ctx, span := tracing.StartDB(ctx, "GetLimitChange")
+	defer func() { tracing.End(span, err) }()
+
+	var c domain.LimitChange
+	row := s.db.QueryRowContext(ctx, "SELECT "+limitChangeColumns+" FROM limit_changes WHERE id = $1", changeID)
+	if err := scanLimitChange(row, &c); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *BalanceStorage) ListLimitChanges(ctx context.Context, status domain.LimitChangeStatus) (_ []domain.LimitChange, err error) {
+	ctx, span := tracing.StartDB(ctx, "ListLimitChanges")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+limitChangeColumns+`
+		FROM limit_changes
+		WHERE $1 = '' OR status = $1
+		ORDER BY id
+	`, string(status))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.LimitChange
+	for rows.Next() {
+		var c domain.LimitChange
+		if err := scanLimitChange(rows, &c); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// ApproveLimitChange блокирует предложение, затем счёт: из двух
+// одновременных решений по одному предложению проходит одно.
+func (s *BalanceStorage) ApproveLimitChange(ctx context.Context, c *domain.LimitChange) (err error) {
+	ctx, span := tracing.StartDB(ctx, "ApproveLimitChange")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var expired bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT account_id, kind, delta, COALESCE(proposed_by, 0), status, expires_at <= now()
+		FROM limit_changes
+		WHERE id = $1
+		FOR UPDATE
+	`, c.ID).Scan(&c.AccountID, &c.Kind, &c.Delta, &c.ProposedBy, &c.Status, &expired)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if c.Status != domain.LimitChangePending {
+		return domain.ErrNotPending
+	}
+	if expired {
+		if _, err := tx.ExecContext(ctx, "UPDATE limit_changes SET status = 'EXPIRED' WHERE id = $1", c.ID); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		c.Status = domain.LimitChangeExpired
+		return domain.ErrChangeExpired
+	}
+
+	entry := c.Journal()
+	if c.Kind == domain.LimitKindCredit {
+		err = updateCreditLimit(ctx, tx, entry)
+	} else {
+		err = approveLimit(ctx, tx, entry)
+	}
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRowContext(ctx, `
+		UPDATE limit_changes
+		SET status = 'APPROVED', decided_by = $2, note = $3, entry_id = $4, decided_at = now()
+		WHERE id = $1
+		RETURNING decided_at
+	`, c.ID, nullInt64(c.DecidedBy), c.Note, entry.ID).Scan(&c.DecidedAt)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	c.Status, c.EntryID = domain.LimitChangeApproved, entry.ID
+	return nil
+}
+
+// approveLimit блокирует счёт, меняет лимит и пишет проводку entry в tx.
+func approveLimit(ctx context.Context, tx *sql.Tx, entry *domain.JournalEntry) error {
+	var current, maxAmount int64
+	err := tx.QueryRowContext(ctx, `
+		SELECT current_amount, max_amount
+		FROM accounts
+		WHERE id = $1
+		FOR UPDATE
+	`, entry.AccountID).Scan(&current, &maxAmount)
+	if err != nil {
+		return ErrNotFound
+	}
+	if maxAmount+entry.DeltaMax < 0 || maxAmount+entry.DeltaMax < current {
+		return domain.ErrLimitExceeded
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE accounts SET max_amount = max_amount + $1 WHERE id = $2", entry.DeltaMax, entry.AccountID); err != nil {
+		return err
+	}
+	return insertJournal(ctx, tx, entry)
+}
+
+func (s *BalanceStorage) RejectLimitChange(ctx context.Context, c *domain.LimitChange) (err error) {
+	ctx, span := tracing.StartDB(ctx, "RejectLimitChange")
+	defer func() { tracing.End(span, err) }()
+
+	err = s.db.QueryRowContext(ctx, `
+		UPDATE limit_changes
+		SET status = 'REJECTED', decided_by = $2, note = $3, decided_at = now()
+		WHERE id = $1 AND status = 'PENDING'
+		RETURNING status, decided_at
+	`, c.ID, nullInt64(c.DecidedBy), c.Note).Scan(&c.Status, &c.DecidedAt)
+	if err != sql.ErrNoRows {
+		return err
+	}
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM limit_changes WHERE id = $1)", c.ID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return domain.ErrNotPending
+}
+
+// IncreaseLimit блокирует счёт до подсчёта недавних увеличений, так что
+// параллельные увеличения лимита одного счёта проверяются по очереди.
+func (s *BalanceStorage) IncreaseLimit(ctx context.Context, accountID, delta int64, policy domain.LimitPolicy) (err error) {
+	ctx, span := tracing.StartDB(ctx, "IncreaseLimit", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkLimitIncrease(ctx, tx, domain.LimitKindMax, accountID, delta, policy); err != nil {
+		return err
+	}
+	if err := updateLimit(ctx, tx, accountID, delta); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// IncreaseCreditLimit проверяет увеличение кредитной линии так же, как
+// IncreaseLimit — увеличение лимита.
+func (s *BalanceStorage) IncreaseCreditLimit(ctx context.Context, actorServiceID, accountID, delta int64, policy domain.LimitPolicy) (err error) {
+	ctx, span := tracing.StartDB(ctx, "IncreaseCreditLimit", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkLimitIncrease(ctx, tx, domain.LimitKindCredit, accountID, delta, policy); err != nil {
+		return err
+	}
+	entry := domain.CreditLimitJournal(accountID, delta)
+	entry.ActorServiceID = actorServiceID
+	if err := updateCreditLimit(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkLimitIncrease блокирует счёт и возвращает domain.ErrApprovalRequired,
+// если увеличение лимита вида kind на delta требует подтверждения.
+// Увеличения без подтверждения — проводки увеличения этого лимита за окно,
+// не записанные подтверждением предложения.
+func checkLimitIncrease(ctx context.Context, tx *sql.Tx, kind domain.LimitKind, accountID, delta int64, policy domain.LimitPolicy) error {
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM accounts WHERE id = $1 FOR UPDATE", accountID).Scan(&accountID); err != nil {
+		return ErrNotFound
+	}
+	op := domain.OpLimitIncrease
+	if kind == domain.LimitKindCredit {
+		op = domain.OpCreditIncrease
+	}
+	var recent int64
+	err := tx.QueryRowContext(ctx, `
+		SELECT (SELECT COALESCE(SUM(l.delta_max + l.delta_credit), 0)
+		        FROM ledger l
+		        WHERE l.account_id = $1
+		          AND l.operation = $3
+		          AND l.created_at > now() - $2::interval
+		          AND NOT EXISTS (SELECT 1 FROM limit_changes c WHERE c.entry_id = l.id))
+		     + (SELECT COALESCE(SUM(delta), 0)
+		        FROM limit_changes
+		        WHERE account_id = $1 AND kind = $4 AND status = 'PENDING' AND expires_at > now())
+	`, accountID, policy.Window.String(), op, string(kind)).Scan(&recent)
+	if err != nil {
+		return err
+	}
+	if policy.RequiresApproval(recent, delta) {
+		return domain.ErrApprovalRequired
+	}
+	return nil
+}
+
+func (s *BalanceStorage) ExpireLimitChanges(ctx context.Context, now time.Time) (_ int64, err error) {
+	ctx, span := tracing.StartDB(ctx, "ExpireLimitChanges")
+	defer func() { tracing.End(span, err) }()
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE limit_changes
+		SET status = 'EXPIRED'
+		WHERE status = 'PENDING' AND expires_at <= $1
+	`, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func scanLimitChange(row scanner, c *domain.LimitChange) error {
+	var decidedAt sql.NullTime
+	err := row.Scan(&c.ID, &c.AccountID, &c.Kind, &c.Delta, &c.ProposedBy, &c.Comment, &c.Status,
+		&c.DecidedBy, &c.Note, &c.EntryID, &c.CreatedAt, &c.ExpiresAt, &decidedAt)
+	if err != nil {
+		return err
+	}
+	c.DecidedAt = decidedAt.Time
+	return nil
+}
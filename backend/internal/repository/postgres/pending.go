@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/tracing"
+	"time"
+)
+
+const pendingColumns = `id, kind, service_id, account_id, amount, idempotency_key, timeout_seconds, description, postings,
+	COALESCE(reversal_of, 0), reason_code, reason, status, COALESCE(decided_by, 0), note, COALESCE(reservation_id, 0), COALESCE(entry_id, 0), error, created_at, decided_at`
+
+// CreatePendingOperation вставляет операцию; повтор резерва с тем же ключом
+// упирается в pending_operations_idempotency_idx и читает сохранённую.
+func (s *BalanceStorage) CreatePendingOperation(ctx context.Context, op *domain.PendingOperation) (err error) {
+	ctx, span := tracing.StartDB(ctx, "CreatePendingOperation", tracing.AccountID(op.AccountID), tracing.ServiceID(op.ServiceID))
+	defer func() { tracing.End(span, err) }()
+
+	postings, err := json.Marshal(nonNil(op.Postings))
+	if err != nil {
+		return err
+	}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO pending_operations (kind, service_id, account_id, amount, idempotency_key, timeout_seconds, description, postings,
+			reversal_of, reason_code, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (service_id, idempotency_key) WHERE kind = 'reservation' DO NOTHING
+		RETURNING id, status, created_at
+	`, string(op.Kind), op.ServiceID, op.AccountID, op.Amount, op.IdempotencyKey,
+		int64(op.Timeout/time.Second), op.Description, postings, nullInt64(op.ReversalOf), op.ReasonCode, op.Reason,
+	).Scan(&op.ID, &op.Status, &op.CreatedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		row := s.db.QueryRowContext(ctx, `
+			SELECT `+pendingColumns+`
+			FROM pending_operations
+			WHERE service_id = $1 AND idempotency_key = $2 AND kind = 'reservation'
+		`, op.ServiceID, op.IdempotencyKey)
+		return scanPendingOperation(row, op)
+	case isForeignKeyViolation(err):
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *BalanceStorage) GetPendingOperation(ctx context.Context, operationID int64) (_ *domain.PendingOperation, err error) {
+	ctx, span := tracing.StartDB(ctx, "GetPendingOperation")
+	defer func() { tracing.End(span, err) }()
+
+	var op domain.PendingOperation
+	row := s.db.QueryRowContext(ctx, "SELECT "+pendingColumns+" FROM pending_operations WHERE id = $1", operationID)
+	if err := scanPendingOperation(row, &op); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &op, nil
+}
+
+func (s *BalanceStorage) ListPendingOperations(ctx context.Context, status domain.PendingStatus) (_ []domain.PendingOperation, err error) {
+	ctx, span := tracing.StartDB(ctx, "ListPendingOperations")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+pendingColumns+`
+		FROM pending_operations
+		WHERE $1 = '' OR status = $1
+		ORDER BY id
+	`, string(status))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.PendingOperation
+	for rows.Next() {
+		var op domain.PendingOperation
+		if err := scanPendingOperation(rows, &op); err != nil {
+			return nil, err
+		}
+		out = append(out, op)
+	}
+	return out, rows.Err()
+}
+
+// ApprovePendingOperation блокирует строку операции на всю транзакцию:
+// одновременные решения по ней ждут и получают domain.ErrNotPending.
+// Неудачное выполнение откатывается до точки сохранения, а статус FAILED
+// записывается в той же транзакции.
+func (s *BalanceStorage) ApprovePendingOperation(ctx context.Context, op *domain.PendingOperation) (err error) {
+	ctx, span := tracing.StartDB(ctx, "ApprovePendingOperation", tracing.AccountID(op.AccountID))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stored domain.PendingOperation
+	row := tx.QueryRowContext(ctx, "SELECT "+pendingColumns+" FROM pending_operations WHERE id = $1 FOR UPDATE", op.ID)
+	if err := scanPendingOperation(row, &stored); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	if stored.Status != domain.PendingWaiting {
+		return domain.ErrNotPending
+	}
+	stored.DecidedBy, stored.Note = op.DecidedBy, op.Note
+	*op = stored
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT execute_pending"); err != nil {
+		return err
+	}
+	execErr := executePending(ctx, tx, op)
+	op.Status = domain.PendingApproved
+	if execErr != nil {
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT execute_pending"); err != nil {
+			return err
+		}
+		op.Status, op.Error = domain.PendingFailed, execErr.Error()
+		op.ReservationID, op.EntryID = 0, 0
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE pending_operations
+		SET status = $2, decided_by = $3, note = $4, reservation_id = $5, entry_id = $6, error = $7, decided_at = now()
+		WHERE id = $1
+		RETURNING decided_at
+	`, op.ID, string(op.Status), nullInt64(op.DecidedBy), op.Note,
+		nullInt64(op.ReservationID), nullInt64(op.EntryID), op.Error,
+	).Scan(&op.DecidedAt)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return execErr
+}
+
+// executePending выполняет одобренную операцию в tx.
+func executePending(ctx context.Context, tx *sql.Tx, op *domain.PendingOperation) error {
+	switch op.Kind {
+	case domain.OperationReservation:
+		res, err := openReservation(ctx, tx, op.ServiceID, op.AccountID, op.Amount, op.IdempotencyKey, op.Timeout)
+		if err != nil {
+			return err
+		}
+		op.ReservationID = res.ID
+		return nil
+	case domain.OperationReversal:
+		rev, err := reverseEntry(ctx, tx, op.ReversalOf, op.ServiceID, op.ReasonCode, op.Description, false)
+		if err != nil {
+			return err
+		}
+		op.EntryID = rev.ID
+		return nil
+	}
+	entry := op.Journal()
+	if entry == nil {
+		return fmt.Errorf("unknown operation kind %q", op.Kind)
+	}
+	if err := postJournal(ctx, tx, entry); err != nil {
+		return err
+	}
+	op.EntryID = entry.ID
+	return nil
+}
+
+// ResolvePendingOperation меняет статус условным UPDATE: из двух
+// одновременных решений по одной операции проходит одно.
+func (s *BalanceStorage) ResolvePendingOperation(ctx context.Context, op *domain.PendingOperation, from domain.PendingStatus) (err error) {
+	ctx, span := tracing.StartDB(ctx, "ResolvePendingOperation", tracing.AccountID(op.AccountID))
+	defer func() { tracing.End(span, err) }()
+
+	err = s.db.QueryRowContext(ctx, `
+		UPDATE pending_operations
+		SET status = $3, decided_by = $4, note = $5, reservation_id = $6, entry_id = $7, error = $8, decided_at = now()
+		WHERE id = $1 AND status = $2
+		RETURNING decided_at
+	`, op.ID, string(from), string(op.Status), nullInt64(op.DecidedBy), op.Note,
+		nullInt64(op.ReservationID), nullInt64(op.EntryID), op.Error,
+	).Scan(&op.DecidedAt)
+	if err != sql.ErrNoRows {
+		return err
+	}
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pending_operations WHERE id = $1)", op.ID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return domain.ErrNotPending
+}
+
+func scanPendingOperation(row scanner, op *domain.PendingOperation) error {
+	var timeoutSeconds int64
+	var postings []byte
+	var decidedAt sql.NullTime
+	err := row.Scan(&op.ID, &op.Kind, &op.ServiceID, &op.AccountID, &op.Amount, &op.IdempotencyKey, &timeoutSeconds,
+		&op.Description, &postings, &op.ReversalOf, &op.ReasonCode, &op.Reason, &op.Status, &op.DecidedBy, &op.Note, &op.ReservationID, &op.EntryID,
+		&op.Error, &op.CreatedAt, &decidedAt)
+	if err != nil {
+		return err
+	}
+	op.Timeout = time.Duration(timeoutSeconds) * time.Second
+	op.DecidedAt = decidedAt.Time
+	op.Postings = nil
+	return json.Unmarshal(postings, &op.Postings)
+}
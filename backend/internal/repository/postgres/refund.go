@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/tracing"
+)
+
+// RefundReservation возвращает на счёт часть или всю сумму подтверждённого резерва.
+// Повторный вызов с тем же idempotencyKey возвращает ранее созданный возврат.
+func (s *BalanceStorage) RefundReservation(ctx context.Context, reservationID, ownerServiceID int64, amount int64, idempotencyKey string) (_ *domain.Refund, err error) {
+	ctx, span := tracing.StartDB(ctx, "RefundReservation", tracing.ReservationID(reservationID), tracing.ServiceID(ownerServiceID))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Блокируем резерв: возвраты по нему идут по очереди
+	var res domain.Reservation
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, account_id, owner_service_id, amount, status
+		FROM reservations
+		WHERE id = $1 AND owner_service_id = $2
+		FOR UPDATE
+	`, reservationID, ownerServiceID).Scan(&res.ID, &res.AccountID, &res.OwnerServiceID, &res.Amount, &res.Status)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	span.SetAttributes(tracing.AccountID(res.AccountID))
+
+	var existing domain.Refund
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, reservation_id, owner_service_id, amount, idempotency_key, ledger_entry_id, created_at
+		FROM reservation_refunds
+		WHERE reservation_id = $1 AND idempotency_key = $2
+	`, reservationID, idempotencyKey).Scan(
+		&existing.ID, &existing.ReservationID, &existing.OwnerServiceID, &existing.Amount,
+		&existing.IdempotencyKey, &existing.LedgerEntryID, &existing.CreatedAt,
+	)
+	if err == nil {
+		// Уже есть такой возврат
+		return &existing, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if res.Status != "CONFIRMED" {
+		return nil, domain.ErrNotConfirmed
+	}
+	if err := checkNotFrozen(ctx, tx, res.AccountID); err != nil {
+		return nil, err
+	}
+
+	// Сумма возвратов не превышает списанного; сторно подтверждения вернуло всё
+	var refunded int64
+	var reversed bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE((SELECT SUM(amount) FROM reservation_refunds WHERE reservation_id = $1), 0)::bigint,
+		       EXISTS (
+		           SELECT 1 FROM ledger c JOIN ledger r ON r.reversal_of = c.id
+		           WHERE c.reservation_id = $1 AND c.operation = 'RESERVE_CONFIRM'
+		       )
+	`, reservationID).Scan(&refunded, &reversed)
+	if err != nil {
+		return nil, err
+	}
+	if reversed || refunded+amount > res.Amount {
+		return nil, domain.ErrRefundExceeded
+	}
+
+	cmd, err := tx.ExecContext(ctx, `
+		UPDATE accounts
+		SET current_amount = current_amount + $1
+		WHERE id = $2
+		  AND (current_amount + $1) <= max_amount
+	`, amount, res.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := cmd.RowsAffected()
+	if rows == 0 {
+		return nil, domain.ErrLimitExceeded
+	}
+
+	entry := domain.RefundJournal(&res, amount)
+	if err := insertJournal(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+
+	var refund domain.Refund
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO reservation_refunds (reservation_id, owner_service_id, amount, idempotency_key, ledger_entry_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, reservation_id, owner_service_id, amount, idempotency_key, ledger_entry_id, created_at
+	`, reservationID, ownerServiceID, amount, idempotencyKey, entry.ID).Scan(
+		&refund.ID, &refund.ReservationID, &refund.OwnerServiceID, &refund.Amount,
+		&refund.IdempotencyKey, &refund.LedgerEntryID, &refund.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
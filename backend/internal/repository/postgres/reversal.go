@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+// ReverseEntry сторнирует проводку entryID компенсирующей проводкой с кодом причины.
+func (s *BalanceStorage) ReverseEntry(ctx context.Context, entryID, actorServiceID int64, reasonCode, description string) (_ *domain.JournalEntry, err error) {
+	ctx, span := tracing.StartDB(ctx, "ReverseEntry", tracing.EntryID(entryID), tracing.ServiceID(actorServiceID))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rev, err := reverseEntry(ctx, tx, entryID, actorServiceID, reasonCode, description, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return rev, nil
+}
+
+// ReverseReservation сторнирует подтверждение резерва: возвращает списанные средства на счёт.
+func (s *BalanceStorage) ReverseReservation(ctx context.Context, reservationID, ownerServiceID int64, reasonCode, description string) (_ *domain.JournalEntry, err error) {
+	ctx, span := tracing.StartDB(ctx, "ReverseReservation", tracing.ReservationID(reservationID), tracing.ServiceID(ownerServiceID))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx, `
+		SELECT status
+		FROM reservations
+		WHERE id = $1 AND owner_service_id = $2
+		FOR UPDATE
+	`, reservationID, ownerServiceID).Scan(&status)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	if status != "CONFIRMED" {
+		return nil, domain.ErrNotReversible
+	}
+
+	var entryID int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT id
+		FROM ledger
+		WHERE reservation_id = $1 AND operation = 'RESERVE_CONFIRM'
+	`, reservationID).Scan(&entryID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	rev, err := reverseEntry(ctx, tx, entryID, ownerServiceID, reasonCode, description, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return rev, nil
+}
+
+// reverseEntry сторнирует проводку в tx. Подтверждение резерва сторнируется
+// только при reservation — через ReverseReservation, которая проверила
+// владельца резерва.
+func reverseEntry(ctx context.Context, tx *sql.Tx, entryID, actorServiceID int64, reasonCode, description string, reservation bool) (*domain.JournalEntry, error) {
+	// Блокируем исходную проводку, чтобы параллельные сторно шли по очереди
+	var orig domain.JournalEntry
+	err := scanJournalEntry(tx.QueryRowContext(ctx, `
+		SELECT `+journalColumns+`
+		FROM ledger l
+		WHERE l.id = $1
+		FOR UPDATE OF l
+	`, entryID), &orig)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if orig.ReversedBy != 0 {
+		return nil, domain.ErrAlreadyReversed
+	}
+	if orig.Operation == domain.OpReserveConfirm && !reservation {
+		return nil, domain.ErrNotReversible
+	}
+
+	if orig.Operation == domain.OpReserveConfirm {
+		// После возвратов сторно подтверждения вернуло бы больше, чем списано.
+		// Блокируем резерв, чтобы не разойтись с параллельным RefundReservation
+		var refunded bool
+		err = tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM reservation_refunds WHERE reservation_id = r.id)
+			FROM reservations r
+			WHERE r.id = $1
+			FOR UPDATE OF r
+		`, orig.ReservationID).Scan(&refunded)
+		if err != nil {
+			return nil, err
+		}
+		if refunded {
+			return nil, domain.ErrNotReversible
+		}
+	}
+	if err := checkNotFrozen(ctx, tx, orig.AccountID); err != nil {
+		return nil, err
+	}
+	if err := checkVelocity(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	err = loadPostings(ctx, tx, []int64{entryID}, func(_ int64, p domain.Posting) {
+		orig.Postings = append(orig.Postings, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rev, err := domain.ReversalJournal(&orig, actorServiceID, reasonCode, description)
+	if err != nil {
+		return nil, err
+	}
+
+	// Сторно не должно уводить доступные средства в минус, а баланс выше лимита
+	cmd, err := tx.ExecContext(ctx, `
+		UPDATE accounts
+		SET current_amount = current_amount + $1,
+		    max_amount = max_amount + $2,
+		    credit_limit = credit_limit + $3
+		WHERE id = $4
+		  AND (credit_limit + $3) >= 0
+		  AND (current_amount + $1 + credit_limit + $3) >= reserved_amount
+		  AND (current_amount + $1) <= (max_amount + $2)
+	`, rev.DeltaCurrent, rev.DeltaMax, rev.DeltaCredit, rev.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := cmd.RowsAffected()
+	if rows == 0 {
+		return nil, ErrNotEnoughFunds
+	}
+
+	if err := insertJournal(ctx, tx, rev); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, domain.ErrAlreadyReversed
+		}
+		return nil, err
+	}
+	return rev, nil
+}
@@ -0,0 +1,461 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/tracing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// lastUsedResolution — как часто обновляется api_keys.last_used_at: не чаще
+// раза в минуту на ключ, чтобы аутентификация не писала в БД на каждый запрос.
+// Экземпляр не отправляет UPDATE чаще, а условие в запросе ограничивает
+// записи нескольких экземпляров.
+const lastUsedResolution = time.Minute
+
+// CreateService регистрирует внешний сервис с бессрочным API-ключом apiKey.
+func (s *BalanceStorage) CreateService(ctx context.Context, name, apiKey string) (_ int64, err error) {
+	ctx, span := tracing.StartDB(ctx, "CreateService")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO services (name, permissions)
+		VALUES ($1, $2)
+		RETURNING id
+	`, name, permissionsArray(domain.DefaultPermissions)).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, domain.ErrServiceExists
+	}
+	if err != nil {
+		return 0, err
+	}
+	span.SetAttributes(tracing.ServiceID(id))
+
+	key := &domain.APIKey{ServiceID: id, Prefix: domain.APIKeyPrefix(apiKey), Hash: domain.HashAPIKey(apiKey)}
+	if err := insertAPIKey(ctx, tx, key); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// AuthenticateAPIKey ищет действующие ключи по префиксу apiKey и сравнивает хеши.
+func (s *BalanceStorage) AuthenticateAPIKey(ctx context.Context, apiKey string) (_ *domain.Service, err error) {
+	ctx, span := tracing.StartDB(ctx, "AuthenticateAPIKey")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT k.id, k.hash, `+serviceColumns+`
+		FROM api_keys k
+		JOIN services s ON s.id = k.service_id
+		WHERE k.prefix = $1
+		  AND k.revoked_at IS NULL
+		  AND (k.expires_at IS NULL OR k.expires_at > now())
+	`, domain.APIKeyPrefix(apiKey))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keyID int64
+	var svc domain.Service
+	for rows.Next() {
+		var key domain.APIKey
+		var candidate domain.Service
+		var permissions []string
+		if err := rows.Scan(append([]any{&key.ID, &key.Hash}, serviceFields(&candidate, &permissions)...)...); err != nil {
+			return nil, err
+		}
+		candidate.Permissions = toPermissions(permissions)
+		if key.Matches(apiKey) {
+			keyID, svc = key.ID, candidate
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if keyID == 0 {
+		return nil, ErrNotFound
+	}
+	span.SetAttributes(tracing.ServiceID(svc.ID))
+
+	now := time.Now()
+	if last, ok := s.lastUsed.Load(keyID); ok && now.Sub(last.(time.Time)) < lastUsedResolution {
+		return &svc, nil
+	}
+	s.lastUsed.Store(keyID, now)
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE api_keys
+		SET last_used_at = now()
+		WHERE id = $1
+		  AND (last_used_at IS NULL OR last_used_at < now() - $2::interval)
+	`, keyID, lastUsedResolution.String())
+	if err != nil {
+		return nil, err
+	}
+	return &svc, nil
+}
+
+// AuthenticateCertificate ищет сервис, которому назначено любое из имён
+// identities; второго подходящего достаточно, чтобы отказать.
+func (s *BalanceStorage) AuthenticateCertificate(ctx context.Context, identities []string) (_ *domain.Service, err error) {
+	ctx, span := tracing.StartDB(ctx, "AuthenticateCertificate")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+serviceColumns+`
+		FROM services s
+		WHERE s.cert_identities && $1
+		ORDER BY s.id
+		LIMIT 2
+	`, pq.Array(nonNil(identities)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []domain.Service
+	for rows.Next() {
+		var svc domain.Service
+		var permissions []string
+		if err := rows.Scan(serviceFields(&svc, &permissions)...); err != nil {
+			return nil, err
+		}
+		svc.Permissions = toPermissions(permissions)
+		found = append(found, svc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	switch len(found) {
+	case 0:
+		return nil, ErrNotFound
+	case 2:
+		return nil, domain.ErrAmbiguousIdentity
+	}
+	svc := found[0]
+	span.SetAttributes(tracing.ServiceID(svc.ID))
+	return &svc, nil
+}
+
+// SetServicePermissions заменяет права сервиса.
+func (s *BalanceStorage) SetServicePermissions(ctx context.Context, serviceID int64, permissions []domain.Permission) (err error) {
+	ctx, span := tracing.StartDB(ctx, "SetServicePermissions", tracing.ServiceID(serviceID))
+	defer func() { tracing.End(span, err) }()
+
+	cmd, err := s.db.ExecContext(ctx, "UPDATE services SET permissions = $1 WHERE id = $2",
+		permissionsArray(permissions), serviceID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := cmd.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetServiceScope заменяет область сервиса.
+func (s *BalanceStorage) SetServiceScope(ctx context.Context, serviceID int64, scope domain.AccountScope) (err error) {
+	ctx, span := tracing.StartDB(ctx, "SetServiceScope", tracing.ServiceID(serviceID))
+	defer func() { tracing.End(span, err) }()
+
+	cmd, err := s.db.ExecContext(ctx, `
+		UPDATE services
+		SET scope_account_ids = $1, scope_tags = $2
+		WHERE id = $3
+	`, pq.Array(nonNil(scope.AccountIDs)), pq.Array(nonNil(scope.Tags)), serviceID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := cmd.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetServiceSigning задаёт режим и секрет подписи запросов сервиса.
+func (s *BalanceStorage) SetServiceSigning(ctx context.Context, serviceID int64, mode domain.SigningMode, secret string) (err error) {
+	ctx, span := tracing.StartDB(ctx, "SetServiceSigning", tracing.ServiceID(serviceID))
+	defer func() { tracing.End(span, err) }()
+
+	cmd, err := s.db.ExecContext(ctx, "UPDATE services SET signing_mode = $1, signing_secret = $2 WHERE id = $3",
+		string(mode), secret, serviceID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := cmd.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetServiceRateLimit задаёт ограничение частоты запросов сервиса.
+func (s *BalanceStorage) SetServiceRateLimit(ctx context.Context, serviceID int64, limit domain.RateLimit) (err error) {
+	ctx, span := tracing.StartDB(ctx, "SetServiceRateLimit", tracing.ServiceID(serviceID))
+	defer func() { tracing.End(span, err) }()
+
+	cmd, err := s.db.ExecContext(ctx, `
+		UPDATE services
+		SET rate_limit = $1, rate_burst = $2, account_rate_limit = $3, account_rate_burst = $4
+		WHERE id = $5
+	`, limit.Rate, limit.Burst, limit.AccountRate, limit.AccountBurst, serviceID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := cmd.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetServiceCertIdentities заменяет имена сертификатов сервиса; имя,
+// назначенное другому сервису, — domain.ErrIdentityInUse.
+func (s *BalanceStorage) SetServiceCertIdentities(ctx context.Context, serviceID int64, identities []string) (err error) {
+	ctx, span := tracing.StartDB(ctx, "SetServiceCertIdentities", tracing.ServiceID(serviceID))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Назначения упорядочены блокировкой таблицы: проверка и запись не разрываются
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE services IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+	var taken bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM services WHERE id <> $1 AND cert_identities && $2)",
+		serviceID, pq.Array(nonNil(identities))).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return domain.ErrIdentityInUse
+	}
+	cmd, err := tx.ExecContext(ctx, "UPDATE services SET cert_identities = $1 WHERE id = $2",
+		pq.Array(nonNil(identities)), serviceID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := cmd.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return tx.Commit()
+}
+
+// ListServices возвращает зарегистрированные сервисы по возрастанию ID.
+func (s *BalanceStorage) ListServices(ctx context.Context) (_ []domain.Service, err error) {
+	ctx, span := tracing.StartDB(ctx, "ListServices")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+serviceColumns+`
+		FROM services s
+		ORDER BY s.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.Service
+	for rows.Next() {
+		var svc domain.Service
+		var permissions []string
+		if err := rows.Scan(serviceFields(&svc, &permissions)...); err != nil {
+			return nil, err
+		}
+		svc.Permissions = toPermissions(permissions)
+		out = append(out, svc)
+	}
+	return out, rows.Err()
+}
+
+// CreateAPIKey добавляет сервису ещё один ключ.
+func (s *BalanceStorage) CreateAPIKey(ctx context.Context, key *domain.APIKey) (err error) {
+	ctx, span := tracing.StartDB(ctx, "CreateAPIKey", tracing.ServiceID(key.ServiceID))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockService(ctx, tx, key.ServiceID); err != nil {
+		return err
+	}
+	if err := insertAPIKey(ctx, tx, key); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RotateAPIKey добавляет ключ key и сокращает срок остальных действующих
+// ключей сервиса до now + overlap; более ранний срок не продлевается.
+func (s *BalanceStorage) RotateAPIKey(ctx context.Context, key *domain.APIKey, overlap time.Duration) (err error) {
+	ctx, span := tracing.StartDB(ctx, "RotateAPIKey", tracing.ServiceID(key.ServiceID))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Блокировка сервиса упорядочивает параллельные ротации
+	if err := lockService(ctx, tx, key.ServiceID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE api_keys
+		SET expires_at = LEAST(COALESCE(expires_at, 'infinity'), now() + $2::interval)
+		WHERE service_id = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > now())
+	`, key.ServiceID, overlap.String())
+	if err != nil {
+		return err
+	}
+	if err := insertAPIKey(ctx, tx, key); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListAPIKeys возвращает все ключи сервиса по возрастанию ID.
+func (s *BalanceStorage) ListAPIKeys(ctx context.Context, serviceID int64) (_ []domain.APIKey, err error) {
+	ctx, span := tracing.StartDB(ctx, "ListAPIKeys", tracing.ServiceID(serviceID))
+	defer func() { tracing.End(span, err) }()
+
+	var exists bool
+	err = s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM services WHERE id = $1)", serviceID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, service_id, prefix, hash, created_at, expires_at, revoked_at, last_used_at
+		FROM api_keys
+		WHERE service_id = $1
+		ORDER BY id
+	`, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.APIKey
+	for rows.Next() {
+		var key domain.APIKey
+		var expiresAt, revokedAt, lastUsedAt sql.NullTime
+		err := rows.Scan(&key.ID, &key.ServiceID, &key.Prefix, &key.Hash, &key.CreatedAt, &expiresAt, &revokedAt, &lastUsedAt)
+		if err != nil {
+			return nil, err
+		}
+		key.ExpiresAt, key.RevokedAt, key.LastUsedAt = expiresAt.Time, revokedAt.Time, lastUsedAt.Time
+		out = append(out, key)
+	}
+	return out, rows.Err()
+}
+
+// RevokeAPIKey отзывает ключ: он перестаёт приниматься сразу.
+func (s *BalanceStorage) RevokeAPIKey(ctx context.Context, serviceID, keyID int64) (err error) {
+	ctx, span := tracing.StartDB(ctx, "RevokeAPIKey", tracing.ServiceID(serviceID))
+	defer func() { tracing.End(span, err) }()
+
+	cmd, err := s.db.ExecContext(ctx, `
+		UPDATE api_keys
+		SET revoked_at = now()
+		WHERE id = $1 AND service_id = $2 AND revoked_at IS NULL
+	`, keyID, serviceID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := cmd.RowsAffected(); rows > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM api_keys WHERE id = $1 AND service_id = $2)", keyID, serviceID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return domain.ErrKeyRevoked
+	}
+	return ErrNotFound
+}
+
+// lockService блокирует строку сервиса до конца транзакции; нет сервиса — ErrNotFound.
+func lockService(ctx context.Context, tx *sql.Tx, serviceID int64) error {
+	var id int64
+	err := tx.QueryRowContext(ctx, "SELECT id FROM services WHERE id = $1 FOR UPDATE", serviceID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+// insertAPIKey записывает ключ в рамках транзакции tx и заполняет ID и CreatedAt.
+func insertAPIKey(ctx context.Context, tx *sql.Tx, key *domain.APIKey) error {
+	var expiresAt sql.NullTime
+	if !key.ExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: key.ExpiresAt, Valid: true}
+	}
+	return tx.QueryRowContext(ctx, `
+		INSERT INTO api_keys (service_id, prefix, hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, key.ServiceID, key.Prefix, key.Hash, expiresAt).Scan(&key.ID, &key.CreatedAt)
+}
+
+// serviceColumns — колонки services (псевдоним s) в порядке serviceFields.
+const serviceColumns = "s.id, s.name, s.permissions, s.scope_account_ids, s.scope_tags, s.signing_mode, s.signing_secret, s.cert_identities, " +
+	"s.rate_limit, s.rate_burst, s.account_rate_limit, s.account_rate_burst"
+
+// serviceFields возвращает получатели Scan для serviceColumns; права
+// читаются в permissions и переводятся вызывающим через toPermissions.
+func serviceFields(svc *domain.Service, permissions *[]string) []any {
+	return []any{&svc.ID, &svc.Name, pq.Array(permissions), pq.Array(&svc.Scope.AccountIDs), pq.Array(&svc.Scope.Tags),
+		&svc.SigningMode, &svc.SigningSecret, pq.Array(&svc.CertIdentities),
+		&svc.RateLimit.Rate, &svc.RateLimit.Burst, &svc.RateLimit.AccountRate, &svc.RateLimit.AccountBurst}
+}
+
+func permissionsArray(permissions []domain.Permission) any {
+	out := make([]string, len(permissions))
+	for i, p := range permissions {
+		out[i] = string(p)
+	}
+	return pq.Array(out)
+}
+
+func toPermissions(names []string) []domain.Permission {
+	out := make([]domain.Permission, len(names))
+	for i, name := range names {
+		out[i] = domain.Permission(name)
+	}
+	return out
+}
+
+// nonNil заменяет nil пустым срезом: pq.Array(nil) записывает NULL, а
+// колонки-массивы объявлены NOT NULL.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/repository"
+	"test_nanimai/backend/internal/tracing"
+	"time"
+)
+
+const velocityRuleColumns = "id, COALESCE(account_id, 0), COALESCE(account_tag, ''), kind, limit_value, window_seconds, action, created_at"
+
+// accountVelocityRulesQuery выбирает правила счёта $1 и его тегов одним запросом.
+const accountVelocityRulesQuery = `
+	SELECT ` + velocityRuleColumns + `
+	FROM velocity_rules
+	WHERE account_id = $1
+	   OR account_tag = ANY (SELECT unnest(tags) FROM accounts WHERE id = $1)
+	ORDER BY id
+`
+
+func (s *BalanceStorage) AccountVelocityRules(ctx context.Context, accountID int64) (_ []domain.VelocityRule, err error) {
+	ctx, span := tracing.StartDB(ctx, "AccountVelocityRules", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	return queryVelocityRules(ctx, s.db, accountVelocityRulesQuery, accountID)
+}
+
+func (s *BalanceStorage) VelocityUsage(ctx context.Context, accountID int64, window time.Duration) (_ domain.VelocityUsage, err error) {
+	ctx, span := tracing.StartDB(ctx, "VelocityUsage", tracing.AccountID(accountID))
+	defer func() { tracing.End(span, err) }()
+
+	return velocityUsage(ctx, s.db, accountID, window)
+}
+
+// checkVelocity проверяет списание, заданное в ctx через
+// repository.WithVelocityCheck, по правилам счёта. Счёт уже заблокирован в
+// tx, поэтому параллельные списания с него ждут коммита и видят это.
+func checkVelocity(ctx context.Context, tx *sql.Tx) error {
+	op, ok := repository.VelocityCheck(ctx)
+	if !ok {
+		return nil
+	}
+	rules, err := queryVelocityRules(ctx, tx, accountVelocityRulesQuery, op.AccountID)
+	if err != nil {
+		return err
+	}
+	v, err := domain.CheckVelocity(rules, op, func(window time.Duration) (domain.VelocityUsage, error) {
+		return velocityUsage(ctx, tx, op.AccountID, window)
+	})
+	if err != nil {
+		return err
+	}
+	if v != nil {
+		return v
+	}
+	return nil
+}
+
+// velocityUsage считает списания по ledger_account_created_idx; окно
+// отсчитывается от now() базы, как и created_at проводок.
+func velocityUsage(ctx context.Context, q queryer, accountID int64, window time.Duration) (domain.VelocityUsage, error) {
+	var usage domain.VelocityUsage
+	err := q.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(CASE WHEN operation = 'RESERVE_OPEN' THEN delta_reserved ELSE -delta_current END), 0)::bigint,
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE operation = 'RESERVE_OPEN')
+		FROM ledger
+		WHERE account_id = $1
+		  AND created_at > now() - $2::interval
+		  AND (operation IN ('BALANCE_DECREASE', 'RESERVE_OPEN')
+		       OR (operation IN ('ADJUSTMENT', 'REVERSAL') AND delta_current < 0))
+	`, accountID, window.String()).Scan(&usage.DebitAmount, &usage.Debits, &usage.Reservations)
+	return usage, err
+}
+
+func (s *BalanceStorage) CreateVelocityRule(ctx context.Context, rule *domain.VelocityRule) (err error) {
+	ctx, span := tracing.StartDB(ctx, "CreateVelocityRule", tracing.AccountID(rule.AccountID))
+	defer func() { tracing.End(span, err) }()
+
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO velocity_rules (account_id, account_tag, kind, limit_value, window_seconds, action)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, nullInt64(rule.AccountID), sql.NullString{String: rule.Tag, Valid: rule.Tag != ""},
+		string(rule.Kind), rule.Limit, int64(rule.Window/time.Second), string(rule.Action)).Scan(&rule.ID, &rule.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *BalanceStorage) ListVelocityRules(ctx context.Context) (_ []domain.VelocityRule, err error) {
+	ctx, span := tracing.StartDB(ctx, "ListVelocityRules")
+	defer func() { tracing.End(span, err) }()
+
+	return queryVelocityRules(ctx, s.db, "SELECT "+velocityRuleColumns+" FROM velocity_rules ORDER BY id")
+}
+
+func (s *BalanceStorage) DeleteVelocityRule(ctx context.Context, ruleID int64) (err error) {
+	ctx, span := tracing.StartDB(ctx, "DeleteVelocityRule")
+	defer func() { tracing.End(span, err) }()
+
+	cmd, err := s.db.ExecContext(ctx, "DELETE FROM velocity_rules WHERE id = $1", ruleID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := cmd.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func queryVelocityRules(ctx context.Context, q queryer, query string, args ...any) ([]domain.VelocityRule, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.VelocityRule
+	for rows.Next() {
+		var r domain.VelocityRule
+		var windowSeconds int64
+		if err := rows.Scan(&r.ID, &r.AccountID, &r.Tag, &r.Kind, &r.Limit, &windowSeconds, &r.Action, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		r.Window = time.Duration(windowSeconds) * time.Second
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,754 @@
+// Package repotest — общий набор проверок для реализаций repository.Balance.
+// Один и тот же набор прогоняется против postgres и memory, чтобы расхождения
+// в семантике находились автоматически:
+//
+//	func TestMemory(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) repotest.Store { return memory.NewBalanceStorage() })
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/repository"
+)
+
+// Store — хранилище, которое можно наполнить тестовыми счетами и сервисами.
+type Store interface {
+	repository.Balance
+	repository.LimitChanges
+	repository.PendingOperations
+	CreateAccount(ctx context.Context, userID, maxAmount int64) (*domain.Account, error)
+	CreateService(ctx context.Context, name, apiKey string) (int64, error)
+	SetAccountFrozen(ctx context.Context, accountID int64, frozen bool) error
+}
+
+// missingID — заведомо несуществующий ID счёта, резерва или проводки.
+const missingID = 1 << 60
+
+var seq atomic.Int64
+
+// Run прогоняет все проверки; newStore вызывается для каждого подтеста.
+// Проверки заводят собственные счета и сервисы, поэтому хранилище может
+// быть общим для всех подтестов.
+func Run(t *testing.T, newStore func(t *testing.T) Store) {
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, s Store)
+	}{
+		{"GetAccount", testGetAccount},
+		{"UpdateBalance", testUpdateBalance},
+		{"UpdateLimit", testUpdateLimit},
+		{"IncreaseLimit", testIncreaseLimit},
+		{"IncreaseCreditLimit", testIncreaseCreditLimit},
+		{"CreditLimit", testCreditLimit},
+		{"Reservations", testReservations},
+		{"CancelReservation", testCancelReservation},
+		{"ExpiredReservation", testExpiredReservation},
+		{"Refunds", testRefunds},
+		{"RefundRespectsMaxAmount", testRefundRespectsMaxAmount},
+		{"PostJournal", testPostJournal},
+		{"ReverseEntry", testReverseEntry},
+		{"ReverseReservation", testReverseReservation},
+		{"AsOf", testAsOf},
+		{"ApprovePendingOperation", testApprovePendingOperation},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.fn(t, newStore(t))
+		})
+	}
+}
+
+func testGetAccount(t *testing.T, s Store) {
+	ctx := context.Background()
+	acc := newAccount(t, s, 1000)
+	got, err := s.GetAccount(ctx, acc.ID)
+	if err != nil {
+		t.Fatalf("GetAccount: %v", err)
+	}
+	if got.CurrentAmount != 0 || got.ReservedAmount != 0 || got.MaxAmount != 1000 {
+		t.Errorf("GetAccount = %+v, want current 0, reserved 0, max 1000", got)
+	}
+	_, err = s.GetAccount(ctx, missingID)
+	wantErr(t, "GetAccount(missing)", err, domain.ErrNotFound)
+}
+
+func testUpdateBalance(t *testing.T, s Store) {
+	ctx := context.Background()
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateBalance(+500)", s.UpdateBalance(ctx, acc.ID, 500))
+	wantAccount(t, s, acc.ID, 500, 0, 1000)
+
+	wantErr(t, "UpdateBalance(below zero)", s.UpdateBalance(ctx, acc.ID, -600), domain.ErrNotEnoughFunds)
+	wantErr(t, "UpdateBalance(above max)", s.UpdateBalance(ctx, acc.ID, 600), domain.ErrNotEnoughFunds)
+	wantErr(t, "UpdateBalance(missing)", s.UpdateBalance(ctx, missingID, 1), domain.ErrNotEnoughFunds)
+
+	must(t, "UpdateBalance(-500)", s.UpdateBalance(ctx, acc.ID, -500))
+	wantAccount(t, s, acc.ID, 0, 0, 1000)
+	checkLedger(t, s, acc.ID)
+}
+
+func testUpdateLimit(t *testing.T, s Store) {
+	ctx := context.Background()
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateLimit(+100)", s.UpdateLimit(ctx, acc.ID, 100))
+	must(t, "UpdateBalance(+500)", s.UpdateBalance(ctx, acc.ID, 500))
+	wantAccount(t, s, acc.ID, 500, 0, 1100)
+
+	wantErr(t, "UpdateLimit(below current)", s.UpdateLimit(ctx, acc.ID, -700), domain.ErrLimitExceeded)
+	wantErr(t, "UpdateLimit(missing)", s.UpdateLimit(ctx, missingID, 1), domain.ErrNotFound)
+	checkLedger(t, s, acc.ID)
+}
+
+func testIncreaseLimit(t *testing.T, s Store) {
+	ctx := context.Background()
+	policy := domain.LimitPolicy{Threshold: 100, Window: time.Hour, TTL: time.Hour}
+	// Начальный лимит — тоже увеличение без подтверждения
+	wantErr(t, "IncreaseLimit(after opening)", s.IncreaseLimit(ctx, newAccount(t, s, 100).ID, 1, policy), domain.ErrApprovalRequired)
+	acc := newAccount(t, s, 0)
+
+	// Увеличения складываются: второе вместе с первым больше порога
+	must(t, "IncreaseLimit(+60)", s.IncreaseLimit(ctx, acc.ID, 60, policy))
+	wantErr(t, "IncreaseLimit(+60 again)", s.IncreaseLimit(ctx, acc.ID, 60, policy), domain.ErrApprovalRequired)
+	must(t, "UpdateLimit(-60)", s.UpdateLimit(ctx, acc.ID, -60))
+	must(t, "IncreaseLimit(+40)", s.IncreaseLimit(ctx, acc.ID, 40, policy))
+	wantAccount(t, s, acc.ID, 0, 0, 40)
+	wantErr(t, "IncreaseLimit(+1)", s.IncreaseLimit(ctx, acc.ID, 1, policy), domain.ErrApprovalRequired)
+
+	// Ожидающее предложение входит в сумму, подтверждённое — нет
+	other := newAccount(t, s, 0)
+	change := policy.Propose(domain.LimitKindMax, other.ID, 80, newService(t, s), "", time.Now())
+	must(t, "CreateLimitChange", s.CreateLimitChange(ctx, change))
+	wantErr(t, "IncreaseLimit(with pending)", s.IncreaseLimit(ctx, other.ID, 30, policy), domain.ErrApprovalRequired)
+	change.DecidedBy = newService(t, s)
+	must(t, "ApproveLimitChange", s.ApproveLimitChange(ctx, change))
+	must(t, "IncreaseLimit(after approval)", s.IncreaseLimit(ctx, other.ID, 30, policy))
+	wantAccount(t, s, other.ID, 0, 0, 110)
+
+	wantErr(t, "IncreaseLimit(missing)", s.IncreaseLimit(ctx, missingID, 1, policy), domain.ErrNotFound)
+	checkLedger(t, s, acc.ID)
+	checkLedger(t, s, other.ID)
+}
+
+func testIncreaseCreditLimit(t *testing.T, s Store) {
+	ctx := context.Background()
+	policy := domain.LimitPolicy{Threshold: 100, Window: time.Hour, TTL: time.Hour}
+	actor := newService(t, s)
+	// Лимит и кредитная линия считаются по отдельности
+	acc := newAccount(t, s, 1000)
+
+	must(t, "IncreaseCreditLimit(+60)", s.IncreaseCreditLimit(ctx, actor, acc.ID, 60, policy))
+	if e := findEntry(t, s, acc.ID, domain.OpCreditIncrease); e.ActorServiceID != actor {
+		t.Errorf("CREDIT_LIMIT_INCREASE actor = %d, want %d", e.ActorServiceID, actor)
+	}
+	wantErr(t, "IncreaseCreditLimit(+60 again)", s.IncreaseCreditLimit(ctx, actor, acc.ID, 60, policy), domain.ErrApprovalRequired)
+	must(t, "UpdateCreditLimit(-60)", s.UpdateCreditLimit(ctx, actor, acc.ID, -60))
+	must(t, "IncreaseCreditLimit(+40)", s.IncreaseCreditLimit(ctx, actor, acc.ID, 40, policy))
+	if got := wantAccount(t, s, acc.ID, 0, 0, 1000); got.CreditLimit != 40 {
+		t.Errorf("CreditLimit = %d, want 40", got.CreditLimit)
+	}
+
+	// Ожидающее предложение кредитной линии входит в сумму, лимита — нет
+	other := newAccount(t, s, 0)
+	must(t, "CreateLimitChange(max)", s.CreateLimitChange(ctx, policy.Propose(domain.LimitKindMax, other.ID, 80, actor, "", time.Now())))
+	must(t, "IncreaseCreditLimit(with max pending)", s.IncreaseCreditLimit(ctx, actor, other.ID, 10, policy))
+	change := policy.Propose(domain.LimitKindCredit, other.ID, 80, actor, "", time.Now())
+	must(t, "CreateLimitChange(credit)", s.CreateLimitChange(ctx, change))
+	wantErr(t, "IncreaseCreditLimit(with pending)", s.IncreaseCreditLimit(ctx, actor, other.ID, 20, policy), domain.ErrApprovalRequired)
+
+	// Подтверждение меняет кредитную линию, а не лимит, от имени подтвердившего
+	change.DecidedBy = newService(t, s)
+	must(t, "ApproveLimitChange", s.ApproveLimitChange(ctx, change))
+	if got := wantAccount(t, s, other.ID, 0, 0, 0); got.CreditLimit != 90 {
+		t.Errorf("CreditLimit = %d, want 90", got.CreditLimit)
+	}
+	if e := findEntry(t, s, other.ID, domain.OpCreditIncrease); e.ID != change.EntryID || e.ActorServiceID != change.DecidedBy {
+		t.Errorf("approved entry = %+v, want ID %d actor %d", e, change.EntryID, change.DecidedBy)
+	}
+	must(t, "IncreaseCreditLimit(after approval)", s.IncreaseCreditLimit(ctx, actor, other.ID, 20, policy))
+
+	wantErr(t, "IncreaseCreditLimit(missing)", s.IncreaseCreditLimit(ctx, actor, missingID, 1, policy), domain.ErrNotFound)
+	checkLedger(t, s, acc.ID)
+	checkLedger(t, s, other.ID)
+}
+
+func testCreditLimit(t *testing.T, s Store) {
+	ctx := context.Background()
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateCreditLimit(+300)", s.UpdateCreditLimit(ctx, 0, acc.ID, 300))
+	must(t, "UpdateBalance(-200)", s.UpdateBalance(ctx, acc.ID, -200))
+
+	got := wantAccount(t, s, acc.ID, -200, 0, 1000)
+	if got.UsedCredit() != 200 || got.Available() != 100 {
+		t.Errorf("UsedCredit = %d, Available = %d, want 200 and 100", got.UsedCredit(), got.Available())
+	}
+
+	wantErr(t, "UpdateBalance(beyond credit)", s.UpdateBalance(ctx, acc.ID, -200), domain.ErrNotEnoughFunds)
+	wantErr(t, "UpdateCreditLimit(below used)", s.UpdateCreditLimit(ctx, 0, acc.ID, -200), domain.ErrCreditInUse)
+	wantErr(t, "UpdateCreditLimit(missing)", s.UpdateCreditLimit(ctx, 0, missingID, 1), domain.ErrNotFound)
+
+	res, err := s.OpenReservation(ctx, newService(t, s), acc.ID, 100, key(), time.Minute)
+	if err != nil {
+		t.Fatalf("OpenReservation(within credit): %v", err)
+	}
+	if res.Amount != 100 {
+		t.Errorf("reservation amount = %d, want 100", res.Amount)
+	}
+	checkLedger(t, s, acc.ID)
+}
+
+func testReservations(t *testing.T, s Store) {
+	ctx := context.Background()
+	owner, other := newService(t, s), newService(t, s)
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateBalance(+1000)", s.UpdateBalance(ctx, acc.ID, 1000))
+
+	k := key()
+	res, err := s.OpenReservation(ctx, owner, acc.ID, 600, k, time.Minute)
+	if err != nil {
+		t.Fatalf("OpenReservation: %v", err)
+	}
+	if res.Status != "ACTIVE" || res.Amount != 600 || res.AccountID != acc.ID || res.OwnerServiceID != owner {
+		t.Errorf("OpenReservation = %+v", res)
+	}
+	wantAccount(t, s, acc.ID, 1000, 600, 1000)
+
+	_, err = s.OpenReservation(ctx, owner, acc.ID, 500, key(), time.Minute)
+	wantErr(t, "OpenReservation(not enough)", err, domain.ErrNotEnoughFunds)
+	_, err = s.OpenReservation(ctx, owner, missingID, 1, key(), time.Minute)
+	wantErr(t, "OpenReservation(missing)", err, domain.ErrNotFound)
+
+	again, err := s.OpenReservation(ctx, owner, acc.ID, 600, k, time.Minute)
+	if err != nil {
+		t.Fatalf("OpenReservation(same key): %v", err)
+	}
+	if again.ID != res.ID {
+		t.Errorf("OpenReservation(same key) ID = %d, want %d", again.ID, res.ID)
+	}
+	wantAccount(t, s, acc.ID, 1000, 600, 1000)
+
+	wantErr(t, "ConfirmReservation(other owner)", s.ConfirmReservation(ctx, res.ID, other), domain.ErrNotFound)
+	must(t, "ConfirmReservation", s.ConfirmReservation(ctx, res.ID, owner))
+	wantAccount(t, s, acc.ID, 400, 0, 1000)
+
+	wantErr(t, "ConfirmReservation(again)", s.ConfirmReservation(ctx, res.ID, owner), domain.ErrNotActive)
+	wantErr(t, "CancelReservation(confirmed)", s.CancelReservation(ctx, res.ID, owner), domain.ErrNotActive)
+	wantErr(t, "ConfirmReservation(missing)", s.ConfirmReservation(ctx, missingID, owner), domain.ErrNotFound)
+
+	list, err := s.ListReservations(ctx, acc.ID)
+	if err != nil {
+		t.Fatalf("ListReservations: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != res.ID || list[0].Status != "CONFIRMED" {
+		t.Errorf("ListReservations = %+v, want one CONFIRMED reservation %d", list, res.ID)
+	}
+	checkLedger(t, s, acc.ID)
+}
+
+func testCancelReservation(t *testing.T, s Store) {
+	ctx := context.Background()
+	owner, other := newService(t, s), newService(t, s)
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateBalance(+1000)", s.UpdateBalance(ctx, acc.ID, 1000))
+
+	res, err := s.OpenReservation(ctx, owner, acc.ID, 300, key(), time.Minute)
+	if err != nil {
+		t.Fatalf("OpenReservation: %v", err)
+	}
+	wantErr(t, "CancelReservation(other owner)", s.CancelReservation(ctx, res.ID, other), domain.ErrNotFound)
+	must(t, "CancelReservation", s.CancelReservation(ctx, res.ID, owner))
+	wantAccount(t, s, acc.ID, 1000, 0, 1000)
+	wantErr(t, "CancelReservation(again)", s.CancelReservation(ctx, res.ID, owner), domain.ErrNotActive)
+	wantErr(t, "CancelReservation(missing)", s.CancelReservation(ctx, missingID, owner), domain.ErrNotFound)
+	checkLedger(t, s, acc.ID)
+}
+
+func testExpiredReservation(t *testing.T, s Store) {
+	ctx := context.Background()
+	owner := newService(t, s)
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateBalance(+1000)", s.UpdateBalance(ctx, acc.ID, 1000))
+
+	res, err := s.OpenReservation(ctx, owner, acc.ID, 300, key(), 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("OpenReservation: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	wantErr(t, "ConfirmReservation(expired)", s.ConfirmReservation(ctx, res.ID, owner), domain.ErrExpired)
+	wantAccount(t, s, acc.ID, 1000, 300, 1000)
+
+	must(t, "CancelReservation(expired)", s.CancelReservation(ctx, res.ID, owner))
+	wantAccount(t, s, acc.ID, 1000, 0, 1000)
+	checkLedger(t, s, acc.ID)
+}
+
+func testRefunds(t *testing.T, s Store) {
+	ctx := context.Background()
+	owner, other := newService(t, s), newService(t, s)
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateBalance(+1000)", s.UpdateBalance(ctx, acc.ID, 1000))
+	res := confirmed(t, s, owner, acc.ID, 600)
+	wantAccount(t, s, acc.ID, 400, 0, 1000)
+
+	r1, err := s.RefundReservation(ctx, res.ID, owner, 200, "r1")
+	if err != nil {
+		t.Fatalf("RefundReservation: %v", err)
+	}
+	if r1.Amount != 200 || r1.ReservationID != res.ID || r1.LedgerEntryID == 0 {
+		t.Errorf("RefundReservation = %+v", r1)
+	}
+	wantAccount(t, s, acc.ID, 600, 0, 1000)
+
+	again, err := s.RefundReservation(ctx, res.ID, owner, 200, "r1")
+	if err != nil {
+		t.Fatalf("RefundReservation(same key): %v", err)
+	}
+	if again.ID != r1.ID {
+		t.Errorf("RefundReservation(same key) ID = %d, want %d", again.ID, r1.ID)
+	}
+	wantAccount(t, s, acc.ID, 600, 0, 1000)
+
+	_, err = s.RefundReservation(ctx, res.ID, owner, 500, "r2")
+	wantErr(t, "RefundReservation(exceeds captured)", err, domain.ErrRefundExceeded)
+	_, err = s.RefundReservation(ctx, res.ID, other, 100, "r2")
+	wantErr(t, "RefundReservation(other owner)", err, domain.ErrNotFound)
+
+	if _, err := s.RefundReservation(ctx, res.ID, owner, 400, "r2"); err != nil {
+		t.Fatalf("RefundReservation(rest): %v", err)
+	}
+	wantAccount(t, s, acc.ID, 1000, 0, 1000)
+
+	active, err := s.OpenReservation(ctx, owner, acc.ID, 100, key(), time.Minute)
+	if err != nil {
+		t.Fatalf("OpenReservation: %v", err)
+	}
+	_, err = s.RefundReservation(ctx, active.ID, owner, 100, "r1")
+	wantErr(t, "RefundReservation(active)", err, domain.ErrNotConfirmed)
+	checkLedger(t, s, acc.ID)
+}
+
+func testRefundRespectsMaxAmount(t *testing.T, s Store) {
+	ctx := context.Background()
+	owner := newService(t, s)
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateBalance(+1000)", s.UpdateBalance(ctx, acc.ID, 1000))
+	res := confirmed(t, s, owner, acc.ID, 300)
+	must(t, "UpdateBalance(+300)", s.UpdateBalance(ctx, acc.ID, 300))
+
+	_, err := s.RefundReservation(ctx, res.ID, owner, 100, "r1")
+	wantErr(t, "RefundReservation(above max)", err, domain.ErrLimitExceeded)
+	wantAccount(t, s, acc.ID, 1000, 0, 1000)
+	checkLedger(t, s, acc.ID)
+}
+
+func testPostJournal(t *testing.T, s Store) {
+	ctx := context.Background()
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateBalance(+1000)", s.UpdateBalance(ctx, acc.ID, 1000))
+
+	fee := domain.AdjustmentJournal(acc.ID, 0, "fee", []domain.Posting{
+		{LedgerAccount: domain.AccountLedger(acc.ID), Side: domain.Debit, Amount: 50},
+		{LedgerAccount: domain.LedgerFees, Side: domain.Credit, Amount: 50},
+	})
+	must(t, "PostJournal(fee)", s.PostJournal(ctx, fee))
+	if fee.ID == 0 {
+		t.Errorf("PostJournal did not assign ID")
+	}
+	wantAccount(t, s, acc.ID, 950, 0, 1000)
+
+	unbalanced := domain.AdjustmentJournal(acc.ID, 0, "", []domain.Posting{
+		{LedgerAccount: domain.AccountLedger(acc.ID), Side: domain.Debit, Amount: 50},
+		{LedgerAccount: domain.LedgerFees, Side: domain.Credit, Amount: 40},
+	})
+	wantErr(t, "PostJournal(unbalanced)", s.PostJournal(ctx, unbalanced), domain.ErrUnbalancedJournal)
+
+	foreign := domain.AdjustmentJournal(acc.ID, 0, "", []domain.Posting{
+		{LedgerAccount: domain.AccountLedger(acc.ID + 1), Side: domain.Debit, Amount: 50},
+		{LedgerAccount: domain.LedgerFees, Side: domain.Credit, Amount: 50},
+	})
+	wantErr(t, "PostJournal(foreign account)", s.PostJournal(ctx, foreign), domain.ErrInvalidPosting)
+
+	overdraw := domain.AdjustmentJournal(acc.ID, 0, "", []domain.Posting{
+		{LedgerAccount: domain.AccountLedger(acc.ID), Side: domain.Debit, Amount: 5000},
+		{LedgerAccount: domain.LedgerFees, Side: domain.Credit, Amount: 5000},
+	})
+	wantErr(t, "PostJournal(overdraw)", s.PostJournal(ctx, overdraw), domain.ErrNotEnoughFunds)
+	checkLedger(t, s, acc.ID)
+}
+
+func testReverseEntry(t *testing.T, s Store) {
+	ctx := context.Background()
+	owner := newService(t, s)
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateBalance(+300)", s.UpdateBalance(ctx, acc.ID, 300))
+	credit := findEntry(t, s, acc.ID, domain.OpBalanceIncrease)
+
+	rev, err := s.ReverseEntry(ctx, credit.ID, owner, domain.ReasonOperatorError, "wrong account")
+	if err != nil {
+		t.Fatalf("ReverseEntry: %v", err)
+	}
+	if rev.Operation != domain.OpReversal || rev.ReversalOf != credit.ID || rev.ReasonCode != domain.ReasonOperatorError {
+		t.Errorf("ReverseEntry = %+v", rev)
+	}
+	wantAccount(t, s, acc.ID, 0, 0, 1000)
+
+	_, err = s.ReverseEntry(ctx, credit.ID, owner, domain.ReasonOperatorError, "")
+	wantErr(t, "ReverseEntry(again)", err, domain.ErrAlreadyReversed)
+	_, err = s.ReverseEntry(ctx, rev.ID, owner, domain.ReasonOperatorError, "")
+	wantErr(t, "ReverseEntry(reversal)", err, domain.ErrNotReversible)
+	_, err = s.ReverseEntry(ctx, missingID, owner, domain.ReasonOperatorError, "")
+	wantErr(t, "ReverseEntry(missing)", err, domain.ErrNotFound)
+
+	if got := findEntry(t, s, acc.ID, domain.OpBalanceIncrease); got.ReversedBy != rev.ID {
+		t.Errorf("original ReversedBy = %d, want %d", got.ReversedBy, rev.ID)
+	}
+
+	must(t, "UpdateBalance(+500)", s.UpdateBalance(ctx, acc.ID, 500))
+	res, err := s.OpenReservation(ctx, owner, acc.ID, 100, key(), time.Minute)
+	if err != nil {
+		t.Fatalf("OpenReservation: %v", err)
+	}
+	open := findEntry(t, s, acc.ID, domain.OpReserveOpen)
+	if open.ReservationID != res.ID {
+		t.Fatalf("RESERVE_OPEN entry reservation = %d, want %d", open.ReservationID, res.ID)
+	}
+	_, err = s.ReverseEntry(ctx, open.ID, owner, domain.ReasonOperatorError, "")
+	wantErr(t, "ReverseEntry(reserve open)", err, domain.ErrNotReversible)
+
+	// Сторно пополнения не может залезть в зарезервированные средства
+	deposit := findEntry(t, s, acc.ID, domain.OpBalanceIncrease)
+	must(t, "UpdateBalance(-400)", s.UpdateBalance(ctx, acc.ID, -400))
+	_, err = s.ReverseEntry(ctx, deposit.ID, owner, domain.ReasonOperatorError, "")
+	wantErr(t, "ReverseEntry(into reserved)", err, domain.ErrNotEnoughFunds)
+
+	// Сторно уменьшения лимита подняло бы лимит в обход подтверждения
+	must(t, "UpdateLimit(-100)", s.UpdateLimit(ctx, acc.ID, -100))
+	_, err = s.ReverseEntry(ctx, findEntry(t, s, acc.ID, domain.OpLimitDecrease).ID, owner, domain.ReasonOperatorError, "")
+	wantErr(t, "ReverseEntry(limit decrease)", err, domain.ErrNotReversible)
+	must(t, "UpdateCreditLimit(+100)", s.UpdateCreditLimit(ctx, 0, acc.ID, 100))
+	_, err = s.ReverseEntry(ctx, findEntry(t, s, acc.ID, domain.OpCreditIncrease).ID, owner, domain.ReasonOperatorError, "")
+	wantErr(t, "ReverseEntry(credit limit)", err, domain.ErrNotReversible)
+
+	// Подтверждение резерва сторнируется только владельцем резерва
+	must(t, "UpdateBalance(+50)", s.UpdateBalance(ctx, acc.ID, 50))
+	res = confirmed(t, s, owner, acc.ID, 50)
+	_, err = s.ReverseEntry(ctx, findEntry(t, s, acc.ID, domain.OpReserveConfirm).ID, owner, domain.ReasonOperatorError, "")
+	wantErr(t, "ReverseEntry(reserve confirm)", err, domain.ErrNotReversible)
+
+	// Замороженный счёт не двигается и через сторно
+	must(t, "UpdateBalance(+100)", s.UpdateBalance(ctx, acc.ID, 100))
+	must(t, "SetAccountFrozen", s.SetAccountFrozen(ctx, acc.ID, true))
+	_, err = s.ReverseEntry(ctx, findEntry(t, s, acc.ID, domain.OpBalanceIncrease).ID, owner, domain.ReasonOperatorError, "")
+	wantErr(t, "ReverseEntry(frozen)", err, domain.ErrAccountFrozen)
+	_, err = s.ReverseReservation(ctx, res.ID, owner, domain.ReasonCustomerRefund, "")
+	wantErr(t, "ReverseReservation(frozen)", err, domain.ErrAccountFrozen)
+	checkLedger(t, s, acc.ID)
+}
+
+func testReverseReservation(t *testing.T, s Store) {
+	ctx := context.Background()
+	owner, other := newService(t, s), newService(t, s)
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateBalance(+1000)", s.UpdateBalance(ctx, acc.ID, 1000))
+
+	res := confirmed(t, s, owner, acc.ID, 400)
+	_, err := s.ReverseReservation(ctx, res.ID, other, domain.ReasonCustomerRefund, "")
+	wantErr(t, "ReverseReservation(other owner)", err, domain.ErrNotFound)
+
+	rev, err := s.ReverseReservation(ctx, res.ID, owner, domain.ReasonCustomerRefund, "")
+	if err != nil {
+		t.Fatalf("ReverseReservation: %v", err)
+	}
+	if rev.ReservationID != res.ID || rev.DeltaCurrent != 400 {
+		t.Errorf("ReverseReservation = %+v", rev)
+	}
+	wantAccount(t, s, acc.ID, 1000, 0, 1000)
+
+	_, err = s.ReverseReservation(ctx, res.ID, owner, domain.ReasonCustomerRefund, "")
+	wantErr(t, "ReverseReservation(again)", err, domain.ErrAlreadyReversed)
+	_, err = s.RefundReservation(ctx, res.ID, owner, 100, "r1")
+	wantErr(t, "RefundReservation(after reversal)", err, domain.ErrRefundExceeded)
+
+	refunded := confirmed(t, s, owner, acc.ID, 200)
+	if _, err := s.RefundReservation(ctx, refunded.ID, owner, 50, "r1"); err != nil {
+		t.Fatalf("RefundReservation: %v", err)
+	}
+	_, err = s.ReverseReservation(ctx, refunded.ID, owner, domain.ReasonCustomerRefund, "")
+	wantErr(t, "ReverseReservation(after refund)", err, domain.ErrNotReversible)
+
+	active, err := s.OpenReservation(ctx, owner, acc.ID, 100, key(), time.Minute)
+	if err != nil {
+		t.Fatalf("OpenReservation: %v", err)
+	}
+	_, err = s.ReverseReservation(ctx, active.ID, owner, domain.ReasonCustomerRefund, "")
+	wantErr(t, "ReverseReservation(active)", err, domain.ErrNotReversible)
+	checkLedger(t, s, acc.ID)
+}
+
+func testAsOf(t *testing.T, s Store) {
+	ctx := context.Background()
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateBalance(+100)", s.UpdateBalance(ctx, acc.ID, 100))
+	first := findEntry(t, s, acc.ID, domain.OpBalanceIncrease)
+	time.Sleep(20 * time.Millisecond)
+	must(t, "UpdateBalance(+50)", s.UpdateBalance(ctx, acc.ID, 50))
+	must(t, "UpdateLimit(+500)", s.UpdateLimit(ctx, acc.ID, 500))
+
+	got, err := s.GetAccountAsOf(ctx, acc.ID, first.CreatedAt)
+	if err != nil {
+		t.Fatalf("GetAccountAsOf: %v", err)
+	}
+	if got.CurrentAmount != 100 || got.MaxAmount != 1000 {
+		t.Errorf("GetAccountAsOf(first) = %+v, want current 100, max 1000", got)
+	}
+
+	got, err = s.GetAccountAsOf(ctx, acc.ID, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetAccountAsOf: %v", err)
+	}
+	if got.CurrentAmount != 150 || got.MaxAmount != 1500 {
+		t.Errorf("GetAccountAsOf(now) = %+v, want current 150, max 1500", got)
+	}
+
+	_, err = s.GetAccountAsOf(ctx, missingID, time.Now())
+	wantErr(t, "GetAccountAsOf(missing)", err, domain.ErrNotFound)
+}
+
+func testApprovePendingOperation(t *testing.T, s Store) {
+	ctx := context.Background()
+	owner := newService(t, s)
+	acc := newAccount(t, s, 1000)
+	must(t, "UpdateBalance(+300)", s.UpdateBalance(ctx, acc.ID, 300))
+
+	// Невыполнимая операция переходит в FAILED, счёт не меняется
+	failed := &domain.PendingOperation{Kind: domain.OperationDebit, ServiceID: owner, AccountID: acc.ID, Amount: 500}
+	must(t, "CreatePendingOperation", s.CreatePendingOperation(ctx, failed))
+	wantErr(t, "ApprovePendingOperation(not enough funds)", s.ApprovePendingOperation(ctx, failed), domain.ErrNotEnoughFunds)
+	if failed.Status != domain.PendingFailed || failed.Error == "" || failed.EntryID != 0 {
+		t.Errorf("failed operation = %+v", failed)
+	}
+	wantAccount(t, s, acc.ID, 300, 0, 1000)
+	wantErr(t, "ApprovePendingOperation(failed)", s.ApprovePendingOperation(ctx, failed), domain.ErrNotPending)
+
+	// Из одновременных одобрений выполняется одно
+	op := &domain.PendingOperation{Kind: domain.OperationReservation, ServiceID: owner, AccountID: acc.ID, Amount: 100,
+		IdempotencyKey: key(), Timeout: time.Minute}
+	must(t, "CreatePendingOperation", s.CreatePendingOperation(ctx, op))
+	const n = 4
+	errs := make([]error, n)
+	ops := make([]domain.PendingOperation, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		ops[i] = domain.PendingOperation{ID: op.ID, Note: "ok"}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.ApprovePendingOperation(ctx, &ops[i])
+		}()
+	}
+	wg.Wait()
+	var approved *domain.PendingOperation
+	for i, err := range errs {
+		if err == nil {
+			if approved != nil {
+				t.Fatalf("operation %d approved twice", op.ID)
+			}
+			approved = &ops[i]
+			continue
+		}
+		wantErr(t, "ApprovePendingOperation(concurrent)", err, domain.ErrNotPending)
+	}
+	if approved == nil || approved.Status != domain.PendingApproved || approved.ReservationID == 0 || approved.Note != "ok" || approved.DecidedAt.IsZero() {
+		t.Fatalf("approved operation = %+v", approved)
+	}
+	wantAccount(t, s, acc.ID, 300, 100, 1000)
+	got, err := s.GetPendingOperation(ctx, op.ID)
+	if err != nil {
+		t.Fatalf("GetPendingOperation: %v", err)
+	}
+	if got.Status != domain.PendingApproved || got.ReservationID != approved.ReservationID {
+		t.Errorf("GetPendingOperation = %+v, want approved with reservation %d", got, approved.ReservationID)
+	}
+
+	wantErr(t, "ApprovePendingOperation(missing)", s.ApprovePendingOperation(ctx, &domain.PendingOperation{ID: missingID}), domain.ErrNotFound)
+	checkLedger(t, s, acc.ID)
+}
+
+// checkLedger проверяет инварианты счёта, см. CheckAccount.
+func checkLedger(t *testing.T, s Store, accountID int64) {
+	t.Helper()
+	if err := CheckAccount(context.Background(), s, accountID); err != nil {
+		t.Error(err)
+	}
+}
+
+// CheckAccount проверяет инварианты счёта accountID: доступные средства не
+// отрицательны, баланс не выше max_amount, reserved равен сумме ACTIVE
+// резервов, а журнал сходится с балансом (см. CheckLedger).
+func CheckAccount(ctx context.Context, s repository.Balance, accountID int64) error {
+	acc, err := s.GetAccount(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("GetAccount(%d): %w", accountID, err)
+	}
+	if acc.Available() < 0 {
+		return fmt.Errorf("account %d: negative available funds %d", accountID, acc.Available())
+	}
+	if acc.CurrentAmount > acc.MaxAmount {
+		return fmt.Errorf("account %d: current %d above max %d", accountID, acc.CurrentAmount, acc.MaxAmount)
+	}
+
+	reservations, err := s.ListReservations(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("ListReservations(%d): %w", accountID, err)
+	}
+	var active int64
+	for _, res := range reservations {
+		if res.Status == "ACTIVE" {
+			active += res.Amount
+		}
+	}
+	if active != acc.ReservedAmount {
+		return fmt.Errorf("account %d: reserved %d != sum of active reservations %d", accountID, acc.ReservedAmount, active)
+	}
+	return CheckLedger(ctx, s, accountID)
+}
+
+// CheckLedger проверяет инварианты журнала по счёту accountID. Счёт должен
+// быть заведён через CreateAccount, чтобы вся его история была в журнале.
+func CheckLedger(ctx context.Context, s repository.Balance, accountID int64) error {
+	acc, err := s.GetAccount(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("GetAccount(%d): %w", accountID, err)
+	}
+	entries, err := s.ListJournal(ctx, accountID, 1<<30)
+	if err != nil {
+		return fmt.Errorf("ListJournal(%d): %w", accountID, err)
+	}
+
+	own := domain.AccountLedger(accountID)
+	var sum domain.Account
+	var net int64
+	for _, e := range entries {
+		var debit, credit int64
+		for _, p := range e.Postings {
+			switch p.Side {
+			case domain.Debit:
+				debit += p.Amount
+				if p.LedgerAccount == own {
+					net -= p.Amount
+				}
+			case domain.Credit:
+				credit += p.Amount
+				if p.LedgerAccount == own {
+					net += p.Amount
+				}
+			}
+		}
+		if debit != credit {
+			return fmt.Errorf("entry %d (%s): debit %d != credit %d", e.ID, e.Operation, debit, credit)
+		}
+		sum.CurrentAmount += e.DeltaCurrent
+		sum.ReservedAmount += e.DeltaReserved
+		sum.MaxAmount += e.DeltaMax
+		sum.CreditLimit += e.DeltaCredit
+	}
+
+	if sum.CurrentAmount != acc.CurrentAmount || sum.ReservedAmount != acc.ReservedAmount ||
+		sum.MaxAmount != acc.MaxAmount || sum.CreditLimit != acc.CreditLimit {
+		return fmt.Errorf("account %d: journal sums current %d reserved %d max %d credit %d, account has %d %d %d %d",
+			accountID, sum.CurrentAmount, sum.ReservedAmount, sum.MaxAmount, sum.CreditLimit,
+			acc.CurrentAmount, acc.ReservedAmount, acc.MaxAmount, acc.CreditLimit)
+	}
+	if net != acc.CurrentAmount-acc.ReservedAmount {
+		return fmt.Errorf("account %d: ledger %s balance %d != current - reserved %d",
+			accountID, own, net, acc.CurrentAmount-acc.ReservedAmount)
+	}
+	return nil
+}
+
+func newAccount(t *testing.T, s Store, maxAmount int64) *domain.Account {
+	t.Helper()
+	acc, err := s.CreateAccount(context.Background(), seq.Add(1), maxAmount)
+	if err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+	return acc
+}
+
+func newService(t *testing.T, s Store) int64 {
+	t.Helper()
+	n := seq.Add(1)
+	id, err := s.CreateService(context.Background(), fmt.Sprintf("repotest-%d-%d", time.Now().UnixNano(), n), fmt.Sprintf("repotest-key-%d-%d", time.Now().UnixNano(), n))
+	if err != nil {
+		t.Fatalf("CreateService: %v", err)
+	}
+	return id
+}
+
+func confirmed(t *testing.T, s Store, owner, accountID, amount int64) *domain.Reservation {
+	t.Helper()
+	ctx := context.Background()
+	res, err := s.OpenReservation(ctx, owner, accountID, amount, key(), time.Minute)
+	if err != nil {
+		t.Fatalf("OpenReservation: %v", err)
+	}
+	if err := s.ConfirmReservation(ctx, res.ID, owner); err != nil {
+		t.Fatalf("ConfirmReservation: %v", err)
+	}
+	return res
+}
+
+// findEntry возвращает последнюю проводку по счёту с операцией op.
+func findEntry(t *testing.T, s Store, accountID int64, op string) domain.JournalEntry {
+	t.Helper()
+	entries, err := s.ListJournal(context.Background(), accountID, 1000)
+	if err != nil {
+		t.Fatalf("ListJournal: %v", err)
+	}
+	for _, e := range entries {
+		if e.Operation == op {
+			return e
+		}
+	}
+	t.Fatalf("no %s entry for account %d", op, accountID)
+	return domain.JournalEntry{}
+}
+
+func wantAccount(t *testing.T, s Store, accountID, current, reserved, maxAmount int64) *domain.Account {
+	t.Helper()
+	acc, err := s.GetAccount(context.Background(), accountID)
+	if err != nil {
+		t.Fatalf("GetAccount: %v", err)
+	}
+	if acc.CurrentAmount != current || acc.ReservedAmount != reserved || acc.MaxAmount != maxAmount {
+		t.Errorf("account %d: current %d reserved %d max %d, want %d %d %d",
+			accountID, acc.CurrentAmount, acc.ReservedAmount, acc.MaxAmount, current, reserved, maxAmount)
+	}
+	return acc
+}
+
+func must(t *testing.T, op string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", op, err)
+	}
+}
+
+func wantErr(t *testing.T, op string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%s: error = %v, want %v", op, err, target)
+	}
+}
+
+func key() string {
+	return fmt.Sprintf("repotest-%d-%d", time.Now().UnixNano(), seq.Add(1))
+}
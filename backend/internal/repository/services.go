@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"test_nanimai/backend/domain"
+)
+
+// Services — сервисы-клиенты API.
+type Services interface {
+	// AuthenticateAPIKey возвращает сервис, которому принадлежит действующий
+	// (не истёкший и не отозванный) ключ apiKey, и отмечает время его
+	// использования. Для неизвестного или недействующего ключа — domain.ErrNotFound.
+	AuthenticateAPIKey(ctx context.Context, apiKey string) (*domain.Service, error)
+	// AuthenticateCertificate возвращает сервис, которому назначено любое из
+	// имён identities проверенного клиентского сертификата. Нет такого —
+	// domain.ErrNotFound; имена назначены разным сервисам —
+	// domain.ErrAmbiguousIdentity: сертификат не выбирает сервис сам.
+	AuthenticateCertificate(ctx context.Context, identities []string) (*domain.Service, error)
+}
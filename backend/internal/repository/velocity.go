@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"test_nanimai/backend/domain"
+	"time"
+)
+
+// Velocity — правила частоты и объёма списаний и данные ledger для их проверки.
+type Velocity interface {
+	// AccountVelocityRules возвращает правила, действующие для счёта: заданные
+	// для него самого и для любого из его тегов, по возрастанию ID. Для
+	// несуществующего счёта — пустой список.
+	AccountVelocityRules(ctx context.Context, accountID int64) ([]domain.VelocityRule, error)
+	// VelocityUsage возвращает списания со счёта за последние window по часам хранилища.
+	VelocityUsage(ctx context.Context, accountID int64, window time.Duration) (domain.VelocityUsage, error)
+}
+
+type velocityCheckKey struct{}
+
+// WithVelocityCheck возвращает ctx, с которым хранилище проверяет списание
+// op по правилам счёта в транзакции самого списания, под блокировкой
+// счёта: параллельные списания с одного счёта проверяются по очереди, и
+// правило не обойти одновременными запросами. Нарушение —
+// *domain.VelocityViolation (domain.CheckVelocity), списание не
+// выполняется. Проверку делают UpdateBalance, OpenReservation (кроме
+// повтора с тем же ключом), PostJournal и ReverseEntry.
+func WithVelocityCheck(ctx context.Context, op domain.RiskOperation) context.Context {
+	return context.WithValue(ctx, velocityCheckKey{}, op)
+}
+
+// VelocityCheck возвращает операцию, заданную WithVelocityCheck.
+func VelocityCheck(ctx context.Context) (domain.RiskOperation, bool) {
+	op, ok := ctx.Value(velocityCheckKey{}).(domain.RiskOperation)
+	return op, ok
+}
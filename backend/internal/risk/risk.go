@@ -0,0 +1,109 @@
+// Package risk — проверка риска списаний до их выполнения. Checker
+// оценивает операцию: одобряет, отклоняет или отправляет на решение
+// оператора. Guard ограничивает время оценки и по политике решает, что
+// делать, если решения нет: выполнить операцию (fail-open) или отклонить
+// её (fail-closed).
+//
+// Встроенная проверка — правила списаний (пакет velocity); внешнюю
+// систему можно подключить, реализовав Checker.
+package risk
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/metrics"
+)
+
+// Checker оценивает списание.
+type Checker interface {
+	// Assess возвращает решение по операции op. Ошибка означает, что решения нет.
+	Assess(ctx context.Context, op domain.RiskOperation) (domain.RiskAssessment, error)
+}
+
+// Политики при сбое проверки (настройка RISK_FAILURE_POLICY).
+const (
+	FailOpen   = "open"   // операция выполняется, как одобренная
+	FailClosed = "closed" // операция отклоняется с domain.ErrRiskUnavailable
+)
+
+// DefaultTimeout — сколько Guard ждёт решения по умолчанию.
+const DefaultTimeout = 2 * time.Second
+
+// ParseFailurePolicy сообщает, пропускает ли политика policy операции при сбое проверки.
+func ParseFailurePolicy(policy string) (failOpen bool, err error) {
+	switch policy {
+	case FailOpen:
+		return true, nil
+	case FailClosed:
+		return false, nil
+	}
+	return false, fmt.Errorf("unknown failure policy %q", policy)
+}
+
+// Policy — сколько ждать решения и что делать без него.
+type Policy struct {
+	Timeout  time.Duration
+	FailOpen bool
+}
+
+// Guard — Checker с ограничением времени и политикой при сбое. Ошибку
+// возвращает, только если политика отклоняет операцию (domain.ErrRiskUnavailable)
+// или ctx отменён самим вызывающим.
+type Guard struct {
+	checker Checker
+	policy  Policy
+}
+
+func NewGuard(checker Checker, policy Policy) *Guard {
+	return &Guard{checker: checker, policy: policy}
+}
+
+func (g *Guard) Assess(ctx context.Context, op domain.RiskOperation) (domain.RiskAssessment, error) {
+	a, err := g.assess(ctx, op)
+	if err == nil {
+		metrics.RiskDecision(string(op.Kind), string(a.Decision))
+		return a, nil
+	}
+	// Запрос отменён клиентом: решать нечего
+	if ctx.Err() != nil {
+		return domain.RiskAssessment{}, ctx.Err()
+	}
+	if g.policy.FailOpen {
+		slog.Warn("risk: check failed, operation allowed", "operation", op.Kind, "account_id", op.AccountID, "error", err)
+		metrics.RiskDecision(string(op.Kind), metrics.DecisionFailOpen)
+		return domain.RiskAssessment{Decision: domain.RiskApprove, Reason: "risk check failed: " + err.Error()}, nil
+	}
+	slog.Warn("risk: check failed, operation declined", "operation", op.Kind, "account_id", op.AccountID, "error", err)
+	metrics.RiskDecision(string(op.Kind), metrics.DecisionFailClosed)
+	return domain.RiskAssessment{}, fmt.Errorf("%w: %v", domain.ErrRiskUnavailable, err)
+}
+
+// assess ждёт решения не дольше policy.Timeout. Проверка, не успевшая
+// ответить, доработает в фоне с отменённым контекстом.
+func (g *Guard) assess(ctx context.Context, op domain.RiskOperation) (domain.RiskAssessment, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.policy.Timeout)
+	defer cancel()
+
+	type result struct {
+		assessment domain.RiskAssessment
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		a, err := g.checker.Assess(ctx, op)
+		done <- result{a, err}
+	}()
+	select {
+	case r := <-done:
+		if r.err == nil && !r.assessment.Decision.Valid() {
+			return r.assessment, fmt.Errorf("unknown decision %q", r.assessment.Decision)
+		}
+		return r.assessment, r.err
+	case <-ctx.Done():
+		return domain.RiskAssessment{}, fmt.Errorf("no decision in %s", g.policy.Timeout)
+	}
+}
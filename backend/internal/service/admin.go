@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"test_nanimai/backend/domain"
+	"time"
+)
+
+// Admin — управление сервисами, их API-ключами, именами сертификатов,
+// правами, областью, подписью и частотой запросов, а также тегами счетов,
+// правилами списаний, решениями по отложенным операциям и предложениями
+// изменения лимита; доступно по REST сервисам с правом admin.
+type Admin interface {
+	RegisterService(ctx context.Context, name string, permissions []string) (*domain.Service, string, error)
+	ListServices(ctx context.Context) ([]domain.Service, error)
+	SetServicePermissions(ctx context.Context, serviceID int64, permissions []string) error
+	SetServiceScope(ctx context.Context, serviceID int64, accountIDs []int64, tags []string) error
+	SetSigning(ctx context.Context, serviceID int64, mode string, rotate bool) (string, error)
+	SetCertIdentities(ctx context.Context, serviceID int64, identities []string) ([]string, error)
+	SetRateLimit(ctx context.Context, serviceID int64, limit domain.RateLimit) error
+	SetAccountTags(ctx context.Context, accountID int64, tags []string) error
+	CreateVelocityRule(ctx context.Context, rule domain.VelocityRule) (*domain.VelocityRule, error)
+	ListVelocityRules(ctx context.Context, accountID int64) ([]domain.VelocityRule, error)
+	DeleteVelocityRule(ctx context.Context, ruleID int64) error
+	ListPendingOperations(ctx context.Context, status string) ([]domain.PendingOperation, error)
+	GetPendingOperation(ctx context.Context, operationID int64) (*domain.PendingOperation, error)
+	ApprovePendingOperation(ctx context.Context, operationID, decidedBy int64, note string) (*domain.PendingOperation, error)
+	RejectPendingOperation(ctx context.Context, operationID, decidedBy int64, note string) (*domain.PendingOperation, error)
+	ListLimitChanges(ctx context.Context, status string) ([]domain.LimitChange, error)
+	GetLimitChange(ctx context.Context, changeID int64) (*domain.LimitChange, error)
+	ProposeLimitChange(ctx context.Context, kind string, accountID, delta, proposedBy int64, comment string) (*domain.LimitChange, error)
+	ApproveLimitChange(ctx context.Context, changeID, decidedBy int64, note string) (*domain.LimitChange, error)
+	RejectLimitChange(ctx context.Context, changeID, decidedBy int64, note string) (*domain.LimitChange, error)
+	CreateAPIKey(ctx context.Context, serviceID int64, ttl time.Duration) (string, *domain.APIKey, error)
+	RotateAPIKey(ctx context.Context, serviceID int64, overlap, ttl time.Duration) (string, *domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, serviceID int64) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, serviceID, keyID int64) error
+}
@@ -0,0 +1,316 @@
+// Package admin — операции оператора: регистрация сервисов, управление их
+// API-ключами, именами сертификатов, правами, областью, подписью и частотой
+// запросов, заведение, пометка тегами и заморозка счетов, правила
+// списаний, решения по отложенным операциям, предложение и подтверждение
+// изменений лимита, просмотр счёта, принудительное закрытие резервов и сверка.
+// Изменения проходят через репозиторий так же, как операции API: пишутся
+// проводки, метрики и запись в лог.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/metrics"
+	"test_nanimai/backend/internal/repository"
+	"test_nanimai/backend/internal/signing"
+)
+
+var ErrReasonRequired = errors.New("reason is required")
+
+type AdminService struct {
+	repo        repository.Admin
+	limitPolicy domain.LimitPolicy
+}
+
+// NewAdminService создаёт сервис; limitPolicy задаёт срок предложений
+// изменения лимита.
+func NewAdminService(repo repository.Admin, limitPolicy domain.LimitPolicy) *AdminService {
+	return &AdminService{repo: repo, limitPolicy: limitPolicy}
+}
+
+// AccountReport — счёт с его тегами, резервами и последними проводками.
+type AccountReport struct {
+	Account      *domain.Account
+	Tags         []string
+	Reservations []domain.Reservation
+	Journal      []domain.JournalEntry
+}
+
+// RegisterService регистрирует сервис name и выдаёт ему бессрочный API-ключ.
+// permissions = nil — права по умолчанию (domain.DefaultPermissions).
+// Открытое значение ключа возвращается только здесь.
+func (s *AdminService) RegisterService(ctx context.Context, name string, permissions []string) (*domain.Service, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", domain.ErrInvalidName
+	}
+	perms := domain.DefaultPermissions
+	if permissions != nil {
+		var err error
+		if perms, err = domain.ParsePermissions(permissions); err != nil {
+			return nil, "", err
+		}
+	}
+	plain, _ := domain.NewAPIKey(0, 0, time.Now())
+	id, err := s.repo.CreateService(ctx, name, plain)
+	if err != nil {
+		return nil, "", err
+	}
+	if permissions != nil {
+		if err := s.repo.SetServicePermissions(ctx, id, perms); err != nil {
+			return nil, "", err
+		}
+	}
+	slog.Info("admin: service registered", "service_id", id, "name", name, "permissions", perms)
+	return &domain.Service{ID: id, Name: name, Permissions: perms}, plain, nil
+}
+
+// SetServicePermissions заменяет права сервиса.
+func (s *AdminService) SetServicePermissions(ctx context.Context, serviceID int64, permissions []string) error {
+	perms, err := domain.ParsePermissions(permissions)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SetServicePermissions(ctx, serviceID, perms); err != nil {
+		return err
+	}
+	slog.Info("admin: service permissions changed", "service_id", serviceID, "permissions", perms)
+	return nil
+}
+
+// SetServiceScope ограничивает сервис счетами accountIDs и счетами с любым
+// из тегов tags; пустые оба списка снимают ограничение.
+func (s *AdminService) SetServiceScope(ctx context.Context, serviceID int64, accountIDs []int64, tags []string) error {
+	for _, id := range accountIDs {
+		if id <= 0 {
+			return fmt.Errorf("%w: account id %d", domain.ErrInvalidScope, id)
+		}
+	}
+	tags, err := domain.NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	scope := domain.AccountScope{AccountIDs: accountIDs, Tags: tags}
+	if err := s.repo.SetServiceScope(ctx, serviceID, scope); err != nil {
+		return err
+	}
+	slog.Info("admin: service scope changed", "service_id", serviceID, "account_ids", accountIDs, "tags", tags)
+	return nil
+}
+
+// SetSigning задаёт режим подписи запросов сервиса. При включении подписи
+// сервису выдаётся секрет, если его ещё нет или rotate; новый секрет
+// возвращается один раз, иначе возвращается пустая строка. SigningOff
+// удаляет секрет.
+func (s *AdminService) SetSigning(ctx context.Context, serviceID int64, mode string, rotate bool) (string, error) {
+	m, err := domain.ParseSigningMode(mode)
+	if err != nil {
+		return "", err
+	}
+	svc, err := s.serviceByID(ctx, serviceID)
+	if err != nil {
+		return "", err
+	}
+	secret, issued := svc.SigningSecret, ""
+	switch {
+	case m == domain.SigningOff:
+		secret = ""
+	case secret == "" || rotate:
+		secret = signing.NewSecret()
+		issued = secret
+	}
+	if err := s.repo.SetServiceSigning(ctx, serviceID, m, secret); err != nil {
+		return "", err
+	}
+	slog.Info("admin: service signing changed", "service_id", serviceID, "mode", m, "secret_issued", issued != "")
+	return issued, nil
+}
+
+// SetCertIdentities заменяет имена клиентских сертификатов (CN или SAN), по
+// которым сервис аутентифицируется без API-ключа, и возвращает их в
+// нормализованном виде; пустой список отключает вход по сертификату.
+func (s *AdminService) SetCertIdentities(ctx context.Context, serviceID int64, identities []string) ([]string, error) {
+	identities, err := domain.NormalizeCertIdentities(identities)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetServiceCertIdentities(ctx, serviceID, identities); err != nil {
+		return nil, err
+	}
+	slog.Info("admin: service certificate identities changed", "service_id", serviceID, "identities", identities)
+	return identities, nil
+}
+
+// SetRateLimit задаёт ограничение частоты запросов сервиса; действует с
+// первого следующего запроса сервиса.
+func (s *AdminService) SetRateLimit(ctx context.Context, serviceID int64, limit domain.RateLimit) error {
+	if err := limit.Validate(); err != nil {
+		return err
+	}
+	if err := s.repo.SetServiceRateLimit(ctx, serviceID, limit); err != nil {
+		return err
+	}
+	slog.Info("admin: service rate limit changed", "service_id", serviceID, "rate", limit.Rate, "burst", limit.Burst,
+		"account_rate", limit.AccountRate, "account_burst", limit.AccountBurst)
+	return nil
+}
+
+func (s *AdminService) ListServices(ctx context.Context) ([]domain.Service, error) {
+	return s.repo.ListServices(ctx)
+}
+
+// ServiceByName возвращает сервис по имени или domain.ErrNotFound.
+func (s *AdminService) ServiceByName(ctx context.Context, name string) (*domain.Service, error) {
+	services, err := s.repo.ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range services {
+		if services[i].Name == name {
+			return &services[i], nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (s *AdminService) serviceByID(ctx context.Context, serviceID int64) (*domain.Service, error) {
+	services, err := s.repo.ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range services {
+		if services[i].ID == serviceID {
+			return &services[i], nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+// CreateAPIKey выдаёт сервису ещё один ключ, не трогая действующие.
+// При ttl > 0 ключ истекает через ttl.
+func (s *AdminService) CreateAPIKey(ctx context.Context, serviceID int64, ttl time.Duration) (string, *domain.APIKey, error) {
+	if ttl < 0 {
+		return "", nil, domain.ErrInvalidDuration
+	}
+	plain, key := domain.NewAPIKey(serviceID, ttl, time.Now())
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		return "", nil, err
+	}
+	slog.Info("admin: api key created", "service_id", serviceID, "key_id", key.ID, "prefix", key.Prefix)
+	return plain, key, nil
+}
+
+// RotateAPIKey выдаёт сервису новый ключ, а остальные его действующие ключи
+// истекают через overlap: за это время клиенты переходят на новый ключ.
+// overlap = 0 отключает старые ключи сразу.
+func (s *AdminService) RotateAPIKey(ctx context.Context, serviceID int64, overlap, ttl time.Duration) (string, *domain.APIKey, error) {
+	if overlap < 0 || ttl < 0 {
+		return "", nil, domain.ErrInvalidDuration
+	}
+	plain, key := domain.NewAPIKey(serviceID, ttl, time.Now())
+	if err := s.repo.RotateAPIKey(ctx, key, overlap); err != nil {
+		return "", nil, err
+	}
+	slog.Info("admin: api key rotated", "service_id", serviceID, "key_id", key.ID, "prefix", key.Prefix, "overlap", overlap.String())
+	return plain, key, nil
+}
+
+func (s *AdminService) ListAPIKeys(ctx context.Context, serviceID int64) ([]domain.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx, serviceID)
+}
+
+// RevokeAPIKey отзывает ключ сервиса; он перестаёт приниматься сразу.
+func (s *AdminService) RevokeAPIKey(ctx context.Context, serviceID, keyID int64) error {
+	if err := s.repo.RevokeAPIKey(ctx, serviceID, keyID); err != nil {
+		return err
+	}
+	slog.Info("admin: api key revoked", "service_id", serviceID, "key_id", keyID)
+	return nil
+}
+
+// CreateAccount заводит счёт; начальный лимит проводится через журнал.
+func (s *AdminService) CreateAccount(ctx context.Context, userID, maxAmount int64) (*domain.Account, error) {
+	if maxAmount < 0 {
+		return nil, domain.ErrInvalidAmount
+	}
+	acc, err := s.repo.CreateAccount(ctx, userID, maxAmount)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("admin: account created", "account_id", acc.ID, "user_id", userID, "max_amount", maxAmount)
+	return acc, nil
+}
+
+// SetAccountFrozen замораживает или размораживает счёт.
+func (s *AdminService) SetAccountFrozen(ctx context.Context, accountID int64, frozen bool) error {
+	if err := s.repo.SetAccountFrozen(ctx, accountID, frozen); err != nil {
+		return err
+	}
+	slog.Info("admin: account frozen flag changed", "account_id", accountID, "frozen", frozen)
+	return nil
+}
+
+// SetAccountTags заменяет теги счёта; по ним счёт попадает в область сервисов.
+func (s *AdminService) SetAccountTags(ctx context.Context, accountID int64, tags []string) error {
+	tags, err := domain.NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SetAccountTags(ctx, accountID, tags); err != nil {
+		return err
+	}
+	slog.Info("admin: account tags changed", "account_id", accountID, "tags", tags)
+	return nil
+}
+
+// InspectAccount возвращает счёт, все его резервы и journalLimit последних проводок.
+func (s *AdminService) InspectAccount(ctx context.Context, accountID int64, journalLimit int) (*AccountReport, error) {
+	acc, err := s.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.repo.AccountTags(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := s.repo.ListReservations(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	journal, err := s.repo.ListJournal(ctx, accountID, journalLimit)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountReport{Account: acc, Tags: tags, Reservations: reservations, Journal: journal}, nil
+}
+
+// CancelReservation отменяет ACTIVE-резерв любого владельца и возвращает средства на счёт.
+func (s *AdminService) CancelReservation(ctx context.Context, reservationID int64, reason string) (*domain.JournalEntry, error) {
+	return s.closeReservation(ctx, reservationID, domain.OpReserveCancel, metrics.EventCancelled, reason)
+}
+
+// ExpireReservation помечает ACTIVE-резерв истёкшим, не дожидаясь его срока.
+func (s *AdminService) ExpireReservation(ctx context.Context, reservationID int64, reason string) (*domain.JournalEntry, error) {
+	return s.closeReservation(ctx, reservationID, domain.OpReserveExpire, metrics.EventExpired, reason)
+}
+
+func (s *AdminService) closeReservation(ctx context.Context, reservationID int64, op, event, reason string) (*domain.JournalEntry, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+	entry, err := s.repo.CloseReservation(ctx, reservationID, op, reason)
+	if err != nil {
+		return nil, err
+	}
+	metrics.ReservationEvent(event)
+	slog.Info("admin: reservation closed", "reservation_id", reservationID, "account_id", entry.AccountID,
+		"operation", op, "entry_id", entry.ID, "reason", reason)
+	return entry, nil
+}
@@ -0,0 +1,123 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/metrics"
+)
+
+// Operator возвращает сервис name, от имени которого оператор CLI
+// предлагает и решает изменения лимита: у сервиса должно быть право admin,
+// как у тех, кто делает это через API.
+func (s *AdminService) Operator(ctx context.Context, name string) (*domain.Service, error) {
+	if name == "" {
+		return nil, domain.ErrOperatorRequired
+	}
+	svc, err := s.ServiceByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.Require(domain.PermAdmin); err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+// ListLimitChanges возвращает предложения изменения лимита в статусе
+// status (пустой — во всех).
+func (s *AdminService) ListLimitChanges(ctx context.Context, status string) ([]domain.LimitChange, error) {
+	st, err := domain.ParseLimitChangeStatus(status)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListLimitChanges(ctx, st)
+}
+
+func (s *AdminService) GetLimitChange(ctx context.Context, changeID int64) (*domain.LimitChange, error) {
+	return s.repo.GetLimitChange(ctx, changeID)
+}
+
+// ProposeLimitChange предлагает увеличить лимит вида kind (пустой — лимит
+// счёта, CREDIT — кредитную линию) счёта на delta от имени сервиса
+// proposedBy; без него — domain.ErrOperatorRequired. Предложить можно любое
+// увеличение, не только выше порога; лимит не меняется до подтверждения.
+func (s *AdminService) ProposeLimitChange(ctx context.Context, kind string, accountID, delta, proposedBy int64, comment string) (*domain.LimitChange, error) {
+	if proposedBy == 0 {
+		return nil, domain.ErrOperatorRequired
+	}
+	k, err := domain.ParseLimitKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	if delta <= 0 {
+		return nil, domain.ErrInvalidAmount
+	}
+	change := s.limitPolicy.Propose(k, accountID, delta, proposedBy, strings.TrimSpace(comment), time.Now())
+	if err := s.repo.CreateLimitChange(ctx, change); err != nil {
+		return nil, err
+	}
+	metrics.LimitChanges(string(change.Status), 1)
+	slog.Info("admin: limit change proposed", "change_id", change.ID, "account_id", accountID, "kind", k, "delta", delta,
+		"proposed_by", proposedBy, "expires_at", change.ExpiresAt)
+	return change, nil
+}
+
+// ApproveLimitChange подтверждает предложение от имени сервиса decidedBy:
+// лимит меняется и пишется проводка. Подтвердить своё предложение нельзя
+// (domain.ErrSelfApproval), истёкшее — тоже (domain.ErrChangeExpired).
+// Решение без decidedBy и предложение без автора (прежние предложения из
+// CLI или удалённого сервиса) не доказывают, что решают двое:
+// domain.ErrOperatorRequired, такое предложение можно только отклонить.
+func (s *AdminService) ApproveLimitChange(ctx context.Context, changeID, decidedBy int64, note string) (*domain.LimitChange, error) {
+	if decidedBy == 0 {
+		return nil, domain.ErrOperatorRequired
+	}
+	change, err := s.repo.GetLimitChange(ctx, changeID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case change.ProposedBy == 0:
+		return nil, domain.ErrOperatorRequired
+	case change.ProposedBy == decidedBy:
+		return nil, domain.ErrSelfApproval
+	case change.Status == domain.LimitChangeExpired:
+		return nil, domain.ErrChangeExpired
+	}
+	change.DecidedBy, change.Note = decidedBy, strings.TrimSpace(note)
+	if err := s.repo.ApproveLimitChange(ctx, change); err != nil {
+		if errors.Is(err, domain.ErrChangeExpired) {
+			metrics.LimitChanges(string(domain.LimitChangeExpired), 1)
+		}
+		return nil, err
+	}
+	metrics.LimitChanges(string(change.Status), 1)
+	slog.Info("admin: limit change approved", "change_id", change.ID, "account_id", change.AccountID, "kind", change.Kind, "delta", change.Delta,
+		"proposed_by", change.ProposedBy, "decided_by", decidedBy, "entry_id", change.EntryID)
+	return change, nil
+}
+
+// RejectLimitChange отклоняет предложение от имени сервиса decidedBy;
+// предложивший может так отозвать своё.
+func (s *AdminService) RejectLimitChange(ctx context.Context, changeID, decidedBy int64, note string) (*domain.LimitChange, error) {
+	if decidedBy == 0 {
+		return nil, domain.ErrOperatorRequired
+	}
+	change, err := s.repo.GetLimitChange(ctx, changeID)
+	if err != nil {
+		return nil, err
+	}
+	change.DecidedBy, change.Note = decidedBy, strings.TrimSpace(note)
+	if err := s.repo.RejectLimitChange(ctx, change); err != nil {
+		return nil, err
+	}
+	metrics.LimitChanges(string(change.Status), 1)
+	slog.Info("admin: limit change rejected", "change_id", change.ID, "account_id", change.AccountID, "kind", change.Kind, "delta", change.Delta,
+		"proposed_by", change.ProposedBy, "decided_by", decidedBy)
+	return change, nil
+}
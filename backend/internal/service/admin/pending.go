@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/metrics"
+)
+
+// ListPendingOperations возвращает отложенные операции в статусе status
+// (пустой — во всех).
+func (s *AdminService) ListPendingOperations(ctx context.Context, status string) ([]domain.PendingOperation, error) {
+	st, err := domain.ParsePendingStatus(status)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListPendingOperations(ctx, st)
+}
+
+func (s *AdminService) GetPendingOperation(ctx context.Context, operationID int64) (*domain.PendingOperation, error) {
+	return s.repo.GetPendingOperation(ctx, operationID)
+}
+
+// ApprovePendingOperation одобряет отложенную операцию от имени decidedBy
+// (0 — оператор из CLI) и выполняет её без повторной проверки риска: срок
+// резерва отсчитывается от одобрения. Одобрение и выполнение атомарны
+// (repository.PendingOperations.ApprovePendingOperation): операция не
+// остаётся одобренной, но невыполненной. Если выполнить не удалось
+// (например, средств уже не хватает), операция остаётся в статусе FAILED с
+// причиной, а ошибка возвращается вместе с ней. Сервис не может одобрить
+// свою же операцию (domain.ErrSelfApproval).
+func (s *AdminService) ApprovePendingOperation(ctx context.Context, operationID, decidedBy int64, note string) (*domain.PendingOperation, error) {
+	op, err := s.repo.GetPendingOperation(ctx, operationID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case op.Status != domain.PendingWaiting:
+		return nil, domain.ErrNotPending
+	case decidedBy != 0 && decidedBy == op.ServiceID:
+		return nil, domain.ErrSelfApproval
+	}
+	op.DecidedBy, op.Note = decidedBy, strings.TrimSpace(note)
+	execErr := s.repo.ApprovePendingOperation(ctx, op)
+	if execErr != nil && op.Status != domain.PendingFailed {
+		return nil, execErr
+	}
+	if op.Kind == domain.OperationReservation && op.Status == domain.PendingApproved {
+		metrics.ReservationEvent(metrics.EventOpened)
+	}
+	metrics.PendingResolved(string(op.Kind), string(op.Status))
+	slog.Info("admin: pending operation approved", "operation_id", op.ID, "kind", op.Kind, "account_id", op.AccountID,
+		"amount", op.Amount, "decided_by", decidedBy, "status", op.Status, "error", op.Error)
+	if execErr != nil {
+		return op, fmt.Errorf("pending operation %d failed: %w", op.ID, execErr)
+	}
+	return op, nil
+}
+
+// RejectPendingOperation отклоняет отложенную операцию; повтор резерва с
+// тем же ключом получит отказ с комментарием note.
+func (s *AdminService) RejectPendingOperation(ctx context.Context, operationID, decidedBy int64, note string) (*domain.PendingOperation, error) {
+	op, err := s.repo.GetPendingOperation(ctx, operationID)
+	if err != nil {
+		return nil, err
+	}
+	op.Status, op.DecidedBy, op.Note = domain.PendingRejected, decidedBy, strings.TrimSpace(note)
+	if err := s.repo.ResolvePendingOperation(ctx, op, domain.PendingWaiting); err != nil {
+		return nil, err
+	}
+	metrics.PendingResolved(string(op.Kind), string(op.Status))
+	slog.Info("admin: pending operation rejected", "operation_id", op.ID, "kind", op.Kind, "account_id", op.AccountID,
+		"amount", op.Amount, "decided_by", decidedBy)
+	return op, nil
+}
@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"test_nanimai/backend/domain"
+)
+
+// Discrepancy — нарушенный инвариант счёта.
+type Discrepancy struct {
+	AccountID int64
+	Problem   string
+}
+
+// ReconcileReport — результат сверки.
+type ReconcileReport struct {
+	Accounts      int
+	Discrepancies []Discrepancy
+}
+
+// Reconcile сверяет каждый счёт с его резервами и журналом: доступные
+// средства не отрицательны, баланс не выше лимита, reserved равен сумме
+// ACTIVE-резервов, суммы изменений по журналу равны полям счёта, каждая
+// проводка сбалансирована, а остаток счёта главной книги равен current - reserved.
+// Сверка только читает данные; расхождения исправляются отдельно.
+func (s *AdminService) Reconcile(ctx context.Context) (*ReconcileReport, error) {
+	ids, err := s.repo.ListAccountIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &ReconcileReport{Accounts: len(ids)}
+	for _, id := range ids {
+		problems, err := s.checkAccount(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("account %d: %w", id, err)
+		}
+		for _, p := range problems {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{AccountID: id, Problem: p})
+		}
+	}
+	return report, nil
+}
+
+func (s *AdminService) checkAccount(ctx context.Context, accountID int64) ([]string, error) {
+	acc, err := s.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := s.repo.ListReservations(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.ListJournal(ctx, accountID, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	if acc.Available() < 0 {
+		problems = append(problems, fmt.Sprintf("negative available funds %d", acc.Available()))
+	}
+	if acc.CurrentAmount > acc.MaxAmount {
+		problems = append(problems, fmt.Sprintf("current %d above max %d", acc.CurrentAmount, acc.MaxAmount))
+	}
+
+	var active int64
+	for _, res := range reservations {
+		if res.Status == "ACTIVE" {
+			active += res.Amount
+		}
+	}
+	if active != acc.ReservedAmount {
+		problems = append(problems, fmt.Sprintf("reserved %d != sum of active reservations %d", acc.ReservedAmount, active))
+	}
+
+	own := domain.AccountLedger(accountID)
+	var sum domain.Account
+	var net int64
+	for _, e := range entries {
+		var debit, credit int64
+		for _, p := range e.Postings {
+			switch p.Side {
+			case domain.Debit:
+				debit += p.Amount
+				if p.LedgerAccount == own {
+					net -= p.Amount
+				}
+			case domain.Credit:
+				credit += p.Amount
+				if p.LedgerAccount == own {
+					net += p.Amount
+				}
+			}
+		}
+		if debit != credit {
+			problems = append(problems, fmt.Sprintf("entry %d (%s): debit %d != credit %d", e.ID, e.Operation, debit, credit))
+		}
+		sum.CurrentAmount += e.DeltaCurrent
+		sum.ReservedAmount += e.DeltaReserved
+		sum.MaxAmount += e.DeltaMax
+		sum.CreditLimit += e.DeltaCredit
+	}
+
+	if sum.CurrentAmount != acc.CurrentAmount || sum.ReservedAmount != acc.ReservedAmount ||
+		sum.MaxAmount != acc.MaxAmount || sum.CreditLimit != acc.CreditLimit {
+		problems = append(problems, fmt.Sprintf("journal sums current %d reserved %d max %d credit %d, account has %d %d %d %d",
+			sum.CurrentAmount, sum.ReservedAmount, sum.MaxAmount, sum.CreditLimit,
+			acc.CurrentAmount, acc.ReservedAmount, acc.MaxAmount, acc.CreditLimit))
+	}
+	if net != acc.CurrentAmount-acc.ReservedAmount {
+		problems = append(problems, fmt.Sprintf("ledger %s balance %d != current - reserved %d",
+			own, net, acc.CurrentAmount-acc.ReservedAmount))
+	}
+	return problems, nil
+}
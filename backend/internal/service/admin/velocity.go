@@ -0,0 +1,44 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+
+	"test_nanimai/backend/domain"
+)
+
+// CreateVelocityRule проверяет и сохраняет правило списаний; оно действует
+// с первого следующего списания со счёта.
+func (s *AdminService) CreateVelocityRule(ctx context.Context, rule domain.VelocityRule) (*domain.VelocityRule, error) {
+	if err := rule.Normalize(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateVelocityRule(ctx, &rule); err != nil {
+		return nil, err
+	}
+	slog.Info("admin: velocity rule created", "rule_id", rule.ID, "account_id", rule.AccountID, "tag", rule.Tag,
+		"kind", rule.Kind, "limit", rule.Limit, "window", rule.Window.String(), "action", rule.Action)
+	return &rule, nil
+}
+
+// ListVelocityRules возвращает все правила, а при accountID != 0 — правила,
+// действующие для счёта: его собственные и его тегов.
+func (s *AdminService) ListVelocityRules(ctx context.Context, accountID int64) ([]domain.VelocityRule, error) {
+	if accountID == 0 {
+		return s.repo.ListVelocityRules(ctx)
+	}
+	// Правила несуществующего счёта — пустой список; отвечаем 404
+	if _, err := s.repo.AccountTags(ctx, accountID); err != nil {
+		return nil, err
+	}
+	return s.repo.AccountVelocityRules(ctx, accountID)
+}
+
+// DeleteVelocityRule удаляет правило.
+func (s *AdminService) DeleteVelocityRule(ctx context.Context, ruleID int64) error {
+	if err := s.repo.DeleteVelocityRule(ctx, ruleID); err != nil {
+		return err
+	}
+	slog.Info("admin: velocity rule deleted", "rule_id", ruleID)
+	return nil
+}
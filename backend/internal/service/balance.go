@@ -7,9 +7,16 @@ import (
 )
 
 type Balance interface {
-	UpdateLimit(ctx context.Context, accountID int64, delta int64) error
-	UpdateBalance(ctx context.Context, accountID int64, delta int64) error
+	GetAccount(ctx context.Context, accountID int64, asOf time.Time) (*domain.Account, error)
+	UpdateLimit(ctx context.Context, actorServiceID, accountID int64, delta int64) error
+	UpdateBalance(ctx context.Context, actorServiceID, accountID int64, delta int64) error
+	UpdateCreditLimit(ctx context.Context, actorServiceID, accountID int64, delta int64) error
 	OpenReservation(ctx context.Context, ownerServiceID, accountID int64, amount int64, idempotencyKey string, timeout time.Duration) (*domain.Reservation, error)
 	ConfirmReservation(ctx context.Context, reservationID int64, ownerServiceID int64) error
 	CancelReservation(ctx context.Context, reservationID int64, ownerServiceID int64) error
+	RefundReservation(ctx context.Context, reservationID, ownerServiceID int64, amount int64, idempotencyKey string) (*domain.Refund, error)
+	PostJournal(ctx context.Context, actorServiceID, accountID int64, description string, postings []domain.Posting) (*domain.JournalEntry, error)
+	ListJournal(ctx context.Context, accountID int64, limit int) ([]domain.JournalEntry, error)
+	ReverseEntry(ctx context.Context, entryID, actorServiceID int64, reasonCode, description string) (*domain.JournalEntry, error)
+	ReverseReservation(ctx context.Context, reservationID, ownerServiceID int64, reasonCode, description string) (*domain.JournalEntry, error)
 }
@@ -2,36 +2,295 @@ package balance
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/logging"
+	"test_nanimai/backend/internal/metrics"
 	"test_nanimai/backend/internal/repository"
+	"test_nanimai/backend/internal/risk"
 )
 
 type BalanceService struct {
 	balanceRepo repository.Balance
+	pending     repository.PendingOperations
+	risk        risk.Checker
+	limits      repository.LimitChanges
+	limitPolicy domain.LimitPolicy
 }
 
-func NewBalanceService(balanceRepo repository.Balance) *BalanceService {
-	return &BalanceService{balanceRepo: balanceRepo}
+// NewBalanceService создаёт сервис. Списания, открытие резервов, переводы
+// со счёта и сторно, уменьшающие баланс, оцениваются checker; операции, отправленные на
+// решение оператора, сохраняются в pending. Увеличения лимита и кредитной
+// линии, требующие подтверждения по limitPolicy, сохраняются в limits.
+func NewBalanceService(balanceRepo repository.Balance, pending repository.PendingOperations, checker risk.Checker,
+	limits repository.LimitChanges, limitPolicy domain.LimitPolicy) *BalanceService {
+	return &BalanceService{balanceRepo: balanceRepo, pending: pending, risk: checker, limits: limits, limitPolicy: limitPolicy}
 }
 
-func (s *BalanceService) UpdateLimit(ctx context.Context, accountID int64, delta int64) error {
-	return s.balanceRepo.UpdateLimit(ctx, accountID, delta)
+// GetAccount возвращает текущее состояние счёта, а при ненулевом asOf —
+// состояние на указанный момент, восстановленное по ledger.
+func (s *BalanceService) GetAccount(ctx context.Context, accountID int64, asOf time.Time) (*domain.Account, error) {
+	if asOf.IsZero() {
+		return s.balanceRepo.GetAccount(ctx, accountID)
+	}
+	return s.balanceRepo.GetAccountAsOf(ctx, accountID, asOf)
 }
 
-func (s *BalanceService) UpdateBalance(ctx context.Context, accountID int64, delta int64) error {
-	return s.balanceRepo.UpdateBalance(ctx, accountID, delta)
+// UpdateLimit изменяет лимит. Увеличение, которое вместе с недавними
+// увеличениями и ожидающими предложениями по счёту превышает порог, не
+// применяется, а сохраняется как предложение от имени actorServiceID,
+// которое должен подтвердить другой оператор: ошибка *domain.LimitChangeProposed.
+func (s *BalanceService) UpdateLimit(ctx context.Context, actorServiceID, accountID int64, delta int64) error {
+	switch {
+	case delta <= 0 || s.limitPolicy.Threshold == 0:
+		return s.balanceRepo.UpdateLimit(ctx, accountID, delta)
+	case !s.limitPolicy.RequiresApproval(0, delta):
+		// Само по себе в пределах порога: решает сумма с недавними увеличениями
+		err := s.limits.IncreaseLimit(ctx, accountID, delta, s.limitPolicy)
+		if !errors.Is(err, domain.ErrApprovalRequired) {
+			return err
+		}
+	}
+	return s.propose(ctx, domain.LimitKindMax, actorServiceID, accountID, delta)
+}
+
+// propose сохраняет увеличение лимита вида kind как предложение от имени
+// actorServiceID и возвращает *domain.LimitChangeProposed.
+func (s *BalanceService) propose(ctx context.Context, kind domain.LimitKind, actorServiceID, accountID, delta int64) error {
+	change := s.limitPolicy.Propose(kind, accountID, delta, actorServiceID, "", time.Now())
+	if err := s.limits.CreateLimitChange(ctx, change); err != nil {
+		return err
+	}
+	metrics.LimitChanges(string(change.Status), 1)
+	logging.AddAttrs(ctx, logging.LimitChangeID(change.ID))
+	return &domain.LimitChangeProposed{ChangeID: change.ID, ExpiresAt: change.ExpiresAt}
+}
+
+// UpdateBalance изменяет баланс; списание сначала оценивает проверка риска.
+func (s *BalanceService) UpdateBalance(ctx context.Context, actorServiceID, accountID int64, delta int64) error {
+	if delta >= 0 {
+		return s.balanceRepo.UpdateBalance(ctx, accountID, delta)
+	}
+	op := domain.RiskOperation{Kind: domain.OperationDebit, ServiceID: actorServiceID, AccountID: accountID, Amount: -delta}
+	if err := s.assess(ctx, op, nil); err != nil {
+		return err
+	}
+	err := s.balanceRepo.UpdateBalance(repository.WithVelocityCheck(ctx, op), accountID, delta)
+	if errors.Is(err, domain.ErrNotEnoughFunds) {
+		metrics.InsufficientFunds("update_balance")
+	}
+	return s.violated(ctx, op, err, nil)
+}
+
+// UpdateCreditLimit изменяет кредитную линию от имени actorServiceID:
+// баланс может уйти в минус не глубже её. Увеличение линии сразу
+// увеличивает доступные средства, поэтому подтверждается по тем же
+// правилам, что и увеличение лимита в UpdateLimit.
+func (s *BalanceService) UpdateCreditLimit(ctx context.Context, actorServiceID, accountID int64, delta int64) error {
+	switch {
+	case delta <= 0 || s.limitPolicy.Threshold == 0:
+		return s.balanceRepo.UpdateCreditLimit(ctx, actorServiceID, accountID, delta)
+	case !s.limitPolicy.RequiresApproval(0, delta):
+		err := s.limits.IncreaseCreditLimit(ctx, actorServiceID, accountID, delta, s.limitPolicy)
+		if !errors.Is(err, domain.ErrApprovalRequired) {
+			return err
+		}
+	}
+	return s.propose(ctx, domain.LimitKindCredit, actorServiceID, accountID, delta)
 }
 
 func (s *BalanceService) OpenReservation(ctx context.Context, ownerServiceID, accountID int64, amount int64, idempotencyKey string, timeout time.Duration) (*domain.Reservation, error) {
-	return s.balanceRepo.OpenReservation(ctx, ownerServiceID, accountID, amount, idempotencyKey, timeout)
+	if amount <= 0 {
+		return nil, domain.ErrInvalidAmount
+	}
+	op := domain.RiskOperation{Kind: domain.OperationReservation, ServiceID: ownerServiceID, AccountID: accountID, Amount: amount}
+	a, err := s.risk.Assess(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	if a.Decision != domain.RiskApprove {
+		// Повтор уже открытого резерва, в том числе одобренного оператором,
+		// ничего не списывает: решение проверки к нему не относится
+		if res, err := s.balanceRepo.ReservationByKey(ctx, ownerServiceID, idempotencyKey); err == nil {
+			logging.AddAttrs(ctx, logging.ReservationID(res.ID))
+			return res, nil
+		}
+		if err := s.hold(ctx, op, a, &domain.PendingOperation{IdempotencyKey: idempotencyKey, Timeout: timeout}); err != nil {
+			return nil, err
+		}
+	}
+	res, err := s.balanceRepo.OpenReservation(repository.WithVelocityCheck(ctx, op), ownerServiceID, accountID, amount, idempotencyKey, timeout)
+	switch {
+	case err == nil:
+		metrics.ReservationEvent(metrics.EventOpened)
+		logging.AddAttrs(ctx, logging.ReservationID(res.ID))
+	case errors.Is(err, domain.ErrNotEnoughFunds):
+		metrics.InsufficientFunds("open_reservation")
+	}
+	if err != nil {
+		return nil, s.violated(ctx, op, err, &domain.PendingOperation{IdempotencyKey: idempotencyKey, Timeout: timeout})
+	}
+	return res, nil
 }
 
 func (s *BalanceService) ConfirmReservation(ctx context.Context, reservationID, ownerServiceID int64) error {
-	return s.balanceRepo.ConfirmReservation(ctx, reservationID, ownerServiceID)
+	err := s.balanceRepo.ConfirmReservation(ctx, reservationID, ownerServiceID)
+	switch {
+	case err == nil:
+		metrics.ReservationEvent(metrics.EventConfirmed)
+	case errors.Is(err, domain.ErrExpired):
+		metrics.ReservationEvent(metrics.EventExpired)
+	}
+	return err
 }
 
 func (s *BalanceService) CancelReservation(ctx context.Context, reservationID, ownerServiceID int64) error {
-	return s.balanceRepo.CancelReservation(ctx, reservationID, ownerServiceID)
+	err := s.balanceRepo.CancelReservation(ctx, reservationID, ownerServiceID)
+	if err == nil {
+		metrics.ReservationEvent(metrics.EventCancelled)
+	}
+	return err
+}
+
+// RefundReservation возвращает на счёт часть или всю сумму подтверждённого
+// резерва. Возврат только пополняет счёт, поэтому проверку риска не проходит.
+func (s *BalanceService) RefundReservation(ctx context.Context, reservationID, ownerServiceID int64, amount int64, idempotencyKey string) (*domain.Refund, error) {
+	if amount <= 0 || idempotencyKey == "" {
+		return nil, domain.ErrInvalidAmount
+	}
+	refund, err := s.balanceRepo.RefundReservation(ctx, reservationID, ownerServiceID, amount, idempotencyKey)
+	if err == nil {
+		metrics.ReservationEvent(metrics.EventRefunded)
+	}
+	return refund, err
+}
+
+func (s *BalanceService) PostJournal(ctx context.Context, actorServiceID, accountID int64, description string, postings []domain.Posting) (*domain.JournalEntry, error) {
+	entry := domain.AdjustmentJournal(accountID, actorServiceID, description, postings)
+	if len(entry.Postings) == 0 {
+		return nil, domain.ErrInvalidPosting
+	}
+	if err := entry.ValidateAdjustment(); err != nil {
+		return nil, err
+	}
+	if entry.DeltaCurrent >= 0 {
+		if err := s.balanceRepo.PostJournal(ctx, entry); err != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+	op := domain.RiskOperation{Kind: domain.OperationTransfer, ServiceID: actorServiceID, AccountID: accountID, Amount: -entry.DeltaCurrent}
+	params := &domain.PendingOperation{Description: description, Postings: postings}
+	if err := s.assess(ctx, op, params); err != nil {
+		return nil, err
+	}
+	if err := s.balanceRepo.PostJournal(repository.WithVelocityCheck(ctx, op), entry); err != nil {
+		if errors.Is(err, domain.ErrNotEnoughFunds) {
+			metrics.InsufficientFunds("post_journal")
+		}
+		return nil, s.violated(ctx, op, err, params)
+	}
+	return entry, nil
+}
+
+func (s *BalanceService) ListJournal(ctx context.Context, accountID int64, limit int) ([]domain.JournalEntry, error) {
+	return s.balanceRepo.ListJournal(ctx, accountID, limit)
+}
+
+// ReverseEntry сторнирует проводку entryID. Сторно, уменьшающее баланс
+// счёта (например, сторно пополнения), сначала оценивает проверка риска,
+// как и любое списание.
+func (s *BalanceService) ReverseEntry(ctx context.Context, entryID, actorServiceID int64, reasonCode, description string) (*domain.JournalEntry, error) {
+	if !domain.IsValidReason(reasonCode) {
+		return nil, domain.ErrInvalidReason
+	}
+	orig, err := s.balanceRepo.GetEntry(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if orig.ReversedBy != 0 {
+		return nil, domain.ErrAlreadyReversed
+	}
+	rev, err := domain.ReversalJournal(orig, actorServiceID, reasonCode, description)
+	if err != nil {
+		return nil, err
+	}
+	if rev.DeltaCurrent >= 0 {
+		return s.balanceRepo.ReverseEntry(ctx, entryID, actorServiceID, reasonCode, description)
+	}
+	op := domain.RiskOperation{Kind: domain.OperationReversal, ServiceID: actorServiceID, AccountID: rev.AccountID, Amount: -rev.DeltaCurrent}
+	params := &domain.PendingOperation{Description: description, ReversalOf: entryID, ReasonCode: reasonCode}
+	if err := s.assess(ctx, op, params); err != nil {
+		return nil, err
+	}
+	rev, err = s.balanceRepo.ReverseEntry(repository.WithVelocityCheck(ctx, op), entryID, actorServiceID, reasonCode, description)
+	if err != nil {
+		return nil, s.violated(ctx, op, err, params)
+	}
+	return rev, nil
+}
+
+// ReverseReservation сторнирует подтверждение резерва. Сторно только
+// возвращает списанное на счёт, поэтому проверку риска не проходит.
+func (s *BalanceService) ReverseReservation(ctx context.Context, reservationID, ownerServiceID int64, reasonCode, description string) (*domain.JournalEntry, error) {
+	if !domain.IsValidReason(reasonCode) {
+		return nil, domain.ErrInvalidReason
+	}
+	return s.balanceRepo.ReverseReservation(ctx, reservationID, ownerServiceID, reasonCode, description)
+}
+
+// assess оценивает op и передаёт решение в hold.
+func (s *BalanceService) assess(ctx context.Context, op domain.RiskOperation, params *domain.PendingOperation) error {
+	a, err := s.risk.Assess(ctx, op)
+	if err != nil {
+		return err
+	}
+	return s.hold(ctx, op, a, params)
+}
+
+// violated передаёт в hold нарушение правила списаний, которое хранилище
+// нашло под блокировкой счёта (repository.WithVelocityCheck): проверка до
+// операции его не видела, потому что параллельное списание ещё не было
+// записано. Остальные ошибки возвращает как есть.
+func (s *BalanceService) violated(ctx context.Context, op domain.RiskOperation, err error, params *domain.PendingOperation) error {
+	var v *domain.VelocityViolation
+	if !errors.As(err, &v) {
+		return err
+	}
+	if v.Rule.Action == domain.RiskDecline {
+		metrics.VelocityRejected(string(v.Rule.Kind))
+	}
+	return s.hold(ctx, op, v.Assessment(), params)
+}
+
+// hold применяет решение a по op: одобрение пропускает, отказ возвращает
+// как *domain.RiskDeclined. Операцию, отправленную на решение оператора,
+// сохраняет как отложенную с параметрами из params (может быть nil) и
+// возвращает *domain.PendingReview; повтор резерва с ключом уже решённой
+// операции получает её решение.
+func (s *BalanceService) hold(ctx context.Context, op domain.RiskOperation, a domain.RiskAssessment, params *domain.PendingOperation) error {
+	switch a.Decision {
+	case domain.RiskApprove:
+		return nil
+	case domain.RiskDecline:
+		return &domain.RiskDeclined{Reason: a.Reason}
+	}
+	pending := &domain.PendingOperation{}
+	if params != nil {
+		*pending = *params
+	}
+	pending.Kind, pending.ServiceID, pending.AccountID, pending.Amount = op.Kind, op.ServiceID, op.AccountID, op.Amount
+	pending.Reason = a.Reason
+	if err := s.pending.CreatePendingOperation(ctx, pending); err != nil {
+		return err
+	}
+	logging.AddAttrs(ctx, logging.PendingOperationID(pending.ID))
+	if err := pending.Resolution(); err != nil {
+		return err
+	}
+	// Одобренная операция уже выполнена, но её резерв не нашёлся: отвечаем
+	// ссылкой на операцию, по ней видно, чем всё закончилось
+	return &domain.PendingReview{OperationID: pending.ID, Reason: pending.Reason}
 }
@@ -0,0 +1,36 @@
+package balance
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/metrics"
+)
+
+// RunLimitChangeExpiry периодически переводит в EXPIRED предложения
+// изменения лимита, которые не подтвердили в срок. Подтверждение проверяет
+// срок и само, так что это только уборка списка ожидающих. Работает, пока
+// не отменён ctx.
+func (s *BalanceService) RunLimitChangeExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.limits.ExpireLimitChanges(ctx, time.Now())
+			if err != nil {
+				slog.Error("limit change expiry failed", "error", err)
+				continue
+			}
+			if n > 0 {
+				metrics.LimitChanges(string(domain.LimitChangeExpired), int(n))
+				slog.Info("limit changes expired", "count", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package balance
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	// snapshotMinEntries — сколько новых записей ledger должно накопиться по счёту для нового снимка
+	snapshotMinEntries = 100
+	// snapshotSettle — задержка, после которой запись ledger считается окончательной
+	snapshotSettle = time.Minute
+)
+
+// RunSnapshots периодически сохраняет снимки балансов, ускоряющие запросы
+// баланса на момент времени. Работает, пока не отменён ctx.
+func (s *BalanceService) RunSnapshots(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.balanceRepo.SnapshotBalances(ctx, snapshotMinEntries, snapshotSettle)
+			if err != nil {
+				slog.Error("balance snapshots failed", "error", err)
+				continue
+			}
+			if n > 0 {
+				slog.Info("balance snapshots saved", "accounts", n)
+			}
+		}
+	}
+}
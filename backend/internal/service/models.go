@@ -1,11 +1,34 @@
 package service
 
-import "time"
+import (
+	"test_nanimai/backend/domain"
+	"time"
+)
 
 type AccountDTO struct {
-	UserID        int64
-	CurrentAmount int64
-	MaxAmount     int64
+	ID              int64
+	UserID          int64
+	CurrentAmount   int64
+	ReservedAmount  int64
+	MaxAmount       int64
+	CreditLimit     int64
+	UsedCredit      int64
+	AvailableAmount int64
+	Frozen          bool
+}
+
+func NewAccountDTO(acc *domain.Account) AccountDTO {
+	return AccountDTO{
+		ID:              acc.ID,
+		UserID:          acc.UserID,
+		CurrentAmount:   acc.CurrentAmount,
+		ReservedAmount:  acc.ReservedAmount,
+		MaxAmount:       acc.MaxAmount,
+		CreditLimit:     acc.CreditLimit,
+		UsedCredit:      acc.UsedCredit(),
+		AvailableAmount: acc.Available(),
+		Frozen:          acc.Frozen,
+	}
 }
 
 type ReservationDTO struct {
@@ -27,10 +50,322 @@ type UpdateLimitInput struct {
 	Delta     int64
 }
 
+type UpdateCreditLimitInput struct {
+	AccountID int64
+	Delta     int64
+}
+
 type OpenReservationInput struct {
 	AccountID      int64
-	OwnerServiceID int64
 	Amount         int64
 	IdempotencyKey string
 	Timeout        time.Duration
 }
+
+type RefundReservationInput struct {
+	Amount         int64
+	IdempotencyKey string
+}
+
+type PostJournalInput struct {
+	AccountID   int64
+	Description string
+	Postings    []domain.Posting
+}
+
+type ReverseInput struct {
+	ReasonCode  string
+	Description string
+}
+
+// ServiceDTO — сервис с правами, областью, режимом подписи (без секрета),
+// именами сертификатов и ограничением частоты; пустые ScopeAccountIDs и
+// ScopeTags — все счета.
+type ServiceDTO struct {
+	ID              int64
+	Name            string
+	Permissions     []string
+	ScopeAccountIDs []int64
+	ScopeTags       []string
+	SigningMode     string
+	CertIdentities  []string
+	RateLimit       RateLimitDTO
+}
+
+func NewServiceDTO(svc *domain.Service) ServiceDTO {
+	perms := make([]string, len(svc.Permissions))
+	for i, p := range svc.Permissions {
+		perms[i] = string(p)
+	}
+	return ServiceDTO{
+		ID:              svc.ID,
+		Name:            svc.Name,
+		Permissions:     perms,
+		ScopeAccountIDs: nonNil(svc.Scope.AccountIDs),
+		ScopeTags:       nonNil(svc.Scope.Tags),
+		SigningMode:     string(svc.SigningMode),
+		CertIdentities:  nonNil(svc.CertIdentities),
+		RateLimit:       RateLimitDTO(svc.RateLimit),
+	}
+}
+
+// nonNil заменяет nil пустым срезом, чтобы в JSON был [], а не null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// APIKeyDTO описывает ключ без его значения и хеша. Нулевые времена
+// отдаются как null.
+type APIKeyDTO struct {
+	ID         int64
+	ServiceID  int64
+	Prefix     string
+	Active     bool
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+func NewAPIKeyDTO(key *domain.APIKey, now time.Time) APIKeyDTO {
+	return APIKeyDTO{
+		ID:         key.ID,
+		ServiceID:  key.ServiceID,
+		Prefix:     key.Prefix,
+		Active:     key.Active(now),
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  optionalTime(key.ExpiresAt),
+		RevokedAt:  optionalTime(key.RevokedAt),
+		LastUsedAt: optionalTime(key.LastUsedAt),
+	}
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// IssuedAPIKeyDTO — только что выпущенный ключ; APIKey показывается один раз.
+type IssuedAPIKeyDTO struct {
+	APIKey string
+	Key    APIKeyDTO
+}
+
+type CreateServiceInput struct {
+	Name        string
+	Permissions []string // не задано — все права, кроме admin
+}
+
+type SetPermissionsInput struct {
+	Permissions []string
+}
+
+type SetScopeInput struct {
+	AccountIDs []int64
+	Tags       []string
+}
+
+type SetSigningInput struct {
+	Mode         string // off, optional или required
+	RotateSecret bool   // выдать новый секрет, даже если он уже есть
+}
+
+// SigningDTO — режим подписи; Secret заполнен, только если выдан новый секрет.
+type SigningDTO struct {
+	Mode   string
+	Secret string
+}
+
+// SetCertIdentitiesInput — имена клиентских сертификатов: URI, DNS или
+// email из SAN либо CN субъекта.
+type SetCertIdentitiesInput struct {
+	Identities []string
+}
+
+// CertIdentitiesDTO — назначенные имена сертификатов без повторов, по возрастанию.
+type CertIdentitiesDTO struct {
+	Identities []string
+}
+
+// RateLimitDTO — ограничение частоты запросов сервиса: в среднем Rate
+// запросов в секунду и до Burst подряд, для каждого счёта — AccountRate и
+// AccountBurst. Нулевая частота — без ограничения.
+type RateLimitDTO struct {
+	Rate         float64
+	Burst        int
+	AccountRate  float64
+	AccountBurst int
+}
+
+type SetTagsInput struct {
+	Tags []string
+}
+
+// VelocityRuleDTO — правило списаний для счёта AccountID или для счетов с
+// тегом Tag (задано одно из двух); WindowSeconds — скользящее окно, 0 у
+// single_debit. Action: decline — отклонить операцию, review — отправить
+// её на решение оператора.
+type VelocityRuleDTO struct {
+	ID            int64
+	AccountID     int64
+	Tag           string
+	Kind          string
+	Limit         int64
+	WindowSeconds int64
+	Action        string
+	CreatedAt     time.Time
+}
+
+func NewVelocityRuleDTO(r *domain.VelocityRule) VelocityRuleDTO {
+	return VelocityRuleDTO{
+		ID:            r.ID,
+		AccountID:     r.AccountID,
+		Tag:           r.Tag,
+		Kind:          string(r.Kind),
+		Limit:         r.Limit,
+		WindowSeconds: int64(r.Window / time.Second),
+		Action:        string(r.Action),
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+// CreateVelocityRuleInput — новое правило списаний. Kind: debit_amount —
+// сумма списаний и резервов за окно, debit_count — их число,
+// reservation_count — число резервов, single_debit — наибольшее прямое
+// списание (без окна), больше — только после подтверждения оператором.
+// Action: decline (по умолчанию) или review; у single_debit — только review
+// (он же по умолчанию).
+type CreateVelocityRuleInput struct {
+	AccountID     int64
+	Tag           string
+	Kind          string
+	Limit         int64
+	WindowSeconds int64
+	Action        string
+}
+
+// PendingOperationDTO — операция, отложенная проверкой риска. Kind: debit,
+// reservation или transfer; Status: PENDING, APPROVED, REJECTED или FAILED.
+// ReservationID и EntryID — результат выполнения после одобрения, Error —
+// почему выполнить не удалось.
+type PendingOperationDTO struct {
+	ID             int64
+	Kind           string
+	ServiceID      int64
+	AccountID      int64
+	Amount         int64
+	IdempotencyKey string
+	TimeoutSeconds int64
+	Description    string
+	Postings       []domain.Posting
+	ReversalOf     int64
+	ReasonCode     string
+	Reason         string
+	Status         string
+	DecidedBy      int64
+	Note           string
+	ReservationID  int64
+	EntryID        int64
+	Error          string
+	CreatedAt      time.Time
+	DecidedAt      *time.Time
+}
+
+func NewPendingOperationDTO(op *domain.PendingOperation) PendingOperationDTO {
+	return PendingOperationDTO{
+		ID:             op.ID,
+		Kind:           string(op.Kind),
+		ServiceID:      op.ServiceID,
+		AccountID:      op.AccountID,
+		Amount:         op.Amount,
+		IdempotencyKey: op.IdempotencyKey,
+		TimeoutSeconds: int64(op.Timeout / time.Second),
+		Description:    op.Description,
+		Postings:       op.Postings,
+		ReversalOf:     op.ReversalOf,
+		ReasonCode:     op.ReasonCode,
+		Reason:         op.Reason,
+		Status:         string(op.Status),
+		DecidedBy:      op.DecidedBy,
+		Note:           op.Note,
+		ReservationID:  op.ReservationID,
+		EntryID:        op.EntryID,
+		Error:          op.Error,
+		CreatedAt:      op.CreatedAt,
+		DecidedAt:      optionalTime(op.DecidedAt),
+	}
+}
+
+// DecidePendingInput — комментарий оператора к решению по отложенной
+// операции или предложению изменения лимита.
+type DecidePendingInput struct {
+	Note string
+}
+
+// LimitChangeDTO — предложение увеличить на Delta лимит счёта (Kind MAX)
+// или кредитную линию (Kind CREDIT). Status:
+// PENDING, APPROVED, REJECTED или EXPIRED; EntryID — проводка после
+// подтверждения. ProposedBy и DecidedBy — сервисы (оператор CLI действует
+// от имени сервиса); 0 — автор удалён или предложение создано из CLI без него.
+type LimitChangeDTO struct {
+	ID         int64
+	AccountID  int64
+	Kind       string
+	Delta      int64
+	ProposedBy int64
+	Comment    string
+	Status     string
+	DecidedBy  int64
+	Note       string
+	EntryID    int64
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	DecidedAt  *time.Time
+}
+
+func NewLimitChangeDTO(c *domain.LimitChange) LimitChangeDTO {
+	return LimitChangeDTO{
+		ID:         c.ID,
+		AccountID:  c.AccountID,
+		Kind:       string(c.Kind),
+		Delta:      c.Delta,
+		ProposedBy: c.ProposedBy,
+		Comment:    c.Comment,
+		Status:     string(c.Status),
+		DecidedBy:  c.DecidedBy,
+		Note:       c.Note,
+		EntryID:    c.EntryID,
+		CreatedAt:  c.CreatedAt,
+		ExpiresAt:  c.ExpiresAt,
+		DecidedAt:  optionalTime(c.DecidedAt),
+	}
+}
+
+// ProposeLimitChangeInput — предложение увеличить лимит счёта на Delta (> 0);
+// Kind CREDIT — кредитную линию, пустой или MAX — лимит.
+type ProposeLimitChangeInput struct {
+	AccountID int64
+	Kind      string
+	Delta     int64
+	Comment   string
+}
+
+// CreatedServiceDTO — зарегистрированный сервис и его первый ключ.
+type CreatedServiceDTO struct {
+	Service ServiceDTO
+	APIKey  string
+}
+
+type CreateAPIKeyInput struct {
+	TTLSeconds int64 // 0 — бессрочный
+}
+
+type RotateAPIKeyInput struct {
+	OverlapSeconds int64 // сколько ещё работают прежние ключи; 0 — отключить сразу
+	TTLSeconds     int64 // 0 — бессрочный
+}
@@ -0,0 +1,206 @@
+// Package signing — подпись запросов HMAC-SHA256 секретом сервиса. Подпись
+// покрывает метод, путь, хеш тела, время и одноразовый nonce, поэтому
+// перехваченный из логов API-ключ без секрета бесполезен, а перехваченный
+// подписанный запрос нельзя повторить: время проверяется с допуском на
+// расхождение часов, а nonce запоминается до истечения этого допуска.
+package signing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"test_nanimai/backend/domain"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// Заголовки REST; в gRPC те же имена в нижнем регистре передаются в метаданных.
+const (
+	HeaderSignature = "X-Signature"
+	HeaderTimestamp = "X-Signature-Timestamp"
+	HeaderNonce     = "X-Signature-Nonce"
+)
+
+// GRPCMethod — метод в строке для подписи gRPC-вызова; путь — полное имя
+// метода (/balance.BalanceService/UpdateBalance), тело — сообщение запроса,
+// сериализованное детерминированно.
+const GRPCMethod = "GRPC"
+
+// DefaultSkew — допустимое расхождение времени подписи и часов сервера.
+const DefaultSkew = 5 * time.Minute
+
+// maxNonceLen ограничивает размер nonce, который попадает в кеш.
+const maxNonceLen = 128
+
+var (
+	ErrSignatureRequired = errors.New("request signature required")
+	ErrInvalidSignature  = errors.New("invalid request signature")
+	ErrStaleTimestamp    = errors.New("signature timestamp outside allowed clock skew")
+	ErrReplayedNonce     = errors.New("signature nonce already used")
+)
+
+// Signature — значения заголовков подписи как они пришли в запросе.
+type Signature struct {
+	Value     string
+	Timestamp string
+	Nonce     string
+}
+
+func (s Signature) empty() bool {
+	return s.Value == "" && s.Timestamp == "" && s.Nonce == ""
+}
+
+// StringToSign собирает строку для подписи:
+//
+//	METHOD\nPATH\nTIMESTAMP\nNONCE\nhex(SHA-256(body))
+//
+// PATH для REST — путь с query-строкой, как в строке запроса.
+func StringToSign(method, path, timestamp, nonce string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{method, path, timestamp, nonce, hex.EncodeToString(sum[:])}, "\n")
+}
+
+// Sign возвращает HMAC-SHA256 строки для подписи в hex.
+func Sign(secret, method, path, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(StringToSign(method, path, timestamp, nonce, body)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret возвращает случайный секрет подписи.
+func NewSecret() string {
+	var b [32]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// NewSignature подписывает запрос текущим временем и случайным nonce.
+func NewSignature(secret, method, path string, body []byte) Signature {
+	var b [16]byte
+	rand.Read(b[:])
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := hex.EncodeToString(b[:])
+	return Signature{Value: Sign(secret, method, path, ts, nonce, body), Timestamp: ts, Nonce: nonce}
+}
+
+// SignRequest ставит заголовки подписи в REST-запрос с телом body.
+func SignRequest(req *http.Request, secret string, body []byte) {
+	sig := NewSignature(secret, req.Method, req.URL.RequestURI(), body)
+	req.Header.Set(HeaderSignature, sig.Value)
+	req.Header.Set(HeaderTimestamp, sig.Timestamp)
+	req.Header.Set(HeaderNonce, sig.Nonce)
+}
+
+// UnaryClientInterceptor подписывает каждый gRPC-вызов секретом secret.
+func UnaryClientInterceptor(secret string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		body, err := MarshalGRPC(req)
+		if err != nil {
+			return err
+		}
+		sig := NewSignature(secret, GRPCMethod, method, body)
+		ctx = metadata.AppendToOutgoingContext(ctx,
+			strings.ToLower(HeaderSignature), sig.Value,
+			strings.ToLower(HeaderTimestamp), sig.Timestamp,
+			strings.ToLower(HeaderNonce), sig.Nonce)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// MarshalGRPC сериализует сообщение запроса так же, как при проверке подписи.
+func MarshalGRPC(req any) ([]byte, error) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil, nil
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+}
+
+// Verifier проверяет подписи и хранит использованные nonce. Кеш nonce
+// живёт в памяти процесса: повтор на другой экземпляр ограничен только
+// допуском по времени.
+type Verifier struct {
+	skew time.Duration
+	now  func() time.Time
+
+	mu        sync.Mutex
+	nonces    map[string]time.Time // serviceID:nonce → когда запись можно забыть
+	lastSweep time.Time
+}
+
+// NewVerifier создаёт проверку подписей с допуском skew на расхождение часов.
+func NewVerifier(skew time.Duration) *Verifier {
+	return &Verifier{skew: skew, now: time.Now, nonces: make(map[string]time.Time)}
+}
+
+// Verify проверяет подпись запроса сервиса svc по его режиму:
+// domain.SigningOff — подпись не ожидается, и присланная подпись отклоняется;
+// domain.SigningOptional — неподписанный запрос принимается, подписанный проверяется;
+// domain.SigningRequired — запрос без подписи отклоняется с ErrSignatureRequired.
+func (v *Verifier) Verify(svc *domain.Service, method, path string, body []byte, sig Signature) error {
+	if sig.empty() {
+		if svc.SigningMode == domain.SigningRequired {
+			return ErrSignatureRequired
+		}
+		return nil
+	}
+	if svc.SigningMode == domain.SigningOff || svc.SigningSecret == "" {
+		return ErrInvalidSignature
+	}
+	if sig.Nonce == "" || len(sig.Nonce) > maxNonceLen {
+		return ErrInvalidSignature
+	}
+	unix, err := strconv.ParseInt(sig.Timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	ts := time.Unix(unix, 0)
+	now := v.now()
+	if ts.Before(now.Add(-v.skew)) || ts.After(now.Add(v.skew)) {
+		return ErrStaleTimestamp
+	}
+	got, err := hex.DecodeString(sig.Value)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	want, _ := hex.DecodeString(Sign(svc.SigningSecret, method, path, sig.Timestamp, sig.Nonce, body))
+	if !hmac.Equal(got, want) {
+		return ErrInvalidSignature
+	}
+	// nonce запоминается только для верной подписи, чтобы кеш нельзя было забить
+	if !v.remember(strconv.FormatInt(svc.ID, 10)+":"+sig.Nonce, ts.Add(v.skew), now) {
+		return ErrReplayedNonce
+	}
+	return nil
+}
+
+// remember записывает nonce до момента until; false — nonce уже был.
+func (v *Verifier) remember(key string, until, now time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if now.Sub(v.lastSweep) > v.skew {
+		for k, exp := range v.nonces {
+			if now.After(exp) {
+				delete(v.nonces, k)
+			}
+		}
+		v.lastSweep = now
+	}
+	if exp, ok := v.nonces[key]; ok && !now.After(exp) {
+		return false
+	}
+	v.nonces[key] = until
+	return true
+}
@@ -0,0 +1,178 @@
+package signing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"test_nanimai/backend/domain"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	secret = "secret"
+	method = http.MethodPut
+	path   = "/accounts/1/balance?dry_run=1"
+)
+
+var body = []byte(`{"Delta":-100}`)
+
+// newVerifier возвращает Verifier с часами, стоящими в now.
+func newVerifier(now *time.Time) *Verifier {
+	v := NewVerifier(DefaultSkew)
+	v.now = func() time.Time { return *now }
+	return v
+}
+
+// signAt подписывает запрос временем ts и nonce секретом key.
+func signAt(key string, ts time.Time, nonce string) Signature {
+	unix := strconv.FormatInt(ts.Unix(), 10)
+	return Signature{Value: Sign(key, method, path, unix, nonce, body), Timestamp: unix, Nonce: nonce}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	valid := signAt(secret, now, "n1")
+
+	cases := []struct {
+		name string
+		mode domain.SigningMode
+		path string // пусто — path
+		body []byte // nil — body
+		sig  Signature
+		want error
+
+		noSecret bool // у сервиса нет секрета
+	}{
+		{name: "off/unsigned", mode: domain.SigningOff},
+		{name: "off/signed", mode: domain.SigningOff, sig: valid, want: ErrInvalidSignature},
+		{name: "optional/unsigned", mode: domain.SigningOptional},
+		{name: "optional/valid", mode: domain.SigningOptional, sig: valid},
+		{name: "required/unsigned", mode: domain.SigningRequired, want: ErrSignatureRequired},
+		{name: "required/valid", mode: domain.SigningRequired, sig: valid},
+		{name: "wrong secret", mode: domain.SigningRequired, sig: signAt("other", now, "n1"), want: ErrInvalidSignature},
+		{name: "other path", mode: domain.SigningRequired, path: "/accounts/2/balance", sig: valid, want: ErrInvalidSignature},
+		{name: "other body", mode: domain.SigningRequired, body: []byte(`{"Delta":-1000}`), sig: valid, want: ErrInvalidSignature},
+		{name: "not hex", mode: domain.SigningRequired, sig: Signature{Value: "zz", Timestamp: valid.Timestamp, Nonce: "n1"}, want: ErrInvalidSignature},
+		{name: "bad timestamp", mode: domain.SigningRequired, sig: Signature{Value: valid.Value, Timestamp: "now", Nonce: "n1"}, want: ErrInvalidSignature},
+		{name: "no nonce", mode: domain.SigningRequired, sig: signAt(secret, now, ""), want: ErrInvalidSignature},
+		{name: "long nonce", mode: domain.SigningRequired, sig: signAt(secret, now, strings.Repeat("n", maxNonceLen+1)), want: ErrInvalidSignature},
+		{name: "within skew", mode: domain.SigningRequired, sig: signAt(secret, now.Add(-DefaultSkew+time.Second), "n1")},
+		{name: "too old", mode: domain.SigningRequired, sig: signAt(secret, now.Add(-DefaultSkew-time.Second), "n1"), want: ErrStaleTimestamp},
+		{name: "from future", mode: domain.SigningRequired, sig: signAt(secret, now.Add(DefaultSkew+time.Second), "n1"), want: ErrStaleTimestamp},
+		{name: "no secret", mode: domain.SigningRequired, noSecret: true, sig: valid, want: ErrInvalidSignature},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			svc := &domain.Service{ID: 1, SigningMode: c.mode, SigningSecret: secret}
+			if c.noSecret {
+				svc.SigningSecret = ""
+			}
+			p, b := path, body
+			if c.path != "" {
+				p = c.path
+			}
+			if c.body != nil {
+				b = c.body
+			}
+			if err := newVerifier(&now).Verify(svc, method, p, b, c.sig); !errors.Is(err, c.want) {
+				t.Errorf("Verify = %v, want %v", err, c.want)
+			}
+		})
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	v := newVerifier(&now)
+	svc := &domain.Service{ID: 1, SigningMode: domain.SigningRequired, SigningSecret: secret}
+	other := &domain.Service{ID: 2, SigningMode: domain.SigningRequired, SigningSecret: secret}
+
+	// Неверная подпись nonce не занимает
+	if err := v.Verify(svc, method, path, body, signAt("wrong", now, "n1")); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Verify(wrong secret) = %v", err)
+	}
+	sig := signAt(secret, now, "n1")
+	if err := v.Verify(svc, method, path, body, sig); err != nil {
+		t.Fatalf("Verify = %v", err)
+	}
+	if err := v.Verify(svc, method, path, body, sig); !errors.Is(err, ErrReplayedNonce) {
+		t.Errorf("Verify(replay) = %v, want %v", err, ErrReplayedNonce)
+	}
+	// nonce у каждого сервиса свой
+	if err := v.Verify(other, method, path, body, sig); err != nil {
+		t.Errorf("Verify(other service) = %v", err)
+	}
+
+	// Запись о nonce забывается, когда подпись с ним уже устарела бы
+	now = now.Add(2*DefaultSkew + time.Second)
+	if err := v.Verify(svc, method, path, body, signAt(secret, now, "n2")); err != nil {
+		t.Fatalf("Verify(later) = %v", err)
+	}
+	if _, ok := v.nonces["1:n1"]; ok {
+		t.Error("expired nonce was not swept")
+	}
+	if err := v.Verify(svc, method, path, body, sig); !errors.Is(err, ErrStaleTimestamp) {
+		t.Errorf("Verify(replay after skew) = %v, want %v", err, ErrStaleTimestamp)
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	req, err := http.NewRequest(method, "http://localhost"+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SignRequest(req, secret, body)
+	sig := Signature{
+		Value:     req.Header.Get(HeaderSignature),
+		Timestamp: req.Header.Get(HeaderTimestamp),
+		Nonce:     req.Header.Get(HeaderNonce),
+	}
+	svc := &domain.Service{ID: 1, SigningMode: domain.SigningRequired, SigningSecret: secret}
+	// Путь подписывается вместе с query-строкой
+	if err := NewVerifier(DefaultSkew).Verify(svc, method, req.URL.RequestURI(), body, sig); err != nil {
+		t.Errorf("Verify(SignRequest) = %v", err)
+	}
+	if err := NewVerifier(DefaultSkew).Verify(svc, method, req.URL.Path, body, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify(without query) = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	const fullMethod = "/balance.BalanceService/UpdateBalance"
+	req := wrapperspb.String("request")
+	var md metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	if err := UnaryClientInterceptor(secret)(context.Background(), fullMethod, req, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	get := func(name string) string {
+		if v := md.Get(strings.ToLower(name)); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	sig := Signature{Value: get(HeaderSignature), Timestamp: get(HeaderTimestamp), Nonce: get(HeaderNonce)}
+	b, err := MarshalGRPC(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := &domain.Service{ID: 1, SigningMode: domain.SigningRequired, SigningSecret: secret}
+	if err := NewVerifier(DefaultSkew).Verify(svc, GRPCMethod, fullMethod, b, sig); err != nil {
+		t.Errorf("Verify(UnaryClientInterceptor) = %v", err)
+	}
+	other, _ := MarshalGRPC(wrapperspb.String("other"))
+	if err := NewVerifier(DefaultSkew).Verify(svc, GRPCMethod, fullMethod, other, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify(other message) = %v, want %v", err, ErrInvalidSignature)
+	}
+}
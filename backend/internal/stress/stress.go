@@ -0,0 +1,260 @@
+// Package stress нагружает хранилище случайными конкурентными операциями
+// (изменения баланса, открытие, подтверждение, отмена и истечение резервов)
+// над небольшим набором счетов и проверяет инварианты после прогона.
+package stress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/repository/repotest"
+)
+
+// Операции прогона.
+const (
+	OpDeposit  = "deposit"
+	OpWithdraw = "withdraw"
+	OpOpen     = "open"
+	OpReplay   = "replay" // повторное открытие резерва с тем же ключом
+	OpConfirm  = "confirm"
+	OpCancel   = "cancel"
+	OpExpire   = "expire" // подтверждение после истечения и отмена
+)
+
+type Config struct {
+	Accounts     int           // число счетов; чем меньше, тем выше конкуренция
+	Workers      int           // число параллельных горутин
+	Ops          int           // операций на горутину
+	MaxAmount    int64         // лимит каждого счёта
+	ExpireRatio  float64       // доля резервов с коротким таймаутом
+	ShortTimeout time.Duration // таймаут таких резервов
+	Seed         int64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Accounts:     4,
+		Workers:      16,
+		Ops:          200,
+		MaxAmount:    10_000,
+		ExpireRatio:  0.2,
+		ShortTimeout: 20 * time.Millisecond,
+		Seed:         time.Now().UnixNano(),
+	}
+}
+
+// Report — итог прогона. Прогон успешен, если нет неожиданных ошибок и
+// нарушений инвариантов.
+type Report struct {
+	Ops        map[string]int // успешные операции
+	Rejected   map[string]int // ожидаемые отказы: нет средств, резерв не активен или истёк
+	Unexpected []error
+	Violations []error
+	Duration   time.Duration
+
+	mu sync.Mutex
+}
+
+func (r *Report) OK() bool {
+	return len(r.Unexpected) == 0 && len(r.Violations) == 0
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "duration: %s\n", r.Duration.Round(time.Millisecond))
+	writeCounts(&b, "ok", r.Ops)
+	writeCounts(&b, "rejected", r.Rejected)
+	fmt.Fprintf(&b, "unexpected errors: %d\n", len(r.Unexpected))
+	for _, err := range r.Unexpected {
+		fmt.Fprintf(&b, "  %v\n", err)
+	}
+	fmt.Fprintf(&b, "invariant violations: %d\n", len(r.Violations))
+	for _, err := range r.Violations {
+		fmt.Fprintf(&b, "  %v\n", err)
+	}
+	return b.String()
+}
+
+func writeCounts(b *strings.Builder, title string, counts map[string]int) {
+	ops := make([]string, 0, len(counts))
+	for op := range counts {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	fmt.Fprintf(b, "%s:", title)
+	for _, op := range ops {
+		fmt.Fprintf(b, " %s=%d", op, counts[op])
+	}
+	b.WriteString("\n")
+}
+
+func (r *Report) record(op string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case err == nil:
+		r.Ops[op]++
+	case errors.Is(err, domain.ErrNotEnoughFunds),
+		errors.Is(err, domain.ErrNotActive),
+		errors.Is(err, domain.ErrExpired):
+		r.Rejected[op]++
+	default:
+		r.Unexpected = append(r.Unexpected, fmt.Errorf("%s: %w", op, err))
+	}
+}
+
+func (r *Report) violation(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Violations = append(r.Violations, err)
+}
+
+// opened — резерв, открытый в ходе прогона.
+type opened struct {
+	id        int64
+	accountID int64
+	amount    int64
+	key       string
+	expiresAt time.Time
+}
+
+type pool struct {
+	mu   sync.Mutex
+	list []opened
+}
+
+func (p *pool) add(o opened) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.list = append(p.list, o)
+}
+
+// pick возвращает случайный резерв из последних 64, подходящий под match.
+func (p *pool) pick(rnd *rand.Rand, match func(opened) bool) (opened, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	start := max(0, len(p.list)-64)
+	recent := p.list[start:]
+	for range 8 {
+		if len(recent) == 0 {
+			break
+		}
+		o := recent[rnd.Intn(len(recent))]
+		if match == nil || match(o) {
+			return o, true
+		}
+	}
+	return opened{}, false
+}
+
+// Run заводит cfg.Accounts счетов с балансом в половину лимита, прогоняет
+// cfg.Workers горутин по cfg.Ops случайных операций и проверяет инварианты
+// каждого счёта (repotest.CheckAccount). Ошибку возвращает, только если не
+// удалось подготовить данные.
+func Run(ctx context.Context, store repotest.Store, cfg Config) (*Report, error) {
+	report := &Report{Ops: make(map[string]int), Rejected: make(map[string]int)}
+	started := time.Now()
+
+	owner, err := store.CreateService(ctx, fmt.Sprintf("stress-%d", cfg.Seed), fmt.Sprintf("stress-key-%d", cfg.Seed))
+	if err != nil {
+		return nil, fmt.Errorf("create service: %w", err)
+	}
+	accounts := make([]int64, cfg.Accounts)
+	for i := range accounts {
+		acc, err := store.CreateAccount(ctx, cfg.Seed+int64(i), cfg.MaxAmount)
+		if err != nil {
+			return nil, fmt.Errorf("create account: %w", err)
+		}
+		if err := store.UpdateBalance(ctx, acc.ID, cfg.MaxAmount/2); err != nil {
+			return nil, fmt.Errorf("fund account: %w", err)
+		}
+		accounts[i] = acc.ID
+	}
+
+	var reservations pool
+	var wg sync.WaitGroup
+	for w := range cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(cfg.Seed + int64(w)))
+			for i := 0; i < cfg.Ops && ctx.Err() == nil; i++ {
+				step(ctx, store, cfg, rnd, owner, accounts, &reservations, report, fmt.Sprintf("%d-%d-%d", cfg.Seed, w, i))
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, id := range accounts {
+		if err := repotest.CheckAccount(ctx, store, id); err != nil {
+			report.violation(err)
+		}
+	}
+	report.Duration = time.Since(started)
+	return report, nil
+}
+
+func step(ctx context.Context, store repotest.Store, cfg Config, rnd *rand.Rand, owner int64, accounts []int64, reservations *pool, report *Report, key string) {
+	accountID := accounts[rnd.Intn(len(accounts))]
+	quarter := max(cfg.MaxAmount/4, 1)
+
+	switch n := rnd.Intn(100); {
+	case n < 15:
+		report.record(OpDeposit, store.UpdateBalance(ctx, accountID, 1+rnd.Int63n(quarter)))
+	case n < 30:
+		report.record(OpWithdraw, store.UpdateBalance(ctx, accountID, -1-rnd.Int63n(quarter)))
+	case n < 60:
+		timeout := time.Minute
+		if rnd.Float64() < cfg.ExpireRatio {
+			timeout = cfg.ShortTimeout
+		}
+		amount := 1 + rnd.Int63n(max(cfg.MaxAmount/5, 1))
+		res, err := store.OpenReservation(ctx, owner, accountID, amount, key, timeout)
+		report.record(OpOpen, err)
+		if err == nil {
+			reservations.add(opened{id: res.ID, accountID: accountID, amount: amount, key: key, expiresAt: res.ExpiresAt})
+		}
+	case n < 65:
+		o, ok := reservations.pick(rnd, nil)
+		if !ok {
+			return
+		}
+		res, err := store.OpenReservation(ctx, owner, o.accountID, o.amount, o.key, time.Minute)
+		report.record(OpReplay, err)
+		if err == nil && res.ID != o.id {
+			report.violation(fmt.Errorf("replay of key %s opened reservation %d, want %d", o.key, res.ID, o.id))
+		}
+	case n < 80:
+		if o, ok := reservations.pick(rnd, nil); ok {
+			report.record(OpConfirm, store.ConfirmReservation(ctx, o.id, owner))
+		}
+	case n < 92:
+		if o, ok := reservations.pick(rnd, nil); ok {
+			report.record(OpCancel, store.CancelReservation(ctx, o.id, owner))
+		}
+	default:
+		o, ok := reservations.pick(rnd, func(o opened) bool { return o.expiresAt.Before(time.Now().Add(cfg.ShortTimeout)) })
+		if !ok {
+			return
+		}
+		time.Sleep(time.Until(o.expiresAt) + time.Millisecond)
+		err := store.ConfirmReservation(ctx, o.id, owner)
+		switch {
+		case err == nil:
+			report.violation(fmt.Errorf("reservation %d confirmed after expiry", o.id))
+		case errors.Is(err, domain.ErrExpired):
+			// Истёкший резерв держит средства, пока его не отменят
+			report.record(OpExpire, nil)
+			report.record(OpCancel, store.CancelReservation(ctx, o.id, owner))
+		default:
+			report.record(OpExpire, err)
+		}
+	}
+}
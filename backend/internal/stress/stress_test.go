@@ -0,0 +1,64 @@
+package stress_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/repository/memory"
+	"test_nanimai/backend/internal/repository/repotest"
+	"test_nanimai/backend/internal/stress"
+)
+
+// testConfig — короткий прогон с высокой конкуренцией за два счёта.
+func testConfig() stress.Config {
+	return stress.Config{
+		Accounts:     2,
+		Workers:      8,
+		Ops:          100,
+		MaxAmount:    1_000,
+		ExpireRatio:  0.3,
+		ShortTimeout: 5 * time.Millisecond,
+		Seed:         1,
+	}
+}
+
+func TestRun(t *testing.T) {
+	report, err := stress.Run(context.Background(), memory.NewBalanceStorage(), testConfig())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !report.OK() {
+		t.Fatalf("invariants do not hold:\n%s", report)
+	}
+	for _, op := range []string{stress.OpDeposit, stress.OpWithdraw, stress.OpOpen, stress.OpConfirm, stress.OpCancel} {
+		if report.Ops[op] == 0 {
+			t.Errorf("no successful %s operations:\n%s", op, report)
+		}
+	}
+}
+
+// leakyStore завышает резерв счетов, как если бы хранилище теряло отмены.
+type leakyStore struct {
+	repotest.Store
+}
+
+func (s leakyStore) GetAccount(ctx context.Context, accountID int64) (*domain.Account, error) {
+	acc, err := s.Store.GetAccount(ctx, accountID)
+	if err == nil {
+		acc.ReservedAmount++
+	}
+	return acc, err
+}
+
+func TestRunReportsViolations(t *testing.T) {
+	cfg := testConfig()
+	report, err := stress.Run(context.Background(), leakyStore{memory.NewBalanceStorage()}, cfg)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.OK() || len(report.Violations) != cfg.Accounts {
+		t.Errorf("Run over a broken store reported %d violations, want %d:\n%s", len(report.Violations), cfg.Accounts, report)
+	}
+}
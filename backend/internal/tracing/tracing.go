@@ -0,0 +1,107 @@
+// Package tracing — распределённая трассировка OpenTelemetry: настройка
+// провайдера и экспортёра, span'ы входящих REST- и gRPC-запросов с
+// W3C Trace Context от вызывающей стороны и span'ы обращений к БД.
+// Провайдер глобальный (otel.SetTracerProvider); пока Setup не вызван,
+// span'ы не записываются, но контекст трассировки всё равно передаётся дальше.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "test_nanimai/backend"
+
+// DefaultServiceName — service.name, если OTEL_SERVICE_NAME не задан.
+const DefaultServiceName = "balance-service"
+
+// Экспортёры для Setup.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp" // OTLP/gRPC, адрес из OTEL_EXPORTER_OTLP_ENDPOINT
+)
+
+// Setup настраивает глобальный провайдер с экспортёром exporter и
+// пропагатор W3C Trace Context + Baggage. Возвращает функцию, которая
+// дописывает накопленные span'ы и останавливает экспортёр.
+// Сэмплер и атрибуты ресурса настраиваются стандартными переменными
+// OTEL_TRACES_SAMPLER, OTEL_SERVICE_NAME, OTEL_RESOURCE_ATTRIBUTES.
+func Setup(ctx context.Context, exporter string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exp sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout, "console":
+		exp, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		exp, err = otlptracegrpc.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s exporter: %w", exporter, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(DefaultServiceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("build resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Атрибуты идентификаторов для span'ов.
+func AccountID(id int64) attribute.KeyValue     { return attribute.Int64("balance.account_id", id) }
+func ReservationID(id int64) attribute.KeyValue { return attribute.Int64("balance.reservation_id", id) }
+func ServiceID(id int64) attribute.KeyValue     { return attribute.Int64("balance.service_id", id) }
+func EntryID(id int64) attribute.KeyValue       { return attribute.Int64("balance.entry_id", id) }
+
+// StartDB начинает span обращения к PostgreSQL с именем "BalanceStorage.<operation>".
+// Завершать его нужно через End.
+func StartDB(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, "BalanceStorage."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(operation)),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// End завершает span, отмечая ошибку, если она есть.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,50 @@
+// Package velocity — встроенная проверка риска (risk.Checker) по правилам
+// частоты и объёма списаний (domain.VelocityRule) над ledger: сколько
+// списано, сколько резервов открыто и переводов сделано со счёта за
+// скользящее окно правила.
+//
+// Engine проверяет операцию заранее, вне её транзакции. Окончательно те же
+// правила проверяет хранилище в транзакции списания под блокировкой счёта
+// (repository.WithVelocityCheck), поэтому параллельные списания с одного
+// счёта у самой границы не проходят оба.
+package velocity
+
+import (
+	"context"
+	"time"
+
+	"test_nanimai/backend/domain"
+	"test_nanimai/backend/internal/metrics"
+	"test_nanimai/backend/internal/repository"
+)
+
+type Engine struct {
+	store repository.Velocity
+}
+
+func NewEngine(store repository.Velocity) *Engine {
+	return &Engine{store: store}
+}
+
+// Assess проверяет op по правилам счёта (domain.CheckVelocity). Нарушение
+// правила с действием domain.RiskDecline отклоняет операцию; если нарушены
+// только правила с domain.RiskReview, операция уходит на решение оператора
+// с причиной первого из них.
+func (e *Engine) Assess(ctx context.Context, op domain.RiskOperation) (domain.RiskAssessment, error) {
+	rules, err := e.store.AccountVelocityRules(ctx, op.AccountID)
+	if err != nil {
+		return domain.RiskAssessment{}, err
+	}
+	v, err := domain.CheckVelocity(rules, op, func(window time.Duration) (domain.VelocityUsage, error) {
+		return e.store.VelocityUsage(ctx, op.AccountID, window)
+	})
+	switch {
+	case err != nil:
+		return domain.RiskAssessment{}, err
+	case v == nil:
+		return domain.RiskAssessment{Decision: domain.RiskApprove}, nil
+	case v.Rule.Action == domain.RiskDecline:
+		metrics.VelocityRejected(string(v.Rule.Kind))
+	}
+	return v.Assessment(), nil
+}
@@ -6,98 +6,316 @@ package main
 // @BasePath /
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
-	_ "test_nanimai/backend/docs"
-	balancegrpc "test_nanimai/backend/internal/api/grpc"
-	pb "test_nanimai/backend/internal/api/grpc/pb"
-	rest "test_nanimai/backend/internal/api/rest"
+	"os/signal"
+	"sync"
+	"syscall"
+	"test_nanimai/backend/internal/app"
+	"test_nanimai/backend/internal/authcache"
+	"test_nanimai/backend/internal/config"
+	"test_nanimai/backend/internal/logging"
+	"test_nanimai/backend/internal/metrics"
+	"test_nanimai/backend/internal/migration"
+	"test_nanimai/backend/internal/mtls"
+	"test_nanimai/backend/internal/ratelimit"
 	"test_nanimai/backend/internal/repository/postgres"
+	"test_nanimai/backend/internal/risk"
+	"test_nanimai/backend/internal/service/admin"
 	"test_nanimai/backend/internal/service/balance"
+	"test_nanimai/backend/internal/signing"
+	"test_nanimai/backend/internal/tracing"
+	"test_nanimai/backend/internal/velocity"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-func main() {
-	restAddr := flag.String("rest-addr", ":8080", "REST service address")
-	grpcAddr := flag.String("grpc-addr", ":9090", "gRPC service address")
-	flag.Parse()
+// tracesFlushTimeout — сколько ждать отправки накопленных span'ов при остановке.
+const tracesFlushTimeout = 5 * time.Second
+
+// limitChangeExpiryInterval — как часто неподтверждённые в срок предложения изменения лимита переводятся в EXPIRED.
+const limitChangeExpiryInterval = time.Minute
 
-	if env := os.Getenv("REST_ADDR"); env != "" {
-		*restAddr = env
+func main() {
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+	if cfg.PrintConfig {
+		cfg.Print(os.Stdout)
+		return
 	}
-	if env := os.Getenv("GRPC_ADDR"); env != "" {
-		*grpcAddr = env
+
+	level, _ := logging.ParseLevel(cfg.LogLevel)
+	slog.SetDefault(logging.New(os.Stdout, level))
+	// Текстовый отладочный вывод Gin мешает разбору JSON-логов
+	if os.Getenv(gin.EnvGinMode) == "" {
+		gin.SetMode(gin.ReleaseMode)
 	}
 
-	if err := godotenv.Load(); err != nil {
-		log.Println(".env file not found, using system environment variables")
+	if len(cfg.Args) > 0 {
+		// Вывод команды идёт в stdout, журнал действий — в stderr
+		slog.SetDefault(logging.New(os.Stderr, level))
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := runCommand(ctx, cfg, cfg.Args)
+		stop()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	dsn := os.Getenv("DATABASE_URL")
+	slog.Info("configuration loaded", "file", cfg.File, "settings", cfg.Masked())
 
-	m, err := migrate.New(
-		"file://migrations",
-		dsn,
-	)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracesExporter)
 	if err != nil {
-		log.Fatalf("failed to initialize migrations: %v", err)
+		fatal("failed to initialize tracing", err)
 	}
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatalf("failed to initialize migrations: %v", err)
+	// Схема должна быть на последней встроенной версии: либо применяем
+	// миграции сами, либо их применяют заранее командой migrate up
+	schemaVersion, err := migration.Latest()
+	if err != nil {
+		fatal("failed to read embedded migrations", err)
+	}
+	if cfg.AutoMigrate {
+		if err := autoMigrate(cfg); err != nil {
+			fatal("failed to apply migrations", err)
+		}
+		slog.Info("migrations applied", "version", schemaVersion)
+	} else {
+		slog.Info("auto-migration disabled", "expected_version", schemaVersion)
 	}
-	log.Println("migrations initialized")
 
 	// Repositories
-	balanceRepo, err := postgres.NewBalanceStorage(dsn)
+	balanceRepo, err := postgres.NewBalanceStorage(cfg.DatabaseURL)
 	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+		fatal("failed to connect to database", err)
 	}
+	db := balanceRepo.GetDb()
+	db.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DB.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DB.ConnMaxIdleTime)
 
 	// Services
-	balanceService := balance.NewBalanceService(balanceRepo)
+	riskChecker := risk.NewGuard(velocity.NewEngine(balanceRepo), cfg.Risk.Policy())
+	balanceService := balance.NewBalanceService(balanceRepo, balanceRepo, riskChecker, balanceRepo, cfg.LimitApproval)
+
+	// Metrics
+	metrics.RegisterDBStats(db)
+	metrics.RegisterReservationGauges(balanceRepo.ReservationStats)
+
+	// Readiness: БД доступна, схема на последней встроенной версии
+	checker := app.NewHealthChecker()
+	checker.Add("database", balanceRepo.Ping)
+	checker.Add("migrations", func(ctx context.Context) error {
+		version, dirty, err := balanceRepo.MigrationVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty || version != int64(schemaVersion) {
+			return fmt.Errorf("schema version %d (dirty %t), want %d", version, dirty, schemaVersion)
+		}
+		return nil
+	})
+
+	// SIGINT/SIGTERM запускают остановку; повторный сигнал завершает процесс сразу
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background workers
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		checker.Run(workersCtx, cfg.HealthInterval)
+	}()
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		balanceService.RunLimitChangeExpiry(workersCtx, limitChangeExpiryInterval)
+	}()
+	if cfg.SnapshotInterval > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			balanceService.RunSnapshots(workersCtx, cfg.SnapshotInterval)
+		}()
+	}
+
+	// Подписи запросов и ограничение частоты: кеш nonce и корзины общие для REST и gRPC
+	verifier := signing.NewVerifier(cfg.SignatureSkew)
+	limiter := ratelimit.NewLimiter()
+	// Сервисы с правами и ограничениями кешируются; /admin сбрасывает кеш изменённого сервиса
+	services := authcache.New(balanceRepo, cfg.AuthCacheTTL)
+
+	// TLS: сертификаты общие для REST и gRPC и перечитываются при изменении файлов
+	var certs *mtls.Reloader
+	var clientAuth tls.ClientAuthType
+	if cfg.TLS.Enabled() {
+		if certs, err = mtls.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile); err != nil {
+			fatal("failed to load TLS certificates", err)
+		}
+		clientAuth, _ = mtls.ParseClientAuth(cfg.TLS.ClientAuth)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			certs.Run(workersCtx, cfg.TLS.ReloadInterval)
+		}()
+	}
 
 	// HTTP server (Gin)
-	r := gin.Default()
-	// API-key middleware
-	r.Use(rest.ApiKeyAuthMiddleware(balanceRepo.GetDb()))
-	// REST routes
-	rest.RegisterRoutes(r, balanceService)
-	// Swagger UI (Gin)
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	restServer := &http.Server{
+		Addr: cfg.RESTAddr,
+		Handler: app.NewRESTHandler(balanceService, services, balanceRepo, verifier, limiter, checker, app.RESTOptions{
+			Metrics: cfg.MetricsEnabled,
+			Swagger: cfg.SwaggerEnabled,
+			Admin:   admin.NewAdminService(services, cfg.LimitApproval),
+		}),
+		ReadHeaderTimeout: cfg.REST.ReadHeaderTimeout,
+		ReadTimeout:       cfg.REST.ReadTimeout,
+		WriteTimeout:      cfg.REST.WriteTimeout,
+		IdleTimeout:       cfg.REST.IdleTimeout,
+	}
 
 	// gRPC server
-	lis, err := net.Listen("tcp", *grpcAddr)
+	lis, err := net.Listen("tcp", cfg.GRPCAddr)
 	if err != nil {
-		log.Fatalf("failed to listen on %s: %v", *grpcAddr, err)
+		fatal("failed to listen", err, "addr", cfg.GRPCAddr)
+	}
+	var grpcOpts []grpc.ServerOption
+	if certs != nil {
+		restServer.TLSConfig = certs.ServerConfig(clientAuth, "h2", "http/1.1")
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(certs.ServerConfig(clientAuth, "h2"))))
 	}
-	grpcServer := grpc.NewServer()
-	pb.RegisterBalanceServiceServer(grpcServer, balancegrpc.NewBalanceGRPCServer(balanceService))
+	grpcServer := app.NewGRPCServer(balanceService, services, balanceRepo, verifier, limiter, checker, grpcOpts...)
 
 	errCh := make(chan error, 2)
 
 	go func() {
-		log.Printf("REST listening on %s", *restAddr)
-		errCh <- r.Run(*restAddr)
+		slog.Info("REST listening", "addr", cfg.RESTAddr, "tls", certs != nil)
+		serve := restServer.ListenAndServe
+		if certs != nil {
+			// Сертификаты уже в TLSConfig
+			serve = func() error { return restServer.ListenAndServeTLS("", "") }
+		}
+		if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- fmt.Errorf("REST: %w", err)
+		}
 	}()
 
 	go func() {
-		log.Printf("gRPC listening on %s", *grpcAddr)
-		errCh <- grpcServer.Serve(lis)
+		slog.Info("gRPC listening", "addr", cfg.GRPCAddr, "tls", certs != nil)
+		if err := grpcServer.Serve(lis); err != nil {
+			errCh <- fmt.Errorf("gRPC: %w", err)
+		}
 	}()
 
-	if err := <-errCh; err != nil {
-		log.Fatalf("server stopped with error: %v", err)
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("shutdown signal received")
+	case serveErr = <-errCh:
+		slog.Error("server stopped with error", "error", serveErr)
 	}
+	stop()
+	checker.SetDraining()
+
+	// Перестаём принимать запросы и дожидаемся текущих, но не дольше shutdownTimeout
+	slog.Info("shutting down, draining requests", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	var servers sync.WaitGroup
+	servers.Add(2)
+	go func() {
+		defer servers.Done()
+		if err := restServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("REST shutdown failed", "error", err)
+		}
+	}()
+	go func() {
+		defer servers.Done()
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			slog.Warn("gRPC graceful stop timed out, closing remaining connections")
+			grpcServer.Stop()
+		}
+	}()
+	servers.Wait()
+
+	stopWorkers()
+	workers.Wait()
+
+	if err := balanceRepo.Close(); err != nil {
+		slog.Error("failed to close database", "error", err)
+	}
+	// Дописываем накопленные span'ы, даже если время на остановку вышло
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), tracesFlushTimeout)
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+	slog.Info("shutdown complete")
+
+	if serveErr != nil {
+		os.Exit(1)
+	}
+}
+
+// runCommand выполняет подкоманду вместо запуска сервера.
+func runCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(ctx, cfg, args[1:])
+	case "service", "account", "reservation", "velocity", "pending", "limit":
+		return runAdmin(ctx, cfg, args[0], args[1:])
+	case "reconcile":
+		return runReconcile(ctx, cfg, args[1:])
+	}
+	return fmt.Errorf("unknown command %q, available: migrate, service, account, reservation, velocity, pending, limit, reconcile", args[0])
+}
+
+// autoMigrate применяет миграции при старте сервера. Реплики, стартующие
+// одновременно, ждут друг друга на advisory-lock не дольше MIGRATE_LOCK_TIMEOUT.
+func autoMigrate(cfg *config.Config) error {
+	mg, err := migration.New(cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.MigrateLockTimeout)
+	defer cancel()
+	return mg.Up(ctx)
+}
+
+// fatal пишет ошибку запуска в лог и завершает процесс.
+func fatal(msg string, err error, args ...any) {
+	slog.Error(msg, append([]any{"error", err}, args...)...)
+	os.Exit(1)
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"test_nanimai/backend/internal/config"
+	"test_nanimai/backend/internal/migration"
+)
+
+const migrateUsage = `usage: backend [flags] migrate <command>
+
+commands:
+  up                 apply all pending migrations
+  down [-n N | -all] roll back the last N migrations (default 1) or all of them
+  status             show applied, latest and pending versions
+  force VERSION      record VERSION without running migrations and clear the dirty flag`
+
+// runMigrate выполняет подкоманду migrate. Ожидание блокировки, которую
+// держит другой экземпляр, ограничено MIGRATE_LOCK_TIMEOUT.
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	mg, err := migration.New(cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.MigrateLockTimeout)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		if err := mg.Up(ctx); err != nil {
+			return err
+		}
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		n := fs.Int("n", 1, "number of migrations to roll back")
+		all := fs.Bool("all", false, "roll back all migrations")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *n < 1 && !*all {
+			return errors.New("migrate down: -n must be positive")
+		}
+		steps := *n
+		if *all {
+			steps = 0
+		}
+		if err := mg.Down(ctx, steps); err != nil {
+			return err
+		}
+	case "force":
+		if len(args) != 2 {
+			return errors.New("usage: backend migrate force VERSION")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < -1 {
+			return fmt.Errorf("migrate force: invalid version %q", args[1])
+		}
+		if err := mg.Force(ctx, version); err != nil {
+			return err
+		}
+	case "status":
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", args[0], migrateUsage)
+	}
+
+	st, err := mg.Status(ctx)
+	if err != nil {
+		return err
+	}
+	printMigrationStatus(st)
+	return nil
+}
+
+func printMigrationStatus(st migration.Status) {
+	fmt.Fprintf(os.Stdout, "version: %d\n", st.Version)
+	fmt.Fprintf(os.Stdout, "dirty:   %t\n", st.Dirty)
+	fmt.Fprintf(os.Stdout, "latest:  %d\n", st.Latest)
+	pending := "none"
+	if len(st.Pending) > 0 {
+		parts := make([]string, len(st.Pending))
+		for i, v := range st.Pending {
+			parts[i] = strconv.FormatUint(uint64(v), 10)
+		}
+		pending = strings.Join(parts, ", ")
+	}
+	fmt.Fprintf(os.Stdout, "pending: %s\n", pending)
+}
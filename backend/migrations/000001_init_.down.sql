@@ -1,10 +1,10 @@
-DROP TABLE services;
-
-DROP TABLE users;
+DROP TABLE ledger;
 
 DROP TABLE reservations;
 
-DROP TABLE ledger;
+DROP TABLE accounts;
+
+DROP TABLE services;
 
 DROP TYPE reservation_status;
 DROP TYPE ledger_op;
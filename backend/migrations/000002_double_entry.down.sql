@@ -0,0 +1,11 @@
+DROP TABLE ledger_postings;
+
+DROP TYPE posting_side;
+
+DROP INDEX ledger_account_id_idx;
+
+ALTER TABLE ledger DROP COLUMN description;
+
+DROP TABLE ledger_accounts;
+
+-- Значение ADJUSTMENT из ledger_op не удаляется: PostgreSQL не поддерживает удаление значений enum
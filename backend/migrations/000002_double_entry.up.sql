@@ -0,0 +1,34 @@
+-- Счета главной книги: системные и по одному на каждый пользовательский счёт (account:<id>)
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+code        TEXT PRIMARY KEY,
+kind        TEXT NOT NULL CHECK (kind IN ('SYSTEM', 'ACCOUNT')),
+account_id  BIGINT UNIQUE,
+created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO ledger_accounts (code, kind) VALUES ('external_funding', 'SYSTEM'),
+                                                ('fees',             'SYSTEM'),
+                                                ('holds',            'SYSTEM'),
+                                                ('settlement',       'SYSTEM')
+ON CONFLICT (code) DO NOTHING;
+
+-- Записи ledger становятся заголовками проводок
+ALTER TYPE ledger_op ADD VALUE IF NOT EXISTS 'ADJUSTMENT';
+
+ALTER TABLE ledger ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS ledger_account_id_idx ON ledger (account_id, id);
+
+-- Строки проводок: по каждой записи сумма дебета равна сумме кредита
+CREATE TYPE posting_side AS ENUM ('DEBIT', 'CREDIT');
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+id              BIGSERIAL PRIMARY KEY,
+entry_id        BIGINT NOT NULL REFERENCES ledger(id) ON DELETE CASCADE,
+ledger_account  TEXT NOT NULL REFERENCES ledger_accounts(code),
+side            posting_side NOT NULL,
+amount          BIGINT NOT NULL CHECK (amount > 0)
+);
+
+CREATE INDEX IF NOT EXISTS ledger_postings_entry_id_idx ON ledger_postings (entry_id);
+CREATE INDEX IF NOT EXISTS ledger_postings_ledger_account_idx ON ledger_postings (ledger_account);
@@ -0,0 +1,3 @@
+DROP INDEX ledger_account_created_idx;
+
+DROP TABLE balance_snapshots;
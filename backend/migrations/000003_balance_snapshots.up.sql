@@ -0,0 +1,24 @@
+-- Снимки балансов: состояние счёта после проводки last_entry_id.
+-- Позволяют восстанавливать баланс на момент времени без суммирования всей истории.
+CREATE TABLE IF NOT EXISTS balance_snapshots (
+id               BIGSERIAL PRIMARY KEY,
+account_id       BIGINT NOT NULL,
+last_entry_id    BIGINT NOT NULL,
+current_amount   BIGINT NOT NULL,
+reserved_amount  BIGINT NOT NULL,
+max_amount       BIGINT NOT NULL,
+taken_at         TIMESTAMPTZ NOT NULL,
+UNIQUE (account_id, last_entry_id)
+);
+
+CREATE INDEX IF NOT EXISTS balance_snapshots_account_taken_idx ON balance_snapshots (account_id, taken_at);
+
+CREATE INDEX IF NOT EXISTS ledger_account_created_idx ON ledger (account_id, created_at);
+
+-- Начальные снимки: история до появления журнала в ledger не восстанавливается
+INSERT INTO balance_snapshots (account_id, last_entry_id, current_amount, reserved_amount, max_amount, taken_at)
+SELECT a.id,
+       COALESCE((SELECT MAX(l.id) FROM ledger l WHERE l.account_id = a.id), 0),
+       a.current_amount, a.reserved_amount, a.max_amount, now()
+FROM accounts a
+ON CONFLICT (account_id, last_entry_id) DO NOTHING;
@@ -0,0 +1,6 @@
+DROP INDEX ledger_reversal_of_uniq;
+
+ALTER TABLE ledger DROP COLUMN reason_code;
+ALTER TABLE ledger DROP COLUMN reversal_of;
+
+-- Значение REVERSAL из ledger_op не удаляется: PostgreSQL не поддерживает удаление значений enum
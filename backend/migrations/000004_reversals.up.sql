@@ -0,0 +1,8 @@
+-- Сторнирование: компенсирующая проводка ссылается на исходную
+ALTER TYPE ledger_op ADD VALUE IF NOT EXISTS 'REVERSAL';
+
+ALTER TABLE ledger ADD COLUMN IF NOT EXISTS reversal_of BIGINT REFERENCES ledger(id);
+ALTER TABLE ledger ADD COLUMN IF NOT EXISTS reason_code TEXT NOT NULL DEFAULT '';
+
+-- Проводку можно сторнировать только один раз
+CREATE UNIQUE INDEX IF NOT EXISTS ledger_reversal_of_uniq ON ledger (reversal_of) WHERE reversal_of IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP TABLE reservation_refunds;
+
+-- Значение RESERVE_REFUND из ledger_op не удаляется: PostgreSQL не поддерживает удаление значений enum
@@ -0,0 +1,13 @@
+-- Возвраты по подтверждённым резервам (полные и частичные)
+ALTER TYPE ledger_op ADD VALUE IF NOT EXISTS 'RESERVE_REFUND';
+
+CREATE TABLE IF NOT EXISTS reservation_refunds (
+id                BIGSERIAL PRIMARY KEY,
+reservation_id    BIGINT NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+owner_service_id  BIGINT NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+amount            BIGINT NOT NULL CHECK (amount > 0),
+idempotency_key   TEXT NOT NULL,
+ledger_entry_id   BIGINT NOT NULL REFERENCES ledger(id),
+created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+UNIQUE (reservation_id, idempotency_key) -- идемпотентность по ключу возврата
+);
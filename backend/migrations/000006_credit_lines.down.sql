@@ -0,0 +1,12 @@
+ALTER TABLE balance_snapshots DROP COLUMN credit_limit;
+
+ALTER TABLE ledger DROP COLUMN delta_credit;
+
+ALTER TABLE accounts DROP CONSTRAINT accounts_reserved_available_check;
+ALTER TABLE accounts DROP CONSTRAINT accounts_credit_floor_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_check CHECK (reserved_amount <= current_amount);
+ALTER TABLE accounts ADD CONSTRAINT accounts_current_amount_check CHECK (current_amount >= 0);
+
+ALTER TABLE accounts DROP COLUMN credit_limit;
+
+-- Значения CREDIT_LIMIT_* из ledger_op не удаляются: PostgreSQL не поддерживает удаление значений enum
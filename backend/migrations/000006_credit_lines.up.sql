@@ -0,0 +1,15 @@
+-- Кредитная линия: баланс может уйти в минус не глубже credit_limit
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS credit_limit BIGINT NOT NULL DEFAULT 0 CHECK (credit_limit >= 0);
+
+-- Заменяем проверки current_amount >= 0 и reserved_amount <= current_amount
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_current_amount_check;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_credit_floor_check CHECK (current_amount >= -credit_limit);
+ALTER TABLE accounts ADD CONSTRAINT accounts_reserved_available_check CHECK (reserved_amount <= current_amount + credit_limit);
+
+ALTER TYPE ledger_op ADD VALUE IF NOT EXISTS 'CREDIT_LIMIT_INCREASE';
+ALTER TYPE ledger_op ADD VALUE IF NOT EXISTS 'CREDIT_LIMIT_DECREASE';
+
+ALTER TABLE ledger ADD COLUMN IF NOT EXISTS delta_credit BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE balance_snapshots ADD COLUMN IF NOT EXISTS credit_limit BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Заморозка счёта оператором: движение средств по счёту запрещено
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS frozen BOOLEAN NOT NULL DEFAULT false;
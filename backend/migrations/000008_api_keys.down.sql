@@ -0,0 +1,8 @@
+ALTER TABLE services DROP COLUMN admin;
+
+-- Открытые ключи по хешам не восстановить: сервисам нужно выдать новые
+ALTER TABLE services ADD COLUMN api_key TEXT UNIQUE;
+UPDATE services SET api_key = md5(random()::text || id::text);
+ALTER TABLE services ALTER COLUMN api_key SET NOT NULL;
+
+DROP TABLE api_keys;
@@ -0,0 +1,25 @@
+-- API-ключи сервисов: хранится только SHA-256 ключа, поиск идёт по открытому префиксу.
+-- У сервиса может быть несколько действующих ключей (ротация с перекрытием)
+CREATE TABLE IF NOT EXISTS api_keys (
+id            BIGSERIAL PRIMARY KEY,
+service_id    BIGINT NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+prefix        TEXT NOT NULL,
+hash          TEXT NOT NULL UNIQUE,
+created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+expires_at    TIMESTAMPTZ,
+revoked_at    TIMESTAMPTZ,
+last_used_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_prefix_idx ON api_keys (prefix);
+CREATE INDEX IF NOT EXISTS api_keys_service_id_idx ON api_keys (service_id);
+
+-- Переносим открытые ключи: префикс — первые 8 символов
+INSERT INTO api_keys (service_id, prefix, hash)
+SELECT id, left(api_key, 8), encode(sha256(convert_to(api_key, 'UTF8')), 'hex')
+FROM services;
+
+ALTER TABLE services DROP COLUMN api_key;
+
+-- Сервисы с доступом к операциям оператора (/admin)
+ALTER TABLE services ADD COLUMN IF NOT EXISTS admin BOOLEAN NOT NULL DEFAULT false;
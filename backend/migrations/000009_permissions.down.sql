@@ -0,0 +1,8 @@
+ALTER TABLE accounts DROP COLUMN tags;
+
+ALTER TABLE services DROP COLUMN scope_tags;
+ALTER TABLE services DROP COLUMN scope_account_ids;
+
+ALTER TABLE services ADD COLUMN admin BOOLEAN NOT NULL DEFAULT false;
+UPDATE services SET admin = 'admin' = ANY(permissions);
+ALTER TABLE services DROP COLUMN permissions;
@@ -0,0 +1,12 @@
+-- Права сервисов вместо флага admin. Существующие сервисы сохраняют доступ
+-- ко всем операциям API, сервисы с admin получают ещё и право admin
+ALTER TABLE services ADD COLUMN IF NOT EXISTS permissions TEXT[] NOT NULL
+    DEFAULT ARRAY['balance:credit', 'balance:debit', 'limit:write', 'reservation:open', 'journal:write'];
+UPDATE services SET permissions = permissions || ARRAY['admin'] WHERE admin;
+ALTER TABLE services DROP COLUMN admin;
+
+-- Область сервиса: счета по ID и по тегам; обе пустые — все счета
+ALTER TABLE services ADD COLUMN IF NOT EXISTS scope_account_ids BIGINT[] NOT NULL DEFAULT '{}';
+ALTER TABLE services ADD COLUMN IF NOT EXISTS scope_tags TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
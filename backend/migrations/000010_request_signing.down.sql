@@ -0,0 +1,2 @@
+ALTER TABLE services DROP COLUMN signing_secret;
+ALTER TABLE services DROP COLUMN signing_mode;
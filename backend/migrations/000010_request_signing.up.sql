@@ -0,0 +1,5 @@
+-- Подпись запросов HMAC: режим и секрет сервиса. Секрет нужен серверу в
+-- открытом виде, чтобы проверять подпись, поэтому хранится как есть
+ALTER TABLE services ADD COLUMN IF NOT EXISTS signing_mode TEXT NOT NULL DEFAULT 'off'
+    CHECK (signing_mode IN ('off', 'optional', 'required'));
+ALTER TABLE services ADD COLUMN IF NOT EXISTS signing_secret TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS services_cert_identities_idx;
+ALTER TABLE services DROP COLUMN cert_identities;
@@ -0,0 +1,5 @@
+-- Аутентификация по клиентскому сертификату: имена (CN или SAN), по
+-- которым сертификат сопоставляется с сервисом. Имя принадлежит не более
+-- чем одному сервису; это проверяется при назначении
+ALTER TABLE services ADD COLUMN IF NOT EXISTS cert_identities TEXT[] NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS services_cert_identities_idx ON services USING GIN (cert_identities);
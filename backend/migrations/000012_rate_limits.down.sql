@@ -0,0 +1,4 @@
+ALTER TABLE services DROP COLUMN account_rate_burst;
+ALTER TABLE services DROP COLUMN account_rate_limit;
+ALTER TABLE services DROP COLUMN rate_burst;
+ALTER TABLE services DROP COLUMN rate_limit;
@@ -0,0 +1,6 @@
+-- Ограничение частоты запросов сервиса (token bucket): запросов в секунду
+-- и размер всплеска для сервиса в целом и для каждого счёта; 0 — без ограничения
+ALTER TABLE services ADD COLUMN IF NOT EXISTS rate_limit DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (rate_limit >= 0);
+ALTER TABLE services ADD COLUMN IF NOT EXISTS rate_burst INT NOT NULL DEFAULT 0 CHECK (rate_burst >= 0);
+ALTER TABLE services ADD COLUMN IF NOT EXISTS account_rate_limit DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (account_rate_limit >= 0);
+ALTER TABLE services ADD COLUMN IF NOT EXISTS account_rate_burst INT NOT NULL DEFAULT 0 CHECK (account_rate_burst >= 0);
@@ -0,0 +1 @@
+DROP TABLE velocity_rules;
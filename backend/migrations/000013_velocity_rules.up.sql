@@ -0,0 +1,15 @@
+-- Правила частоты и объёма списаний: для счёта или для всех счетов с тегом
+-- (группы); окно скользящее, в секундах, 0 — у правил без окна
+CREATE TABLE IF NOT EXISTS velocity_rules (
+id             BIGSERIAL PRIMARY KEY,
+account_id     BIGINT REFERENCES accounts(id) ON DELETE CASCADE,
+account_tag    TEXT,
+kind           TEXT NOT NULL CHECK (kind IN ('debit_amount', 'debit_count', 'reservation_count', 'single_debit')),
+limit_value    BIGINT NOT NULL CHECK (limit_value > 0),
+window_seconds BIGINT NOT NULL DEFAULT 0 CHECK (window_seconds >= 0),
+created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+CHECK ((account_id IS NULL) <> (account_tag IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS velocity_rules_account_id_idx ON velocity_rules (account_id);
+CREATE INDEX IF NOT EXISTS velocity_rules_account_tag_idx ON velocity_rules (account_tag);
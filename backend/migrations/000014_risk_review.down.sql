@@ -0,0 +1,2 @@
+DROP TABLE pending_operations;
+ALTER TABLE velocity_rules DROP COLUMN action;
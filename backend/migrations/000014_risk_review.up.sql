@@ -0,0 +1,33 @@
+-- Что делает правило списаний с нарушившей его операцией: отклоняет или
+-- отправляет на решение оператора
+ALTER TABLE velocity_rules ADD COLUMN IF NOT EXISTS action TEXT NOT NULL DEFAULT 'decline'
+    CHECK (action IN ('decline', 'review'));
+
+-- Операции, отложенные проверкой риска до решения оператора. Хранят всё
+-- нужное для выполнения после одобрения: ключ и срок резерва, описание и
+-- строки проводки
+CREATE TABLE IF NOT EXISTS pending_operations (
+id              BIGSERIAL PRIMARY KEY,
+kind            TEXT NOT NULL CHECK (kind IN ('debit', 'reservation', 'transfer')),
+service_id      BIGINT NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+account_id      BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+amount          BIGINT NOT NULL CHECK (amount > 0),
+idempotency_key TEXT NOT NULL DEFAULT '',
+timeout_seconds BIGINT NOT NULL DEFAULT 0,
+description     TEXT NOT NULL DEFAULT '',
+postings        JSONB NOT NULL DEFAULT '[]',
+reason          TEXT NOT NULL,
+status          TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'FAILED')),
+decided_by      BIGINT REFERENCES services(id) ON DELETE SET NULL,
+note            TEXT NOT NULL DEFAULT '',
+reservation_id  BIGINT REFERENCES reservations(id) ON DELETE SET NULL,
+entry_id        BIGINT REFERENCES ledger(id),
+error           TEXT NOT NULL DEFAULT '',
+created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+decided_at      TIMESTAMPTZ
+);
+
+-- Повтор отложенного резерва с тем же ключом возвращает ту же операцию
+CREATE UNIQUE INDEX IF NOT EXISTS pending_operations_idempotency_idx
+    ON pending_operations (service_id, idempotency_key) WHERE kind = 'reservation';
+CREATE INDEX IF NOT EXISTS pending_operations_status_idx ON pending_operations (status, id);
@@ -0,0 +1,18 @@
+-- Предложенные увеличения лимита выше порога: лимит меняется и проводка
+-- пишется только после подтверждения другим оператором
+CREATE TABLE IF NOT EXISTS limit_changes (
+id          BIGSERIAL PRIMARY KEY,
+account_id  BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+delta       BIGINT NOT NULL CHECK (delta > 0),
+proposed_by BIGINT REFERENCES services(id) ON DELETE SET NULL,
+comment     TEXT NOT NULL DEFAULT '',
+status      TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'EXPIRED')),
+decided_by  BIGINT REFERENCES services(id) ON DELETE SET NULL,
+note        TEXT NOT NULL DEFAULT '',
+entry_id    BIGINT REFERENCES ledger(id),
+created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+expires_at  TIMESTAMPTZ NOT NULL,
+decided_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS limit_changes_status_idx ON limit_changes (status, expires_at);
@@ -0,0 +1,2 @@
+-- Прежнее действие правил single_debit не восстанавливается
+ALTER TABLE velocity_rules DROP CONSTRAINT IF EXISTS velocity_rules_single_debit_review_check;
@@ -0,0 +1,5 @@
+-- single_debit не отклоняет крупное списание, а откладывает его до решения
+-- оператора: у таких правил действие всегда review
+UPDATE velocity_rules SET action = 'review' WHERE kind = 'single_debit';
+ALTER TABLE velocity_rules ADD CONSTRAINT velocity_rules_single_debit_review_check
+    CHECK (kind <> 'single_debit' OR action = 'review');
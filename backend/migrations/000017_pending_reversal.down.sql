@@ -0,0 +1,6 @@
+DELETE FROM pending_operations WHERE kind = 'reversal';
+ALTER TABLE pending_operations DROP COLUMN reason_code;
+ALTER TABLE pending_operations DROP COLUMN reversal_of;
+ALTER TABLE pending_operations DROP CONSTRAINT pending_operations_kind_check;
+ALTER TABLE pending_operations ADD CONSTRAINT pending_operations_kind_check
+    CHECK (kind IN ('debit', 'reservation', 'transfer'));
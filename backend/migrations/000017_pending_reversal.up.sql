@@ -0,0 +1,7 @@
+-- Сторно, уменьшающее баланс счёта, проходит проверку риска и может ждать
+-- решения оператора: храним сторнируемую проводку и код причины
+ALTER TABLE pending_operations DROP CONSTRAINT pending_operations_kind_check;
+ALTER TABLE pending_operations ADD CONSTRAINT pending_operations_kind_check
+    CHECK (kind IN ('debit', 'reservation', 'transfer', 'reversal'));
+ALTER TABLE pending_operations ADD COLUMN reversal_of BIGINT REFERENCES ledger(id);
+ALTER TABLE pending_operations ADD COLUMN reason_code TEXT NOT NULL DEFAULT '';
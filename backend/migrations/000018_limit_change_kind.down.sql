@@ -0,0 +1,2 @@
+DELETE FROM limit_changes WHERE kind = 'CREDIT';
+ALTER TABLE limit_changes DROP COLUMN kind;
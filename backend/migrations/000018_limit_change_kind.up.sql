@@ -0,0 +1,3 @@
+-- Увеличение кредитной линии выше порога тоже ждёт подтверждения: у
+-- предложения есть вид меняемого лимита
+ALTER TABLE limit_changes ADD COLUMN kind TEXT NOT NULL DEFAULT 'MAX' CHECK (kind IN ('MAX', 'CREDIT'));
@@ -0,0 +1,10 @@
+// Package migrations содержит SQL-миграции схемы (формат golang-migrate),
+// встроенные в бинарник: сервис не зависит от рабочего каталога.
+package migrations
+
+import "embed"
+
+// FS — файлы NNNNNN_name.up.sql и NNNNNN_name.down.sql.
+//
+//go:embed *.sql
+var FS embed.FS